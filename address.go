@@ -51,22 +51,42 @@ func GetAddressFromScript(script string, networkType NetworkType) (string, error
 	}
 
 	// Extract the addresses from the script
-	_, addresses, _, err := txscript.ExtractPkScriptAddrs(scriptBytes, networkType)
+	class, addresses, _, err := txscript.ExtractPkScriptAddrs(scriptBytes, networkType)
 	if err != nil {
 		return "", err
 	}
 
-	// Safety check: Ensure at least one address was extracted.
-	// This case is unlikely because the error above should already cover it,
-	// but we include this check to avoid using an empty address list below.
+	// Nonstandard and data carrier scripts extract without error but have no address,
+	// GetScriptInfo can be used to inspect those
 	if len(addresses) == 0 {
-		return "", fmt.Errorf("invalid output script, missing an address")
+		return "", fmt.Errorf("%w: script type is %s", ErrScriptHasNoAddress, class)
 	}
 
 	// Use the encoded version of the address
 	return addresses[0].EncodeAddress(), nil
 }
 
+// DecodeAddressForNetwork decodes an address, returning ErrWrongNetwork when it is not for network.
+// btcutil.DecodeAddress alone accepts segwit addresses of any network
+func DecodeAddressForNetwork(address string, network NetworkType) (btcutil.Address, error) {
+
+	// Missing network
+	if network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	addr, err := btcutil.DecodeAddress(address, network)
+	if err != nil {
+		return nil, err
+	}
+
+	if !addr.IsForNet(network) {
+		return nil, fmt.Errorf("%w: %s", ErrWrongNetwork, address)
+	}
+
+	return addr, nil
+}
+
 /*
 CreateAddressFromMnemonic creates a new bitcoin wallet address from an addressIndex and mnemonic phrase.
 MnemonicPassword can be an empty string if not required
@@ -6,6 +6,7 @@ import (
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetAddressFromScript(t *testing.T) {
@@ -19,6 +20,7 @@ func TestGetAddressFromScript(t *testing.T) {
 	}{
 		{script: "5120c63ae2b830aee511ff6b3c606b53d6ca0e22d6a7516ad506b5346bf74fa5e3ae", networkType: Mainnet, expectedAddress: "bc1pccaw9wps4mj3rlmt83sxk57keg8z9448294d2p44x34lwna9uwhqe2lxas", expectedError: false},
 		{script: "76a91443c1c9de50e52e35546084083363b4586782cbf388ac", networkType: Mainnet, expectedAddress: "17BGRWzKtPstTTvJK9rDusVNMTbWit52XT", expectedError: false},
+		{script: "6a0b68656c6c6f20776f726c64", networkType: Mainnet, expectedAddress: "", expectedError: true},
 		{script: "gkckfcbnlkjhoiu7890987654", networkType: Mainnet, expectedAddress: "", expectedError: true},
 		{script: "", networkType: Mainnet, expectedAddress: "", expectedError: true},
 	}
@@ -147,3 +149,22 @@ func TestGetAddressFromPubKeyString(t *testing.T) {
 	}
 
 }
+
+// TestDecodeAddressForNetwork will test the method DecodeAddressForNetwork()
+func TestDecodeAddressForNetwork(t *testing.T) {
+	t.Parallel()
+
+	addr, err := DecodeAddressForNetwork("bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", addr.EncodeAddress())
+
+	// segwit addresses decode regardless of the network
+	_, err = DecodeAddressForNetwork("tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", Mainnet)
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+	_, err = DecodeAddressForNetwork("1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH", Testnet)
+	assert.Error(t, err)
+	_, err = DecodeAddressForNetwork("invalid", Mainnet)
+	assert.Error(t, err)
+	_, err = DecodeAddressForNetwork("1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH", nil)
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+}
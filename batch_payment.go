@@ -0,0 +1,370 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MaxStandardTxWeight is the largest transaction weight relayed by nodes
+const MaxStandardTxWeight = 400000
+
+// FeeSource estimates fee rates, implemented by every ChainBackend and by FeeEstimator
+type FeeSource interface {
+
+	// EstimateFee returns the fee rate in sat/vB for a transaction to confirm within confTarget blocks
+	EstimateFee(ctx context.Context, confTarget int) (float64, error)
+}
+
+// Payout is a pending payment to an address
+type Payout struct {
+	ID      string // reference of the caller, e.g. a withdrawal id
+	Address string
+	Value   btcutil.Amount
+}
+
+// RejectedPayout is a payout that cannot be paid, with the reason why
+type RejectedPayout struct {
+	Payout Payout
+	Err    error // ErrWrongNetwork, ErrDustOutput or the address decoding error
+}
+
+// PaymentBatch is an unsigned transaction of a BatchPlan, signed with Wallet.SignTransaction and
+// broadcast with Wallet.Broadcast
+type PaymentBatch struct {
+	Tx       *wire.MsgTx
+	PrevOuts map[wire.OutPoint]*wire.TxOut // the outputs spent by Tx
+	Payouts  []Payout                      // paid by the first outputs of Tx, in order
+	Fee      btcutil.Amount
+	Weight   int64          // estimated with the largest signatures
+	Change   btcutil.Amount // paid to the last output, 0 when the change was left to the fee
+}
+
+// BatchPlan is the set of transactions paying a list of payouts
+type BatchPlan struct {
+	FeeRate       float64 // sat/vB paid by every transaction
+	Batches       []PaymentBatch
+	Consolidation *PaymentBatch    // spends small utxos to a change output, set when the fee rate is low
+	Deferred      []Payout         // not funded by the wallet's confirmed utxos, left for a later plan
+	Rejected      []RejectedPayout // never payable
+}
+
+// BatchPlannerConfig holds the settings of a BatchPlanner
+type BatchPlannerConfig struct {
+	Wallet     *Wallet
+	FeeSource  FeeSource // defaults to the wallet's backend
+	ConfTarget int       // blocks the payouts should confirm within, defaults to 6
+	MaxWeight  int64     // of each transaction, defaults to MaxStandardTxWeight
+
+	ConsolidationFeeRate   float64        // consolidate when the fee rate is at or below it (sat/vB), 0 disables consolidation
+	ConsolidationMinUtxos  int            // utxos worth consolidating needed, defaults to 10
+	ConsolidationMaxInputs int            // defaults to 100
+	ConsolidationMaxValue  btcutil.Amount // larger utxos are left alone, defaults to 100 times the fee of their input at ConsolidationFeeRate
+}
+
+// BatchPlanner aggregates payouts into as few transactions as fit MaxWeight, funded by the confirmed
+// utxos of a wallet largest first, and consolidates the wallet's small utxos while fees are low. Plans
+// are deterministic for a given wallet state and fee rate
+type BatchPlanner struct {
+	config BatchPlannerConfig
+}
+
+// NewBatchPlanner creates a planner for config.Wallet
+func NewBatchPlanner(config BatchPlannerConfig) (*BatchPlanner, error) {
+
+	// Missing wallet
+	if config.Wallet == nil {
+		return nil, ErrMissingWallet
+	}
+
+	if config.FeeSource == nil {
+		if config.Wallet.config.Backend == nil {
+			return nil, ErrMissingFeeSource
+		}
+		config.FeeSource = config.Wallet.config.Backend
+	}
+
+	if config.ConfTarget <= 0 {
+		config.ConfTarget = 6
+	}
+	if config.MaxWeight <= 0 {
+		config.MaxWeight = MaxStandardTxWeight
+	}
+	if config.ConsolidationMinUtxos <= 0 {
+		config.ConsolidationMinUtxos = 10
+	}
+	if config.ConsolidationMaxInputs <= 0 {
+		config.ConsolidationMaxInputs = 100
+	}
+
+	return &BatchPlanner{config: config}, nil
+}
+
+// Plan estimates the fee rate and plans the payment of payouts, in order. The transactions spend the
+// wallet's confirmed utxos: a plan is to be broadcast (or dropped) before planning again
+func (p *BatchPlanner) Plan(ctx context.Context, payouts []Payout) (*BatchPlan, error) {
+	feeRate, err := p.config.FeeSource.EstimateFee(ctx, p.config.ConfTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.PlanWithFeeRate(payouts, feeRate)
+}
+
+// PlanWithFeeRate is like Plan with a given fee rate (sat/vB)
+func (p *BatchPlanner) PlanWithFeeRate(payouts []Payout, feeRate float64) (*BatchPlan, error) {
+	w := p.config.Wallet
+	plan := &BatchPlan{FeeRate: feeRate}
+
+	var outputs []*wire.TxOut
+	var payable []Payout
+	for _, payout := range payouts {
+		out, err := p.payoutTxOut(payout)
+		if err != nil {
+			plan.Rejected = append(plan.Rejected, RejectedPayout{Payout: payout, Err: err})
+			continue
+		}
+		outputs = append(outputs, out)
+		payable = append(payable, payout)
+	}
+
+	w.mu.Lock()
+	pool := w.spendableUtxos()
+	w.mu.Unlock()
+
+	changeScript, err := w.placeholderChangeScript()
+	if err != nil {
+		return nil, err
+	}
+
+	var current *batchBuilder
+	for i, payout := range payable {
+		if current != nil {
+			next, err := p.fund(current, outputs[i], pool, changeScript, feeRate)
+			switch {
+			case err == nil:
+				next.payouts = append(next.payouts, payout)
+				current = next
+				continue
+			case errors.Is(err, ErrInsufficientFunds):
+				plan.Deferred = append(plan.Deferred, payout)
+				continue
+			case !errors.Is(err, errBatchTooHeavy):
+				return nil, err
+			}
+
+			// the payout starts the next batch
+			if err = p.closeBatch(plan, current); err != nil {
+				return nil, err
+			}
+			pool = pool[current.used:]
+			current = nil
+		}
+
+		first, err := p.fund(&batchBuilder{tx: wire.NewMsgTx(wire.TxVersion), prevOuts: map[wire.OutPoint]*wire.TxOut{}}, outputs[i], pool, changeScript, feeRate)
+		switch {
+		case err == nil:
+			first.payouts = []Payout{payout}
+			current = first
+		case errors.Is(err, ErrInsufficientFunds) || errors.Is(err, errBatchTooHeavy):
+			plan.Deferred = append(plan.Deferred, payout)
+		default:
+			return nil, err
+		}
+	}
+
+	if current != nil {
+		if err = p.closeBatch(plan, current); err != nil {
+			return nil, err
+		}
+		pool = pool[current.used:]
+	}
+
+	if feeRate <= p.config.ConsolidationFeeRate {
+		if plan.Consolidation, err = p.consolidate(pool, changeScript, feeRate); err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// errBatchTooHeavy reports a batch going over MaxWeight
+var errBatchTooHeavy = errors.New("batch too heavy")
+
+// batchBuilder is a batch being filled, funded by the first used utxos of the pool
+type batchBuilder struct {
+	tx         *wire.MsgTx // with the change output when withChange
+	prevOuts   map[wire.OutPoint]*wire.TxOut
+	payouts    []Payout
+	used       int
+	withChange bool
+}
+
+// fund returns the batch with out added before its change, funded at feeRate with more utxos of the
+// pool when needed
+func (p *BatchPlanner) fund(batch *batchBuilder, out *wire.TxOut, pool []WalletUtxo, changeScript []byte, feeRate float64) (*batchBuilder, error) {
+	w := p.config.Wallet
+
+	next := &batchBuilder{
+		tx:       batch.tx.Copy(),
+		prevOuts: make(map[wire.OutPoint]*wire.TxOut, len(batch.prevOuts)+1),
+		payouts:  append([]Payout(nil), batch.payouts...),
+	}
+	if batch.withChange {
+		next.tx.TxOut = next.tx.TxOut[:len(next.tx.TxOut)-1]
+	}
+	next.tx.AddTxOut(out)
+
+	var available btcutil.Amount
+	for outPoint, prevOut := range batch.prevOuts {
+		next.prevOuts[outPoint] = prevOut
+		available += btcutil.Amount(prevOut.Value)
+	}
+	for _, out := range next.tx.TxOut {
+		available -= btcutil.Amount(out.Value)
+	}
+
+	inputs := len(next.tx.TxIn)
+	withChange, err := w.fundWithChange(next.tx, next.prevOuts, available, pool[batch.used:], changeScript,
+		func(vsize int64) btcutil.Amount {
+			return feeAtRate(feeRate, vsize)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	if w.estimateWeight(next.tx) > p.config.MaxWeight {
+		return nil, errBatchTooHeavy
+	}
+	next.withChange = withChange
+	next.used = batch.used + len(next.tx.TxIn) - inputs
+
+	return next, nil
+}
+
+// closeBatch adds a batch to the plan, paying its change to a new change address
+func (p *BatchPlanner) closeBatch(plan *BatchPlan, batch *batchBuilder) error {
+	paymentBatch, err := p.paymentBatch(batch.tx, batch.prevOuts, batch.withChange)
+	if err != nil {
+		return err
+	}
+	paymentBatch.Payouts = batch.payouts
+
+	plan.Batches = append(plan.Batches, *paymentBatch)
+	return nil
+}
+
+// consolidate spends the smallest utxos of the pool worth more than the fee of their input, and at most
+// ConsolidationMaxValue, to a change address when there are at least ConsolidationMinUtxos of them. It
+// returns nil otherwise
+func (p *BatchPlanner) consolidate(pool []WalletUtxo, changeScript []byte, feeRate float64) (*PaymentBatch, error) {
+	w := p.config.Wallet
+
+	smallest := append([]WalletUtxo(nil), pool...)
+	sort.SliceStable(smallest, func(i, j int) bool { return smallest[i].Value < smallest[j].Value })
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(0, changeScript))
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+
+	var inputs, fee btcutil.Amount
+	for _, utxo := range smallest {
+		if len(tx.TxIn) == p.config.ConsolidationMaxInputs {
+			break
+		}
+
+		vsize := w.estimateVSize(tx)
+		value, err := addWalletInput(tx, prevOuts, utxo)
+		if err != nil {
+			return nil, err
+		}
+		added := tx.TxIn[len(tx.TxIn)-1]
+
+		if w.estimateWeight(tx) > p.config.MaxWeight {
+			tx.TxIn = tx.TxIn[:len(tx.TxIn)-1]
+			delete(prevOuts, added.PreviousOutPoint)
+			break
+		}
+
+		// the utxos left are as large, they are cheap enough to spend when needed
+		maxValue := p.config.ConsolidationMaxValue
+		if maxValue <= 0 {
+			maxValue = 100 * feeAtRate(p.config.ConsolidationFeeRate, w.estimateVSize(tx)-vsize)
+		}
+		if value > maxValue {
+			tx.TxIn = tx.TxIn[:len(tx.TxIn)-1]
+			delete(prevOuts, added.PreviousOutPoint)
+			break
+		}
+
+		// utxos costing more to spend than they hold are left alone
+		nextFee := feeAtRate(feeRate, w.estimateVSize(tx))
+		if value <= nextFee-fee {
+			tx.TxIn = tx.TxIn[:len(tx.TxIn)-1]
+			delete(prevOuts, added.PreviousOutPoint)
+			continue
+		}
+		inputs += value
+		fee = nextFee
+	}
+
+	if len(tx.TxIn) < p.config.ConsolidationMinUtxos || inputs-fee < dustLimit(changeScript) {
+		return nil, nil
+	}
+	tx.TxOut[0].Value = int64(inputs - fee)
+
+	return p.paymentBatch(tx, prevOuts, true)
+}
+
+// paymentBatch measures a funded transaction, paying its last output to a new change address when withChange
+func (p *BatchPlanner) paymentBatch(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut, withChange bool) (*PaymentBatch, error) {
+	w := p.config.Wallet
+
+	batch := &PaymentBatch{Tx: tx, PrevOuts: prevOuts, Weight: w.estimateWeight(tx)}
+	if withChange {
+		if err := w.setChangeScript(tx); err != nil {
+			return nil, err
+		}
+		batch.Change = btcutil.Amount(tx.TxOut[len(tx.TxOut)-1].Value)
+	}
+
+	for _, prevOut := range prevOuts {
+		batch.Fee += btcutil.Amount(prevOut.Value)
+	}
+	for _, out := range tx.TxOut {
+		batch.Fee -= btcutil.Amount(out.Value)
+	}
+
+	return batch, nil
+}
+
+// payoutTxOut returns the output paying a payout, checking its address and value
+func (p *BatchPlanner) payoutTxOut(payout Payout) (*wire.TxOut, error) {
+	network := p.config.Wallet.config.Network
+
+	if _, err := DecodeAddressForNetwork(payout.Address, network); err != nil {
+		return nil, err
+	}
+
+	script, err := GetScriptFromAddress(payout.Address, network)
+	if err != nil {
+		return nil, err
+	}
+
+	pkScript, err := hex.DecodeString(script)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit := dustLimit(pkScript); payout.Value < limit {
+		return nil, fmt.Errorf("%w: %d sat below %d sat", ErrDustOutput, payout.Value, limit)
+	}
+
+	return wire.NewTxOut(int64(payout.Value), pkScript), nil
+}
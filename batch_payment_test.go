@@ -0,0 +1,283 @@
+package bitcoin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFeeSource is a FeeSource returning a fixed fee rate
+type fakeFeeSource struct {
+	feeRate float64
+	err     error
+}
+
+// EstimateFee implements FeeSource
+func (f *fakeFeeSource) EstimateFee(context.Context, int) (float64, error) {
+	return f.feeRate, f.err
+}
+
+// newTestBatchPlanner returns a planner of a test wallet funded with values
+func newTestBatchPlanner(t *testing.T, config BatchPlannerConfig, values ...int64) *BatchPlanner {
+	wallet, _ := fundedTestWallet(t, values...)
+	config.Wallet = wallet
+
+	planner, err := NewBatchPlanner(config)
+	require.NoError(t, err)
+	return planner
+}
+
+// batchInputs returns the outpoints spent by the batches
+func batchInputs(batches ...PaymentBatch) []wire.OutPoint {
+	var outPoints []wire.OutPoint
+	for _, batch := range batches {
+		for _, in := range batch.Tx.TxIn {
+			outPoints = append(outPoints, in.PreviousOutPoint)
+		}
+	}
+	return outPoints
+}
+
+// assertUniqueOutPoints checks that no outpoint is spent twice
+func assertUniqueOutPoints(t *testing.T, outPoints []wire.OutPoint) {
+	seen := make(map[wire.OutPoint]bool, len(outPoints))
+	for _, outPoint := range outPoints {
+		assert.False(t, seen[outPoint], outPoint.String())
+		seen[outPoint] = true
+	}
+}
+
+// TestNewBatchPlanner will test the method NewBatchPlanner()
+func TestNewBatchPlanner(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewBatchPlanner(BatchPlannerConfig{})
+	assert.ErrorIs(t, err, ErrMissingWallet)
+
+	offline, err := NewWallet(WalletConfig{Network: Mainnet, AddressType: NativeSegwit, Mnemonic: testVectorMnemonic})
+	require.NoError(t, err)
+	_, err = NewBatchPlanner(BatchPlannerConfig{Wallet: offline})
+	assert.ErrorIs(t, err, ErrMissingFeeSource)
+
+	// the wallet's backend is the default fee source
+	wallet, backend := newTestWallet(t, nil)
+	backend.SetFeeRate(7)
+	planner, err := NewBatchPlanner(BatchPlannerConfig{Wallet: wallet})
+	require.NoError(t, err)
+
+	plan, err := planner.Plan(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, plan.FeeRate)
+	assert.Empty(t, plan.Batches)
+	assert.Nil(t, plan.Consolidation)
+
+	failing := &fakeFeeSource{err: errors.New("no estimate")}
+	planner, err = NewBatchPlanner(BatchPlannerConfig{Wallet: wallet, FeeSource: failing})
+	require.NoError(t, err)
+	_, err = planner.Plan(context.Background(), nil)
+	assert.ErrorIs(t, err, failing.err)
+}
+
+// TestBatchPlannerPlan will test the method Plan()
+func TestBatchPlannerPlan(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	config := BatchPlannerConfig{FeeSource: &fakeFeeSource{feeRate: 5}}
+	planner := newTestBatchPlanner(t, config, 100000, 50000, 20000)
+
+	payouts := []Payout{
+		{ID: "1", Address: testPaymentAddress, Value: 30000},
+		{ID: "2", Address: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", Value: 500}, // below the P2PKH dust limit
+		{ID: "3", Address: "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", Value: 40000},
+		{ID: "4", Address: "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", Value: 10000},
+		{ID: "5", Address: "invalid", Value: 10000},
+		{ID: "6", Address: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", Value: 10000},
+		{ID: "7", Address: testPaymentAddress, Value: 1000000},
+	}
+
+	plan, err := planner.Plan(ctx, payouts)
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, plan.FeeRate)
+
+	require.Len(t, plan.Rejected, 3)
+	assert.Equal(t, "2", plan.Rejected[0].Payout.ID)
+	assert.ErrorIs(t, plan.Rejected[0].Err, ErrDustOutput)
+	assert.ErrorIs(t, plan.Rejected[1].Err, ErrWrongNetwork)
+	assert.Error(t, plan.Rejected[2].Err)
+
+	// the payout the wallet cannot fund waits for the next plan
+	assert.Equal(t, []Payout{payouts[6]}, plan.Deferred)
+
+	// one transaction, funded by the largest utxo
+	require.Len(t, plan.Batches, 1)
+	batch := plan.Batches[0]
+	assert.Equal(t, []Payout{payouts[0], payouts[2], payouts[5]}, batch.Payouts)
+	require.Len(t, batch.Tx.TxIn, 1)
+	require.Len(t, batch.Tx.TxOut, 4)
+	for i, payout := range batch.Payouts {
+		assert.Equal(t, int64(payout.Value), batch.Tx.TxOut[i].Value)
+		assert.Equal(t, pkScriptForAddress(t, payout.Address), batch.Tx.TxOut[i].PkScript)
+	}
+	assert.Equal(t, btcutil.Amount(100000-80000)-batch.Fee, batch.Change)
+	assert.Equal(t, batch.Change, btcutil.Amount(batch.Tx.TxOut[3].Value))
+
+	// signed, it pays at least the fee rate
+	wallet := planner.config.Wallet
+	require.NoError(t, wallet.SignTransaction(batch.Tx, batch.PrevOuts))
+	require.NoError(t, VerifyTransaction(batch.Tx, batch.PrevOuts, StandardScriptFlags))
+	assert.GreaterOrEqual(t, batch.Weight, blockchain.GetTransactionWeight(btcutil.NewTx(batch.Tx)))
+	assert.GreaterOrEqual(t, float64(batch.Fee)/float64(GetTransactionVSize(batch.Tx)), 5.0)
+
+	_, err = wallet.Broadcast(ctx, batch.Tx)
+	require.NoError(t, err)
+	assert.Len(t, wallet.Utxos(), 3)
+}
+
+// TestBatchPlannerPlanDeterministic will test that plans only depend on the wallet state and fee rate
+func TestBatchPlannerPlanDeterministic(t *testing.T) {
+	t.Parallel()
+
+	payouts := []Payout{
+		{ID: "1", Address: testPaymentAddress, Value: 60000},
+		{ID: "2", Address: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", Value: 45000},
+	}
+
+	var txIDs []string
+	for i := 0; i < 2; i++ {
+		planner := newTestBatchPlanner(t, BatchPlannerConfig{FeeSource: &fakeFeeSource{feeRate: 12}}, 30000, 50000, 40000, 50000)
+		plan, err := planner.Plan(context.Background(), payouts)
+		require.NoError(t, err)
+		require.Len(t, plan.Batches, 1)
+		txIDs = append(txIDs, plan.Batches[0].Tx.TxHash().String())
+	}
+	assert.Equal(t, txIDs[0], txIDs[1])
+}
+
+// TestBatchPlannerPlanMaxWeight will test that batches are split to stay under MaxWeight
+func TestBatchPlannerPlanMaxWeight(t *testing.T) {
+	t.Parallel()
+
+	config := BatchPlannerConfig{FeeSource: &fakeFeeSource{feeRate: 2}, MaxWeight: 1200}
+	planner := newTestBatchPlanner(t, config, 100000, 100000, 100000)
+
+	var payouts []Payout
+	for i := 0; i < 9; i++ {
+		payouts = append(payouts, Payout{Address: testPaymentAddress, Value: 20000})
+	}
+
+	plan, err := planner.PlanWithFeeRate(payouts, 2)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Deferred)
+	require.Greater(t, len(plan.Batches), 1)
+
+	paid := 0
+	for _, batch := range plan.Batches {
+		assert.LessOrEqual(t, batch.Weight, int64(1200))
+		paid += len(batch.Payouts)
+	}
+	assert.Equal(t, len(payouts), paid)
+
+	// every utxo funds a single batch
+	inputs := batchInputs(plan.Batches...)
+	assert.Len(t, inputs, len(plan.Batches))
+	assertUniqueOutPoints(t, inputs)
+}
+
+// TestBatchPlannerConsolidation will test the consolidation of small utxos while fees are low
+func TestBatchPlannerConsolidation(t *testing.T) {
+	t.Parallel()
+
+	values := []int64{500000, 100} // the 100 sat utxo costs more to spend than it holds
+	for i := 0; i < 12; i++ {
+		values = append(values, 5000)
+	}
+	config := BatchPlannerConfig{ConsolidationFeeRate: 3, ConsolidationMaxInputs: 11}
+	planner := newTestBatchPlanner(t, config, values...)
+
+	payouts := []Payout{{Address: testPaymentAddress, Value: 200000}}
+
+	// too expensive
+	plan, err := planner.PlanWithFeeRate(payouts, 10)
+	require.NoError(t, err)
+	require.Len(t, plan.Batches, 1)
+	assert.Nil(t, plan.Consolidation)
+
+	plan, err = planner.PlanWithFeeRate(payouts, 2)
+	require.NoError(t, err)
+	require.Len(t, plan.Batches, 1)
+	require.NotNil(t, plan.Consolidation)
+
+	consolidation := plan.Consolidation
+	require.Len(t, consolidation.Tx.TxIn, 11)
+	require.Len(t, consolidation.Tx.TxOut, 1)
+	for _, prevOut := range consolidation.PrevOuts {
+		assert.Equal(t, int64(5000), prevOut.Value)
+	}
+	assert.Equal(t, btcutil.Amount(55000)-consolidation.Fee, consolidation.Change)
+	assertUniqueOutPoints(t, batchInputs(append(plan.Batches, *consolidation)...))
+
+	wallet := planner.config.Wallet
+	require.NoError(t, wallet.SignTransaction(consolidation.Tx, consolidation.PrevOuts))
+	require.NoError(t, VerifyTransaction(consolidation.Tx, consolidation.PrevOuts, StandardScriptFlags))
+
+	// not enough utxos worth it
+	planner.config.ConsolidationMinUtxos = 12
+	plan, err = planner.PlanWithFeeRate(payouts, 2)
+	require.NoError(t, err)
+	assert.Nil(t, plan.Consolidation)
+}
+
+// TestBatchPlannerConsolidationMaxValue will test that large utxos are not consolidated
+func TestBatchPlannerConsolidationMaxValue(t *testing.T) {
+	t.Parallel()
+
+	values := []int64{50000}
+	for i := 0; i < 10; i++ {
+		values = append(values, 5000)
+	}
+	planner := newTestBatchPlanner(t, BatchPlannerConfig{ConsolidationFeeRate: 3}, values...)
+
+	// 100 times the fee of a P2WPKH input at 3 sat/vB is about 20000 sat
+	plan, err := planner.PlanWithFeeRate(nil, 2)
+	require.NoError(t, err)
+	require.NotNil(t, plan.Consolidation)
+	require.Len(t, plan.Consolidation.Tx.TxIn, 10)
+	for _, prevOut := range plan.Consolidation.PrevOuts {
+		assert.Equal(t, int64(5000), prevOut.Value)
+	}
+
+	planner.config.ConsolidationMaxValue = 50000
+	plan, err = planner.PlanWithFeeRate(nil, 2)
+	require.NoError(t, err)
+	require.NotNil(t, plan.Consolidation)
+	assert.Len(t, plan.Consolidation.Tx.TxIn, 11)
+
+	planner.config.ConsolidationMaxValue = 4999
+	plan, err = planner.PlanWithFeeRate(nil, 2)
+	require.NoError(t, err)
+	assert.Nil(t, plan.Consolidation)
+}
+
+// TestBatchPlannerPlanChangeDust will test that change is kept down to the dust limit of the change script
+func TestBatchPlannerPlanChangeDust(t *testing.T) {
+	t.Parallel()
+
+	planner := newTestBatchPlanner(t, BatchPlannerConfig{}, 100000)
+
+	// about 400 sat of change, above the 294 sat dust limit of P2WPKH
+	plan, err := planner.PlanWithFeeRate([]Payout{{Address: testPaymentAddress, Value: 100000 - 545}}, 1)
+	require.NoError(t, err)
+	require.Len(t, plan.Batches, 1)
+
+	batch := plan.Batches[0]
+	require.Len(t, batch.Tx.TxOut, 2)
+	assert.Greater(t, batch.Change, btcutil.Amount(294))
+	assert.Less(t, batch.Change, btcutil.Amount(546))
+}
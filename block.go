@@ -0,0 +1,115 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BlockFromString decodes a serialized block (hex string)
+func BlockFromString(rawBlock string) (*wire.MsgBlock, error) {
+	blockBytes, err := hex.DecodeString(rawBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	var block wire.MsgBlock
+	if err = block.Deserialize(bytes.NewReader(blockBytes)); err != nil {
+		return nil, err
+	}
+
+	return &block, nil
+}
+
+// BlockHeaderFromString decodes a serialized 80 byte block header (hex string)
+func BlockHeaderFromString(rawHeader string) (*wire.BlockHeader, error) {
+	headerBytes, err := hex.DecodeString(rawHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(headerBytes) != wire.MaxBlockHeaderPayload {
+		return nil, ErrInvalidBlockHeader
+	}
+
+	var header wire.BlockHeader
+	if err = header.Deserialize(bytes.NewReader(headerBytes)); err != nil {
+		return nil, err
+	}
+
+	return &header, nil
+}
+
+// CheckProofOfWork checks that the target of a header is within the proof of work limit of the
+// network and that the header hash meets it
+func CheckProofOfWork(header *wire.BlockHeader, networkType NetworkType) error {
+	target := blockchain.CompactToBig(header.Bits)
+	if target.Sign() <= 0 || target.Cmp(networkType.PowLimit) > 0 {
+		return fmt.Errorf("%w: target %064x out of range", ErrInvalidProofOfWork, target)
+	}
+
+	hash := header.BlockHash()
+	if blockchain.HashToBig(&hash).Cmp(target) > 0 {
+		return fmt.Errorf("%w: hash %s above target %064x", ErrInvalidProofOfWork, hash, target)
+	}
+
+	return nil
+}
+
+// GetBlockWork returns the expected number of hashes needed to meet the target of a header,
+// the measure of the chain with the most work
+func GetBlockWork(header *wire.BlockHeader) *big.Int {
+	return blockchain.CalcWork(header.Bits)
+}
+
+// GetDifficulty returns the difficulty of a header relative to the proof of work limit of
+// the network, as reported by getblockchaininfo
+func GetDifficulty(header *wire.BlockHeader, networkType NetworkType) float64 {
+	target := blockchain.CompactToBig(header.Bits)
+	if target.Sign() <= 0 {
+		return 0
+	}
+
+	// the limit is the target of the compact form of the network limit, e.g. 0x1d00ffff on mainnet
+	limit := blockchain.CompactToBig(blockchain.BigToCompact(networkType.PowLimit))
+	difficulty, _ := new(big.Rat).SetFrac(limit, target).Float64()
+	return difficulty
+}
+
+// CheckBlock checks the proof of work of a block and that its transactions hash to the merkle root
+// of its header, including the witness commitment when the block has segwit transactions
+func CheckBlock(block *wire.MsgBlock, networkType NetworkType) error {
+	if err := CheckProofOfWork(&block.Header, networkType); err != nil {
+		return err
+	}
+
+	if len(block.Transactions) == 0 {
+		return fmt.Errorf("%w: block has no transactions", ErrInvalidMerkleRoot)
+	}
+
+	// duplicated transactions leave the merkle root unchanged (CVE-2012-2459)
+	txs := btcutil.NewBlock(block).Transactions()
+	seen := make(map[chainhash.Hash]bool, len(txs))
+	for _, tx := range txs {
+		if seen[*tx.Hash()] {
+			return fmt.Errorf("%w: duplicate transaction %s", ErrInvalidMerkleRoot, tx.Hash())
+		}
+		seen[*tx.Hash()] = true
+	}
+
+	if merkleRoot := blockchain.CalcMerkleRoot(txs, false); merkleRoot != block.Header.MerkleRoot {
+		return fmt.Errorf("%w: computed %s, header has %s", ErrInvalidMerkleRoot, merkleRoot, block.Header.MerkleRoot)
+	}
+
+	if err := blockchain.ValidateWitnessCommitment(btcutil.NewBlock(block)); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMerkleRoot, err)
+	}
+
+	return nil
+}
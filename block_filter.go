@@ -0,0 +1,120 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/gcs"
+	"github.com/btcsuite/btcd/btcutil/gcs/builder"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BuildBasicFilter builds the BIP158 basic filter (hex string, as returned by getblockfilter) of a block.
+// prevOutScripts are the output scripts (hex strings) spent by the inputs of the block
+func BuildBasicFilter(block *wire.MsgBlock, prevOutScripts []string) (string, error) {
+	scripts := make([][]byte, 0, len(prevOutScripts))
+	for _, script := range prevOutScripts {
+		scriptBytes, err := hex.DecodeString(script)
+		if err != nil {
+			return "", err
+		}
+		scripts = append(scripts, scriptBytes)
+	}
+
+	filter, err := builder.BuildBasicFilter(block, scripts)
+	if err != nil {
+		return "", err
+	}
+
+	filterBytes, err := filter.NBytes()
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(filterBytes), nil
+}
+
+// MatchBasicFilter reports whether the basic filter (hex string) of the block with blockHash matches any
+// of scripts (hex strings, e.g. from GetScriptFromAddress). A filter matches a script it does not hold with
+// a probability of 1/784931, so a match has to be confirmed against the block itself
+func MatchBasicFilter(filter, blockHash string, scripts []string) (bool, error) {
+	gcsFilter, err := basicFilterFromString(filter)
+	if err != nil {
+		return false, err
+	}
+
+	hash, err := chainhash.NewHashFromStr(blockHash)
+	if err != nil {
+		return false, err
+	}
+
+	data := make([][]byte, 0, len(scripts))
+	for _, script := range scripts {
+		scriptBytes, err := hex.DecodeString(script)
+		if err != nil {
+			return false, err
+		}
+		data = append(data, scriptBytes)
+	}
+
+	// an empty filter holds nothing
+	if gcsFilter.N() == 0 || len(data) == 0 {
+		return false, nil
+	}
+
+	return gcsFilter.MatchAny(builder.DeriveKey(hash), data)
+}
+
+// GetFilterHeader returns the BIP157 filter header (hex string) of a filter, chained to the header of
+// the filter of the previous block (all zeros before the genesis block)
+func GetFilterHeader(filter, prevHeader string) (string, error) {
+	gcsFilter, err := basicFilterFromString(filter)
+	if err != nil {
+		return "", err
+	}
+
+	prevHash, err := chainhash.NewHashFromStr(prevHeader)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := builder.MakeHeaderForFilter(gcsFilter, *prevHash)
+	if err != nil {
+		return "", err
+	}
+
+	return header.String(), nil
+}
+
+// VerifyFilterHeaders checks that filters of consecutive blocks chain from prevHeader to headers, e.g.
+// filters served by one peer against the headers another peer committed to
+func VerifyFilterHeaders(prevHeader string, filters, headers []string) error {
+	if len(filters) != len(headers) {
+		return fmt.Errorf("%w: %d filters for %d headers", ErrInvalidFilterHeader, len(filters), len(headers))
+	}
+
+	for i, filter := range filters {
+		header, err := GetFilterHeader(filter, prevHeader)
+		if err != nil {
+			return err
+		}
+
+		if header != headers[i] {
+			return fmt.Errorf("%w: filter %d hashes to header %s, expected %s", ErrInvalidFilterHeader, i, header, headers[i])
+		}
+		prevHeader = header
+	}
+
+	return nil
+}
+
+// basicFilterFromString decodes a serialized basic filter (hex string)
+func basicFilterFromString(filter string) (*gcs.Filter, error) {
+	filterBytes, err := hex.DecodeString(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcs.FromNBytes(builder.DefaultP, builder.DefaultM, filterBytes)
+}
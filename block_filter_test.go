@@ -0,0 +1,138 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testnetGenesisBlock is the testnet3 genesis block, the first BIP158 test vector
+const testnetGenesisBlock = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4adae5494dffff001d1aa4ae18" + "01" + genesisCoinbaseTx
+
+// zeroHash is the filter header before the genesis block
+var zeroHash = strings.Repeat("0", 64)
+
+// TestBuildBasicFilter will test the methods BuildBasicFilter() and GetFilterHeader() against the BIP158 genesis vector
+func TestBuildBasicFilter(t *testing.T) {
+	t.Parallel()
+
+	block, err := BlockFromString(testnetGenesisBlock)
+	require.NoError(t, err)
+	require.Equal(t, "000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943", block.BlockHash().String())
+
+	filter, err := BuildBasicFilter(block, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "019dfca8", filter)
+
+	header, err := GetFilterHeader(filter, zeroHash)
+	require.NoError(t, err)
+	assert.Equal(t, "21584579b7eb08997773e5aeff3a7f932700042d0ed2a6129012b7d7ae81b750", header)
+
+	matched, err := MatchBasicFilter(filter, block.BlockHash().String(), []string{hex.EncodeToString(block.Transactions[0].TxOut[0].PkScript)})
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	_, err = BuildBasicFilter(block, []string{"zz"})
+	assert.Error(t, err)
+	_, err = GetFilterHeader(filter, "zz")
+	assert.Error(t, err)
+	_, err = GetFilterHeader("zz", zeroHash)
+	assert.Error(t, err)
+}
+
+// TestMatchBasicFilter will test the method MatchBasicFilter()
+func TestMatchBasicFilter(t *testing.T) {
+	t.Parallel()
+
+	var scripts []string
+	for i := 0; i < 20; i++ {
+		privateKey, err := CreatePrivateKey()
+		require.NoError(t, err)
+		scripts = append(scripts, hex.EncodeToString(scriptForAddressType(t, privateKey, Taproot)))
+	}
+
+	// the block pays to the first five scripts and spends from the next five
+	block := newTestBlock(1)
+	for _, script := range scripts[:5] {
+		pkScript, err := hex.DecodeString(script)
+		require.NoError(t, err)
+		block.Transactions[0].AddTxOut(wire.NewTxOut(1000, pkScript))
+	}
+	block.Transactions[0].AddTxOut(wire.NewTxOut(0, []byte{0x6a, 0x01, 0x01}))
+	blockHash := block.BlockHash().String()
+
+	filter, err := BuildBasicFilter(block, scripts[5:10])
+	require.NoError(t, err)
+
+	var tests = []struct {
+		scripts       []string
+		expectedMatch bool
+	}{
+		{scripts[:1], true},
+		{scripts[7:8], true},
+		{scripts[10:], false},
+		{append([]string{}, scripts[12], scripts[4]), true},
+		{[]string{"6a0101"}, false}, // OP_RETURN outputs are left out
+		{nil, false},
+	}
+
+	for _, test := range tests {
+		matched, err := MatchBasicFilter(filter, blockHash, test.scripts)
+		require.NoError(t, err)
+		assert.Equal(t, test.expectedMatch, matched, "scripts %v", test.scripts)
+	}
+
+	// the filter is keyed by the block hash
+	matched, err := MatchBasicFilter(filter, chainhash.Hash{0x01}.String(), scripts[:5])
+	require.NoError(t, err)
+	assert.False(t, matched)
+
+	// an empty filter
+	matched, err = MatchBasicFilter("00", blockHash, scripts)
+	require.NoError(t, err)
+	assert.False(t, matched)
+
+	_, err = MatchBasicFilter(filter, blockHash, []string{"zz"})
+	assert.Error(t, err)
+	_, err = MatchBasicFilter(filter, "zz", scripts)
+	assert.Error(t, err)
+}
+
+// TestVerifyFilterHeaders will test the method VerifyFilterHeaders()
+func TestVerifyFilterHeaders(t *testing.T) {
+	t.Parallel()
+
+	var filters, headers []string
+	prevHeader := zeroHash
+	for i := 1; i <= 3; i++ {
+		block := newTestBlock(1)
+		block.Transactions[0].AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE, byte(i)}))
+
+		filter, err := BuildBasicFilter(block, nil)
+		require.NoError(t, err)
+
+		header, err := GetFilterHeader(filter, prevHeader)
+		require.NoError(t, err)
+
+		filters = append(filters, filter)
+		headers = append(headers, header)
+		prevHeader = header
+	}
+
+	require.NoError(t, VerifyFilterHeaders(zeroHash, filters, headers))
+
+	// filters swapped by a peer
+	swapped := []string{filters[1], filters[0], filters[2]}
+	assert.ErrorIs(t, VerifyFilterHeaders(zeroHash, swapped, headers), ErrInvalidFilterHeader)
+
+	// a different starting header
+	assert.ErrorIs(t, VerifyFilterHeaders(headers[0], filters, headers), ErrInvalidFilterHeader)
+
+	assert.ErrorIs(t, VerifyFilterHeaders(zeroHash, filters[:2], headers), ErrInvalidFilterHeader)
+}
@@ -0,0 +1,101 @@
+package bitcoin
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// genesisHeader is the header of the mainnet genesis block
+const genesisHeader = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c"
+
+// genesisBlock is the mainnet genesis block
+const genesisBlock = genesisHeader + "01" + genesisCoinbaseTx
+
+// mainnetHeaders are the headers of mainnet blocks 1 and 2
+var mainnetHeaders = []string{
+	"010000006fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000982051fd1e4ba744bbbe680e1fee14677ba1a3c3540bf7b1cdb606e857233e0e61bc6649ffff001d01e36299",
+	"010000004860eb18bf1b1620e37e9490fc8a427514416fd75159ab86688e9a8300000000d5fdcc541e25de1c7a5addedf24858b8bb665c9f36ef744ee42c316022c90f9bb0bc6649ffff001d08d2bd61",
+}
+
+// TestBlockHeaderFromString will test the method BlockHeaderFromString()
+func TestBlockHeaderFromString(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		rawHeader     string
+		expectedHash  string
+		expectedError bool
+	}{
+		{genesisHeader, "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f", false},
+		{mainnetHeaders[0], "00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048", false},
+		{mainnetHeaders[1], "000000006a625f06636b8bb6ac7b960a8d03705d1ace08b1a19da3fdcc99ddbd", false},
+		{genesisHeader[:158], "", true},
+		{genesisHeader + "00", "", true},
+		{"zz", "", true},
+	}
+
+	for _, test := range tests {
+		header, err := BlockHeaderFromString(test.rawHeader)
+		if test.expectedError {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.expectedHash, header.BlockHash().String())
+	}
+}
+
+// TestCheckProofOfWork will test the methods CheckProofOfWork(), GetBlockWork() and GetDifficulty()
+func TestCheckProofOfWork(t *testing.T) {
+	t.Parallel()
+
+	header, err := BlockHeaderFromString(genesisHeader)
+	require.NoError(t, err)
+
+	require.NoError(t, CheckProofOfWork(header, Mainnet))
+	assert.Equal(t, big.NewInt(0x100010001), GetBlockWork(header))
+	assert.InDelta(t, 1.0, GetDifficulty(header, Mainnet), 1e-12)
+
+	// a different nonce misses the target
+	tampered := *header
+	tampered.Nonce++
+	assert.ErrorIs(t, CheckProofOfWork(&tampered, Mainnet), ErrInvalidProofOfWork)
+
+	// a target above the network limit
+	tampered = *header
+	tampered.Bits = 0x1e00ffff
+	assert.ErrorIs(t, CheckProofOfWork(&tampered, Mainnet), ErrInvalidProofOfWork)
+}
+
+// TestCheckBlock will test the methods BlockFromString() and CheckBlock()
+func TestCheckBlock(t *testing.T) {
+	t.Parallel()
+
+	block, err := BlockFromString(genesisBlock)
+	require.NoError(t, err)
+	require.Len(t, block.Transactions, 1)
+	assert.Equal(t, "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f", block.BlockHash().String())
+	require.NoError(t, CheckBlock(block, Mainnet))
+
+	// a transaction that is not committed to
+	tampered := *block
+	tampered.Transactions = append([]*wire.MsgTx{}, block.Transactions...)
+	tampered.Transactions = append(tampered.Transactions, wire.NewMsgTx(1))
+	assert.ErrorIs(t, CheckBlock(&tampered, Mainnet), ErrInvalidMerkleRoot)
+
+	// a duplicated transaction
+	tampered.Transactions = []*wire.MsgTx{block.Transactions[0], block.Transactions[0]}
+	assert.ErrorIs(t, CheckBlock(&tampered, Mainnet), ErrInvalidMerkleRoot)
+
+	tampered.Transactions = nil
+	assert.ErrorIs(t, CheckBlock(&tampered, Mainnet), ErrInvalidMerkleRoot)
+
+	_, err = BlockFromString(genesisBlock[:200])
+	assert.Error(t, err)
+	_, err = BlockFromString("zz")
+	assert.Error(t, err)
+}
@@ -0,0 +1,441 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BSMSVersion is the first line of every BIP129 record
+const BSMSVersion = "BSMS 1.0"
+
+// BSMSNoPathRestrictions is the path restrictions line of descriptor records whose keys may derive any path
+const BSMSNoPathRestrictions = "No path restrictions"
+
+// bsmsPathRestrictions are the path restrictions of the descriptor records of a MultisigCoordinator
+const bsmsPathRestrictions = "/0/*,/1/*"
+
+// bsmsPassword is the password the encryption key of a token is derived with
+const bsmsPassword = "No SPOF"
+
+// BSMSEncryption is the encryption level of the records of a BIP129 setup
+type BSMSEncryption string
+
+const (
+	BSMSNoEncryption       BSMSEncryption = "NO_ENCRYPTION" // token 00, records in the clear
+	BSMSStandardEncryption BSMSEncryption = "STANDARD"      // 64-bit token
+	BSMSExtendedEncryption BSMSEncryption = "EXTENDED"      // 128-bit token
+)
+
+// bsmsNoToken is the token of records that are not encrypted
+const bsmsNoToken = "00"
+
+// GenerateBSMSToken returns a random token of an encryption level, as hex
+func GenerateBSMSToken(encryption BSMSEncryption) (string, error) {
+	var size int
+	switch encryption {
+	case BSMSNoEncryption:
+		return bsmsNoToken, nil
+	case BSMSStandardEncryption:
+		size = 8
+	case BSMSExtendedEncryption:
+		size = 16
+	default:
+		return "", fmt.Errorf("%w: encryption %s", ErrInvalidBSMSToken, encryption)
+	}
+
+	token := make([]byte, size)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+// EncryptBSMSRecord encrypts a record with a token as BIP129 describes: the key is derived from the token
+// with PBKDF2-SHA512, the record is authenticated with HMAC-SHA256 and encrypted with AES-256-CTR using
+// the first 16 bytes of the MAC as IV. It returns hex(MAC || ciphertext), or the record as is for token 00
+func EncryptBSMSRecord(token, record string) (string, error) {
+	if token == bsmsNoToken {
+		return record, nil
+	}
+
+	key, err := bsmsEncryptionKey(token)
+	if err != nil {
+		return "", err
+	}
+
+	mac := bsmsMAC(key, token, []byte(record))
+	ciphertext, err := bsmsCrypt(key, mac[:aes.BlockSize], []byte(record))
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(append(mac, ciphertext...)), nil
+}
+
+// DecryptBSMSRecord decrypts and authenticates a record encrypted by EncryptBSMSRecord
+func DecryptBSMSRecord(token, data string) (string, error) {
+	if token == bsmsNoToken {
+		return data, nil
+	}
+
+	key, err := bsmsEncryptionKey(token)
+	if err != nil {
+		return "", err
+	}
+
+	encrypted, err := hex.DecodeString(strings.TrimSpace(data))
+	if err != nil || len(encrypted) < sha256.Size {
+		return "", fmt.Errorf("%w: encrypted record", ErrInvalidBSMSRecord)
+	}
+
+	mac, ciphertext := encrypted[:sha256.Size], encrypted[sha256.Size:]
+	record, err := bsmsCrypt(key, mac[:aes.BlockSize], ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	if !hmac.Equal(mac, bsmsMAC(key, token, record)) {
+		return "", ErrBSMSAuthentication
+	}
+
+	return string(record), nil
+}
+
+// BSMSKeyRecord is the record a signer returns in the first round of BIP129: its key with its key
+// origin, signed with the private key of that key
+type BSMSKeyRecord struct {
+	Token     string
+	Signer    MultisigSigner // the key and the description
+	Signature string         // base64 message signature
+}
+
+// NewBSMSKeyRecord creates the key record of a signer for a token, signing it with accountKey, the private
+// key of the signer (e.g. from DeriveMultisigSigner)
+func NewBSMSKeyRecord(token string, signer MultisigSigner, accountKey *hdkeychain.ExtendedKey) (*BSMSKeyRecord, error) {
+	if err := validateBSMSToken(token); err != nil {
+		return nil, err
+	}
+	if strings.ContainsAny(signer.Description, "\r\n") {
+		return nil, fmt.Errorf("%w: multiline description", ErrInvalidBSMSRecord)
+	}
+
+	privateKey, err := accountKey.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+
+	accountPubKey, err := accountKey.Neuter()
+	if err != nil {
+		return nil, err
+	}
+	if accountPubKey.String() != signer.XPub {
+		return nil, fmt.Errorf("%w: account key does not match the signer", ErrInvalidSignature)
+	}
+
+	r := &BSMSKeyRecord{Token: token, Signer: signer}
+	signature, err := ecdsa.SignCompact(privateKey, bitcoinMessageHash(r.message()), true)
+	if err != nil {
+		return nil, err
+	}
+	r.Signature = base64.StdEncoding.EncodeToString(signature)
+
+	return r, nil
+}
+
+// ParseBSMSKeyRecord parses a key record and verifies its signature
+func ParseBSMSKeyRecord(record string, network NetworkType) (*BSMSKeyRecord, error) {
+	lines := bsmsLines(record)
+	if len(lines) != 5 || lines[0] != BSMSVersion {
+		return nil, fmt.Errorf("%w: expected a key record", ErrInvalidBSMSRecord)
+	}
+	if err := validateBSMSToken(lines[1]); err != nil {
+		return nil, err
+	}
+
+	signer, err := ParseMultisigSigner(lines[2], network)
+	if err != nil {
+		return nil, err
+	}
+	signer.Description = lines[3]
+
+	r := &BSMSKeyRecord{Token: lines[1], Signer: *signer, Signature: lines[4]}
+	if err = r.verify(network); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// String returns the record
+func (r *BSMSKeyRecord) String() string {
+	return r.message() + "\n" + r.Signature
+}
+
+// message returns the signed lines of the record
+func (r *BSMSKeyRecord) message() string {
+	return strings.Join([]string{BSMSVersion, r.Token, r.Signer.KeyExpression(), r.Signer.Description}, "\n")
+}
+
+// verify checks that the signature was made by the key of the signer
+func (r *BSMSKeyRecord) verify(network NetworkType) error {
+	signature, err := base64.StdEncoding.DecodeString(r.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	key, err := r.Signer.accountKey(network)
+	if err != nil {
+		return err
+	}
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return err
+	}
+
+	signer, _, err := ecdsa.RecoverCompact(signature, bitcoinMessageHash(r.message()))
+	if err != nil || !signer.IsEqual(pubKey) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// BSMSDescriptorRecord is the record the coordinator returns in the second round of BIP129: the descriptor
+// template of the wallet, the paths its keys derive and its first address
+type BSMSDescriptorRecord struct {
+	Multisig         *Multisig
+	PathRestrictions string // e.g. /0/*,/1/*
+	FirstAddress     string
+}
+
+// ParseBSMSDescriptorRecord parses a descriptor record and verifies its first address
+func ParseBSMSDescriptorRecord(record string, network NetworkType) (*BSMSDescriptorRecord, error) {
+	lines := bsmsLines(record)
+	if len(lines) != 4 || lines[0] != BSMSVersion {
+		return nil, fmt.Errorf("%w: expected a descriptor record", ErrInvalidBSMSRecord)
+	}
+
+	m, err := ParseMultisigDescriptor(lines[1], network)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &BSMSDescriptorRecord{Multisig: m, PathRestrictions: lines[2], FirstAddress: lines[3]}
+	if r.PathRestrictions != bsmsPathRestrictions && r.PathRestrictions != BSMSNoPathRestrictions {
+		return nil, fmt.Errorf("%w: unsupported path restrictions %s", ErrInvalidBSMSRecord, r.PathRestrictions)
+	}
+
+	firstAddress, err := m.FirstAddress()
+	if err != nil {
+		return nil, err
+	}
+	if firstAddress != r.FirstAddress {
+		return nil, fmt.Errorf("%w: %s", ErrAddressMismatch, r.FirstAddress)
+	}
+
+	return r, nil
+}
+
+// VerifyBSMSDescriptorRecord is the check of a signer in the second round of BIP129: it decrypts the descriptor
+// record with its token, verifies the first address and that signer is one of the signers of the wallet
+func VerifyBSMSDescriptorRecord(token, data string, signer MultisigSigner, network NetworkType) (*BSMSDescriptorRecord, error) {
+	record, err := DecryptBSMSRecord(token, data)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := ParseBSMSDescriptorRecord(record, network)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.Multisig.HasSigner(signer) {
+		return nil, fmt.Errorf("%w: signer %s is not in the descriptor", ErrInvalidBSMSRecord, signer.KeyExpression())
+	}
+
+	return r, nil
+}
+
+// String returns the record
+func (r *BSMSDescriptorRecord) String() string {
+	return strings.Join([]string{BSMSVersion, r.Multisig.DescriptorTemplate(), r.PathRestrictions, r.FirstAddress}, "\n")
+}
+
+// NewToken issues the token of a signer for the first round of BIP129, at the encryption level of the config
+func (c *MultisigCoordinator) NewToken() (string, error) {
+	token, err := GenerateBSMSToken(c.config.Encryption)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.tokens) == c.config.Signers {
+		return "", ErrTooManySigners
+	}
+	c.tokens = append(c.tokens, token)
+	c.used = append(c.used, false)
+
+	return token, nil
+}
+
+// AddKeyRecord adds the signer of a key record made with one of the issued tokens, after decrypting it and
+// verifying its signature. Each token adds a single signer
+func (c *MultisigCoordinator) AddKeyRecord(data string) error {
+	c.mu.Lock()
+	tokens := append([]string(nil), c.tokens...)
+	c.mu.Unlock()
+
+	// the error of a record that decrypted but is invalid wins over unknown tokens
+	parseErr := ErrUnknownBSMSToken
+	for i, token := range tokens {
+		record, err := DecryptBSMSRecord(token, data)
+		if err != nil {
+			continue
+		}
+
+		r, err := ParseBSMSKeyRecord(record, c.config.Network)
+		if err != nil {
+			if token != bsmsNoToken {
+				parseErr = err
+			}
+			continue
+		}
+		if r.Token != token {
+			continue
+		}
+
+		// token 00 is issued to every signer, a record takes the first one left
+		c.mu.Lock()
+		used := c.used[i]
+		c.used[i] = true
+		c.mu.Unlock()
+		if used {
+			parseErr = ErrBSMSTokenUsed
+			continue
+		}
+
+		if err = c.AddSigner(r.Signer); err != nil {
+			c.mu.Lock()
+			c.used[i] = false
+			c.mu.Unlock()
+		}
+		return err
+	}
+
+	return parseErr
+}
+
+// DescriptorRecord returns the descriptor record of the second round of BIP129, once every signer was added
+func (c *MultisigCoordinator) DescriptorRecord() (*BSMSDescriptorRecord, error) {
+	m, err := c.Multisig()
+	if err != nil {
+		return nil, err
+	}
+
+	firstAddress, err := m.FirstAddress()
+	if err != nil {
+		return nil, err
+	}
+
+	return &BSMSDescriptorRecord{Multisig: m, PathRestrictions: bsmsPathRestrictions, FirstAddress: firstAddress}, nil
+}
+
+// DescriptorRecords returns the descriptor record encrypted with each issued token, by token
+func (c *MultisigCoordinator) DescriptorRecords() (map[string]string, error) {
+	r, err := c.DescriptorRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	tokens := append([]string(nil), c.tokens...)
+	c.mu.Unlock()
+
+	records := make(map[string]string, len(tokens))
+	for _, token := range tokens {
+		if records[token], err = EncryptBSMSRecord(token, r.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+// validateBSMSToken checks that a token is 00 or a 64 or 128 bit hex nonce
+func validateBSMSToken(token string) error {
+	if token == bsmsNoToken {
+		return nil
+	}
+
+	decoded, err := hex.DecodeString(token)
+	if err != nil || (len(decoded) != 8 && len(decoded) != 16) {
+		return fmt.Errorf("%w: %s", ErrInvalidBSMSToken, token)
+	}
+
+	return nil
+}
+
+// bsmsEncryptionKey derives the AES-256 key of a token
+func bsmsEncryptionKey(token string) ([]byte, error) {
+	if err := validateBSMSToken(token); err != nil {
+		return nil, err
+	}
+
+	salt, _ := hex.DecodeString(token)
+	return pbkdf2.Key(sha512.New, bsmsPassword, salt, 2048, 32)
+}
+
+// bsmsMAC returns HMAC-SHA256(SHA256(key), token || data), the token as its hex string
+func bsmsMAC(key []byte, token string, data []byte) []byte {
+	macKey := sha256.Sum256(key)
+	mac := hmac.New(sha256.New, macKey[:])
+	mac.Write([]byte(token))
+	mac.Write(data)
+
+	return mac.Sum(nil)
+}
+
+// bsmsCrypt encrypts or decrypts data with AES-256-CTR
+func bsmsCrypt(key, iv, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(out, data)
+	return out, nil
+}
+
+// bsmsLines splits a record into its lines
+func bsmsLines(record string) []string {
+	record = strings.ReplaceAll(strings.TrimSpace(record), "\r\n", "\n")
+	return strings.Split(record, "\n")
+}
+
+// bitcoinMessageHash returns the hash signed by Bitcoin message signatures (signmessage)
+func bitcoinMessageHash(message string) []byte {
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
+	_ = wire.WriteVarString(&buf, 0, message)
+
+	return chainhash.DoubleHashB(buf.Bytes())
+}
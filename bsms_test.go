@@ -0,0 +1,266 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGenerateBSMSToken will test the method GenerateBSMSToken()
+func TestGenerateBSMSToken(t *testing.T) {
+	t.Parallel()
+
+	tests := map[BSMSEncryption]int{
+		BSMSNoEncryption:       2,
+		BSMSStandardEncryption: 16,
+		BSMSExtendedEncryption: 32,
+	}
+	for encryption, length := range tests {
+		token, err := GenerateBSMSToken(encryption)
+		require.NoError(t, err)
+		assert.Len(t, token, length)
+		require.NoError(t, validateBSMSToken(token))
+	}
+
+	_, err := GenerateBSMSToken("ULTRA")
+	assert.ErrorIs(t, err, ErrInvalidBSMSToken)
+	assert.ErrorIs(t, validateBSMSToken("a54044308ceac9"), ErrInvalidBSMSToken)
+	assert.ErrorIs(t, validateBSMSToken("zz"), ErrInvalidBSMSToken)
+}
+
+// TestEncryptBSMSRecord will test the methods EncryptBSMSRecord() and DecryptBSMSRecord()
+func TestEncryptBSMSRecord(t *testing.T) {
+	t.Parallel()
+
+	record := BSMSVersion + "\n00\n[d34db33f/48h/0h/0h/2h]xpub...\nSigner 1\nsig"
+	token := "a54044308ceac9b7"
+
+	encrypted, err := EncryptBSMSRecord(token, record)
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, "BSMS")
+	assert.Len(t, encrypted, 2*(32+len(record)))
+
+	// deterministic, the IV comes from the MAC
+	again, err := EncryptBSMSRecord(token, record)
+	require.NoError(t, err)
+	assert.Equal(t, encrypted, again)
+
+	decrypted, err := DecryptBSMSRecord(token, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, record, decrypted)
+
+	_, err = DecryptBSMSRecord("a54044308ceac9b8", encrypted)
+	assert.ErrorIs(t, err, ErrBSMSAuthentication)
+	tampered := encrypted[:len(encrypted)-2] + "00"
+	if tampered == encrypted {
+		tampered = encrypted[:len(encrypted)-2] + "01"
+	}
+	_, err = DecryptBSMSRecord(token, tampered)
+	assert.ErrorIs(t, err, ErrBSMSAuthentication)
+	_, err = DecryptBSMSRecord(token, "abcd")
+	assert.ErrorIs(t, err, ErrInvalidBSMSRecord)
+
+	// no encryption
+	plain, err := EncryptBSMSRecord("00", record)
+	require.NoError(t, err)
+	assert.Equal(t, record, plain)
+}
+
+// TestEncryptBSMSRecordVectors will test EncryptBSMSRecord() and DecryptBSMSRecord() against fixed keys, MACs
+// and ciphertexts. The expected values are not BIP129's published vectors: they were cross-checked against
+// PBKDF2-SHA512 and HMAC-SHA256 of Python's hashlib
+func TestEncryptBSMSRecordVectors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		token         string
+		encryptionKey string
+		mac           string
+		ciphertext    string
+	}{
+		{
+			token:         "a54044308ceac9b7",
+			encryptionKey: "7673ffd9efd70336a5442eda0b31457f7b6cdf7b42fe17f274434df55efa9839",
+			mac:           "d42166eb531e4479cfb786d539d910c6b8cca4cd7b1ed5402e2cb5936b2106ef",
+			ciphertext: "27c452fbe59a4ab98a97b8698dc014372e028d1fbef55ae35d2f76ba61cb0c9d96d9ed72a428e7df975918e6feb6b11a" +
+				"3dc6ba2a2a153542acf9b2dd3f9944821819b507e380f5a99c1c6ae84f",
+		},
+		{
+			token:         "00112233445566778899aabbccddeeff",
+			encryptionKey: "0ddad86d5e541947dbe4c3ca38a8a7c0023abbd36ce846e877caf066045c97df",
+			mac:           "ff71b77bacd6f8bed82698c263d2cbe95fa7e6b329b97c5ae4a3ef310d036816",
+			ciphertext: "364d4fbf7c436282f42f202ad2e0e67babad62ff4375eaa36a9589dff2fda5b6a51142a50f17d56e474cce62614712686ee49741" +
+				"6d0b9a697a10707773ec5a98a40b2ff0fba2e7ac4c5878c60f35cd4b4d789192bc42829a0409820a37",
+		},
+	}
+
+	for _, test := range tests {
+		record := BSMSVersion + "\n" + test.token + "\n[b7868815/48h/1h/0h/2h]tpub...\nSigner key\nsignature"
+
+		key, err := bsmsEncryptionKey(test.token)
+		require.NoError(t, err)
+		assert.Equal(t, test.encryptionKey, hex.EncodeToString(key))
+
+		encrypted, err := EncryptBSMSRecord(test.token, record)
+		require.NoError(t, err)
+		assert.Equal(t, test.mac+test.ciphertext, encrypted)
+
+		decrypted, err := DecryptBSMSRecord(test.token, test.mac+test.ciphertext)
+		require.NoError(t, err)
+		assert.Equal(t, record, decrypted)
+	}
+}
+
+// TestBSMSKeyRecord will test the methods NewBSMSKeyRecord() and ParseBSMSKeyRecord()
+func TestBSMSKeyRecord(t *testing.T) {
+	t.Parallel()
+
+	signers, accountKeys := testMultisigSigners(t, Testnet, 2)
+	signer := signers[0]
+	signer.Description = "Signer 1 key"
+
+	r, err := NewBSMSKeyRecord("00", signer, accountKeys[0])
+	require.NoError(t, err)
+
+	lines := strings.Split(r.String(), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, []string{BSMSVersion, "00", signer.KeyExpression(), "Signer 1 key"}, lines[:4])
+
+	parsed, err := ParseBSMSKeyRecord(r.String(), Testnet)
+	require.NoError(t, err)
+	assert.Equal(t, r, parsed)
+
+	// signed lines cannot change
+	_, err = ParseBSMSKeyRecord(strings.Replace(r.String(), "Signer 1 key", "Signer 2 key", 1), Testnet)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = ParseBSMSKeyRecord(strings.Replace(r.String(), signer.XPub, signers[1].XPub, 1), Testnet)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = ParseBSMSKeyRecord(strings.Replace(r.String(), BSMSVersion, "BSMS 2.0", 1), Testnet)
+	assert.ErrorIs(t, err, ErrInvalidBSMSRecord)
+	_, err = ParseBSMSKeyRecord(r.String(), Mainnet)
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+
+	// only the private key of the signer signs
+	_, err = NewBSMSKeyRecord("00", signer, accountKeys[1])
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = NewBSMSKeyRecord("0", signer, accountKeys[0])
+	assert.ErrorIs(t, err, ErrInvalidBSMSToken)
+	signer.Description = "two\nlines"
+	_, err = NewBSMSKeyRecord("00", signer, accountKeys[0])
+	assert.ErrorIs(t, err, ErrInvalidBSMSRecord)
+}
+
+// TestMultisigCoordinatorBSMS will test a BIP129 setup: the methods NewToken(), AddKeyRecord(),
+// DescriptorRecords() and VerifyBSMSDescriptorRecord()
+func TestMultisigCoordinatorBSMS(t *testing.T) {
+	t.Parallel()
+
+	signers, accountKeys := testMultisigSigners(t, Testnet, 3)
+	coordinator, err := NewMultisigCoordinator(MultisigConfig{Network: Testnet, Threshold: 2, Signers: 3, Encryption: BSMSExtendedEncryption})
+	require.NoError(t, err)
+
+	_, err = coordinator.DescriptorRecords()
+	assert.ErrorIs(t, err, ErrIncompleteMultisig)
+
+	// round 1: a token for each signer, which returns its encrypted key record
+	var tokens []string
+	for i := range signers {
+		token, err := coordinator.NewToken()
+		require.NoError(t, err)
+		tokens = append(tokens, token)
+
+		r, err := NewBSMSKeyRecord(token, signers[i], accountKeys[i])
+		require.NoError(t, err)
+		encrypted, err := EncryptBSMSRecord(token, r.String())
+		require.NoError(t, err)
+
+		if i == 0 {
+			// records of other tokens are rejected
+			other, err := GenerateBSMSToken(BSMSExtendedEncryption)
+			require.NoError(t, err)
+			foreign, err := EncryptBSMSRecord(other, r.String())
+			require.NoError(t, err)
+			assert.ErrorIs(t, coordinator.AddKeyRecord(foreign), ErrUnknownBSMSToken)
+		}
+
+		require.NoError(t, coordinator.AddKeyRecord(encrypted))
+
+		if i == 0 {
+			// a token adds a single signer
+			r, err := NewBSMSKeyRecord(token, signers[1], accountKeys[1])
+			require.NoError(t, err)
+			encrypted, err := EncryptBSMSRecord(token, r.String())
+			require.NoError(t, err)
+			assert.ErrorIs(t, coordinator.AddKeyRecord(encrypted), ErrBSMSTokenUsed)
+		}
+	}
+	_, err = coordinator.NewToken()
+	assert.ErrorIs(t, err, ErrTooManySigners)
+	assert.Equal(t, signers, coordinator.Signers())
+
+	// round 2: every signer checks the descriptor record encrypted with its token
+	records, err := coordinator.DescriptorRecords()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	firstAddress, err := coordinator.FirstAddress()
+	require.NoError(t, err)
+	for i, token := range tokens {
+		r, err := VerifyBSMSDescriptorRecord(token, records[token], signers[i], Testnet)
+		require.NoError(t, err)
+		assert.Equal(t, firstAddress, r.FirstAddress)
+		assert.Equal(t, "/0/*,/1/*", r.PathRestrictions)
+		assert.Equal(t, 2, r.Multisig.Threshold)
+
+		lines := strings.Split(r.String(), "\n")
+		require.Len(t, lines, 4)
+		assert.True(t, strings.HasPrefix(lines[1], "wsh(sortedmulti(2,"))
+	}
+
+	// a signer left out, or a wrong first address, are caught
+	outsider, _ := testMultisigSigners(t, Testnet, 4)
+	_, err = VerifyBSMSDescriptorRecord(tokens[0], records[tokens[0]], outsider[3], Testnet)
+	assert.ErrorIs(t, err, ErrInvalidBSMSRecord)
+
+	r, err := coordinator.DescriptorRecord()
+	require.NoError(t, err)
+	r.FirstAddress, err = r.Multisig.Address(ExternalChain, 1)
+	require.NoError(t, err)
+	_, err = ParseBSMSDescriptorRecord(r.String(), Testnet)
+	assert.ErrorIs(t, err, ErrAddressMismatch)
+}
+
+// TestMultisigCoordinatorBSMSNoEncryption will test a BIP129 setup with token 00
+func TestMultisigCoordinatorBSMSNoEncryption(t *testing.T) {
+	t.Parallel()
+
+	signers, accountKeys := testMultisigSigners(t, Testnet, 3)
+	coordinator, err := NewMultisigCoordinator(MultisigConfig{Network: Testnet, Threshold: 1, Signers: 2, Encryption: BSMSNoEncryption})
+	require.NoError(t, err)
+
+	for i := range signers[:2] {
+		token, err := coordinator.NewToken()
+		require.NoError(t, err)
+		assert.Equal(t, "00", token)
+
+		r, err := NewBSMSKeyRecord(token, signers[i], accountKeys[i])
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddKeyRecord(r.String()))
+	}
+
+	// every token 00 was used
+	extra, err := NewBSMSKeyRecord("00", signers[2], accountKeys[2])
+	require.NoError(t, err)
+	assert.ErrorIs(t, coordinator.AddKeyRecord(extra.String()), ErrBSMSTokenUsed)
+
+	records, err := coordinator.DescriptorRecords()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.True(t, strings.HasPrefix(records["00"], BSMSVersion+"\n"))
+
+	_, err = VerifyBSMSDescriptorRecord("00", records["00"], signers[1], Testnet)
+	require.NoError(t, err)
+}
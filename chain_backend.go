@@ -0,0 +1,104 @@
+package bitcoin
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Utxo is an unspent output found by a ChainBackend
+type Utxo struct {
+	TxID   string
+	Vout   uint32
+	Value  btcutil.Amount
+	Script string // output script (hex string)
+	Height int64  // 0 while unconfirmed
+}
+
+// OutPoint returns the outpoint of the output
+func (u *Utxo) OutPoint() (*wire.OutPoint, error) {
+	return wire.NewOutPointFromString(u.TxID + ":" + strconv.FormatUint(uint64(u.Vout), 10))
+}
+
+// BlockEvent announces a new best block
+type BlockEvent struct {
+	Height int64
+	Hash   string
+}
+
+// ChainBackend is a source of chain data, implemented for bitcoind (rpc), Electrum (electrum) and
+// Esplora (esplora) servers, and in memory by MemoryChainBackend for tests. Scripts are output
+// scripts as hex strings, e.g. from GetScriptFromAddress
+type ChainBackend interface {
+
+	// GetUtxos returns the unspent outputs paying to any of scripts
+	GetUtxos(ctx context.Context, scripts []string) ([]Utxo, error)
+
+	// GetTransaction returns a transaction by txid
+	GetTransaction(ctx context.Context, txID string) (*wire.MsgTx, error)
+
+	// Broadcast broadcasts a signed transaction and returns its txid
+	Broadcast(ctx context.Context, tx *wire.MsgTx) (string, error)
+
+	// EstimateFee returns the fee rate in sat/vB for a transaction to confirm within confTarget blocks
+	EstimateFee(ctx context.Context, confTarget int) (float64, error)
+
+	// TipHeight returns the height of the best block
+	TipHeight(ctx context.Context) (int64, error)
+
+	// SubscribeBlocks announces new best blocks until ctx is done, then closes the channel.
+	// Events are coalesced: a subscriber that falls behind only receives the latest block
+	SubscribeBlocks(ctx context.Context) (<-chan BlockEvent, error)
+}
+
+// sendLatestBlock sends an event to a subscriber, replacing an event it has not read yet
+func sendLatestBlock(events chan BlockEvent, event BlockEvent) {
+	select {
+	case events <- event:
+	default:
+		select {
+		case <-events:
+		default:
+		}
+		events <- event
+	}
+}
+
+// DefaultPollInterval is the interval backends without block notifications poll the best block at by default
+const DefaultPollInterval = 30 * time.Second
+
+// PollBlocks implements ChainBackend.SubscribeBlocks for backends without block notifications,
+// polling tip for the best block every interval (DefaultPollInterval when not positive) until ctx
+// is done. Polling errors are retried
+func PollBlocks(ctx context.Context, interval time.Duration, tip func(ctx context.Context) (BlockEvent, error)) <-chan BlockEvent {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	events := make(chan BlockEvent, 1)
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last BlockEvent
+		for {
+			if event, err := tip(ctx); err == nil && event != last {
+				last = event
+				sendLatestBlock(events, event)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return events
+}
@@ -0,0 +1,80 @@
+package bitcoin
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUtxoOutPoint will test the method Utxo.OutPoint()
+func TestUtxoOutPoint(t *testing.T) {
+	t.Parallel()
+
+	utxo := Utxo{TxID: "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b", Vout: 3}
+	outPoint, err := utxo.OutPoint()
+	require.NoError(t, err)
+	assert.Equal(t, utxo.TxID, outPoint.Hash.String())
+	assert.Equal(t, uint32(3), outPoint.Index)
+
+	utxo.TxID = "zz"
+	_, err = utxo.OutPoint()
+	assert.Error(t, err)
+}
+
+// TestPollBlocks will test the method PollBlocks()
+func TestPollBlocks(t *testing.T) {
+	t.Parallel()
+
+	var height atomic.Int64
+	tip := func(_ context.Context) (BlockEvent, error) {
+		h := height.Load()
+		if h < 0 {
+			return BlockEvent{}, errors.New("backend down")
+		}
+		return BlockEvent{Height: h, Hash: memoryBlockHash(h, 0)}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	height.Store(1)
+	events := PollBlocks(ctx, time.Millisecond, tip)
+
+	assert.Equal(t, int64(1), (<-events).Height)
+
+	// errors are retried and the same tip is not announced twice
+	height.Store(-1)
+	time.Sleep(10 * time.Millisecond)
+	height.Store(1)
+	time.Sleep(10 * time.Millisecond)
+	height.Store(2)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, int64(2), event.Height)
+	case <-time.After(time.Second):
+		t.Fatal("no block event received")
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+// TestPollBlocksDefaultInterval will test that PollBlocks() polls at DefaultPollInterval without an interval
+func TestPollBlocksDefaultInterval(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := PollBlocks(ctx, 0, func(_ context.Context) (BlockEvent, error) {
+		return BlockEvent{Height: 1, Hash: memoryBlockHash(1, 0)}, nil
+	})
+	assert.Equal(t, int64(1), (<-events).Height)
+
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,141 @@
+package bitcoin
+
+import (
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// parsePoint parses a compressed public key into a point
+func parsePoint(pubKey []byte) (*btcec.JacobianPoint, error) {
+	key, err := btcec.ParsePubKey(pubKey)
+	if err != nil || len(pubKey) != btcec.PubKeyBytesLenCompressed {
+		return nil, ErrInvalidPubKey
+	}
+
+	var point btcec.JacobianPoint
+	key.AsJacobian(&point)
+	return &point, nil
+}
+
+// parsePointExt parses a compressed public key, or 33 zero bytes for the point at infinity
+func parsePointExt(pubKey []byte) (*btcec.JacobianPoint, error) {
+	if isZeroBytes(pubKey) && len(pubKey) == btcec.PubKeyBytesLenCompressed {
+		return &btcec.JacobianPoint{}, nil
+	}
+
+	return parsePoint(pubKey)
+}
+
+// jacobian returns a public key as a point
+func jacobian(pubKey *btcec.PublicKey) *btcec.JacobianPoint {
+	var point btcec.JacobianPoint
+	pubKey.AsJacobian(&point)
+	return &point
+}
+
+// pointBytes returns the compressed serialization of a point, 33 zero bytes for the point at infinity
+func pointBytes(point *btcec.JacobianPoint) []byte {
+	if isInfinity(point) {
+		return make([]byte, btcec.PubKeyBytesLenCompressed)
+	}
+
+	return pointPubKey(point).SerializeCompressed()
+}
+
+// pointXBytes returns the x coordinate of a point, the x-only (BIP340) serialization
+func pointXBytes(point *btcec.JacobianPoint) []byte {
+	return pointBytes(point)[1:]
+}
+
+// pointPubKey returns a point as a public key
+func pointPubKey(point *btcec.JacobianPoint) *btcec.PublicKey {
+	affine := *point
+	affine.ToAffine()
+	return btcec.NewPublicKey(&affine.X, &affine.Y)
+}
+
+// hasEvenY returns whether the y coordinate of a point is even
+func hasEvenY(point *btcec.JacobianPoint) bool {
+	affine := *point
+	affine.ToAffine()
+	return !affine.Y.IsOdd()
+}
+
+// negatePoint negates a point in place
+func negatePoint(point *btcec.JacobianPoint) {
+	point.ToAffine()
+	point.Y.Negate(1).Normalize()
+}
+
+// isInfinity returns whether a point is the point at infinity
+func isInfinity(point *btcec.JacobianPoint) bool {
+	return (point.X.IsZero() && point.Y.IsZero()) || point.Z.IsZero()
+}
+
+// addPoints returns a + b
+func addPoints(a, b *btcec.JacobianPoint) *btcec.JacobianPoint {
+	var sum btcec.JacobianPoint
+	btcec.AddNonConst(a, b, &sum)
+	return &sum
+}
+
+// scalarMult returns k·point
+func scalarMult(k *btcec.ModNScalar, point *btcec.JacobianPoint) *btcec.JacobianPoint {
+	var product btcec.JacobianPoint
+	btcec.ScalarMultNonConst(k, point, &product)
+	return &product
+}
+
+// scalarBaseMult returns k·G
+func scalarBaseMult(k *btcec.ModNScalar) *btcec.JacobianPoint {
+	var product btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(k, &product)
+	return &product
+}
+
+// equalPoints returns whether two points are equal
+func equalPoints(a, b *btcec.JacobianPoint) bool {
+	if isInfinity(a) || isInfinity(b) {
+		return isInfinity(a) && isInfinity(b)
+	}
+
+	affineA, affineB := *a, *b
+	affineA.ToAffine()
+	affineB.ToAffine()
+	return affineA.X.Equals(&affineB.X) && affineA.Y.Equals(&affineB.Y)
+}
+
+// hashScalar returns a hash reduced modulo the curve order
+func hashScalar(hash []byte) *btcec.ModNScalar {
+	var scalar btcec.ModNScalar
+	scalar.SetByteSlice(hash)
+	return &scalar
+}
+
+// parseScalar parses a 32 byte scalar, failing when it is not below the curve order
+func parseScalar(b []byte) (*btcec.ModNScalar, bool) {
+	if len(b) != 32 {
+		return nil, false
+	}
+
+	var scalar btcec.ModNScalar
+	if overflow := scalar.SetByteSlice(b); overflow {
+		return nil, false
+	}
+	return &scalar, true
+}
+
+// scalarBytes returns the 32 byte serialization of a scalar
+func scalarBytes(scalar *btcec.ModNScalar) []byte {
+	b := scalar.Bytes()
+	return b[:]
+}
+
+// isZeroBytes returns whether every byte is zero
+func isZeroBytes(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,411 @@
+package bitcoin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// DepositEventType is the kind of change reported for a deposit
+type DepositEventType string
+
+const (
+	DepositSeen      DepositEventType = "seen"      // first seen, in the mempool or already confirmed
+	DepositConfirmed DepositEventType = "confirmed" // reached one of the confirmation thresholds
+	DepositReorged   DepositEventType = "reorged"   // lost confirmations to a reorg, thresholds are reported again
+	DepositSpent     DepositEventType = "spent"     // spent before reaching the last threshold
+	DepositRemoved   DepositEventType = "removed"   // gone before reaching the last threshold: reorged out or double spent
+)
+
+// Deposit is an output paying to a watched address
+type Deposit struct {
+	Address       string
+	TxID          string
+	Vout          uint32
+	Value         btcutil.Amount
+	Height        int64 // 0 while unconfirmed
+	Confirmations int64
+}
+
+// DepositEvent reports a change of a deposit
+type DepositEvent struct {
+	Type      DepositEventType
+	Deposit   Deposit
+	Threshold int64 // the threshold reached, set for DepositConfirmed
+}
+
+// DepositWatcherConfig holds the settings of a DepositWatcher
+type DepositWatcherConfig struct {
+	Backend      ChainBackend
+	Network      NetworkType
+	Addresses    []string      // e.g. from GetAddressFromMnemonic, more can be added with AddAddresses
+	Thresholds   []int64       // confirmation counts reported with DepositConfirmed, defaults to 1 and 6
+	PollInterval time.Duration // rescan interval catching mempool deposits between blocks, defaults to 30 seconds
+}
+
+// DepositWatcher reports the payments to a set of addresses as they are seen, confirmed and reorged.
+// Deposits are tracked until they reach the last threshold, deeper reorgs are not reported. A deposit
+// leaving the unspent outputs was spent while the backend still knows its transaction, backends only
+// knowing mempool transactions (e.g. a node without -txindex) report confirmed ones as removed
+type DepositWatcher struct {
+	config DepositWatcherConfig
+
+	scanMu sync.Mutex // serializes scans
+
+	mu       sync.Mutex
+	scripts  map[string]string // output script (hex string) to address
+	deposits map[string]trackedDeposit
+	settled  map[string]struct{} // deposits past the last threshold, kept until spent
+}
+
+// trackedDeposit is a deposit with the highest threshold reported for it
+type trackedDeposit struct {
+	deposit  Deposit
+	reported int64
+}
+
+// NewDepositWatcher creates a watcher for config.Addresses
+func NewDepositWatcher(config DepositWatcherConfig) (*DepositWatcher, error) {
+
+	// Missing backend
+	if config.Backend == nil {
+		return nil, ErrMissingChainBackend
+	}
+
+	// Missing network
+	if config.Network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	if len(config.Thresholds) == 0 {
+		config.Thresholds = []int64{1, 6}
+	}
+	thresholds := make([]int64, 0, len(config.Thresholds))
+	for _, threshold := range config.Thresholds {
+		if threshold < 1 {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidThreshold, threshold)
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	sort.Slice(thresholds, func(i, j int) bool { return thresholds[i] < thresholds[j] })
+	config.Thresholds = thresholds
+
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+
+	w := &DepositWatcher{
+		config:   config,
+		scripts:  make(map[string]string),
+		deposits: make(map[string]trackedDeposit),
+		settled:  make(map[string]struct{}),
+	}
+
+	if err := w.AddAddresses(config.Addresses...); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// AddAddresses starts watching addresses, which are validated against the watcher's network
+func (w *DepositWatcher) AddAddresses(addresses ...string) error {
+	scripts := make(map[string]string, len(addresses))
+	for _, address := range addresses {
+		script, err := w.script(address)
+		if err != nil {
+			return err
+		}
+		scripts[script] = address
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for script, address := range scripts {
+		w.scripts[script] = address
+	}
+
+	return nil
+}
+
+// RemoveAddresses stops watching addresses, dropping their deposits without reporting them
+func (w *DepositWatcher) RemoveAddresses(addresses ...string) error {
+	removed := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		script, err := w.script(address)
+		if err != nil {
+			return err
+		}
+		removed[address] = true
+
+		w.mu.Lock()
+		delete(w.scripts, script)
+		w.mu.Unlock()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for key, tracked := range w.deposits {
+		if removed[tracked.deposit.Address] {
+			delete(w.deposits, key)
+		}
+	}
+
+	return nil
+}
+
+// Deposits returns the deposits tracked until their last threshold, ordered by txid and output
+func (w *DepositWatcher) Deposits() []Deposit {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	deposits := make([]Deposit, 0, len(w.deposits))
+	for _, tracked := range w.deposits {
+		deposits = append(deposits, tracked.deposit)
+	}
+	sortDeposits(deposits)
+
+	return deposits
+}
+
+// Scan fetches the deposits of the watched addresses from the backend and returns what changed since
+// the previous scan
+func (w *DepositWatcher) Scan(ctx context.Context) ([]DepositEvent, error) {
+	w.scanMu.Lock()
+	defer w.scanMu.Unlock()
+
+	events, commit, err := w.scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	commit()
+	return events, nil
+}
+
+// Run scans on every new block and every PollInterval, handing the events to handle, until ctx is done,
+// a scan fails or handle fails. A scan is only recorded once handle accepted all its events, so events of
+// a failed run are handed again by the next Scan or Run and handle must be idempotent
+func (w *DepositWatcher) Run(ctx context.Context, handle func(DepositEvent) error) error {
+	blocks, err := w.config.Backend.SubscribeBlocks(ctx)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err = w.runScan(ctx, handle); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case _, ok := <-blocks:
+
+			// without block notifications, the ticker alone triggers scans
+			if !ok {
+				blocks = nil
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// runScan scans and records the scan once handle accepted every event
+func (w *DepositWatcher) runScan(ctx context.Context, handle func(DepositEvent) error) error {
+	w.scanMu.Lock()
+	defer w.scanMu.Unlock()
+
+	events, commit, err := w.scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err = handle(event); err != nil {
+			return err
+		}
+	}
+
+	commit()
+	return nil
+}
+
+// scan compares the unspent outputs of the watched addresses with the tracked deposits. The returned
+// commit records the new state, w.scanMu must be held
+func (w *DepositWatcher) scan(ctx context.Context) ([]DepositEvent, func(), error) {
+	w.mu.Lock()
+	scripts := make(map[string]string, len(w.scripts))
+	for script, address := range w.scripts {
+		scripts[script] = address
+	}
+	deposits := make(map[string]trackedDeposit, len(w.deposits))
+	for key, tracked := range w.deposits {
+		deposits[key] = tracked
+	}
+	settled := make(map[string]struct{}, len(w.settled))
+	for key := range w.settled {
+		settled[key] = struct{}{}
+	}
+	w.mu.Unlock()
+
+	tip, err := w.config.Backend.TipHeight(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	watched := make([]string, 0, len(scripts))
+	for script := range scripts {
+		watched = append(watched, script)
+	}
+
+	var utxos []Utxo
+	if len(watched) > 0 {
+		if utxos, err = w.config.Backend.GetUtxos(ctx, watched); err != nil {
+			return nil, nil, err
+		}
+	}
+	sort.Slice(utxos, func(i, j int) bool {
+		if utxos[i].TxID != utxos[j].TxID {
+			return utxos[i].TxID < utxos[j].TxID
+		}
+		return utxos[i].Vout < utxos[j].Vout
+	})
+
+	lastThreshold := w.config.Thresholds[len(w.config.Thresholds)-1]
+	unspent := make(map[string]bool, len(utxos))
+
+	var events []DepositEvent
+	for _, utxo := range utxos {
+		address, ok := scripts[utxo.Script]
+		if !ok {
+			continue
+		}
+
+		key := utxo.TxID + ":" + strconv.FormatUint(uint64(utxo.Vout), 10)
+		unspent[key] = true
+		if _, ok = settled[key]; ok {
+			continue
+		}
+
+		// a block found between both calls can confirm outputs above the tip read first
+		deposit := Deposit{
+			Address:       address,
+			TxID:          utxo.TxID,
+			Vout:          utxo.Vout,
+			Value:         utxo.Value,
+			Height:        utxo.Height,
+			Confirmations: confirmations(utxo.Height, max(tip, utxo.Height)),
+		}
+
+		tracked, ok := deposits[key]
+		switch {
+		case !ok:
+			events = append(events, DepositEvent{Type: DepositSeen, Deposit: deposit})
+		case tracked.deposit.Height > 0 && (deposit.Height != tracked.deposit.Height || deposit.Confirmations < tracked.reported):
+			events = append(events, DepositEvent{Type: DepositReorged, Deposit: deposit})
+			tracked.reported = 0
+		}
+		tracked.deposit = deposit
+
+		for _, threshold := range w.config.Thresholds {
+			if threshold > tracked.reported && deposit.Confirmations >= threshold {
+				events = append(events, DepositEvent{Type: DepositConfirmed, Deposit: deposit, Threshold: threshold})
+				tracked.reported = threshold
+			}
+		}
+
+		if tracked.reported == lastThreshold {
+			delete(deposits, key)
+			settled[key] = struct{}{}
+		} else {
+			deposits[key] = tracked
+		}
+	}
+
+	var gone []Deposit
+	for key, tracked := range deposits {
+		if !unspent[key] {
+			gone = append(gone, tracked.deposit)
+			delete(deposits, key)
+		}
+	}
+	sortDeposits(gone)
+
+	// the transaction of a spent deposit is still known, that of a reorged out or double spent one is not
+	for _, deposit := range gone {
+		eventType := DepositSpent
+		if _, err = w.config.Backend.GetTransaction(ctx, deposit.TxID); errors.Is(err, ErrTransactionNotFound) {
+			eventType = DepositRemoved
+		} else if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, DepositEvent{Type: eventType, Deposit: deposit})
+	}
+
+	// settled deposits are forgotten once spent
+	for key := range settled {
+		if !unspent[key] {
+			delete(settled, key)
+		}
+	}
+
+	commit := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		// deposits of addresses removed during the scan stay dropped
+		watchedAddresses := make(map[string]bool, len(w.scripts))
+		for _, address := range w.scripts {
+			watchedAddresses[address] = true
+		}
+		for key, tracked := range deposits {
+			if !watchedAddresses[tracked.deposit.Address] {
+				delete(deposits, key)
+			}
+		}
+		w.deposits = deposits
+		w.settled = settled
+	}
+
+	return events, commit, nil
+}
+
+// script returns the output script of an address of the watcher's network
+func (w *DepositWatcher) script(address string) (string, error) {
+	if _, err := DecodeAddressForNetwork(address, w.config.Network); err != nil {
+		return "", err
+	}
+
+	return GetScriptFromAddress(address, w.config.Network)
+}
+
+// confirmations returns the confirmations of an output confirmed at height, 0 while unconfirmed
+func confirmations(height, tip int64) int64 {
+	if height <= 0 {
+		return 0
+	}
+
+	return tip - height + 1
+}
+
+// sortDeposits orders deposits by txid and output
+func sortDeposits(deposits []Deposit) {
+	sort.Slice(deposits, func(i, j int) bool {
+		if deposits[i].TxID != deposits[j].TxID {
+			return deposits[i].TxID < deposits[j].TxID
+		}
+		return deposits[i].Vout < deposits[j].Vout
+	})
+}
@@ -0,0 +1,361 @@
+package bitcoin
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDepositWatcher returns a watcher over a memory backend for two mnemonic addresses
+func newTestDepositWatcher(t *testing.T, thresholds ...int64) (*DepositWatcher, *MemoryChainBackend, []string) {
+	var addresses []string
+	for i := uint32(0); i < 2; i++ {
+		address, err := GetAddressFromMnemonic(Mainnet, NativeSegwit, TestMnemonicPhrase, "", i)
+		require.NoError(t, err)
+		addresses = append(addresses, address)
+	}
+
+	backend := NewMemoryChainBackend()
+	watcher, err := NewDepositWatcher(DepositWatcherConfig{
+		Backend:    backend,
+		Network:    Mainnet,
+		Addresses:  addresses,
+		Thresholds: thresholds,
+	})
+	require.NoError(t, err)
+
+	return watcher, backend, addresses
+}
+
+// payTo returns a transaction paying value to address
+func payTo(t *testing.T, address string, value int64) *wire.MsgTx {
+	tx, _ := newSpendingTx(nil)
+	tx.TxOut[0] = wire.NewTxOut(value, pkScriptForAddress(t, address))
+	return tx
+}
+
+// eventTypes returns the types and thresholds of events
+func eventTypes(events []DepositEvent) []string {
+	var types []string
+	for _, event := range events {
+		if event.Type == DepositConfirmed {
+			types = append(types, string(event.Type)+":"+strconv.FormatInt(event.Threshold, 10))
+		} else {
+			types = append(types, string(event.Type))
+		}
+	}
+	return types
+}
+
+// TestNewDepositWatcher will test the method NewDepositWatcher()
+func TestNewDepositWatcher(t *testing.T) {
+	t.Parallel()
+
+	backend := NewMemoryChainBackend()
+
+	_, err := NewDepositWatcher(DepositWatcherConfig{Network: Mainnet})
+	assert.ErrorIs(t, err, ErrMissingChainBackend)
+
+	_, err = NewDepositWatcher(DepositWatcherConfig{Backend: backend})
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+
+	_, err = NewDepositWatcher(DepositWatcherConfig{Backend: backend, Network: Mainnet, Thresholds: []int64{0}})
+	assert.ErrorIs(t, err, ErrInvalidThreshold)
+
+	_, err = NewDepositWatcher(DepositWatcherConfig{
+		Backend:   backend,
+		Network:   Testnet,
+		Addresses: []string{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+	})
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+
+	// no addresses to watch yet
+	watcher, err := NewDepositWatcher(DepositWatcherConfig{Backend: backend, Network: Mainnet})
+	require.NoError(t, err)
+	events, err := watcher.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+// TestDepositWatcherScan will test the confirmation thresholds of DepositWatcher.Scan()
+func TestDepositWatcherScan(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	watcher, backend, addresses := newTestDepositWatcher(t, 3, 1)
+
+	tx := payTo(t, addresses[1], 25000)
+	backend.AddTransaction(tx)
+
+	events, err := watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"seen"}, eventTypes(events))
+	assert.Equal(t, Deposit{
+		Address: addresses[1],
+		TxID:    tx.TxHash().String(),
+		Value:   btcutil.Amount(25000),
+	}, events[0].Deposit)
+
+	// nothing changed
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	backend.MineBlock()
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"confirmed:1"}, eventTypes(events))
+	assert.Equal(t, int64(1), events[0].Deposit.Height)
+	assert.Equal(t, int64(1), events[0].Deposit.Confirmations)
+
+	// thresholds skipped between scans are all reported
+	backend.MineBlock()
+	backend.MineBlock()
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"confirmed:3"}, eventTypes(events))
+	assert.Equal(t, int64(3), events[0].Deposit.Confirmations)
+	assert.Empty(t, watcher.Deposits())
+
+	// a deposit confirmed before the first scan
+	other := payTo(t, addresses[0], 10000)
+	backend.AddTransaction(other)
+	backend.MineBlock()
+	backend.MineBlock()
+
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"seen", "confirmed:1"}, eventTypes(events))
+	require.Len(t, watcher.Deposits(), 1)
+	assert.Equal(t, int64(2), watcher.Deposits()[0].Confirmations)
+}
+
+// TestDepositWatcherReorg will test the reorg handling of DepositWatcher.Scan()
+func TestDepositWatcherReorg(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	watcher, backend, addresses := newTestDepositWatcher(t, 1, 3, 6)
+
+	tx := payTo(t, addresses[0], 25000)
+	backend.AddTransaction(tx)
+	backend.MineBlock()
+	backend.MineBlock()
+
+	events, err := watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"seen", "confirmed:1"}, eventTypes(events))
+
+	// the block is disconnected, the deposit is back in the mempool
+	backend.Reorg(2)
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"reorged"}, eventTypes(events))
+	assert.Equal(t, int64(0), events[0].Deposit.Height)
+
+	// and confirmed again in the new chain
+	backend.MineBlock()
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"confirmed:1"}, eventTypes(events))
+
+	backend.MineBlock()
+	backend.MineBlock()
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"confirmed:3"}, eventTypes(events))
+
+	// a shorter chain takes confirmations back below a reported threshold
+	backend.Reorg(1)
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"reorged", "confirmed:1"}, eventTypes(events))
+	assert.Equal(t, int64(2), events[1].Deposit.Confirmations)
+
+	backend.MineBlock()
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"confirmed:3"}, eventTypes(events))
+
+	// double spent by the new chain
+	backend.Reorg(3, tx.TxHash().String())
+	backend.MineBlock()
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"removed"}, eventTypes(events))
+	assert.Equal(t, tx.TxHash().String(), events[0].Deposit.TxID)
+	assert.Empty(t, watcher.Deposits())
+}
+
+// TestDepositWatcherSpent will test that spent deposits are told apart from removed ones
+func TestDepositWatcherSpent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	watcher, backend, addresses := newTestDepositWatcher(t, 1, 3)
+
+	tx := payTo(t, addresses[0], 25000)
+	backend.AddTransaction(tx)
+	backend.MineBlock()
+
+	events, err := watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"seen", "confirmed:1"}, eventTypes(events))
+
+	// the deposit is spent before its last threshold, its transaction stays in the chain
+	txHash := tx.TxHash()
+	spend := wire.NewMsgTx(2)
+	spend.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&txHash, 0), nil, nil))
+	spend.AddTxOut(wire.NewTxOut(24000, pkScriptForAddress(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")))
+	backend.AddTransaction(spend)
+
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"spent"}, eventTypes(events))
+	assert.Equal(t, txHash.String(), events[0].Deposit.TxID)
+	assert.Empty(t, watcher.Deposits())
+
+	// backend failures fail the scan
+	failing := &failingTransactionBackend{MemoryChainBackend: backend, err: errors.New("backend down")}
+	watcher, err = NewDepositWatcher(DepositWatcherConfig{Backend: failing, Network: Mainnet, Addresses: addresses})
+	require.NoError(t, err)
+
+	other := payTo(t, addresses[1], 10000)
+	backend.AddTransaction(other)
+	_, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+
+	otherHash := other.TxHash()
+	spendOther := wire.NewMsgTx(2)
+	spendOther.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&otherHash, 0), nil, nil))
+	spendOther.AddTxOut(wire.NewTxOut(9000, pkScriptForAddress(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")))
+	backend.AddTransaction(spendOther)
+
+	_, err = watcher.Scan(ctx)
+	assert.ErrorIs(t, err, failing.err)
+}
+
+// failingTransactionBackend is a memory backend failing GetTransaction
+type failingTransactionBackend struct {
+	*MemoryChainBackend
+	err error
+}
+
+// GetTransaction implements ChainBackend
+func (b *failingTransactionBackend) GetTransaction(_ context.Context, _ string) (*wire.MsgTx, error) {
+	return nil, b.err
+}
+
+// TestDepositWatcherAddresses will test the methods AddAddresses() and RemoveAddresses()
+func TestDepositWatcherAddresses(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	watcher, backend, addresses := newTestDepositWatcher(t)
+
+	address, err := GetAddressFromMnemonic(Mainnet, NativeSegwit, TestMnemonicPhrase, "", 5)
+	require.NoError(t, err)
+	backend.AddTransaction(payTo(t, address, 1000))
+	backend.AddTransaction(payTo(t, addresses[0], 2000))
+
+	events, err := watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	require.NoError(t, watcher.AddAddresses(address))
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, address, events[0].Deposit.Address)
+
+	// removed addresses are dropped silently
+	require.NoError(t, watcher.RemoveAddresses(addresses[0]))
+	require.Len(t, watcher.Deposits(), 1)
+	events, err = watcher.Scan(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	assert.ErrorIs(t, watcher.AddAddresses("tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"), ErrWrongNetwork)
+}
+
+// TestDepositWatcherRun will test the method DepositWatcher.Run()
+func TestDepositWatcherRun(t *testing.T) {
+	t.Parallel()
+
+	watcher, backend, addresses := newTestDepositWatcher(t, 1)
+	backend.AddTransaction(payTo(t, addresses[0], 1000))
+
+	// a failing handler leaves the events for the next run
+	failure := errors.New("database down")
+	err := watcher.Run(context.Background(), func(DepositEvent) error {
+		return failure
+	})
+	require.ErrorIs(t, err, failure)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan DepositEvent, 10)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- watcher.Run(ctx, func(event DepositEvent) error {
+			events <- event
+			return nil
+		})
+	}()
+
+	assert.Equal(t, DepositSeen, (<-events).Type)
+
+	// new blocks trigger a scan
+	backend.MineBlock()
+	event := <-events
+	assert.Equal(t, DepositConfirmed, event.Type)
+	assert.Equal(t, int64(1), event.Threshold)
+
+	cancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+}
+
+// TestDepositWatcherRunClosedBlocks will test that Run falls back to polling once block notifications end
+func TestDepositWatcherRunClosedBlocks(t *testing.T) {
+	t.Parallel()
+
+	backend := &closedBlocksBackend{MemoryChainBackend: NewMemoryChainBackend()}
+	watcher, err := NewDepositWatcher(DepositWatcherConfig{Backend: backend, Network: Mainnet, PollInterval: 20 * time.Millisecond})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, watcher.Run(ctx, func(DepositEvent) error { return nil }), context.DeadlineExceeded)
+
+	// one scan on start and one per poll, not one per read of the closed channel
+	assert.LessOrEqual(t, backend.scans.Load(), int64(10))
+	assert.GreaterOrEqual(t, backend.scans.Load(), int64(2))
+}
+
+// closedBlocksBackend is a memory backend whose block notifications end at once, counting scans by tip reads
+type closedBlocksBackend struct {
+	*MemoryChainBackend
+	scans atomic.Int64
+}
+
+// SubscribeBlocks implements ChainBackend
+func (b *closedBlocksBackend) SubscribeBlocks(_ context.Context) (<-chan BlockEvent, error) {
+	events := make(chan BlockEvent)
+	close(events)
+	return events, nil
+}
+
+// TipHeight implements ChainBackend
+func (b *closedBlocksBackend) TipHeight(ctx context.Context) (int64, error) {
+	b.scans.Add(1)
+	return b.MemoryChainBackend.TipHeight(ctx)
+}
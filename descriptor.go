@@ -0,0 +1,92 @@
+package bitcoin
+
+import (
+	"strings"
+)
+
+// descriptorInputCharset is the character set of output descriptors, ordered for the checksum (BIP380)
+const descriptorInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+	"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+	"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+// descriptorChecksumCharset is the bech32 character set the checksum is written in
+const descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// GetDescriptorChecksum returns the 8 character checksum of an output descriptor (BIP380)
+func GetDescriptorChecksum(descriptor string) (string, error) {
+
+	// Missing descriptor
+	if len(descriptor) == 0 {
+		return "", ErrMissingDescriptor
+	}
+
+	c := uint64(1)
+	cls, clsCount := 0, 0
+	for _, ch := range descriptor {
+		pos := strings.IndexRune(descriptorInputCharset, ch)
+		if pos == -1 {
+			return "", ErrInvalidDescriptor
+		}
+
+		// symbols are fed in groups of 5 bits, their character class in groups of 3
+		c = descriptorPolyMod(c, uint64(pos&31))
+		cls = cls*3 + pos>>5
+		if clsCount++; clsCount == 3 {
+			c = descriptorPolyMod(c, uint64(cls))
+			cls, clsCount = 0, 0
+		}
+	}
+	if clsCount > 0 {
+		c = descriptorPolyMod(c, uint64(cls))
+	}
+	for i := 0; i < 8; i++ {
+		c = descriptorPolyMod(c, 0)
+	}
+	c ^= 1
+
+	checksum := make([]byte, 8)
+	for i := range checksum {
+		checksum[i] = descriptorChecksumCharset[(c>>(5*(7-i)))&31]
+	}
+
+	return string(checksum), nil
+}
+
+// AddDescriptorChecksum returns the descriptor with its checksum appended ("<descriptor>#<checksum>").
+// A descriptor that already carries a checksum is returned as is once the checksum is verified
+func AddDescriptorChecksum(descriptor string) (string, error) {
+	body, checksum, found := strings.Cut(descriptor, "#")
+
+	expected, err := GetDescriptorChecksum(body)
+	if err != nil {
+		return "", err
+	}
+
+	if found && checksum != expected {
+		return "", ErrInvalidDescriptorChecksum
+	}
+
+	return body + "#" + expected, nil
+}
+
+// descriptorPolyMod is the BCH code generator of the descriptor checksum
+func descriptorPolyMod(c, val uint64) uint64 {
+	c0 := c >> 35
+	c = ((c & 0x7ffffffff) << 5) ^ val
+	if c0&1 != 0 {
+		c ^= 0xf5dee51989
+	}
+	if c0&2 != 0 {
+		c ^= 0xa9fdca3312
+	}
+	if c0&4 != 0 {
+		c ^= 0x1bab10e32d
+	}
+	if c0&8 != 0 {
+		c ^= 0x3706b1677a
+	}
+	if c0&16 != 0 {
+		c ^= 0x644d626ffd
+	}
+	return c
+}
@@ -0,0 +1,31 @@
+package bitcoin
+
+import "testing"
+
+// TestAddDescriptorChecksum will test the methods GetDescriptorChecksum() and AddDescriptorChecksum()
+func TestAddDescriptorChecksum(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		descriptor         string
+		expectedDescriptor string
+		expectedError      bool
+	}{
+		{"raw(deadbeef)", "raw(deadbeef)#89f8spxm", false},
+		{"raw(deadbeef)#89f8spxm", "raw(deadbeef)#89f8spxm", false},
+		{"addr(mkmZxiEcEd8ZqjQWVZuC6so5dFMKEFpN2j)", "addr(mkmZxiEcEd8ZqjQWVZuC6so5dFMKEFpN2j)#02wpgw69", false},
+		{"raw(deadbeef)#89f8spxn", "", true},
+		{"raw(deadbeef)\n", "", true},
+		{"", "", true},
+	}
+
+	for _, test := range tests {
+		if descriptor, err := AddDescriptorChecksum(test.descriptor); err != nil && !test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error not expected but got: %s", t.Name(), test.descriptor, err.Error())
+		} else if err == nil && test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error was expected", t.Name(), test.descriptor)
+		} else if descriptor != test.expectedDescriptor {
+			t.Fatalf("%s Failed: [%s] inputted and [%s] expected, but got: %s", t.Name(), test.descriptor, test.expectedDescriptor, descriptor)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// DustRelayFeeRate is the fee rate (sat/vB) outputs are valued at by the dust rule of nodes running the
+// -dustrelayfee default
+const DustRelayFeeRate = 3
+
+// GetDustLimit returns the smallest value nodes relay in an output paying to address: an output is
+// dust when spending it would cost more than a third of its value at DustRelayFeeRate, e.g. 546 sat
+// for P2PKH, 294 sat for P2WPKH and 330 sat for P2TR
+func GetDustLimit(address string, networkType NetworkType) (btcutil.Amount, error) {
+	script, err := GetScriptFromAddress(address, networkType)
+	if err != nil {
+		return 0, err
+	}
+
+	pkScript, err := hex.DecodeString(script)
+	if err != nil {
+		return 0, err
+	}
+
+	return dustLimit(pkScript), nil
+}
+
+// dustLimit returns the dust limit of an output script, counting the output and the input spending it
+// (with a witness discount for witness programs) like Bitcoin Core's GetDustThreshold
+func dustLimit(pkScript []byte) btcutil.Amount {
+	size := int64(wire.NewTxOut(0, pkScript).SerializeSize())
+	if txscript.IsWitnessProgram(pkScript) {
+		// outpoint, sequence and an empty scriptSig, plus a witness of about 107 bytes
+		size += 32 + 4 + 1 + 107/4 + 4
+	} else {
+		size += 32 + 4 + 1 + 107 + 4
+	}
+
+	return btcutil.Amount(size * DustRelayFeeRate)
+}
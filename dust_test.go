@@ -0,0 +1,34 @@
+package bitcoin
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetDustLimit will test the method GetDustLimit() against the limits of Bitcoin Core
+func TestGetDustLimit(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		address       string
+		expectedLimit btcutil.Amount
+	}{
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 546},
+		{"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", 540},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", 294},
+		{"bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3", 330},
+		{"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", 330},
+	}
+
+	for _, test := range tests {
+		limit, err := GetDustLimit(test.address, Mainnet)
+		require.NoError(t, err)
+		assert.Equal(t, test.expectedLimit, limit, test.address)
+	}
+
+	_, err := GetDustLimit("invalid", Mainnet)
+	assert.Error(t, err)
+}
@@ -0,0 +1,135 @@
+package electrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// ChainBackend adapts a Client to bitcoin.ChainBackend
+type ChainBackend struct {
+	client *Client
+}
+
+// NewChainBackend creates a chain backend on top of a connected client
+func NewChainBackend(client *Client) *ChainBackend {
+	return &ChainBackend{client: client}
+}
+
+// GetUtxos implements bitcoin.ChainBackend, including mempool outputs
+func (b *ChainBackend) GetUtxos(ctx context.Context, scripts []string) ([]bitcoin.Utxo, error) {
+	var utxos []bitcoin.Utxo
+	for _, script := range scripts {
+		scriptHash, err := bitcoin.GetElectrumScriptHash(script)
+		if err != nil {
+			return nil, err
+		}
+
+		unspents, err := b.client.listUnspent(ctx, scriptHash)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, unspent := range unspents {
+			utxos = append(utxos, bitcoin.Utxo{
+				TxID:   unspent.TxHash,
+				Vout:   unspent.TxPos,
+				Value:  unspent.Value,
+				Script: script,
+				Height: max(unspent.Height, 0),
+			})
+		}
+	}
+
+	return utxos, nil
+}
+
+// GetTransaction implements bitcoin.ChainBackend. Servers relay the node's error for an unknown
+// transaction in their message, under an implementation specific code
+func (b *ChainBackend) GetTransaction(ctx context.Context, txID string) (*wire.MsgTx, error) {
+	tx, err := b.client.GetTransaction(ctx, txID)
+
+	var serverErr *Error
+	if errors.As(err, &serverErr) && isTransactionNotFound(serverErr) {
+		return nil, fmt.Errorf("%w: %w", bitcoin.ErrTransactionNotFound, err)
+	}
+
+	return tx, err
+}
+
+// Broadcast implements bitcoin.ChainBackend
+func (b *ChainBackend) Broadcast(ctx context.Context, tx *wire.MsgTx) (string, error) {
+	return b.client.BroadcastTransaction(ctx, tx)
+}
+
+// EstimateFee implements bitcoin.ChainBackend
+func (b *ChainBackend) EstimateFee(ctx context.Context, confTarget int) (float64, error) {
+	feeRate, err := b.client.EstimateFee(ctx, confTarget)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(feeRate) / 1000, nil
+}
+
+// TipHeight implements bitcoin.ChainBackend
+func (b *ChainBackend) TipHeight(ctx context.Context) (int64, error) {
+	tip, err := b.client.GetTipHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return tip.Height, nil
+}
+
+// SubscribeBlocks implements bitcoin.ChainBackend with header notifications, the current tip is sent first
+func (b *ChainBackend) SubscribeBlocks(ctx context.Context) (<-chan bitcoin.BlockEvent, error) {
+	tip, headers, err := b.client.SubscribeHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan bitcoin.BlockEvent, 1)
+	events <- blockEvent(tip)
+
+	go func() {
+		defer close(events)
+
+		for header := range headers {
+			event := blockEvent(&header)
+
+			// coalesce like the header channel does
+			select {
+			case events <- event:
+			default:
+				select {
+				case <-events:
+				default:
+				}
+				events <- event
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// blockEvent converts a header to a block event
+func blockEvent(header *Header) bitcoin.BlockEvent {
+	return bitcoin.BlockEvent{Height: header.Height, Hash: header.Header.BlockHash().String()}
+}
+
+// isTransactionNotFound reports whether a server error is the node's unknown transaction error relayed
+// by the server, or the server's own when it looks transactions up in its index
+func isTransactionNotFound(err *Error) bool {
+	message := strings.ToLower(err.Message)
+	return strings.Contains(message, "no such mempool or blockchain transaction") ||
+		strings.Contains(message, "transaction not found")
+}
+
+// compile time check
+var _ bitcoin.ChainBackend = (*ChainBackend)(nil)
@@ -0,0 +1,82 @@
+package electrum
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChainBackend will test the bitcoin.ChainBackend adapter of the client
+func TestChainBackend(t *testing.T) {
+	t.Parallel()
+
+	script, err := bitcoin.GetScriptFromAddress(testAddress, bitcoin.Testnet)
+	require.NoError(t, err)
+	scriptHash := testScriptHash(t)
+
+	server := newTestServer(t, func(method string, params []json.RawMessage) (any, *Error) {
+		if method == "blockchain.scripthash.listunspent" {
+			var requested string
+			require.NoError(t, json.Unmarshal(params[0], &requested))
+			require.Equal(t, scriptHash, requested)
+
+			return []map[string]any{
+				{"tx_hash": "aa", "tx_pos": 0, "height": 2500000, "value": 1000},
+				{"tx_hash": "bb", "tx_pos": 1, "height": -1, "value": 2000},
+			}, nil
+		}
+		if method == "blockchain.transaction.get" {
+			return nil, &Error{Code: 2, Message: "daemon error: DaemonError({'code': -5, 'message': 'No such mempool or blockchain transaction. Use gettransaction for wallet transactions.'})"}
+		}
+		return headersHandler(method, params)
+	})
+	client := newTestClient(t, server)
+
+	var backend bitcoin.ChainBackend = NewChainBackend(client)
+	ctx := context.Background()
+
+	utxos, err := backend.GetUtxos(ctx, []string{script})
+	require.NoError(t, err)
+	require.Len(t, utxos, 2)
+	assert.Equal(t, bitcoin.Utxo{TxID: "aa", Vout: 0, Value: btcutil.Amount(1000), Script: script, Height: 2500000}, utxos[0])
+	assert.Equal(t, int64(0), utxos[1].Height)
+
+	_, err = backend.GetUtxos(ctx, []string{"zz"})
+	assert.Error(t, err)
+
+	_, err = backend.GetTransaction(ctx, "00")
+	assert.ErrorIs(t, err, bitcoin.ErrTransactionNotFound)
+	var serverErr *Error
+	assert.ErrorAs(t, err, &serverErr)
+
+	feeRate, err := backend.EstimateFee(ctx, 6)
+	require.NoError(t, err)
+	assert.InDelta(t, 12.0, feeRate, 1e-9)
+
+	height, err := backend.TipHeight(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), height)
+
+	subCtx, cancel := context.WithCancel(ctx)
+	events, err := backend.SubscribeBlocks(subCtx)
+	require.NoError(t, err)
+	assert.Equal(t, bitcoin.BlockEvent{Height: 0, Hash: genesisBlockHash}, <-events)
+
+	server.notify("blockchain.headers.subscribe", map[string]any{"height": 1, "hex": genesisHeader})
+	select {
+	case event := <-events:
+		assert.Equal(t, int64(1), event.Height)
+	case <-time.After(time.Second):
+		t.Fatal("no block event received")
+	}
+
+	cancel()
+	for range events {
+	}
+}
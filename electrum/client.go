@@ -0,0 +1,307 @@
+package electrum
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// ProtocolVersion is the Electrum protocol version negotiated with the server
+const ProtocolVersion = "1.4"
+
+// Config holds the connection settings of a Client
+type Config struct {
+	Address    string      // host:port, e.g. 127.0.0.1:50001
+	TLSConfig  *tls.Config // nil for a plain TCP connection
+	Network    bitcoin.NetworkType
+	ClientName string // sent to the server with server.version, defaults to go-bitcoin
+}
+
+// Client is an Electrum protocol client over a single connection, safe for concurrent use.
+// Calls are pipelined: responses are matched to calls by id
+type Client struct {
+	config        Config
+	conn          net.Conn
+	serverVersion string
+	nextID        atomic.Uint64
+
+	writeMu sync.Mutex
+
+	mu                  sync.Mutex
+	pending             map[uint64]chan *message
+	subscriptions       map[string]chan ScriptHashStatus
+	headerSubscriptions map[chan Header]struct{}
+	err                 error
+	done                chan struct{}
+}
+
+// request is a JSON-RPC request
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+// message is a response to a call, or a notification when it has a method
+type message struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+// Dial connects to an Electrum server and negotiates the protocol version
+func Dial(ctx context.Context, config Config) (*Client, error) {
+
+	// Missing address
+	if len(config.Address) == 0 {
+		return nil, ErrMissingAddress
+	}
+
+	// Missing network
+	if config.Network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	if len(config.ClientName) == 0 {
+		config.ClientName = "go-bitcoin"
+	}
+
+	var conn net.Conn
+	var err error
+	if config.TLSConfig != nil {
+		dialer := &tls.Dialer{Config: config.TLSConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", config.Address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", config.Address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		config:              config,
+		conn:                conn,
+		pending:             make(map[uint64]chan *message),
+		subscriptions:       make(map[string]chan ScriptHashStatus),
+		headerSubscriptions: make(map[chan Header]struct{}),
+		done:                make(chan struct{}),
+	}
+	go c.readLoop()
+
+	// the server expects the version to be negotiated before any other call
+	var version []string
+	if err = c.Call(ctx, "server.version", []any{config.ClientName, ProtocolVersion}, &version); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	if len(version) == 2 {
+		c.serverVersion = version[0]
+	}
+
+	return c, nil
+}
+
+// ServerVersion returns the software version the server reported
+func (c *Client) ServerVersion() string {
+	return c.serverVersion
+}
+
+// Network returns the network the client was configured for
+func (c *Client) Network() bitcoin.NetworkType {
+	return c.config.Network
+}
+
+// Ping checks the connection, servers drop clients that stay idle for too long
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Call(ctx, "server.ping", nil, nil)
+}
+
+// Close closes the connection. Pending calls fail with ErrClosed and subscription channels are closed
+func (c *Client) Close() error {
+	err := c.conn.Close()
+	<-c.done
+	return err
+}
+
+// Done returns a channel closed once the connection is closed or lost
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection was lost, or ErrClosed after Close
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Call runs a server call and decodes its result into result (a pointer, or nil to discard it)
+func (c *Client) Call(ctx context.Context, method string, params []any, result any) error {
+	if params == nil {
+		params = []any{}
+	}
+
+	req := request{JSONRPC: "2.0", ID: c.nextID.Add(1), Method: method, Params: params}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	respCh := make(chan *message, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.pending[req.ID] = respCh
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}()
+
+	c.writeMu.Lock()
+	_, err = c.conn.Write(append(payload, '\n'))
+	c.writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-respCh:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	case <-c.done:
+		return c.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// readLoop dispatches responses and notifications until the connection is closed
+func (c *Client) readLoop() {
+	reader := bufio.NewReader(c.conn)
+
+	var err error
+	for {
+		var line []byte
+		if line, err = reader.ReadBytes('\n'); err != nil {
+			break
+		}
+
+		var msg message
+		if err = json.Unmarshal(line, &msg); err != nil {
+			err = fmt.Errorf("invalid message from server: %w", err)
+			_ = c.conn.Close()
+			break
+		}
+
+		if len(msg.Method) > 0 {
+			c.notify(&msg)
+			continue
+		}
+
+		// the first response is delivered, one the server repeats finds no pending call
+		c.mu.Lock()
+		if respCh, ok := c.pending[msg.ID]; ok {
+			delete(c.pending, msg.ID)
+			respCh <- &msg
+		}
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	if isClosedErr(err) {
+		c.err = ErrClosed
+	} else {
+		c.err = fmt.Errorf("%w: %w", ErrClosed, err)
+	}
+	for scriptHash, statusCh := range c.subscriptions {
+		close(statusCh)
+		delete(c.subscriptions, scriptHash)
+	}
+	for headerCh := range c.headerSubscriptions {
+		close(headerCh)
+		delete(c.headerSubscriptions, headerCh)
+	}
+	c.mu.Unlock()
+
+	close(c.done)
+}
+
+// notify delivers a notification to its subscriptions
+func (c *Client) notify(msg *message) {
+	switch msg.Method {
+	case "blockchain.scripthash.subscribe":
+		c.notifyScriptHash(msg.Params)
+	case "blockchain.headers.subscribe":
+		c.notifyHeaders(msg.Params)
+	}
+}
+
+// notifyScriptHash delivers a status notification to the subscription of its script hash
+func (c *Client) notifyScriptHash(rawParams json.RawMessage) {
+
+	var params []*string
+	if err := json.Unmarshal(rawParams, &params); err != nil || len(params) != 2 || params[0] == nil {
+		return
+	}
+
+	status := ScriptHashStatus{ScriptHash: *params[0]}
+	if params[1] != nil {
+		status.Status = *params[1]
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	statusCh, ok := c.subscriptions[status.ScriptHash]
+	if !ok {
+		return
+	}
+
+	// only the latest status matters, a status the subscriber has not read yet is replaced
+	select {
+	case statusCh <- status:
+	default:
+		select {
+		case <-statusCh:
+		default:
+		}
+		statusCh <- status
+	}
+}
+
+// scriptHash returns the script hash of an address, validated against the client's network
+func (c *Client) scriptHash(address string) (string, error) {
+	if _, err := bitcoin.DecodeAddressForNetwork(address, c.config.Network); err != nil {
+		return "", err
+	}
+
+	return bitcoin.GetElectrumScriptHashFromAddress(address, c.config.Network)
+}
+
+// isClosedErr reports whether a read error is the connection being closed
+func isClosedErr(err error) bool {
+	return errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF)
+}
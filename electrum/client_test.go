@@ -0,0 +1,243 @@
+package electrum
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// handlerFunc answers a single call of the fake server
+type handlerFunc func(method string, params []json.RawMessage) (any, *Error)
+
+// testServer is a fake Electrum server answering calls over TCP with a handler
+type testServer struct {
+	listener net.Listener
+	handler  handlerFunc
+
+	mu     sync.Mutex
+	conns  []net.Conn
+	repeat bool // every response is sent three times in a single write
+}
+
+// newTestServer starts a fake server, server.version is answered without calling handler
+func newTestServer(t *testing.T, handler handlerFunc) *testServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &testServer{listener: listener, handler: handler}
+	t.Cleanup(server.close)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			server.mu.Lock()
+			server.conns = append(server.conns, conn)
+			server.mu.Unlock()
+
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+// serve answers the calls of a connection
+func (s *testServer) serve(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return
+		}
+
+		var result any
+		var rpcErr *Error
+		if req.Method == "server.version" {
+			result = []string{"ElectrumX 1.16.0", ProtocolVersion}
+		} else {
+			result, rpcErr = s.handler(req.Method, req.Params)
+		}
+
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result, "error": rpcErr}
+
+		s.mu.Lock()
+		if s.repeat {
+			payload, _ := json.Marshal(resp)
+			line := append(payload, '\n')
+			_, _ = conn.Write(bytes.Repeat(line, 3))
+		} else {
+			s.write(conn, resp)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// notify sends a notification to every connected client
+func (s *testServer) notify(method string, params ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		s.write(conn, map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+	}
+}
+
+// send writes a message to a connection
+func (s *testServer) send(conn net.Conn, msg any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.write(conn, msg)
+}
+
+// write writes a message to a connection, s.mu must be held
+func (s *testServer) write(conn net.Conn, msg any) {
+	payload, _ := json.Marshal(msg)
+	_, _ = conn.Write(append(payload, '\n'))
+}
+
+// close stops the server and drops its connections
+func (s *testServer) close() {
+	_ = s.listener.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
+// newTestClient connects a testnet client to server
+func newTestClient(t *testing.T, server *testServer) *Client {
+	client, err := Dial(context.Background(), Config{Address: server.listener.Addr().String(), Network: bitcoin.Testnet})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	return client
+}
+
+// TestDial will test the method Dial()
+func TestDial(t *testing.T) {
+	t.Parallel()
+
+	_, err := Dial(context.Background(), Config{Network: bitcoin.Mainnet})
+	assert.ErrorIs(t, err, ErrMissingAddress)
+
+	_, err = Dial(context.Background(), Config{Address: "127.0.0.1:50001"})
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+	assert.ErrorIs(t, err, bitcoin.ErrMissingNetwork)
+
+	server := newTestServer(t, nil)
+	client := newTestClient(t, server)
+	assert.Equal(t, "ElectrumX 1.16.0", client.ServerVersion())
+	assert.Equal(t, bitcoin.Testnet, client.Network())
+}
+
+// TestClientCall will test the method Call() with pipelined calls and server errors
+func TestClientCall(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(method string, params []json.RawMessage) (any, *Error) {
+		switch method {
+		case "server.ping":
+			return nil, nil
+		case "echo":
+			var value int
+			_ = json.Unmarshal(params[0], &value)
+			return value, nil
+		}
+		return nil, &Error{Code: -32601, Message: "unknown method"}
+	})
+	client := newTestClient(t, server)
+
+	require.NoError(t, client.Ping(context.Background()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var value int
+			assert.NoError(t, client.Call(context.Background(), "echo", []any{i}, &value))
+			assert.Equal(t, i, value)
+		}(i)
+	}
+	wg.Wait()
+
+	err := client.Call(context.Background(), "nope", nil, nil)
+	var rpcErr *Error
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, -32601, rpcErr.Code)
+}
+
+// TestClientCallRepeatedResponse will test that a response the server repeats does not block the client
+func TestClientCallRepeatedResponse(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(_ string, params []json.RawMessage) (any, *Error) {
+		var value int
+		_ = json.Unmarshal(params[0], &value)
+		return value, nil
+	})
+	server.mu.Lock()
+	server.repeat = true
+	server.mu.Unlock()
+	client := newTestClient(t, server)
+
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		var value int
+		require.NoError(t, client.Call(ctx, "echo", []any{i}, &value))
+		assert.Equal(t, i, value)
+		cancel()
+	}
+}
+
+// TestClientClose will test that calls fail once the connection is closed or lost
+func TestClientClose(t *testing.T) {
+	t.Parallel()
+
+	blocked := make(chan struct{})
+	server := newTestServer(t, func(_ string, _ []json.RawMessage) (any, *Error) {
+		<-blocked
+		return nil, nil
+	})
+	client := newTestClient(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.Ping(ctx), context.DeadlineExceeded)
+
+	// the server drops the connection while a call is pending
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.Ping(context.Background())
+	}()
+	time.Sleep(50 * time.Millisecond)
+	server.close()
+	close(blocked)
+
+	assert.ErrorIs(t, <-errCh, ErrClosed)
+	<-client.Done()
+	assert.ErrorIs(t, client.Err(), ErrClosed)
+	assert.ErrorIs(t, client.Ping(context.Background()), ErrClosed)
+}
@@ -0,0 +1,31 @@
+package electrum
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// ErrMissingAddress is returned when a client is created without a server address
+var ErrMissingAddress = errors.New("missing electrum server address")
+
+// ErrMissingNetwork is returned when a client is created without a network, the same error as bitcoin.ErrMissingNetwork
+var ErrMissingNetwork = bitcoin.ErrMissingNetwork
+
+// ErrWrongNetwork is returned when an address is not for the client's network, the same error as bitcoin.ErrWrongNetwork
+var ErrWrongNetwork = bitcoin.ErrWrongNetwork
+
+// ErrClosed is returned by calls on a closed client, or a client whose connection was lost
+var ErrClosed = errors.New("electrum client closed")
+
+// Error is an error returned by the server for a call, e.g. for a rejected broadcast
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("electrum error %d: %s", e.Code, e.Message)
+}
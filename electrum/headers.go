@@ -0,0 +1,134 @@
+package electrum
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// Header is a block header announced by the server
+type Header struct {
+	Height int64
+	Header *wire.BlockHeader
+}
+
+// rawHeader is a header as sent by the server
+type rawHeader struct {
+	Height int64  `json:"height"`
+	Hex    string `json:"hex"`
+}
+
+// decode deserializes the header
+func (h *rawHeader) decode() (*Header, error) {
+	headerBytes, err := hex.DecodeString(h.Hex)
+	if err != nil {
+		return nil, err
+	}
+
+	var header wire.BlockHeader
+	if err = header.Deserialize(bytes.NewReader(headerBytes)); err != nil {
+		return nil, err
+	}
+
+	return &Header{Height: h.Height, Header: &header}, nil
+}
+
+// GetTipHeader returns the header of the best block
+func (c *Client) GetTipHeader(ctx context.Context) (*Header, error) {
+	var raw rawHeader
+	if err := c.Call(ctx, "blockchain.headers.subscribe", nil, &raw); err != nil {
+		return nil, err
+	}
+
+	return raw.decode()
+}
+
+// SubscribeHeaders subscribes to new best blocks. It returns the current tip and a channel receiving
+// every later one until ctx is done; the channel only holds the latest header and is also closed with the client
+func (c *Client) SubscribeHeaders(ctx context.Context) (*Header, <-chan Header, error) {
+	headerCh := make(chan Header, 1)
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, nil, c.err
+	}
+	c.headerSubscriptions[headerCh] = struct{}{}
+	c.mu.Unlock()
+
+	tip, err := c.GetTipHeader(ctx)
+	if err != nil {
+		c.removeHeaderSubscription(headerCh)
+		return nil, nil, err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.removeHeaderSubscription(headerCh)
+		case <-c.done:
+		}
+	}()
+
+	return tip, headerCh, nil
+}
+
+// EstimateFee returns the fee rate per 1000 virtual bytes for a transaction to confirm within blocks
+func (c *Client) EstimateFee(ctx context.Context, blocks int) (btcutil.Amount, error) {
+	var feeRate float64
+	if err := c.Call(ctx, "blockchain.estimatefee", []any{blocks}, &feeRate); err != nil {
+		return 0, err
+	}
+
+	// the server answers -1 when the node has no estimate
+	if feeRate < 0 {
+		return 0, bitcoin.ErrFeeEstimateUnavailable
+	}
+
+	return btcutil.NewAmount(feeRate)
+}
+
+// notifyHeaders delivers a header notification to the header subscriptions
+func (c *Client) notifyHeaders(params json.RawMessage) {
+	var raws []rawHeader
+	if err := json.Unmarshal(params, &raws); err != nil || len(raws) == 0 {
+		return
+	}
+
+	header, err := raws[0].decode()
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// only the latest header matters, a header the subscriber has not read yet is replaced
+	for headerCh := range c.headerSubscriptions {
+		select {
+		case headerCh <- *header:
+		default:
+			select {
+			case <-headerCh:
+			default:
+			}
+			headerCh <- *header
+		}
+	}
+}
+
+// removeHeaderSubscription closes and forgets a header channel
+func (c *Client) removeHeaderSubscription(headerCh chan Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.headerSubscriptions[headerCh]; ok {
+		close(headerCh)
+		delete(c.headerSubscriptions, headerCh)
+	}
+}
@@ -0,0 +1,91 @@
+package electrum
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// genesisHeader is the header of the mainnet genesis block
+const genesisHeader = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c"
+
+// genesisBlockHash is the hash of genesisHeader
+const genesisBlockHash = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+
+// headersHandler answers header and fee calls of the fake server
+func headersHandler(method string, params []json.RawMessage) (any, *Error) {
+	switch method {
+	case "server.ping":
+		return nil, nil
+	case "blockchain.headers.subscribe":
+		return map[string]any{"height": 0, "hex": genesisHeader}, nil
+	case "blockchain.estimatefee":
+		var blocks int
+		_ = json.Unmarshal(params[0], &blocks)
+		if blocks == 1 {
+			return -1, nil
+		}
+		return 0.00012, nil
+	}
+	return nil, &Error{Code: -32601, Message: "unknown method"}
+}
+
+// TestClientSubscribeHeaders will test the methods GetTipHeader() and SubscribeHeaders()
+func TestClientSubscribeHeaders(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, headersHandler)
+	client := newTestClient(t, server)
+
+	tip, err := client.GetTipHeader(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), tip.Height)
+	assert.Equal(t, genesisBlockHash, tip.Header.BlockHash().String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tip, headers, err := client.SubscribeHeaders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), tip.Height)
+
+	server.notify("blockchain.headers.subscribe", map[string]any{"height": 1, "hex": genesisHeader})
+	select {
+	case header := <-headers:
+		assert.Equal(t, int64(1), header.Height)
+	case <-time.After(time.Second):
+		t.Fatal("no header received")
+	}
+
+	// invalid headers are ignored
+	server.notify("blockchain.headers.subscribe", map[string]any{"height": 2, "hex": "zz"})
+	require.NoError(t, client.Ping(context.Background()))
+	assert.Empty(t, headers)
+
+	cancel()
+	select {
+	case _, ok := <-headers:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not closed")
+	}
+}
+
+// TestClientEstimateFee will test the method EstimateFee()
+func TestClientEstimateFee(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, headersHandler)
+	client := newTestClient(t, server)
+
+	feeRate, err := client.EstimateFee(context.Background(), 6)
+	require.NoError(t, err)
+	assert.Equal(t, btcutil.Amount(12000), feeRate)
+
+	_, err = client.EstimateFee(context.Background(), 1)
+	assert.ErrorIs(t, err, bitcoin.ErrFeeEstimateUnavailable)
+}
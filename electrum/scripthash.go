@@ -0,0 +1,144 @@
+package electrum
+
+import (
+	"context"
+
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// Balance is the balance of an address
+type Balance struct {
+	Confirmed   btcutil.Amount `json:"confirmed"`
+	Unconfirmed btcutil.Amount `json:"unconfirmed"`
+}
+
+// HistoryItem is a transaction touching an address. Height is 0 for a mempool transaction
+// and -1 for a mempool transaction with unconfirmed inputs
+type HistoryItem struct {
+	TxHash string         `json:"tx_hash"`
+	Height int64          `json:"height"`
+	Fee    btcutil.Amount `json:"fee"` // mempool transactions only
+}
+
+// Unspent is an unspent output paying to an address. Height is 0 while unconfirmed
+type Unspent struct {
+	TxHash string         `json:"tx_hash"`
+	TxPos  uint32         `json:"tx_pos"`
+	Height int64          `json:"height"`
+	Value  btcutil.Amount `json:"value"`
+}
+
+// ScriptHashStatus is the status of a subscribed script hash, which changes with every transaction
+// touching it. Status is empty while the script has no history
+type ScriptHashStatus struct {
+	ScriptHash string
+	Status     string
+}
+
+// GetBalance returns the confirmed and unconfirmed balance of an address
+func (c *Client) GetBalance(ctx context.Context, address string) (*Balance, error) {
+	scriptHash, err := c.scriptHash(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var balance Balance
+	if err = c.Call(ctx, "blockchain.scripthash.get_balance", []any{scriptHash}, &balance); err != nil {
+		return nil, err
+	}
+
+	return &balance, nil
+}
+
+// GetHistory returns the confirmed and mempool transactions of an address
+func (c *Client) GetHistory(ctx context.Context, address string) ([]HistoryItem, error) {
+	scriptHash, err := c.scriptHash(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []HistoryItem
+	if err = c.Call(ctx, "blockchain.scripthash.get_history", []any{scriptHash}, &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// ListUnspent returns the unspent outputs of an address
+func (c *Client) ListUnspent(ctx context.Context, address string) ([]Unspent, error) {
+	scriptHash, err := c.scriptHash(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.listUnspent(ctx, scriptHash)
+}
+
+// Subscribe subscribes to status changes of an address. It returns the current status and a channel
+// receiving every later change; the channel only holds the latest status and is closed with the client.
+// Subscribing to an address twice returns the same channel
+func (c *Client) Subscribe(ctx context.Context, address string) (string, <-chan ScriptHashStatus, error) {
+	scriptHash, err := c.scriptHash(address)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// registered before the call so no notification sent right after the response is lost
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return "", nil, c.err
+	}
+	statusCh, subscribed := c.subscriptions[scriptHash]
+	if !subscribed {
+		statusCh = make(chan ScriptHashStatus, 1)
+		c.subscriptions[scriptHash] = statusCh
+	}
+	c.mu.Unlock()
+
+	var status *string
+	if err = c.Call(ctx, "blockchain.scripthash.subscribe", []any{scriptHash}, &status); err != nil {
+		if !subscribed {
+			c.removeSubscription(scriptHash)
+		}
+		return "", nil, err
+	}
+
+	if status == nil {
+		return "", statusCh, nil
+	}
+	return *status, statusCh, nil
+}
+
+// Unsubscribe stops the status changes of an address and closes its channel
+func (c *Client) Unsubscribe(ctx context.Context, address string) error {
+	scriptHash, err := c.scriptHash(address)
+	if err != nil {
+		return err
+	}
+
+	c.removeSubscription(scriptHash)
+	return c.Call(ctx, "blockchain.scripthash.unsubscribe", []any{scriptHash}, nil)
+}
+
+// listUnspent returns the unspent outputs of a script hash
+func (c *Client) listUnspent(ctx context.Context, scriptHash string) ([]Unspent, error) {
+	var unspents []Unspent
+	if err := c.Call(ctx, "blockchain.scripthash.listunspent", []any{scriptHash}, &unspents); err != nil {
+		return nil, err
+	}
+
+	return unspents, nil
+}
+
+// removeSubscription closes and forgets the channel of a script hash
+func (c *Client) removeSubscription(scriptHash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if statusCh, ok := c.subscriptions[scriptHash]; ok {
+		close(statusCh)
+		delete(c.subscriptions, scriptHash)
+	}
+}
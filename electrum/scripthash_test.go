@@ -0,0 +1,124 @@
+package electrum
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAddress is a testnet address used against the fake server
+const testAddress = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+
+// testScriptHash returns the script hash of testAddress
+func testScriptHash(t *testing.T) string {
+	scriptHash, err := bitcoin.GetElectrumScriptHashFromAddress(testAddress, bitcoin.Testnet)
+	require.NoError(t, err)
+	return scriptHash
+}
+
+// TestClientScriptHashCalls will test the methods GetBalance(), GetHistory() and ListUnspent()
+func TestClientScriptHashCalls(t *testing.T) {
+	t.Parallel()
+
+	scriptHash := testScriptHash(t)
+	server := newTestServer(t, func(method string, params []json.RawMessage) (any, *Error) {
+		var requested string
+		require.NoError(t, json.Unmarshal(params[0], &requested))
+		require.Equal(t, scriptHash, requested)
+
+		switch method {
+		case "blockchain.scripthash.get_balance":
+			return map[string]any{"confirmed": 100000, "unconfirmed": -2500}, nil
+		case "blockchain.scripthash.get_history":
+			return []map[string]any{
+				{"tx_hash": "aa", "height": 2500000},
+				{"tx_hash": "bb", "height": 0, "fee": 141},
+			}, nil
+		case "blockchain.scripthash.listunspent":
+			return []map[string]any{{"tx_hash": "aa", "tx_pos": 1, "height": 2500000, "value": 100000}}, nil
+		}
+		return nil, &Error{Code: -32601, Message: "unknown method"}
+	})
+	client := newTestClient(t, server)
+
+	balance, err := client.GetBalance(context.Background(), testAddress)
+	require.NoError(t, err)
+	assert.Equal(t, btcutil.Amount(100000), balance.Confirmed)
+	assert.Equal(t, btcutil.Amount(-2500), balance.Unconfirmed)
+
+	history, err := client.GetHistory(context.Background(), testAddress)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, int64(2500000), history[0].Height)
+	assert.Equal(t, btcutil.Amount(141), history[1].Fee)
+
+	unspents, err := client.ListUnspent(context.Background(), testAddress)
+	require.NoError(t, err)
+	require.Len(t, unspents, 1)
+	assert.Equal(t, uint32(1), unspents[0].TxPos)
+	assert.Equal(t, btcutil.Amount(100000), unspents[0].Value)
+
+	// mainnet address on a testnet client
+	_, err = client.GetBalance(context.Background(), "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+
+	_, err = client.GetBalance(context.Background(), "invalid")
+	assert.Error(t, err)
+}
+
+// TestClientSubscribe will test the methods Subscribe() and Unsubscribe()
+func TestClientSubscribe(t *testing.T) {
+	t.Parallel()
+
+	scriptHash := testScriptHash(t)
+	server := newTestServer(t, func(method string, _ []json.RawMessage) (any, *Error) {
+		if method == "blockchain.scripthash.subscribe" {
+			return nil, nil
+		}
+		return true, nil
+	})
+	client := newTestClient(t, server)
+
+	status, updates, err := client.Subscribe(context.Background(), testAddress)
+	require.NoError(t, err)
+	assert.Empty(t, status)
+
+	server.notify("blockchain.scripthash.subscribe", scriptHash, "status1")
+	select {
+	case update := <-updates:
+		assert.Equal(t, ScriptHashStatus{ScriptHash: scriptHash, Status: "status1"}, update)
+	case <-time.After(time.Second):
+		t.Fatal("no status update received")
+	}
+
+	// unread statuses are replaced by the latest one
+	server.notify("blockchain.scripthash.subscribe", scriptHash, "status2")
+	server.notify("blockchain.scripthash.subscribe", scriptHash, "status3")
+	require.NoError(t, client.Ping(context.Background()))
+	assert.Equal(t, "status3", (<-updates).Status)
+
+	// notifications for unknown script hashes are ignored
+	server.notify("blockchain.scripthash.subscribe", "00", "status")
+	require.NoError(t, client.Ping(context.Background()))
+
+	_, again, err := client.Subscribe(context.Background(), testAddress)
+	require.NoError(t, err)
+	assert.Equal(t, updates, again)
+
+	require.NoError(t, client.Unsubscribe(context.Background(), testAddress))
+	_, ok := <-updates
+	assert.False(t, ok)
+
+	// subscriptions are closed with the client
+	_, updates, err = client.Subscribe(context.Background(), testAddress)
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+	_, ok = <-updates
+	assert.False(t, ok)
+}
@@ -0,0 +1,35 @@
+package electrum
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// GetTransaction returns a transaction by txid
+func (c *Client) GetTransaction(ctx context.Context, txID string) (*wire.MsgTx, error) {
+	var rawTx string
+	if err := c.Call(ctx, "blockchain.transaction.get", []any{txID}, &rawTx); err != nil {
+		return nil, err
+	}
+
+	return bitcoin.TransactionFromString(rawTx)
+}
+
+// BroadcastTransaction broadcasts a signed transaction and returns its txid
+func (c *Client) BroadcastTransaction(ctx context.Context, tx *wire.MsgTx) (string, error) {
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+
+	var txID string
+	if err := c.Call(ctx, "blockchain.transaction.broadcast", []any{hex.EncodeToString(buf.Bytes())}, &txID); err != nil {
+		return "", err
+	}
+
+	return txID, nil
+}
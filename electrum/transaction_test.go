@@ -0,0 +1,52 @@
+package electrum
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// genesisCoinbaseTx is the coinbase transaction of the mainnet genesis block
+const genesisCoinbaseTx = "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+// genesisCoinbaseTxID is the txid of genesisCoinbaseTx
+const genesisCoinbaseTxID = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+
+// TestClientTransactions will test the methods GetTransaction() and BroadcastTransaction()
+func TestClientTransactions(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(method string, params []json.RawMessage) (any, *Error) {
+		var param string
+		require.NoError(t, json.Unmarshal(params[0], &param))
+
+		switch method {
+		case "blockchain.transaction.get":
+			if param != genesisCoinbaseTxID {
+				return nil, &Error{Code: 2, Message: "daemon error: No such mempool or blockchain transaction"}
+			}
+			return genesisCoinbaseTx, nil
+		case "blockchain.transaction.broadcast":
+			require.Equal(t, genesisCoinbaseTx, param)
+			return genesisCoinbaseTxID, nil
+		}
+		return nil, &Error{Code: -32601, Message: "unknown method"}
+	})
+	client := newTestClient(t, server)
+
+	tx, err := client.GetTransaction(context.Background(), genesisCoinbaseTxID)
+	require.NoError(t, err)
+	assert.Equal(t, genesisCoinbaseTxID, tx.TxHash().String())
+
+	_, err = client.GetTransaction(context.Background(), "00")
+	var rpcErr *Error
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, 2, rpcErr.Code)
+
+	txID, err := client.BroadcastTransaction(context.Background(), tx)
+	require.NoError(t, err)
+	assert.Equal(t, genesisCoinbaseTxID, txID)
+}
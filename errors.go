@@ -27,3 +27,220 @@ var ErrMissingAddress = errors.New("missing address")
 
 // ErrWifMissing is returned when a wif is missing
 var ErrWifMissing = errors.New("wif is missing")
+
+// ErrMissingTransaction is returned when a raw transaction is missing
+var ErrMissingTransaction = errors.New("missing transaction")
+
+// ErrInvalidAsm is returned when a script asm cannot be assembled
+var ErrInvalidAsm = errors.New("invalid script asm")
+
+// ErrScriptHasNoAddress is returned when an output script does not pay to an address
+var ErrScriptHasNoAddress = errors.New("output script has no address")
+
+// ErrMissingPrevOut is returned when the output spent by an input is missing
+var ErrMissingPrevOut = errors.New("missing previous output")
+
+// ErrInvalidInputIndex is returned when an input index is out of range
+var ErrInvalidInputIndex = errors.New("invalid input index")
+
+// ErrInvalidTapscriptIndex is returned when a tapscript index is out of range
+var ErrInvalidTapscriptIndex = errors.New("invalid tapscript index")
+
+// ErrInvalidLockTime is returned when a lock time is out of range for its kind
+var ErrInvalidLockTime = errors.New("invalid lock time")
+
+// ErrInvalidSequence is returned when a relative lock time cannot be encoded as a sequence
+var ErrInvalidSequence = errors.New("invalid sequence")
+
+// ErrInvalidPaymentHash is returned when a payment hash is neither a SHA256 nor a HASH160 hash
+var ErrInvalidPaymentHash = errors.New("invalid payment hash")
+
+// ErrInvalidPreimage is returned when a preimage does not match its payment hash
+var ErrInvalidPreimage = errors.New("invalid preimage")
+
+// ErrPreimageNotFound is returned when a transaction does not reveal a preimage
+var ErrPreimageNotFound = errors.New("preimage not found")
+
+// ErrNotOpReturnScript is returned when a script is not an OP_RETURN data carrier
+var ErrNotOpReturnScript = errors.New("not an OP_RETURN script")
+
+// ErrDataCarrierTooLarge is returned when OP_RETURN data exceeds the standard data carrier size
+var ErrDataCarrierTooLarge = errors.New("data carrier too large")
+
+// ErrMissingDescriptor is returned when an output descriptor is missing
+var ErrMissingDescriptor = errors.New("missing descriptor")
+
+// ErrInvalidDescriptor is returned when an output descriptor contains invalid characters
+var ErrInvalidDescriptor = errors.New("invalid descriptor")
+
+// ErrInvalidDescriptorChecksum is returned when the checksum of an output descriptor does not match
+var ErrInvalidDescriptorChecksum = errors.New("invalid descriptor checksum")
+
+// ErrTransactionNotFound is returned when a chain backend or a wallet's history does not know a transaction
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// ErrFeeEstimateUnavailable is returned when a chain backend has no fee estimate for a confirmation target
+var ErrFeeEstimateUnavailable = errors.New("fee estimate unavailable")
+
+// ErrInvalidBlockHeader is returned when a serialized block header is not 80 bytes
+var ErrInvalidBlockHeader = errors.New("invalid block header")
+
+// ErrInvalidProofOfWork is returned when a block header does not meet its target, or its target is out of range
+var ErrInvalidProofOfWork = errors.New("invalid proof of work")
+
+// ErrInvalidMerkleRoot is returned when the transactions of a block do not hash to the merkle root of its header
+var ErrInvalidMerkleRoot = errors.New("invalid merkle root")
+
+// ErrInvalidMerkleProof is returned when a merkle proof does not lead to the merkle root of a header
+var ErrInvalidMerkleProof = errors.New("invalid merkle proof")
+
+// ErrHeaderNotConnected is returned when a header does not extend the tip of a header chain
+var ErrHeaderNotConnected = errors.New("header does not connect to the chain")
+
+// ErrHeaderNotFound is returned when a header chain does not hold a requested header
+var ErrHeaderNotFound = errors.New("header not found")
+
+// ErrInvalidFilterHeader is returned when a compact block filter does not match its filter header
+var ErrInvalidFilterHeader = errors.New("invalid filter header")
+
+// ErrMissingChainBackend is returned when a service needing chain data is created without a ChainBackend
+var ErrMissingChainBackend = errors.New("missing chain backend")
+
+// ErrMissingNetwork is returned when a service is created without a network
+var ErrMissingNetwork = errors.New("missing network")
+
+// ErrWrongNetwork is returned when an address is not for the expected network
+var ErrWrongNetwork = errors.New("address is not for the expected network")
+
+// ErrInvalidThreshold is returned for a confirmation threshold below 1
+var ErrInvalidThreshold = errors.New("invalid confirmation threshold")
+
+// ErrMissingWalletKeys is returned when a wallet is created without a mnemonic or descriptors
+var ErrMissingWalletKeys = errors.New("missing wallet mnemonic or descriptors")
+
+// ErrInvalidMnemonic is returned for a mnemonic that is not a valid BIP39 phrase
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// ErrInvalidKeyChain is returned for a key chain other than ExternalChain and InternalChain
+var ErrInvalidKeyChain = errors.New("invalid key chain")
+
+// ErrInvalidAddressIndex is returned for a wallet address index of a hardened child
+var ErrInvalidAddressIndex = errors.New("invalid address index")
+
+// ErrWalletMismatch is returned when a wallet store holds the state of another wallet
+var ErrWalletMismatch = errors.New("wallet store holds another wallet")
+
+// ErrWatchOnlyWallet is returned when a wallet without private keys is asked to sign
+var ErrWatchOnlyWallet = errors.New("watch-only wallet cannot sign")
+
+// ErrTransactionConfirmed is returned when bumping the fee of a transaction that is already confirmed
+var ErrTransactionConfirmed = errors.New("transaction already confirmed")
+
+// ErrNotReplaceable is returned when replacing a transaction that does not signal BIP125 replaceability,
+// or that spends outputs of other wallets
+var ErrNotReplaceable = errors.New("transaction is not replaceable")
+
+// ErrFeeRateTooLow is returned when a fee bump does not raise the fee rate of a transaction
+var ErrFeeRateTooLow = errors.New("fee rate too low")
+
+// ErrInsufficientFunds is returned when a wallet does not hold enough to pay for a transaction
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// ErrNoSpendableOutput is returned when a transaction has no unspent output of the wallet to spend with a child
+var ErrNoSpendableOutput = errors.New("no spendable wallet output")
+
+// ErrMissingWallet is returned when a service needing a wallet is created without one
+var ErrMissingWallet = errors.New("missing wallet")
+
+// ErrMissingFeeSource is returned when a service needing fee estimates has no fee source nor chain backend
+var ErrMissingFeeSource = errors.New("missing fee source")
+
+// ErrDustOutput is returned for a payment below the dust limit of its address type
+var ErrDustOutput = errors.New("output below the dust limit")
+
+// ErrInvalidConfTarget is returned for a confirmation target outside of what a fee estimator tracks
+var ErrInvalidConfTarget = errors.New("invalid confirmation target")
+
+// ErrInsufficientFeeData is returned when a fee estimator has not seen enough blocks to estimate a fee rate
+var ErrInsufficientFeeData = errors.New("insufficient data for fee estimation")
+
+// ErrInvalidMultisigThreshold is returned when a multisig threshold is not between 1 and the number of signers
+var ErrInvalidMultisigThreshold = errors.New("invalid multisig threshold")
+
+// ErrTooManySigners is returned when a multisig wallet gets more signers than it was set up for
+var ErrTooManySigners = errors.New("too many signers")
+
+// ErrDuplicateSigner is returned when a signer key is added twice to a multisig wallet
+var ErrDuplicateSigner = errors.New("duplicate signer")
+
+// ErrMissingKeyOrigin is returned when a multisig signer key has no key origin
+var ErrMissingKeyOrigin = errors.New("missing key origin")
+
+// ErrIncompleteMultisig is returned when a multisig wallet is used before every signer was added
+var ErrIncompleteMultisig = errors.New("multisig wallet is missing signers")
+
+// ErrInvalidBSMSRecord is returned for a malformed BIP129 record
+var ErrInvalidBSMSRecord = errors.New("invalid BSMS record")
+
+// ErrInvalidBSMSToken is returned for a BIP129 token that is not 00 or a 64 or 128 bit hex nonce
+var ErrInvalidBSMSToken = errors.New("invalid BSMS token")
+
+// ErrUnknownBSMSToken is returned when a BIP129 record was not made with any of the tokens issued
+var ErrUnknownBSMSToken = errors.New("unknown BSMS token")
+
+// ErrBSMSTokenUsed is returned when a BIP129 record is made with a token that already added a signer
+var ErrBSMSTokenUsed = errors.New("BSMS token already used")
+
+// ErrBSMSAuthentication is returned when the MAC of an encrypted BIP129 record does not match
+var ErrBSMSAuthentication = errors.New("BSMS record authentication failed")
+
+// ErrInvalidSignature is returned when a signature does not verify
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// ErrAddressMismatch is returned when an address does not match the one derived from a descriptor
+var ErrAddressMismatch = errors.New("address does not match the descriptor")
+
+// ErrInvalidTweak is returned for a key tweak that is not below the curve order or that cancels the key out
+var ErrInvalidTweak = errors.New("invalid tweak")
+
+// ErrInvalidNonce is returned for a malformed, reused or mismatched signing nonce
+var ErrInvalidNonce = errors.New("invalid nonce")
+
+// ErrInvalidPartialSignature is returned when a partial signature of a multi-party signing does not verify
+var ErrInvalidPartialSignature = errors.New("invalid partial signature")
+
+// ErrUnknownSigner is returned when a key signs for an aggregate key it is not part of
+var ErrUnknownSigner = errors.New("unknown signer")
+
+// ErrInvalidShare is returned when a secret share does not match the commitments of its dealer
+var ErrInvalidShare = errors.New("invalid secret share")
+
+// ErrInvalidProof is returned when a key generation participant does not prove knowledge of its secret
+var ErrInvalidProof = errors.New("invalid proof of knowledge")
+
+// ErrNotEnoughSigners is returned when fewer signers than the threshold start a signing session
+var ErrNotEnoughSigners = errors.New("not enough signers")
+
+// ErrInvalidSilentPaymentAddress is returned when a silent payment (BIP352) address cannot be decoded
+var ErrInvalidSilentPaymentAddress = errors.New("invalid silent payment address")
+
+// ErrNoEligibleInputs is returned when the inputs of a transaction cannot derive silent payment outputs
+var ErrNoEligibleInputs = errors.New("no inputs eligible for silent payments")
+
+// ErrInputKeyMismatch is returned when a private key does not own the output it is given for
+var ErrInputKeyMismatch = errors.New("private key does not match the output")
+
+// ErrTooManySilentPayments is returned when a transaction pays a silent payment scan key more times than receivers scan for
+var ErrTooManySilentPayments = errors.New("too many silent payments")
+
+// ErrInvalidPaymentCode is returned when a BIP47 payment code cannot be decoded
+var ErrInvalidPaymentCode = errors.New("invalid payment code")
+
+// ErrNotNotificationTransaction is returned when a transaction does not notify a payment code
+var ErrNotNotificationTransaction = errors.New("not a notification transaction")
+
+// ErrInvalidPaymentURI is returned when a BIP21 payment URI cannot be decoded
+var ErrInvalidPaymentURI = errors.New("invalid payment uri")
+
+// ErrUnknownRequiredParameter is returned when a payment URI has a req- parameter the caller does not understand
+var ErrUnknownRequiredParameter = errors.New("unknown required payment uri parameter")
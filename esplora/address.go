@@ -0,0 +1,119 @@
+package esplora
+
+import (
+	"context"
+
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// AddressTxsPageSize is the number of confirmed transactions in a page of GetAddressTransactions
+const AddressTxsPageSize = 25
+
+// AddressStats are the funding and spending totals of an address
+type AddressStats struct {
+	FundedTxoCount int64          `json:"funded_txo_count"`
+	FundedTxoSum   btcutil.Amount `json:"funded_txo_sum"`
+	SpentTxoCount  int64          `json:"spent_txo_count"`
+	SpentTxoSum    btcutil.Amount `json:"spent_txo_sum"`
+	TxCount        int64          `json:"tx_count"`
+}
+
+// AddressInfo is the confirmed and mempool activity of an address
+type AddressInfo struct {
+	Address      string       `json:"address"`
+	ChainStats   AddressStats `json:"chain_stats"`
+	MempoolStats AddressStats `json:"mempool_stats"`
+}
+
+// Balance returns the confirmed balance and the balance including mempool transactions
+func (a *AddressInfo) Balance() (confirmed, total btcutil.Amount) {
+	confirmed = a.ChainStats.FundedTxoSum - a.ChainStats.SpentTxoSum
+	total = confirmed + a.MempoolStats.FundedTxoSum - a.MempoolStats.SpentTxoSum
+	return confirmed, total
+}
+
+// Unspent is an unspent output paying to an address
+type Unspent struct {
+	TxID   string         `json:"txid"`
+	Vout   uint32         `json:"vout"`
+	Value  btcutil.Amount `json:"value"`
+	Status TxStatus       `json:"status"`
+}
+
+// GetAddress returns the stats of an address
+func (c *Client) GetAddress(ctx context.Context, address string) (*AddressInfo, error) {
+	if err := c.validateAddress(address); err != nil {
+		return nil, err
+	}
+
+	var info AddressInfo
+	if err := c.getJSON(ctx, "/address/"+address, &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// GetAddressUnspents returns the confirmed and mempool unspent outputs of an address
+func (c *Client) GetAddressUnspents(ctx context.Context, address string) ([]Unspent, error) {
+	if err := c.validateAddress(address); err != nil {
+		return nil, err
+	}
+
+	var unspents []Unspent
+	if err := c.getJSON(ctx, "/address/"+address+"/utxo", &unspents); err != nil {
+		return nil, err
+	}
+
+	return unspents, nil
+}
+
+// GetAddressTransactions returns a page of the transactions of an address, newest first. With an empty
+// lastSeenTxID the first page holds the mempool transactions and the first AddressTxsPageSize confirmed ones;
+// later pages of confirmed transactions are requested with the txid of the last transaction of a page
+func (c *Client) GetAddressTransactions(ctx context.Context, address, lastSeenTxID string) ([]Transaction, error) {
+	if err := c.validateAddress(address); err != nil {
+		return nil, err
+	}
+
+	path := "/address/" + address + "/txs"
+	if len(lastSeenTxID) > 0 {
+		path += "/chain/" + lastSeenTxID
+	}
+
+	var txs []Transaction
+	if err := c.getJSON(ctx, path, &txs); err != nil {
+		return nil, err
+	}
+
+	return txs, nil
+}
+
+// GetAllAddressTransactions returns every transaction of an address, newest first, following the pages
+// of GetAddressTransactions
+func (c *Client) GetAllAddressTransactions(ctx context.Context, address string) ([]Transaction, error) {
+	all, err := c.GetAddressTransactions(ctx, address, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// the first page holds mempool transactions on top of a full page of confirmed ones
+	confirmed := 0
+	for _, tx := range all {
+		if tx.Status.Confirmed {
+			confirmed++
+		}
+	}
+
+	for confirmed == AddressTxsPageSize {
+		var page []Transaction
+		if page, err = c.GetAddressTransactions(ctx, address, all[len(all)-1].TxID); err != nil {
+			return nil, err
+		}
+
+		all = append(all, page...)
+		confirmed = len(page)
+	}
+
+	return all, nil
+}
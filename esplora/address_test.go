@@ -0,0 +1,95 @@
+package esplora
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAddress is a testnet address used against the stub API
+const testAddress = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+
+// TestClientGetAddress will test the methods GetAddress() and GetAddressUnspents()
+func TestClientGetAddress(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/address/"+testAddress, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"address":"` + testAddress + `","chain_stats":{"funded_txo_count":2,"funded_txo_sum":150000,"spent_txo_count":1,"spent_txo_sum":50000,"tx_count":3},"mempool_stats":{"funded_txo_count":1,"funded_txo_sum":20000,"spent_txo_count":0,"spent_txo_sum":0,"tx_count":1}}`))
+	})
+	mux.HandleFunc("/api/address/"+testAddress+"/utxo", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"txid":"aa","vout":1,"value":100000,"status":{"confirmed":true,"block_height":2500000,"block_hash":"bb","block_time":1700000000}}]`))
+	})
+	client := newTestClient(t, mux)
+
+	info, err := client.GetAddress(context.Background(), testAddress)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), info.ChainStats.TxCount)
+	confirmed, total := info.Balance()
+	assert.Equal(t, btcutil.Amount(100000), confirmed)
+	assert.Equal(t, btcutil.Amount(120000), total)
+
+	unspents, err := client.GetAddressUnspents(context.Background(), testAddress)
+	require.NoError(t, err)
+	require.Len(t, unspents, 1)
+	assert.Equal(t, btcutil.Amount(100000), unspents[0].Value)
+	assert.True(t, unspents[0].Status.Confirmed)
+	assert.Equal(t, int64(2500000), unspents[0].Status.BlockHeight)
+
+	// mainnet address on a testnet client
+	_, err = client.GetAddress(context.Background(), "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+
+	_, err = client.GetAddressUnspents(context.Background(), "invalid")
+	assert.Error(t, err)
+}
+
+// TestClientGetAllAddressTransactions will test the pagination of GetAllAddressTransactions()
+func TestClientGetAllAddressTransactions(t *testing.T) {
+	t.Parallel()
+
+	// two mempool transactions and 60 confirmed ones, paged like the API does
+	var txs []Transaction
+	for i := 0; i < 2; i++ {
+		txs = append(txs, Transaction{TxID: fmt.Sprintf("mempool%d", i)})
+	}
+	for i := 0; i < 60; i++ {
+		txs = append(txs, Transaction{TxID: fmt.Sprintf("chain%d", i), Status: TxStatus{Confirmed: true}})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/address/"+testAddress+"/txs", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(txs[:2+AddressTxsPageSize])
+	})
+	mux.HandleFunc("/api/address/"+testAddress+"/txs/chain/{lastSeen}", func(w http.ResponseWriter, r *http.Request) {
+		for i, tx := range txs {
+			if tx.TxID == r.PathValue("lastSeen") {
+				end := min(i+1+AddressTxsPageSize, len(txs))
+				_ = json.NewEncoder(w).Encode(txs[i+1 : end])
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	client := newTestClient(t, mux)
+
+	page, err := client.GetAddressTransactions(context.Background(), testAddress, "")
+	require.NoError(t, err)
+	assert.Len(t, page, 2+AddressTxsPageSize)
+
+	page, err = client.GetAddressTransactions(context.Background(), testAddress, "chain24")
+	require.NoError(t, err)
+	assert.Len(t, page, AddressTxsPageSize)
+	assert.Equal(t, "chain25", page[0].TxID)
+
+	all, err := client.GetAllAddressTransactions(context.Background(), testAddress)
+	require.NoError(t, err)
+	require.Len(t, all, len(txs))
+	assert.Equal(t, "chain59", all[len(all)-1].TxID)
+}
@@ -0,0 +1,81 @@
+package esplora
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// GetFeeEstimates returns fee rate estimates in sat/vB keyed by confirmation target in blocks
+func (c *Client) GetFeeEstimates(ctx context.Context) (map[int]float64, error) {
+	var estimates map[string]float64
+	if err := c.getJSON(ctx, "/fee-estimates", &estimates); err != nil {
+		return nil, err
+	}
+
+	feeRates := make(map[int]float64, len(estimates))
+	for target, feeRate := range estimates {
+		blocks, err := strconv.Atoi(target)
+		if err != nil {
+			return nil, err
+		}
+		feeRates[blocks] = feeRate
+	}
+
+	return feeRates, nil
+}
+
+// GetTipHeight returns the height of the best block
+func (c *Client) GetTipHeight(ctx context.Context) (int64, error) {
+	height, err := c.getText(ctx, "/blocks/tip/height")
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(height, 10, 64)
+}
+
+// GetTipHash returns the hash of the best block
+func (c *Client) GetTipHash(ctx context.Context) (string, error) {
+	return c.getText(ctx, "/blocks/tip/hash")
+}
+
+// GetBlockHash returns the hash of the block at height in the best chain
+func (c *Client) GetBlockHash(ctx context.Context, height int64) (string, error) {
+	return c.getText(ctx, "/block-height/"+strconv.FormatInt(height, 10))
+}
+
+// GetBlockHeight returns the height of a block by hash
+func (c *Client) GetBlockHeight(ctx context.Context, blockHash string) (int64, error) {
+	var block struct {
+		Height int64 `json:"height"`
+	}
+	if err := c.getJSON(ctx, "/block/"+blockHash, &block); err != nil {
+		return 0, err
+	}
+
+	return block.Height, nil
+}
+
+// GetBlockHeader returns the header of a block by hash
+func (c *Client) GetBlockHeader(ctx context.Context, blockHash string) (*wire.BlockHeader, error) {
+	rawHeader, err := c.getText(ctx, "/block/"+blockHash+"/header")
+	if err != nil {
+		return nil, err
+	}
+
+	headerBytes, err := hex.DecodeString(rawHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	var header wire.BlockHeader
+	if err = header.Deserialize(bytes.NewReader(headerBytes)); err != nil {
+		return nil, err
+	}
+
+	return &header, nil
+}
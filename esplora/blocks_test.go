@@ -0,0 +1,65 @@
+package esplora
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// genesisBlockHash is the hash of the mainnet genesis block
+const genesisBlockHash = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+
+// TestClientBlocks will test the fee estimate and block methods of the client
+func TestClientBlocks(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/fee-estimates", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"1":87.882,"6":68.285,"144":1.027}`))
+	})
+	mux.HandleFunc("/api/blocks/tip/height", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("0"))
+	})
+	mux.HandleFunc("/api/blocks/tip/hash", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(genesisBlockHash))
+	})
+	mux.HandleFunc("/api/block-height/0", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(genesisBlockHash))
+	})
+	mux.HandleFunc("/api/block/"+genesisBlockHash, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"` + genesisBlockHash + `","height":0,"version":1,"tx_count":1}`))
+	})
+	mux.HandleFunc("/api/block/"+genesisBlockHash+"/header", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c"))
+	})
+	client := newTestClient(t, mux)
+
+	estimates, err := client.GetFeeEstimates(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, estimates, 3)
+	assert.InDelta(t, 68.285, estimates[6], 1e-9)
+
+	height, err := client.GetTipHeight(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), height)
+
+	tipHash, err := client.GetTipHash(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, genesisBlockHash, tipHash)
+
+	blockHash, err := client.GetBlockHash(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, genesisBlockHash, blockHash)
+
+	blockHeight, err := client.GetBlockHeight(context.Background(), blockHash)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), blockHeight)
+
+	header, err := client.GetBlockHeader(context.Background(), blockHash)
+	require.NoError(t, err)
+	assert.Equal(t, genesisBlockHash, header.BlockHash().String())
+	assert.Equal(t, uint32(0x1d00ffff), header.Bits)
+}
@@ -0,0 +1,127 @@
+package esplora
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// ChainBackend adapts a Client to bitcoin.ChainBackend. The API indexes addresses, so scripts
+// without an address cannot be looked up
+type ChainBackend struct {
+	client       *Client
+	pollInterval time.Duration
+}
+
+// NewChainBackend creates a chain backend polling the API for new blocks every pollInterval, bitcoin.DefaultPollInterval when not positive
+func NewChainBackend(client *Client, pollInterval time.Duration) *ChainBackend {
+	if pollInterval <= 0 {
+		pollInterval = bitcoin.DefaultPollInterval
+	}
+
+	return &ChainBackend{client: client, pollInterval: pollInterval}
+}
+
+// GetUtxos implements bitcoin.ChainBackend, including mempool outputs
+func (b *ChainBackend) GetUtxos(ctx context.Context, scripts []string) ([]bitcoin.Utxo, error) {
+	var utxos []bitcoin.Utxo
+	for _, script := range scripts {
+		address, err := bitcoin.GetAddressFromScript(script, b.client.config.Network)
+		if err != nil {
+			return nil, err
+		}
+
+		unspents, err := b.client.GetAddressUnspents(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, unspent := range unspents {
+			utxo := bitcoin.Utxo{TxID: unspent.TxID, Vout: unspent.Vout, Value: unspent.Value, Script: script}
+			if unspent.Status.Confirmed {
+				utxo.Height = unspent.Status.BlockHeight
+			}
+			utxos = append(utxos, utxo)
+		}
+	}
+
+	return utxos, nil
+}
+
+// GetTransaction implements bitcoin.ChainBackend
+func (b *ChainBackend) GetTransaction(ctx context.Context, txID string) (*wire.MsgTx, error) {
+	tx, err := b.client.GetRawTransaction(ctx, txID)
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %w", bitcoin.ErrTransactionNotFound, err)
+	}
+
+	return tx, err
+}
+
+// Broadcast implements bitcoin.ChainBackend
+func (b *ChainBackend) Broadcast(ctx context.Context, tx *wire.MsgTx) (string, error) {
+	return b.client.BroadcastTransaction(ctx, tx)
+}
+
+// EstimateFee implements bitcoin.ChainBackend. The API only estimates some targets, the closest
+// lower target is used for the others, which errs on the side of a higher fee rate
+func (b *ChainBackend) EstimateFee(ctx context.Context, confTarget int) (float64, error) {
+	estimates, err := b.client.GetFeeEstimates(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(estimates) == 0 {
+		return 0, bitcoin.ErrFeeEstimateUnavailable
+	}
+
+	targets := make([]int, 0, len(estimates))
+	for target := range estimates {
+		targets = append(targets, target)
+	}
+	sort.Ints(targets)
+
+	// below the lowest target, the lowest target is the best estimate
+	best := targets[0]
+	for _, target := range targets {
+		if target <= confTarget {
+			best = target
+		}
+	}
+
+	return estimates[best], nil
+}
+
+// TipHeight implements bitcoin.ChainBackend
+func (b *ChainBackend) TipHeight(ctx context.Context) (int64, error) {
+	return b.client.GetTipHeight(ctx)
+}
+
+// SubscribeBlocks implements bitcoin.ChainBackend by polling the tip hash, the height is that of the block
+// polled so both describe the same block when the tip moves in between
+func (b *ChainBackend) SubscribeBlocks(ctx context.Context) (<-chan bitcoin.BlockEvent, error) {
+	return bitcoin.PollBlocks(ctx, b.pollInterval, func(ctx context.Context) (bitcoin.BlockEvent, error) {
+		hash, err := b.client.GetTipHash(ctx)
+		if err != nil {
+			return bitcoin.BlockEvent{}, err
+		}
+
+		height, err := b.client.GetBlockHeight(ctx, hash)
+		if err != nil {
+			return bitcoin.BlockEvent{}, err
+		}
+
+		return bitcoin.BlockEvent{Height: height, Hash: hash}, nil
+	}), nil
+}
+
+// compile time check
+var _ bitcoin.ChainBackend = (*ChainBackend)(nil)
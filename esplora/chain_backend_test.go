@@ -0,0 +1,126 @@
+package esplora
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChainBackend will test the bitcoin.ChainBackend adapter of the client
+func TestChainBackend(t *testing.T) {
+	t.Parallel()
+
+	script, err := bitcoin.GetScriptFromAddress(testAddress, bitcoin.Testnet)
+	require.NoError(t, err)
+
+	var height atomic.Int64
+	height.Store(100)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/address/"+testAddress+"/utxo", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`[{"txid":"aa","vout":0,"value":1000,"status":{"confirmed":true,"block_height":99}},{"txid":"bb","vout":1,"value":2000,"status":{"confirmed":false}}]`))
+	})
+	mux.HandleFunc("/api/tx/{txid}/hex", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/fee-estimates", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"2":20.5,"6":10.25,"144":1.5}`))
+	})
+	mux.HandleFunc("/api/blocks/tip/height", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strconv.FormatInt(height.Load(), 10)))
+	})
+	mux.HandleFunc("/api/blocks/tip/hash", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hash" + strconv.FormatInt(height.Load(), 10)))
+	})
+	mux.HandleFunc("/api/block/{hash}", func(w http.ResponseWriter, r *http.Request) {
+		blockHeight, _ := strconv.ParseInt(strings.TrimPrefix(r.PathValue("hash"), "hash"), 10, 64)
+
+		// the tip moves on between the two requests of a poll
+		height.Add(1)
+		_, _ = w.Write([]byte(`{"height":` + strconv.FormatInt(blockHeight, 10) + `}`))
+	})
+	client := newTestClient(t, mux)
+
+	var backend bitcoin.ChainBackend = NewChainBackend(client, time.Millisecond)
+	ctx := context.Background()
+
+	utxos, err := backend.GetUtxos(ctx, []string{script})
+	require.NoError(t, err)
+	require.Len(t, utxos, 2)
+	assert.Equal(t, bitcoin.Utxo{TxID: "aa", Vout: 0, Value: btcutil.Amount(1000), Script: script, Height: 99}, utxos[0])
+	assert.Equal(t, int64(0), utxos[1].Height)
+
+	// OP_RETURN scripts have no address
+	_, err = backend.GetUtxos(ctx, []string{"6a0100"})
+	assert.ErrorIs(t, err, bitcoin.ErrScriptHasNoAddress)
+
+	_, err = backend.GetTransaction(ctx, "00")
+	assert.ErrorIs(t, err, bitcoin.ErrTransactionNotFound)
+
+	var tests = []struct {
+		confTarget      int
+		expectedFeeRate float64
+	}{
+		{1, 20.5},
+		{2, 20.5},
+		{5, 20.5},
+		{6, 10.25},
+		{100, 10.25},
+		{1008, 1.5},
+	}
+	for _, test := range tests {
+		feeRate, err := backend.EstimateFee(ctx, test.confTarget)
+		require.NoError(t, err)
+		assert.InDelta(t, test.expectedFeeRate, feeRate, 1e-9, "target %d", test.confTarget)
+	}
+
+	tipHeight, err := backend.TipHeight(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), tipHeight)
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := backend.SubscribeBlocks(subCtx)
+	require.NoError(t, err)
+
+	// every event pairs a hash with the height of that block
+	for event := range events {
+		assert.Equal(t, "hash"+strconv.FormatInt(event.Height, 10), event.Hash)
+		if event.Height >= 102 {
+			break
+		}
+	}
+}
+
+// TestNewChainBackend will test that NewChainBackend() without a poll interval polls at the default one
+func TestNewChainBackend(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/blocks/tip/hash", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hash100"))
+	})
+	mux.HandleFunc("/api/block/hash100", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"height":100}`))
+	})
+	backend := NewChainBackend(newTestClient(t, mux), 0)
+	assert.Equal(t, bitcoin.DefaultPollInterval, backend.pollInterval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := backend.SubscribeBlocks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, bitcoin.BlockEvent{Height: 100, Hash: "hash100"}, <-events)
+
+	cancel()
+	for range events {
+	}
+}
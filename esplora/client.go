@@ -0,0 +1,149 @@
+package esplora
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// Public instances of the API
+const (
+	BlockstreamMainnetURL = "https://blockstream.info/api"
+	BlockstreamTestnetURL = "https://blockstream.info/testnet/api"
+	MempoolMainnetURL     = "https://mempool.space/api"
+	MempoolTestnetURL     = "https://mempool.space/testnet/api"
+)
+
+// Config holds the settings of a Client
+type Config struct {
+	BaseURL    string // e.g. MempoolMainnetURL or a self-hosted instance
+	Network    bitcoin.NetworkType
+	HTTPClient *http.Client  // defaults to http.DefaultClient
+	MaxRetries int           // retries of a rate limited request, defaults to 3, negative disables them
+	MinBackoff time.Duration // first wait after a rate limited request, doubled on every retry, defaults to 1s
+}
+
+// Client is an Esplora REST API client, safe for concurrent use
+type Client struct {
+	config Config
+}
+
+// New creates a new client for the API at config.BaseURL
+func New(config Config) (*Client, error) {
+
+	// Missing base url
+	if len(config.BaseURL) == 0 {
+		return nil, ErrMissingURL
+	}
+
+	// Missing network
+	if config.Network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	switch {
+	case config.MaxRetries == 0:
+		config.MaxRetries = 3
+	case config.MaxRetries < 0:
+		config.MaxRetries = 0
+	}
+	if config.MinBackoff == 0 {
+		config.MinBackoff = time.Second
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	return &Client{config: config}, nil
+}
+
+// Network returns the network the client was configured for
+func (c *Client) Network() bitcoin.NetworkType {
+	return c.config.Network
+}
+
+// getJSON requests path and decodes the JSON response into out
+func (c *Client) getJSON(ctx context.Context, path string, out any) error {
+	body, err := c.do(ctx, http.MethodGet, path, "")
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// getText requests path and returns the response as text
+func (c *Client) getText(ctx context.Context, path string) (string, error) {
+	body, err := c.do(ctx, http.MethodGet, path, "")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
+// do sends a request, retrying with an exponential backoff while it is rate limited
+func (c *Client) do(ctx context.Context, method, path, body string) ([]byte, error) {
+	backoff := c.config.MinBackoff
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.config.BaseURL+path, strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > 0 {
+			req.Header.Set("Content-Type", "text/plain")
+		}
+
+		resp, err := c.config.HTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return respBody, nil
+		}
+
+		apiErr := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+		if !isRateLimited(resp.StatusCode) || attempt >= c.config.MaxRetries {
+			return nil, apiErr
+		}
+
+		// a Retry-After of the server takes precedence over the backoff
+		wait := backoff
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+		backoff *= 2
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// validateAddress checks that an address is valid for the client's network
+func (c *Client) validateAddress(address string) error {
+	_, err := bitcoin.DecodeAddressForNetwork(address, c.config.Network)
+	return err
+}
+
+// isRateLimited reports whether a status code asks the client to slow down
+func isRateLimited(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
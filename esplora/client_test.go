@@ -0,0 +1,104 @@
+package esplora
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient returns a testnet client for a stub API served by handler
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := New(Config{BaseURL: server.URL + "/api/", Network: bitcoin.Testnet, MinBackoff: time.Millisecond})
+	require.NoError(t, err)
+	return client
+}
+
+// TestNew will test the method New()
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(Config{Network: bitcoin.Mainnet})
+	assert.ErrorIs(t, err, ErrMissingURL)
+
+	_, err = New(Config{BaseURL: MempoolMainnetURL})
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+	assert.ErrorIs(t, err, bitcoin.ErrMissingNetwork)
+
+	client, err := New(Config{BaseURL: MempoolMainnetURL, Network: bitcoin.Mainnet})
+	require.NoError(t, err)
+	assert.Equal(t, bitcoin.Mainnet, client.Network())
+	assert.Equal(t, 3, client.config.MaxRetries)
+
+	client, err = New(Config{BaseURL: MempoolMainnetURL, Network: bitcoin.Mainnet, MaxRetries: -1})
+	require.NoError(t, err)
+	assert.Equal(t, 0, client.config.MaxRetries)
+}
+
+// TestClientRateLimit will test that rate limited requests are retried with a backoff
+func TestClientRateLimit(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("840000"))
+	}))
+
+	height, err := client.GetTipHeight(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(840000), height)
+	assert.Equal(t, int32(3), requests.Load())
+
+	// gives up after MaxRetries
+	requests.Store(0)
+	client.config.MaxRetries = 1
+	_, err = client.GetTipHeight(context.Background())
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// or right away without retries
+	requests.Store(0)
+	client.config.MaxRetries = 0
+	_, err = client.GetTipHeight(context.Background())
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, int32(1), requests.Load())
+
+	// the wait is cancelled with the context
+	client.config.MaxRetries = 3
+	client.config.MinBackoff = time.Hour
+	requests.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.GetTipHeight(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestClientErrors will test that unsuccessful responses are returned as errors
+func TestClientErrors(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+	}))
+
+	_, err := client.GetTransaction(context.Background(), "00")
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "Transaction not found", apiErr.Message)
+}
@@ -0,0 +1,28 @@
+package esplora
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// ErrMissingURL is returned when a client is created without a base URL
+var ErrMissingURL = errors.New("missing esplora base url")
+
+// ErrMissingNetwork is returned when a client is created without a network, the same error as bitcoin.ErrMissingNetwork
+var ErrMissingNetwork = bitcoin.ErrMissingNetwork
+
+// ErrWrongNetwork is returned when an address is not for the client's network, the same error as bitcoin.ErrWrongNetwork
+var ErrWrongNetwork = bitcoin.ErrWrongNetwork
+
+// Error is a non successful response of the API, e.g. a 400 for a rejected broadcast
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("esplora status %d: %s", e.StatusCode, e.Message)
+}
@@ -0,0 +1,99 @@
+package esplora
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// TxStatus is the confirmation status of a transaction, the block fields are only set once confirmed
+type TxStatus struct {
+	Confirmed   bool   `json:"confirmed"`
+	BlockHeight int64  `json:"block_height"`
+	BlockHash   string `json:"block_hash"`
+	BlockTime   int64  `json:"block_time"`
+}
+
+// Output is a transaction output
+type Output struct {
+	ScriptPubKey        string         `json:"scriptpubkey"` // hex encoded
+	ScriptPubKeyAsm     string         `json:"scriptpubkey_asm"`
+	ScriptPubKeyType    string         `json:"scriptpubkey_type"`
+	ScriptPubKeyAddress string         `json:"scriptpubkey_address"`
+	Value               btcutil.Amount `json:"value"`
+}
+
+// Input is a transaction input, with the output it spends
+type Input struct {
+	TxID         string   `json:"txid"`
+	Vout         uint32   `json:"vout"`
+	PrevOut      *Output  `json:"prevout"` // nil for a coinbase input
+	ScriptSig    string   `json:"scriptsig"`
+	ScriptSigAsm string   `json:"scriptsig_asm"`
+	Witness      []string `json:"witness"`
+	IsCoinbase   bool     `json:"is_coinbase"`
+	Sequence     uint32   `json:"sequence"`
+}
+
+// Transaction is a transaction as returned by the API
+type Transaction struct {
+	TxID     string         `json:"txid"`
+	Version  int32          `json:"version"`
+	LockTime uint32         `json:"locktime"`
+	Vin      []Input        `json:"vin"`
+	Vout     []Output       `json:"vout"`
+	Size     int64          `json:"size"`
+	Weight   int64          `json:"weight"`
+	Fee      btcutil.Amount `json:"fee"`
+	Status   TxStatus       `json:"status"`
+}
+
+// GetTransaction returns a transaction by txid
+func (c *Client) GetTransaction(ctx context.Context, txID string) (*Transaction, error) {
+	var tx Transaction
+	if err := c.getJSON(ctx, "/tx/"+txID, &tx); err != nil {
+		return nil, err
+	}
+
+	return &tx, nil
+}
+
+// GetRawTransaction returns a transaction by txid, as a wire transaction
+func (c *Client) GetRawTransaction(ctx context.Context, txID string) (*wire.MsgTx, error) {
+	rawTx, err := c.getText(ctx, "/tx/"+txID+"/hex")
+	if err != nil {
+		return nil, err
+	}
+
+	return bitcoin.TransactionFromString(rawTx)
+}
+
+// GetTransactionStatus returns the confirmation status of a transaction
+func (c *Client) GetTransactionStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	var status TxStatus
+	if err := c.getJSON(ctx, "/tx/"+txID+"/status", &status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// BroadcastTransaction broadcasts a signed transaction and returns its txid
+func (c *Client) BroadcastTransaction(ctx context.Context, tx *wire.MsgTx) (string, error) {
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+
+	txID, err := c.do(ctx, http.MethodPost, "/tx", hex.EncodeToString(buf.Bytes()))
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes.TrimSpace(txID)), nil
+}
@@ -0,0 +1,74 @@
+package esplora
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// genesisCoinbaseTx is the coinbase transaction of the mainnet genesis block
+const genesisCoinbaseTx = "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+// genesisCoinbaseTxID is the txid of genesisCoinbaseTx
+const genesisCoinbaseTxID = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+
+// TestClientTransactions will test the transaction methods of the client
+func TestClientTransactions(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tx/"+genesisCoinbaseTxID, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"txid":"` + genesisCoinbaseTxID + `","version":1,"locktime":0,"size":204,"weight":816,"fee":0,
+			"vin":[{"txid":"0000000000000000000000000000000000000000000000000000000000000000","vout":4294967295,"prevout":null,"is_coinbase":true,"sequence":4294967295}],
+			"vout":[{"scriptpubkey":"4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac","scriptpubkey_type":"p2pk","value":5000000000}],
+			"status":{"confirmed":true,"block_height":0}}`))
+	})
+	mux.HandleFunc("GET /api/tx/"+genesisCoinbaseTxID+"/hex", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(genesisCoinbaseTx))
+	})
+	mux.HandleFunc("GET /api/tx/"+genesisCoinbaseTxID+"/status", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"confirmed":true,"block_height":0,"block_hash":"000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f","block_time":1231006505}`))
+	})
+	mux.HandleFunc("POST /api/tx", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != genesisCoinbaseTx {
+			http.Error(w, "sendrawtransaction RPC error: {\"code\":-22,\"message\":\"TX decode failed\"}", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(genesisCoinbaseTxID))
+	})
+	client := newTestClient(t, mux)
+
+	tx, err := client.GetTransaction(context.Background(), genesisCoinbaseTxID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(816), tx.Weight)
+	require.Len(t, tx.Vin, 1)
+	assert.True(t, tx.Vin[0].IsCoinbase)
+	assert.Nil(t, tx.Vin[0].PrevOut)
+	require.Len(t, tx.Vout, 1)
+	assert.Equal(t, btcutil.Amount(5000000000), tx.Vout[0].Value)
+
+	msgTx, err := client.GetRawTransaction(context.Background(), genesisCoinbaseTxID)
+	require.NoError(t, err)
+	assert.Equal(t, genesisCoinbaseTxID, msgTx.TxHash().String())
+
+	status, err := client.GetTransactionStatus(context.Background(), genesisCoinbaseTxID)
+	require.NoError(t, err)
+	assert.True(t, status.Confirmed)
+	assert.Equal(t, int64(1231006505), status.BlockTime)
+
+	txID, err := client.BroadcastTransaction(context.Background(), msgTx)
+	require.NoError(t, err)
+	assert.Equal(t, genesisCoinbaseTxID, txID)
+
+	msgTx.LockTime = 1
+	_, err = client.BroadcastTransaction(context.Background(), msgTx)
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/funmi4194/go-bitcoin"
+)
+
+func main() {
+	// the coinbase transaction of the genesis block
+	rawTx := "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+	// Decode the transaction
+	decoded, err := bitcoin.DecodeRawTransaction(rawTx, bitcoin.Mainnet)
+	if err != nil {
+		log.Fatalf("error occurred: %s", err.Error())
+	}
+
+	var output []byte
+	if output, err = json.MarshalIndent(decoded, "", "  "); err != nil {
+		log.Fatalf("error occurred: %s", err.Error())
+	}
+
+	// Success!
+	log.Printf("decoded transaction: %s", output)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"crypto/sha256"
+	"log"
+
+	"github.com/funmi4194/go-bitcoin"
+)
+
+func main() {
+	// Hash a document to anchor on-chain
+	documentHash := sha256.Sum256([]byte("the quick brown fox"))
+
+	// Get the OP_RETURN script
+	script, err := bitcoin.GetOpReturnScript([]byte("doc"), documentHash[:])
+	if err != nil {
+		log.Fatalf("error occurred: %s", err.Error())
+	}
+
+	// Read the data back from the script
+	var data [][]byte
+	if data, err = bitcoin.GetDataFromOpReturnScript(script); err != nil {
+		log.Fatalf("error occurred: %s", err.Error())
+	}
+
+	// Success!
+	log.Printf("generated script: %s carrying: %x", script, data)
+}
@@ -0,0 +1,432 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// IncrementalRelayFeeRate is the fee rate (sat/vB) a replacement pays for its own size on top of the fees
+// of the transactions it replaces (BIP125 rule 4), Bitcoin Core's -incrementalrelayfee default
+const IncrementalRelayFeeRate = 1.0
+
+// MinRelayFeeRate is the lowest fee rate (sat/vB) relayed by nodes running the -minrelaytxfee default
+const MinRelayFeeRate = 1.0
+
+// replaceableSequence is the sequence of the inputs added by a wallet, signaling replaceability (BIP125)
+const replaceableSequence = wire.MaxTxInSequenceNum - 2
+
+// FeeBump is a transaction raising the fee rate of an unconfirmed transaction, either replacing it
+// (BIP125) or spending one of its outputs (child-pays-for-parent). Tx is signed unless the wallet is
+// watch-only, it is broadcast with Wallet.Broadcast
+type FeeBump struct {
+	Tx             *wire.MsgTx
+	PrevOuts       map[wire.OutPoint]*wire.TxOut // the outputs spent by Tx, to sign it elsewhere
+	Fee            btcutil.Amount
+	VSize          int64    // estimated with the largest signatures until Tx is signed
+	FeeRate        float64  // sat/vB of Tx
+	PackageFeeRate float64  // sat/vB of the parent and Tx together, set for child-pays-for-parent
+	Replaced       []string // txids replaced by Tx, the original and its descendants, set for replace-by-fee
+}
+
+// SignalsReplacement reports whether tx opts in to replacement (BIP125), one of its inputs having a
+// sequence below 0xfffffffe. Transactions inheriting replaceability from unconfirmed parents are not detected
+func SignalsReplacement(tx *wire.MsgTx) bool {
+	for _, in := range tx.TxIn {
+		if in.Sequence < wire.MaxTxInSequenceNum-1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BumpFeeRBF builds a replacement of an unconfirmed transaction broadcast through the wallet, paying the
+// same outputs at feeRate (sat/vB) out of its change, adding confirmed utxos when the change is short.
+// Following BIP125, the replacement pays at least the fees of the transaction and of its descendants in
+// the wallet, which it also replaces, plus IncrementalRelayFeeRate for its own size
+func (w *Wallet) BumpFeeRBF(txID string, feeRate float64) (*FeeBump, error) {
+	w.mu.Lock()
+	record, err := w.unconfirmedTransaction(txID)
+	if err != nil {
+		w.mu.Unlock()
+		return nil, err
+	}
+
+	// only transactions of the wallet spending its own outputs can be rebuilt and signed again
+	if len(record.RawTx) == 0 {
+		w.mu.Unlock()
+		return nil, fmt.Errorf("%w: not broadcast through the wallet", ErrNotReplaceable)
+	}
+	original, err := TransactionFromString(record.RawTx)
+	if err != nil {
+		w.mu.Unlock()
+		return nil, err
+	}
+	if !SignalsReplacement(original) {
+		w.mu.Unlock()
+		return nil, fmt.Errorf("%w: does not signal BIP125", ErrNotReplaceable)
+	}
+	if len(record.Spent) != len(original.TxIn) {
+		w.mu.Unlock()
+		return nil, fmt.Errorf("%w: spends outputs of other wallets", ErrNotReplaceable)
+	}
+
+	replaced := w.replacedBy(original)
+	var replacedFees btcutil.Amount
+	var replacedFeeRate float64
+	for _, known := range w.state.Transactions {
+		if !replaced[known.TxID] {
+			continue
+		}
+		replacedFees += known.Fee
+
+		tx, err := TransactionFromString(known.RawTx)
+		if err != nil {
+			w.mu.Unlock()
+			return nil, err
+		}
+		replacedFeeRate = max(replacedFeeRate, float64(known.Fee)/float64(GetTransactionVSize(tx)))
+	}
+
+	var changeScript []byte
+	var payments []*wire.TxOut
+	for _, out := range original.TxOut {
+		address, ok := w.scripts[hex.EncodeToString(out.PkScript)]
+		if ok && address.Chain == InternalChain && changeScript == nil {
+			changeScript = out.PkScript
+			continue
+		}
+		payments = append(payments, out)
+	}
+
+	candidates := w.spendableUtxos()
+	w.mu.Unlock()
+
+	if feeRate <= replacedFeeRate {
+		return nil, fmt.Errorf("%w: %.2f sat/vB does not exceed %.2f sat/vB", ErrFeeRateTooLow, feeRate, replacedFeeRate)
+	}
+
+	tx := wire.NewMsgTx(original.Version)
+	tx.LockTime = original.LockTime
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(original.TxIn))
+	var inputs btcutil.Amount
+	for i, in := range original.TxIn {
+		prevOut, err := record.Spent[i].txOut()
+		if err != nil {
+			return nil, err
+		}
+		tx.AddTxIn(wire.NewTxIn(&in.PreviousOutPoint, nil, nil))
+		tx.TxIn[i].Sequence = in.Sequence
+		prevOuts[in.PreviousOutPoint] = prevOut
+		inputs += btcutil.Amount(prevOut.Value)
+	}
+
+	var paid btcutil.Amount
+	for _, out := range payments {
+		tx.AddTxOut(wire.NewTxOut(out.Value, out.PkScript))
+		paid += btcutil.Amount(out.Value)
+	}
+
+	requiredFee := func(vsize int64) btcutil.Amount {
+		return max(feeAtRate(feeRate, vsize), replacedFees+feeAtRate(IncrementalRelayFeeRate, vsize))
+	}
+
+	// a new change address is only taken once the change is kept
+	newChange := changeScript == nil
+	if newChange {
+		if changeScript, err = w.placeholderChangeScript(); err != nil {
+			return nil, err
+		}
+	}
+
+	withChange, err := w.fundWithChange(tx, prevOuts, inputs-paid, candidates, changeScript, requiredFee)
+	if err != nil {
+		return nil, err
+	}
+
+	if withChange && newChange {
+		if err = w.setChangeScript(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	bump, err := w.finishFeeBump(tx, prevOuts)
+	if err != nil {
+		return nil, err
+	}
+
+	bump.Replaced = make([]string, 0, len(replaced))
+	for id := range replaced {
+		bump.Replaced = append(bump.Replaced, id)
+	}
+	sort.Strings(bump.Replaced)
+
+	return bump, nil
+}
+
+// BumpFeeCPFP builds a child of an unconfirmed transaction of the wallet's history, spending one of its
+// outputs to the wallet (change first) so that the parent and the child together pay feeRate (sat/vB).
+// The fee of a parent spending outputs of other wallets is resolved through the backend. Unconfirmed
+// ancestors of the parent are not accounted for
+func (w *Wallet) BumpFeeCPFP(ctx context.Context, txID string, feeRate float64) (*FeeBump, error) {
+	w.mu.Lock()
+	record, err := w.unconfirmedTransaction(txID)
+	if err != nil {
+		w.mu.Unlock()
+		return nil, err
+	}
+
+	var outputs []WalletUtxo
+	for _, utxo := range w.state.Utxos {
+		if utxo.TxID == txID {
+			outputs = append(outputs, utxo)
+		}
+	}
+	candidates := w.spendableUtxos()
+	w.mu.Unlock()
+
+	if len(outputs) == 0 {
+		return nil, ErrNoSpendableOutput
+	}
+	sort.SliceStable(outputs, func(i, j int) bool {
+		if outputs[i].Chain != outputs[j].Chain {
+			return outputs[i].Chain == InternalChain
+		}
+		return outputs[i].Value > outputs[j].Value
+	})
+
+	parent, parentFee, err := w.parentWithFee(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	parentVSize := GetTransactionVSize(parent)
+	if parentFeeRate := float64(parentFee) / float64(parentVSize); feeRate <= parentFeeRate {
+		return nil, fmt.Errorf("%w: %.2f sat/vB does not exceed %.2f sat/vB", ErrFeeRateTooLow, feeRate, parentFeeRate)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, 1)
+	inputs, err := addWalletInput(tx, prevOuts, outputs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	changeScript, err := w.placeholderChangeScript()
+	if err != nil {
+		return nil, err
+	}
+
+	// the child pays for the missing fee of the package, and at least the relay fee of its own size
+	for {
+		tx.TxOut = []*wire.TxOut{wire.NewTxOut(0, changeScript)}
+		vsize := w.estimateVSize(tx)
+		fee := max(feeAtRate(feeRate, parentVSize+vsize)-parentFee, feeAtRate(MinRelayFeeRate, vsize))
+		if change := inputs - fee; change >= dustLimit(changeScript) {
+			tx.TxOut[0].Value = int64(change)
+			break
+		}
+
+		// Missing funds
+		if len(candidates) == 0 {
+			return nil, ErrInsufficientFunds
+		}
+
+		added, err := addWalletInput(tx, prevOuts, candidates[0])
+		if err != nil {
+			return nil, err
+		}
+		inputs += added
+		candidates = candidates[1:]
+	}
+
+	if err = w.setChangeScript(tx); err != nil {
+		return nil, err
+	}
+
+	bump, err := w.finishFeeBump(tx, prevOuts)
+	if err != nil {
+		return nil, err
+	}
+	bump.PackageFeeRate = float64(parentFee+bump.Fee) / float64(parentVSize+bump.VSize)
+
+	return bump, nil
+}
+
+// unconfirmedTransaction returns the record of an unconfirmed transaction of the history, w.mu must be held
+func (w *Wallet) unconfirmedTransaction(txID string) (WalletTransaction, error) {
+	for _, record := range w.state.Transactions {
+		if record.TxID != txID {
+			continue
+		}
+		if record.Height > 0 {
+			return record, ErrTransactionConfirmed
+		}
+		return record, nil
+	}
+
+	return WalletTransaction{}, fmt.Errorf("%w: %s", ErrTransactionNotFound, txID)
+}
+
+// spendableUtxos returns the confirmed utxos, largest first and then by outpoint. Unconfirmed outputs are left out as a
+// replacement may not spend new unconfirmed outputs (BIP125 rule 2). w.mu must be held
+func (w *Wallet) spendableUtxos() []WalletUtxo {
+	var utxos []WalletUtxo
+	for _, utxo := range w.state.Utxos {
+		if utxo.Height > 0 {
+			utxos = append(utxos, utxo)
+		}
+	}
+	sort.Slice(utxos, func(i, j int) bool {
+		if utxos[i].Value != utxos[j].Value {
+			return utxos[i].Value > utxos[j].Value
+		}
+		if utxos[i].TxID != utxos[j].TxID {
+			return utxos[i].TxID < utxos[j].TxID
+		}
+		return utxos[i].Vout < utxos[j].Vout
+	})
+
+	return utxos
+}
+
+// placeholderChangeScript returns the script of the first change address, standing in for the change
+// output while sizing a transaction since the wallet's scripts all have one size
+func (w *Wallet) placeholderChangeScript() ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return hex.DecodeString(w.addresses[InternalChain][0].Script)
+}
+
+// parentWithFee returns the transaction of record and its fee, fetching the outputs it spends from the
+// backend when they were not all the wallet's
+func (w *Wallet) parentWithFee(ctx context.Context, record WalletTransaction) (*wire.MsgTx, btcutil.Amount, error) {
+	if len(record.RawTx) > 0 && record.Fee > 0 {
+		parent, err := TransactionFromString(record.RawTx)
+		return parent, record.Fee, err
+	}
+
+	// Missing backend
+	if w.config.Backend == nil {
+		return nil, 0, ErrMissingChainBackend
+	}
+
+	parent, err := w.config.Backend.GetTransaction(ctx, record.TxID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var fee btcutil.Amount
+	for _, in := range parent.TxIn {
+		prevTx, err := w.config.Backend.GetTransaction(ctx, in.PreviousOutPoint.Hash.String())
+		if err != nil {
+			return nil, 0, err
+		}
+		if int(in.PreviousOutPoint.Index) >= len(prevTx.TxOut) {
+			return nil, 0, fmt.Errorf("%w: %s", ErrMissingPrevOut, in.PreviousOutPoint)
+		}
+		fee += btcutil.Amount(prevTx.TxOut[in.PreviousOutPoint.Index].Value)
+	}
+	for _, out := range parent.TxOut {
+		fee -= btcutil.Amount(out.Value)
+	}
+
+	return parent, fee, nil
+}
+
+// fundWithChange sets the fee of tx, left with available once its outputs are paid, to requiredFee of
+// its signed size. The change goes to a last output to changeScript, or to the fee when below its dust limit,
+// and confirmed candidates are added as inputs until the fee is covered. It reports whether tx kept a
+// change output
+func (w *Wallet) fundWithChange(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut, available btcutil.Amount,
+	candidates []WalletUtxo, changeScript []byte, requiredFee func(vsize int64) btcutil.Amount) (bool, error) {
+
+	for {
+		tx.AddTxOut(wire.NewTxOut(0, changeScript))
+		if change := available - requiredFee(w.estimateVSize(tx)); change >= dustLimit(changeScript) {
+			tx.TxOut[len(tx.TxOut)-1].Value = int64(change)
+			return true, nil
+		}
+
+		tx.TxOut = tx.TxOut[:len(tx.TxOut)-1]
+		if available >= requiredFee(w.estimateVSize(tx)) {
+			return false, nil
+		}
+
+		// Missing funds
+		if len(candidates) == 0 {
+			return false, ErrInsufficientFunds
+		}
+
+		added, err := addWalletInput(tx, prevOuts, candidates[0])
+		if err != nil {
+			return false, err
+		}
+		available += added
+		candidates = candidates[1:]
+	}
+}
+
+// setChangeScript pays the last output of tx to a new change address
+func (w *Wallet) setChangeScript(tx *wire.MsgTx) error {
+	change, err := w.ChangeAddress()
+	if err != nil {
+		return err
+	}
+
+	tx.TxOut[len(tx.TxOut)-1].PkScript, err = hex.DecodeString(change.Script)
+	return err
+}
+
+// finishFeeBump signs tx unless the wallet is watch-only and measures it
+func (w *Wallet) finishFeeBump(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut) (*FeeBump, error) {
+	bump := &FeeBump{Tx: tx, PrevOuts: prevOuts, VSize: w.estimateVSize(tx)}
+	for _, prevOut := range prevOuts {
+		bump.Fee += btcutil.Amount(prevOut.Value)
+	}
+	for _, out := range tx.TxOut {
+		bump.Fee -= btcutil.Amount(out.Value)
+	}
+
+	if !w.IsWatchOnly() {
+		if err := w.SignTransaction(tx, prevOuts); err != nil {
+			return nil, err
+		}
+		bump.VSize = GetTransactionVSize(tx)
+	}
+	bump.FeeRate = float64(bump.Fee) / float64(bump.VSize)
+
+	return bump, nil
+}
+
+// addWalletInput adds an input spending utxo to tx, returning its value
+func addWalletInput(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut, utxo WalletUtxo) (btcutil.Amount, error) {
+	prevOut, err := utxo.txOut()
+	if err != nil {
+		return 0, err
+	}
+
+	outPoint, err := utxo.outPoint()
+	if err != nil {
+		return 0, err
+	}
+
+	in := wire.NewTxIn(outPoint, nil, nil)
+	in.Sequence = replaceableSequence
+	tx.AddTxIn(in)
+	prevOuts[*outPoint] = prevOut
+
+	return utxo.Value, nil
+}
+
+// feeAtRate returns the fee of vsize vbytes at feeRate (sat/vB), rounded up
+func feeAtRate(feeRate float64, vsize int64) btcutil.Amount {
+	return btcutil.Amount(math.Ceil(feeRate * float64(vsize)))
+}
@@ -0,0 +1,315 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPaymentAddress is an address outside the test wallets
+const testPaymentAddress = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+
+// fundedTestWallet returns a test wallet holding confirmed utxos of values
+func fundedTestWallet(t *testing.T, values ...int64) (*Wallet, *MemoryChainBackend) {
+	wallet, backend := newTestWallet(t, nil)
+	for _, value := range values {
+		address, err := wallet.NextAddress()
+		require.NoError(t, err)
+		backend.AddTransaction(payTo(t, address.Address, value))
+	}
+	backend.MineBlock()
+	require.NoError(t, wallet.Sync(context.Background()))
+
+	return wallet, backend
+}
+
+// utxoOf returns the wallet utxo of value
+func utxoOf(t *testing.T, wallet *Wallet, value btcutil.Amount) WalletUtxo {
+	for _, utxo := range wallet.Utxos() {
+		if utxo.Value == value {
+			return utxo
+		}
+	}
+	require.FailNow(t, "no utxo", "value %d", value)
+	return WalletUtxo{}
+}
+
+// signedSpend returns a transaction of the wallet spending utxo to outputs, signed with sequence
+func signedSpend(t *testing.T, wallet *Wallet, utxo WalletUtxo, sequence uint32, outputs ...*wire.TxOut) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+	_, err := addWalletInput(tx, prevOuts, utxo)
+	require.NoError(t, err)
+	tx.TxIn[0].Sequence = sequence
+	for _, out := range outputs {
+		tx.AddTxOut(out)
+	}
+
+	require.NoError(t, wallet.SignTransaction(tx, prevOuts))
+	return tx
+}
+
+// changeTxOut returns an output paying value to a new change address of the wallet
+func changeTxOut(t *testing.T, wallet *Wallet, value int64) *wire.TxOut {
+	change, err := wallet.ChangeAddress()
+	require.NoError(t, err)
+	return wire.NewTxOut(value, pkScriptForAddress(t, change.Address))
+}
+
+// TestSignalsReplacement will test the method SignalsReplacement()
+func TestSignalsReplacement(t *testing.T) {
+	t.Parallel()
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+	assert.False(t, SignalsReplacement(tx))
+
+	tx.TxIn[0].Sequence = wire.MaxTxInSequenceNum - 1
+	assert.False(t, SignalsReplacement(tx))
+
+	tx.TxIn[0].Sequence = wire.MaxTxInSequenceNum - 2
+	assert.True(t, SignalsReplacement(tx))
+}
+
+// TestWalletBumpFeeRBF will test the method BumpFeeRBF()
+func TestWalletBumpFeeRBF(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	wallet, backend := fundedTestWallet(t, 100000, 50000)
+
+	payment := wire.NewTxOut(60000, pkScriptForAddress(t, testPaymentAddress))
+	original := signedSpend(t, wallet, utxoOf(t, wallet, 100000), replaceableSequence, payment, changeTxOut(t, wallet, 39000))
+	txID, err := wallet.Broadcast(ctx, original)
+	require.NoError(t, err)
+
+	// the fee rate has to go up
+	_, err = wallet.BumpFeeRBF(txID, 5)
+	assert.ErrorIs(t, err, ErrFeeRateTooLow)
+
+	bump, err := wallet.BumpFeeRBF(txID, 20)
+	require.NoError(t, err)
+	assert.Equal(t, []string{txID}, bump.Replaced)
+	require.Len(t, bump.Tx.TxIn, 1)
+	require.Len(t, bump.Tx.TxOut, 2)
+	assert.Equal(t, payment, bump.Tx.TxOut[0])
+	assert.Equal(t, original.TxOut[1].PkScript, bump.Tx.TxOut[1].PkScript)
+	assert.Equal(t, btcutil.Amount(100000-60000-bump.Tx.TxOut[1].Value), bump.Fee)
+	assert.Equal(t, GetTransactionVSize(bump.Tx), bump.VSize)
+	assert.GreaterOrEqual(t, bump.FeeRate, 20.0)
+	assert.GreaterOrEqual(t, bump.Fee, 1000+btcutil.Amount(bump.VSize)) // BIP125 rule 4
+	require.NoError(t, VerifyTransaction(bump.Tx, bump.PrevOuts, StandardScriptFlags))
+
+	// the replacement takes the place of the original
+	bumpID, err := wallet.Broadcast(ctx, bump.Tx)
+	require.NoError(t, err)
+	require.Len(t, backend.Mempool(), 1)
+	assert.Equal(t, bumpID, backend.Mempool()[0].TxHash().String())
+
+	transactions := wallet.Transactions()
+	assert.Equal(t, bumpID, transactions[len(transactions)-1].TxID)
+	assert.Equal(t, bump.Fee, transactions[len(transactions)-1].Fee)
+	for _, tx := range transactions {
+		assert.NotEqual(t, txID, tx.TxID)
+	}
+	assert.Equal(t, WalletBalance{Confirmed: 50000, Unconfirmed: btcutil.Amount(bump.Tx.TxOut[1].Value)}, wallet.Balance())
+
+	// the change goes to the fee when it would be dust
+	again, err := wallet.BumpFeeRBF(bumpID, 300)
+	require.NoError(t, err)
+	require.Len(t, again.Tx.TxIn, 1)
+	require.Len(t, again.Tx.TxOut, 1)
+	assert.Equal(t, btcutil.Amount(40000), again.Fee)
+
+	// a change too small for the fee pulls in a confirmed utxo
+	again, err = wallet.BumpFeeRBF(bumpID, 400)
+	require.NoError(t, err)
+	require.Len(t, again.Tx.TxIn, 2)
+	require.Len(t, again.Tx.TxOut, 2)
+	assert.GreaterOrEqual(t, again.FeeRate, 400.0)
+	require.NoError(t, VerifyTransaction(again.Tx, again.PrevOuts, StandardScriptFlags))
+
+	_, err = wallet.BumpFeeRBF(bumpID, 10000)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// confirmed transactions cannot be bumped
+	backend.MineBlock()
+	require.NoError(t, wallet.Sync(ctx))
+	_, err = wallet.BumpFeeRBF(bumpID, 50)
+	assert.ErrorIs(t, err, ErrTransactionConfirmed)
+
+	_, err = wallet.BumpFeeRBF(txID, 50)
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+}
+
+// TestWalletBumpFeeRBFDescendants will test that a replacement pays for the descendants it replaces
+func TestWalletBumpFeeRBFDescendants(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	wallet, backend := fundedTestWallet(t, 100000)
+
+	payment := wire.NewTxOut(60000, pkScriptForAddress(t, testPaymentAddress))
+	original := signedSpend(t, wallet, utxoOf(t, wallet, 100000), replaceableSequence, payment, changeTxOut(t, wallet, 39000))
+	txID, err := wallet.Broadcast(ctx, original)
+	require.NoError(t, err)
+
+	child := signedSpend(t, wallet, utxoOf(t, wallet, 39000), replaceableSequence, changeTxOut(t, wallet, 34000))
+	childID, err := wallet.Broadcast(ctx, child)
+	require.NoError(t, err)
+
+	// the child pays 5000 sat at about 45 sat/vB, the replacement has to beat it
+	_, err = wallet.BumpFeeRBF(txID, 20)
+	assert.ErrorIs(t, err, ErrFeeRateTooLow)
+
+	bump, err := wallet.BumpFeeRBF(txID, 50)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{txID, childID}, bump.Replaced)
+	assert.GreaterOrEqual(t, bump.Fee, 6000+btcutil.Amount(bump.VSize))
+
+	_, err = wallet.Broadcast(ctx, bump.Tx)
+	require.NoError(t, err)
+	assert.Len(t, backend.Mempool(), 1)
+	assert.Len(t, wallet.Utxos(), 1)
+}
+
+// TestWalletBumpFeeRBFNotReplaceable will test BumpFeeRBF() with transactions that cannot be replaced
+func TestWalletBumpFeeRBFNotReplaceable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	wallet, backend := fundedTestWallet(t, 100000)
+
+	final := signedSpend(t, wallet, utxoOf(t, wallet, 100000), wire.MaxTxInSequenceNum,
+		wire.NewTxOut(60000, pkScriptForAddress(t, testPaymentAddress)), changeTxOut(t, wallet, 39000))
+	txID, err := wallet.Broadcast(ctx, final)
+	require.NoError(t, err)
+
+	_, err = wallet.BumpFeeRBF(txID, 20)
+	assert.ErrorIs(t, err, ErrNotReplaceable)
+
+	// received transactions were not built by the wallet
+	address, err := wallet.NextAddress()
+	require.NoError(t, err)
+	received := payTo(t, address.Address, 20000)
+	backend.AddTransaction(received)
+	require.NoError(t, wallet.Sync(ctx))
+
+	_, err = wallet.BumpFeeRBF(received.TxHash().String(), 20)
+	assert.ErrorIs(t, err, ErrNotReplaceable)
+}
+
+// TestWalletBumpFeeCPFP will test the method BumpFeeCPFP()
+func TestWalletBumpFeeCPFP(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	wallet, backend := fundedTestWallet(t, 100000)
+
+	// a payment to the wallet at 1 sat/vB, spending an output of another wallet
+	funding := payTo(t, testPaymentAddress, 50000)
+	backend.AddTransaction(funding)
+	backend.MineBlock()
+
+	address, err := wallet.NextAddress()
+	require.NoError(t, err)
+	fundingHash := funding.TxHash()
+	parent := wire.NewMsgTx(2)
+	parent.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&fundingHash, 0), nil, [][]byte{make([]byte, 72), make([]byte, 33)}))
+	parent.AddTxOut(wire.NewTxOut(20000, pkScriptForAddress(t, address.Address)))
+	parent.AddTxOut(wire.NewTxOut(29890, pkScriptForAddress(t, testPaymentAddress)))
+	backend.AddTransaction(parent)
+	require.NoError(t, wallet.Sync(ctx))
+
+	parentID := parent.TxHash().String()
+	parentVSize := GetTransactionVSize(parent)
+	bump, err := wallet.BumpFeeCPFP(ctx, parentID, 10)
+	require.NoError(t, err)
+	require.Len(t, bump.Tx.TxIn, 1)
+	assert.Equal(t, parentID, bump.Tx.TxIn[0].PreviousOutPoint.Hash.String())
+	assert.True(t, SignalsReplacement(bump.Tx))
+	assert.Equal(t, btcutil.Amount(20000-bump.Tx.TxOut[0].Value), bump.Fee)
+	assert.Equal(t, float64(110+bump.Fee)/float64(parentVSize+bump.VSize), bump.PackageFeeRate)
+	assert.GreaterOrEqual(t, bump.PackageFeeRate, 10.0)
+	assert.Less(t, bump.PackageFeeRate, 10.1)
+	require.NoError(t, VerifyTransaction(bump.Tx, bump.PrevOuts, StandardScriptFlags))
+
+	// the child goes to a change address
+	changeAddresses, err := wallet.Addresses(InternalChain)
+	require.NoError(t, err)
+	nextChange, err := wallet.NextIndex(InternalChain)
+	require.NoError(t, err)
+	change := changeAddresses[nextChange-1]
+	assert.Equal(t, change.Script, hex.EncodeToString(bump.Tx.TxOut[0].PkScript))
+
+	// the parent already pays more
+	_, err = wallet.BumpFeeCPFP(ctx, parentID, 0.5)
+	assert.ErrorIs(t, err, ErrFeeRateTooLow)
+
+	// a parent with no output left to the wallet
+	_, err = wallet.Broadcast(ctx, bump.Tx)
+	require.NoError(t, err)
+	_, err = wallet.BumpFeeCPFP(ctx, parentID, 20)
+	assert.ErrorIs(t, err, ErrNoSpendableOutput)
+
+	_, err = wallet.BumpFeeCPFP(ctx, "00", 20)
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+}
+
+// TestWalletBumpFeeCPFPOwnTransaction will test BumpFeeCPFP() spending the change of a wallet transaction
+func TestWalletBumpFeeCPFPOwnTransaction(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	wallet, _ := fundedTestWallet(t, 100000, 10000)
+
+	// the change is too small to pay for the package alone
+	parent := signedSpend(t, wallet, utxoOf(t, wallet, 100000), wire.MaxTxInSequenceNum,
+		wire.NewTxOut(98000, pkScriptForAddress(t, testPaymentAddress)), changeTxOut(t, wallet, 1800))
+	txID, err := wallet.Broadcast(ctx, parent)
+	require.NoError(t, err)
+
+	bump, err := wallet.BumpFeeCPFP(ctx, txID, 15)
+	require.NoError(t, err)
+	require.Len(t, bump.Tx.TxIn, 2)
+	assert.Equal(t, txID, bump.Tx.TxIn[0].PreviousOutPoint.Hash.String())
+	assert.GreaterOrEqual(t, bump.PackageFeeRate, 15.0)
+	require.NoError(t, VerifyTransaction(bump.Tx, bump.PrevOuts, StandardScriptFlags))
+
+	_, err = wallet.BumpFeeCPFP(ctx, txID, 1000)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+// TestWalletBumpFeeWatchOnly will test that watch-only wallets return unsigned fee bumps
+func TestWalletBumpFeeWatchOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	signer, backend := fundedTestWallet(t, 100000)
+
+	external, internal := signer.Descriptors()
+	wallet, err := NewWallet(WalletConfig{Network: Mainnet, ExternalDescriptor: external, InternalDescriptor: internal, Backend: backend})
+	require.NoError(t, err)
+	require.NoError(t, wallet.Sync(ctx))
+
+	original := signedSpend(t, signer, utxoOf(t, wallet, 100000), replaceableSequence,
+		wire.NewTxOut(60000, pkScriptForAddress(t, testPaymentAddress)), changeTxOut(t, signer, 39000))
+	require.NoError(t, wallet.Sync(ctx))
+	txID, err := wallet.Broadcast(ctx, original)
+	require.NoError(t, err)
+
+	bump, err := wallet.BumpFeeRBF(txID, 20)
+	require.NoError(t, err)
+	assert.Empty(t, bump.Tx.TxIn[0].Witness)
+	assert.GreaterOrEqual(t, bump.FeeRate, 20.0)
+
+	// signed elsewhere, the estimate holds
+	require.NoError(t, signer.SignTransaction(bump.Tx, bump.PrevOuts))
+	require.NoError(t, VerifyTransaction(bump.Tx, bump.PrevOuts, StandardScriptFlags))
+	assert.LessOrEqual(t, GetTransactionVSize(bump.Tx), bump.VSize)
+}
@@ -0,0 +1,467 @@
+package bitcoin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// FeeEstimateMode selects how cautious a fee estimate is, like the modes of estimatesmartfee
+type FeeEstimateMode string
+
+const (
+	FeeEstimateEconomical   FeeEstimateMode = "ECONOMICAL"   // reacts quickly to recent blocks
+	FeeEstimateConservative FeeEstimateMode = "CONSERVATIVE" // also holds over a longer history
+)
+
+// Success rates an estimate has to reach, for half, the whole and twice the confirmation target
+const (
+	halfSuccessRate   = 0.6
+	successRate       = 0.85
+	doubleSuccessRate = 0.95
+)
+
+// Transactions per block a range of buckets needs to be estimated from, once decayed
+const (
+	sufficientShortTxs = 0.5
+	sufficientTxs      = 0.1
+)
+
+// Bucket fee rates (sat/vB) grow by feeBucketSpacing from MinRelayFeeRate up to maxBucketFeeRate
+const (
+	feeBucketSpacing = 1.05
+	maxBucketFeeRate = 10000
+)
+
+// FeeEstimate is a fee rate estimate and the confirmation target it was made for, which is lower than
+// the one asked for while the estimator has not seen enough blocks
+type FeeEstimate struct {
+	FeeRate float64 // sat/vB
+	Blocks  int
+}
+
+// FeeEstimatorConfig holds the settings of a FeeEstimator
+type FeeEstimatorConfig struct {
+	Backend ChainBackend // resolves the fees of transactions added with AddMempoolTransaction
+	Height  int64        // tip height when starting, otherwise taken from the first ProcessBlock
+	Mode    FeeEstimateMode
+}
+
+// FeeEstimator estimates fee rates locally from the time transactions take to confirm, the way
+// Bitcoin Core's estimatesmartfee does: transactions entering the mempool are bucketed by fee rate
+// and, as blocks confirm them, it tracks which fee rates confirmed within each number of blocks over
+// a short (12 blocks), medium (48 blocks) and long (1008 blocks) exponentially decaying history.
+// Mempool transactions come from a node (AddMempoolEntry, e.g. from getrawmempool) or from raw
+// transactions (AddMempoolTransaction, e.g. ZMQ or P2P), blocks from ProcessBlock
+type FeeEstimator struct {
+	config FeeEstimatorConfig
+
+	mu          sync.Mutex
+	height      int64
+	firstHeight int64 // height the first transaction was tracked at
+	buckets     []float64
+	mempool     map[string]mempoolEntry
+	short       *confirmStats
+	medium      *confirmStats
+	long        *confirmStats
+}
+
+// mempoolEntry is a tracked mempool transaction
+type mempoolEntry struct {
+	height  int64
+	bucket  int
+	feeRate float64
+}
+
+// NewFeeEstimator creates an estimator without history
+func NewFeeEstimator(config FeeEstimatorConfig) *FeeEstimator {
+	if len(config.Mode) == 0 {
+		config.Mode = FeeEstimateEconomical
+	}
+
+	var buckets []float64
+	for feeRate := MinRelayFeeRate; feeRate <= maxBucketFeeRate; feeRate *= feeBucketSpacing {
+		buckets = append(buckets, feeRate)
+	}
+	buckets = append(buckets, math.Inf(1))
+
+	return &FeeEstimator{
+		config:  config,
+		height:  config.Height,
+		buckets: buckets,
+		mempool: make(map[string]mempoolEntry),
+		short:   newConfirmStats(len(buckets), 12, 1, 0.962, sufficientShortTxs),
+		medium:  newConfirmStats(len(buckets), 24, 2, 0.9952, sufficientTxs),
+		long:    newConfirmStats(len(buckets), 42, 24, 0.99931, sufficientTxs),
+	}
+}
+
+// AddMempoolEntry starts tracking a transaction entering the mempool at the current height, with its
+// fee and virtual size. Transactions are only tracked once the estimator knows the tip height
+func (e *FeeEstimator) AddMempoolEntry(txID string, fee btcutil.Amount, vsize int64) {
+	if vsize <= 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.mempool[txID]; ok || e.height == 0 {
+		return
+	}
+	if e.firstHeight == 0 {
+		e.firstHeight = e.height
+	}
+
+	feeRate := float64(fee) / float64(vsize)
+	e.mempool[txID] = mempoolEntry{height: e.height, bucket: e.bucket(feeRate), feeRate: feeRate}
+}
+
+// AddMempoolTransaction tracks a transaction entering the mempool like AddMempoolEntry, resolving its
+// fee from the transactions it spends through the backend
+func (e *FeeEstimator) AddMempoolTransaction(ctx context.Context, tx *wire.MsgTx) error {
+
+	// Missing backend
+	if e.config.Backend == nil {
+		return ErrMissingChainBackend
+	}
+
+	prevTxs := make(map[string]*wire.MsgTx)
+	var fee btcutil.Amount
+	for _, in := range tx.TxIn {
+		prevTxID := in.PreviousOutPoint.Hash.String()
+		prevTx, ok := prevTxs[prevTxID]
+		if !ok {
+			var err error
+			if prevTx, err = e.config.Backend.GetTransaction(ctx, prevTxID); err != nil {
+				return err
+			}
+			prevTxs[prevTxID] = prevTx
+		}
+
+		if int(in.PreviousOutPoint.Index) >= len(prevTx.TxOut) {
+			return fmt.Errorf("%w: %s", ErrMissingPrevOut, in.PreviousOutPoint)
+		}
+		fee += btcutil.Amount(prevTx.TxOut[in.PreviousOutPoint.Index].Value)
+	}
+	for _, out := range tx.TxOut {
+		fee -= btcutil.Amount(out.Value)
+	}
+
+	e.AddMempoolEntry(tx.TxHash().String(), fee, GetTransactionVSize(tx))
+	return nil
+}
+
+// RemoveMempoolEntry stops tracking a transaction that left the mempool without confirming (evicted,
+// replaced or conflicted), counting it as a failure for the blocks it waited
+func (e *FeeEstimator) RemoveMempoolEntry(txID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry, ok := e.mempool[txID]
+	if !ok {
+		return
+	}
+	delete(e.mempool, txID)
+
+	waited := int(e.height - entry.height)
+	for _, stats := range e.stats() {
+		stats.fail(waited, entry.bucket)
+	}
+}
+
+// ProcessBlock records the tracked transactions confirmed by the block at height. Blocks at or below
+// the highest height processed (reorgs) are ignored
+func (e *FeeEstimator) ProcessBlock(height int64, block *wire.MsgBlock) {
+	txIDs := make([]string, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		txIDs = append(txIDs, tx.TxHash().String())
+	}
+
+	e.processBlock(height, txIDs)
+}
+
+// EstimateFee implements FeeSource, returning the estimate of the configured mode
+func (e *FeeEstimator) EstimateFee(_ context.Context, confTarget int) (float64, error) {
+	estimate, err := e.EstimateSmartFee(confTarget, e.config.Mode)
+	if err != nil {
+		return 0, err
+	}
+
+	return estimate.FeeRate, nil
+}
+
+// EstimateSmartFee estimates the fee rate for a transaction to confirm within confTarget blocks, combining
+// the estimates for half, the whole and twice the target like estimatesmartfee
+func (e *FeeEstimator) EstimateSmartFee(confTarget int, mode FeeEstimateMode) (*FeeEstimate, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if confTarget <= 0 || confTarget > e.long.maxTarget() {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidConfTarget, confTarget)
+	}
+
+	// a transaction cannot confirm in less than a block, 1 is estimated as 2
+	if confTarget == 1 {
+		confTarget = 2
+	}
+
+	// half of the tracked history is needed to tell whether transactions confirmed within the target
+	maxUsable := 0
+	if e.firstHeight > 0 {
+		maxUsable = min(e.long.maxTarget(), int(e.height-e.firstHeight)/2)
+	}
+	confTarget = min(confTarget, maxUsable)
+	if confTarget <= 1 {
+		return nil, ErrInsufficientFeeData
+	}
+
+	conservative := mode == FeeEstimateConservative
+	estimate := e.combinedEstimate(confTarget/2, halfSuccessRate, true)
+	estimate = max(estimate, e.combinedEstimate(confTarget, successRate, true))
+	estimate = max(estimate, e.combinedEstimate(2*confTarget, doubleSuccessRate, !conservative))
+	if conservative || estimate < 0 {
+		estimate = max(estimate, e.conservativeEstimate(2*confTarget))
+	}
+
+	if estimate < 0 {
+		return nil, ErrInsufficientFeeData
+	}
+
+	return &FeeEstimate{FeeRate: max(estimate, MinRelayFeeRate), Blocks: confTarget}, nil
+}
+
+// processBlock records the confirmations of txIDs at height
+func (e *FeeEstimator) processBlock(height int64, txIDs []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if height <= e.height && e.height > 0 {
+		return
+	}
+	e.height = height
+
+	for _, stats := range e.stats() {
+		stats.decay()
+	}
+
+	for _, txID := range txIDs {
+		entry, ok := e.mempool[txID]
+		if !ok {
+			continue
+		}
+		delete(e.mempool, txID)
+
+		// seen at the height of this block, it never waited in the mempool
+		blocks := int(height - entry.height)
+		if blocks <= 0 {
+			continue
+		}
+		for _, stats := range e.stats() {
+			stats.confirm(blocks, entry.bucket, entry.feeRate)
+		}
+	}
+
+	// transactions waiting longer than the long history are given up on
+	for txID, entry := range e.mempool {
+		if waited := int(height - entry.height); waited > e.long.maxTarget() {
+			delete(e.mempool, txID)
+			for _, stats := range e.stats() {
+				stats.fail(waited, entry.bucket)
+			}
+		}
+	}
+}
+
+// combinedEstimate estimates target from the shortest history covering it, returns -1 without an
+// answer. With checkShorter, a lower estimate of a shorter history at its longest target wins
+func (e *FeeEstimator) combinedEstimate(target int, threshold float64, checkShorter bool) float64 {
+	if target < 1 || target > e.long.maxTarget() {
+		return -1
+	}
+
+	var estimate float64
+	switch {
+	case target <= e.short.maxTarget():
+		estimate = e.short.estimate(target, threshold, e.unconfirmed(target))
+	case target <= e.medium.maxTarget():
+		estimate = e.medium.estimate(target, threshold, e.unconfirmed(target))
+	default:
+		estimate = e.long.estimate(target, threshold, e.unconfirmed(target))
+	}
+
+	if checkShorter {
+		for _, stats := range []*confirmStats{e.medium, e.short} {
+			if target <= stats.maxTarget() {
+				continue
+			}
+			shorter := stats.estimate(stats.maxTarget(), threshold, e.unconfirmed(stats.maxTarget()))
+			if shorter > 0 && (estimate < 0 || shorter < estimate) {
+				estimate = shorter
+			}
+		}
+	}
+
+	return estimate
+}
+
+// conservativeEstimate estimates doubleTarget at doubleSuccessRate from the medium and long histories
+func (e *FeeEstimator) conservativeEstimate(doubleTarget int) float64 {
+	estimate := -1.0
+	if doubleTarget <= e.short.maxTarget() {
+		estimate = e.medium.estimate(doubleTarget, doubleSuccessRate, e.unconfirmed(doubleTarget))
+	}
+	if doubleTarget <= e.medium.maxTarget() {
+		estimate = max(estimate, e.long.estimate(doubleTarget, doubleSuccessRate, e.unconfirmed(doubleTarget)))
+	}
+
+	return estimate
+}
+
+// unconfirmed returns per bucket the tracked transactions waiting for at least blocks, e.mu must be held
+func (e *FeeEstimator) unconfirmed(blocks int) []float64 {
+	counts := make([]float64, len(e.buckets))
+	for _, entry := range e.mempool {
+		if int(e.height-entry.height) >= blocks {
+			counts[entry.bucket]++
+		}
+	}
+
+	return counts
+}
+
+// bucket returns the index of the bucket of a fee rate
+func (e *FeeEstimator) bucket(feeRate float64) int {
+	return min(sort.SearchFloat64s(e.buckets, feeRate), len(e.buckets)-1)
+}
+
+// stats returns the histories
+func (e *FeeEstimator) stats() []*confirmStats {
+	return []*confirmStats{e.short, e.medium, e.long}
+}
+
+// confirmStats are the decaying counts of transactions by fee rate bucket and number of blocks to
+// confirm, in periods of scale blocks
+type confirmStats struct {
+	scale      int
+	decayRate  float64
+	sufficient float64     // transactions per block needed in a range of buckets
+	confirmed  [][]float64 // [period][bucket] confirmed within period+1 periods
+	failed     [][]float64 // [period][bucket] left the mempool after waiting period+1 periods
+	total      []float64   // [bucket] confirmed
+	feeRates   []float64   // [bucket] sum of the fee rates confirmed
+}
+
+// newConfirmStats creates empty stats
+func newConfirmStats(buckets, periods, scale int, decayRate, sufficient float64) *confirmStats {
+	s := &confirmStats{
+		scale:      scale,
+		decayRate:  decayRate,
+		sufficient: sufficient,
+		confirmed:  make([][]float64, periods),
+		failed:     make([][]float64, periods),
+		total:      make([]float64, buckets),
+		feeRates:   make([]float64, buckets),
+	}
+	for period := range s.confirmed {
+		s.confirmed[period] = make([]float64, buckets)
+		s.failed[period] = make([]float64, buckets)
+	}
+
+	return s
+}
+
+// maxTarget returns the longest target, in blocks
+func (s *confirmStats) maxTarget() int {
+	return len(s.confirmed) * s.scale
+}
+
+// decay ages every count by a block
+func (s *confirmStats) decay() {
+	for period := range s.confirmed {
+		for bucket := range s.total {
+			s.confirmed[period][bucket] *= s.decayRate
+			s.failed[period][bucket] *= s.decayRate
+		}
+	}
+	for bucket := range s.total {
+		s.total[bucket] *= s.decayRate
+		s.feeRates[bucket] *= s.decayRate
+	}
+}
+
+// confirm records a transaction confirmed after blocks
+func (s *confirmStats) confirm(blocks, bucket int, feeRate float64) {
+	for period := (blocks+s.scale-1)/s.scale - 1; period >= 0 && period < len(s.confirmed); period++ {
+		s.confirmed[period][bucket]++
+	}
+	s.total[bucket]++
+	s.feeRates[bucket] += feeRate
+}
+
+// fail records a transaction leaving the mempool unconfirmed after waiting blocks
+func (s *confirmStats) fail(blocks, bucket int) {
+	for period := 0; period < len(s.failed) && blocks >= (period+1)*s.scale; period++ {
+		s.failed[period][bucket]++
+	}
+}
+
+// estimate returns the median fee rate of the cheapest range of buckets, scanned from the highest fee
+// rates down, in which at least threshold of the transactions confirmed within target blocks, counting
+// the unconfirmed ones waiting longer than target as failures. It returns -1 without enough data
+func (s *confirmStats) estimate(target int, threshold float64, unconfirmed []float64) float64 {
+	period := (target+s.scale-1)/s.scale - 1
+	needed := s.sufficient / (1 - s.decayRate)
+
+	var confirmed, total, failed, waiting float64
+	rangeHigh, bestLow, bestHigh := len(s.total)-1, -1, -1
+	for bucket := len(s.total) - 1; bucket >= 0; bucket-- {
+		confirmed += s.confirmed[period][bucket]
+		total += s.total[bucket]
+		failed += s.failed[period][bucket]
+		waiting += unconfirmed[bucket]
+
+		// a range is judged once it holds enough transactions
+		if total < needed {
+			continue
+		}
+		if confirmed/(total+failed+waiting) < threshold {
+			continue
+		}
+
+		bestLow, bestHigh = bucket, rangeHigh
+		confirmed, total, failed, waiting = 0, 0, 0, 0
+		rangeHigh = bucket - 1
+	}
+
+	if bestLow < 0 {
+		return -1
+	}
+
+	var count float64
+	for bucket := bestLow; bucket <= bestHigh; bucket++ {
+		count += s.total[bucket]
+	}
+
+	half := count / 2
+	for bucket := bestLow; bucket <= bestHigh; bucket++ {
+		if s.total[bucket] == 0 {
+			continue
+		}
+		if s.total[bucket] < half {
+			half -= s.total[bucket]
+			continue
+		}
+		return s.feeRates[bucket] / s.total[bucket]
+	}
+
+	return -1
+}
+
+// compile time check
+var _ FeeSource = (*FeeEstimator)(nil)
@@ -0,0 +1,229 @@
+package bitcoin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feeMarketTx is a class of transactions of a recorded fee market, confirming delay blocks after
+// entering the mempool, or never when delay is 0
+type feeMarketTx struct {
+	feeRate float64
+	delay   int64
+	count   int
+}
+
+// feeMarket replays a fee market on an estimator from its height to height to: after each block it adds
+// the transactions of market(height) to the mempool, and mines them in blocks according to their delay
+func feeMarket(e *FeeEstimator, to int64, market func(height int64) []feeMarketTx) {
+	pending := make(map[int64][]*wire.MsgTx)
+	lockTime := uint32(0)
+
+	for height := e.height + 1; height <= to; height++ {
+		block := wire.NewMsgBlock(&wire.BlockHeader{})
+		for _, tx := range pending[height] {
+			_ = block.AddTransaction(tx)
+		}
+		delete(pending, height)
+		e.ProcessBlock(height, block)
+
+		for _, class := range market(height) {
+			for i := 0; i < class.count; i++ {
+				lockTime++
+				tx := wire.NewMsgTx(2)
+				tx.LockTime = lockTime
+				tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+
+				e.AddMempoolEntry(tx.TxHash().String(), btcutil.Amount(class.feeRate*200), 200)
+				if class.delay > 0 {
+					pending[height+class.delay] = append(pending[height+class.delay], tx)
+				}
+			}
+		}
+	}
+}
+
+// steadyFeeMarket has 50 sat/vB confirm in the next block, 20 sat/vB in 3 blocks, 5 sat/vB in 10 blocks
+// and 1 sat/vB never
+func steadyFeeMarket(int64) []feeMarketTx {
+	return []feeMarketTx{
+		{feeRate: 50, delay: 1, count: 10},
+		{feeRate: 20, delay: 3, count: 10},
+		{feeRate: 5, delay: 10, count: 10},
+		{feeRate: 1, count: 10},
+	}
+}
+
+// TestNewFeeEstimator will test the method NewFeeEstimator()
+func TestNewFeeEstimator(t *testing.T) {
+	t.Parallel()
+
+	estimator := NewFeeEstimator(FeeEstimatorConfig{Height: 100})
+	assert.Equal(t, FeeEstimateEconomical, estimator.config.Mode)
+	assert.Equal(t, MinRelayFeeRate, estimator.buckets[0])
+	assert.Equal(t, 12, estimator.short.maxTarget())
+	assert.Equal(t, 48, estimator.medium.maxTarget())
+	assert.Equal(t, 1008, estimator.long.maxTarget())
+
+	// no history
+	_, err := estimator.EstimateSmartFee(6, FeeEstimateEconomical)
+	assert.ErrorIs(t, err, ErrInsufficientFeeData)
+
+	_, err = estimator.EstimateSmartFee(0, FeeEstimateEconomical)
+	assert.ErrorIs(t, err, ErrInvalidConfTarget)
+	_, err = estimator.EstimateSmartFee(1009, FeeEstimateEconomical)
+	assert.ErrorIs(t, err, ErrInvalidConfTarget)
+}
+
+// TestFeeEstimatorEstimateSmartFee will test the method EstimateSmartFee()
+func TestFeeEstimatorEstimateSmartFee(t *testing.T) {
+	t.Parallel()
+
+	estimator := NewFeeEstimator(FeeEstimatorConfig{Height: 800000})
+	feeMarket(estimator, 800200, steadyFeeMarket)
+
+	tests := []struct {
+		confTarget int
+		feeRate    float64
+		blocks     int
+	}{
+		{1, 50, 2},
+		{2, 50, 2},
+		{4, 50, 4}, // half the target needs 50 sat/vB
+		{6, 20, 6},
+		{20, 5, 20},
+		{98, 5, 98},
+		{500, 5, 99}, // only 199 blocks of history
+	}
+	for _, test := range tests {
+		for _, mode := range []FeeEstimateMode{FeeEstimateEconomical, FeeEstimateConservative} {
+			estimate, err := estimator.EstimateSmartFee(test.confTarget, mode)
+			require.NoError(t, err, test.confTarget)
+			assert.InDelta(t, test.feeRate, estimate.FeeRate, 0.01, "%d %s", test.confTarget, mode)
+			assert.Equal(t, test.blocks, estimate.Blocks)
+		}
+	}
+
+	feeRate, err := estimator.EstimateFee(context.Background(), 6)
+	require.NoError(t, err)
+	assert.InDelta(t, 20, feeRate, 0.01)
+}
+
+// TestFeeEstimatorModes will test that conservative estimates remember older fee rates longer
+func TestFeeEstimatorModes(t *testing.T) {
+	t.Parallel()
+
+	// 5 sat/vB took 50 blocks to confirm for a long time, before confirming in the next block
+	estimator := NewFeeEstimator(FeeEstimatorConfig{Height: 800000})
+	feeMarket(estimator, 800540, func(height int64) []feeMarketTx {
+		if height <= 800340 {
+			return []feeMarketTx{{feeRate: 30, delay: 1, count: 20}, {feeRate: 5, delay: 50, count: 20}}
+		}
+		return []feeMarketTx{{feeRate: 30, delay: 1, count: 20}, {feeRate: 5, delay: 1, count: 20}}
+	})
+
+	economical, err := estimator.EstimateSmartFee(2, FeeEstimateEconomical)
+	require.NoError(t, err)
+	conservative, err := estimator.EstimateSmartFee(2, FeeEstimateConservative)
+	require.NoError(t, err)
+
+	assert.InDelta(t, 5, economical.FeeRate, 0.01)
+	assert.InDelta(t, 30, conservative.FeeRate, 0.01)
+}
+
+// TestFeeEstimatorProcessBlock will test the method ProcessBlock()
+func TestFeeEstimatorProcessBlock(t *testing.T) {
+	t.Parallel()
+
+	estimator := NewFeeEstimator(FeeEstimatorConfig{})
+
+	// without a tip, transactions are not tracked
+	tx := wire.NewMsgTx(2)
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+	estimator.AddMempoolEntry(tx.TxHash().String(), 2000, 200)
+	assert.Empty(t, estimator.mempool)
+
+	estimator.ProcessBlock(100, wire.NewMsgBlock(&wire.BlockHeader{}))
+	estimator.AddMempoolEntry(tx.TxHash().String(), 2000, 200)
+	require.Len(t, estimator.mempool, 1)
+
+	// reorged blocks are ignored
+	block := wire.NewMsgBlock(&wire.BlockHeader{})
+	require.NoError(t, block.AddTransaction(tx))
+	estimator.ProcessBlock(100, block)
+	assert.Len(t, estimator.mempool, 1)
+
+	estimator.ProcessBlock(101, block)
+	assert.Empty(t, estimator.mempool)
+	bucket := estimator.bucket(10)
+	assert.Equal(t, 1.0, estimator.short.confirmed[0][bucket])
+	assert.Equal(t, 1.0, estimator.long.confirmed[41][bucket])
+	assert.InDelta(t, 10, estimator.short.feeRates[bucket], 0.001)
+}
+
+// TestFeeEstimatorRemoveMempoolEntry will test the method RemoveMempoolEntry()
+func TestFeeEstimatorRemoveMempoolEntry(t *testing.T) {
+	t.Parallel()
+
+	estimator := NewFeeEstimator(FeeEstimatorConfig{Height: 100})
+	estimator.AddMempoolEntry("evicted", 2000, 200)
+	for height := int64(101); height <= 103; height++ {
+		estimator.ProcessBlock(height, wire.NewMsgBlock(&wire.BlockHeader{}))
+	}
+
+	estimator.RemoveMempoolEntry("evicted")
+	estimator.RemoveMempoolEntry("unknown")
+	assert.Empty(t, estimator.mempool)
+
+	// failed after waiting 3 blocks
+	bucket := estimator.bucket(10)
+	assert.Equal(t, 1.0, estimator.short.failed[2][bucket])
+	assert.Equal(t, 0.0, estimator.short.failed[3][bucket])
+	assert.Equal(t, 1.0, estimator.medium.failed[0][bucket])
+	assert.Equal(t, 0.0, estimator.medium.failed[1][bucket])
+	assert.Equal(t, 0.0, estimator.long.failed[0][bucket])
+}
+
+// TestFeeEstimatorAddMempoolTransaction will test the method AddMempoolTransaction()
+func TestFeeEstimatorAddMempoolTransaction(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	err := NewFeeEstimator(FeeEstimatorConfig{}).AddMempoolTransaction(ctx, wire.NewMsgTx(2))
+	assert.ErrorIs(t, err, ErrMissingChainBackend)
+
+	backend := NewMemoryChainBackend()
+	parent := wire.NewMsgTx(2)
+	parent.AddTxOut(wire.NewTxOut(30000, []byte{0x51}))
+	parent.AddTxOut(wire.NewTxOut(20000, []byte{0x51}))
+	backend.AddTransaction(parent)
+	backend.MineBlock()
+
+	estimator := NewFeeEstimator(FeeEstimatorConfig{Backend: backend, Height: 1})
+	parentHash := parent.TxHash()
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&parentHash, 0), nil, nil))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&parentHash, 1), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(45000, []byte{0x51}))
+	require.NoError(t, estimator.AddMempoolTransaction(ctx, tx))
+
+	entry, ok := estimator.mempool[tx.TxHash().String()]
+	require.True(t, ok)
+	assert.InDelta(t, 5000/float64(GetTransactionVSize(tx)), entry.feeRate, 0.001)
+
+	// unknown and invalid previous outputs
+	missing := wire.NewMsgTx(2)
+	txHash := tx.TxHash()
+	missing.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&txHash, 0), nil, nil))
+	assert.ErrorIs(t, estimator.AddMempoolTransaction(ctx, missing), ErrTransactionNotFound)
+
+	invalid := wire.NewMsgTx(2)
+	invalid.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&parentHash, 2), nil, nil))
+	assert.ErrorIs(t, estimator.AddMempoolTransaction(ctx, invalid), ErrMissingPrevOut)
+}
@@ -0,0 +1,666 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// FROST tagged hash tags
+var (
+	frostNonceTag       = []byte("FROST/nonce")
+	frostBindingTag     = []byte("FROST/binding")
+	frostCommitmentsTag = []byte("FROST/commitments")
+	frostProofTag       = []byte("FROST/pok")
+	frostProofNonceTag  = []byte("FROST/poknonce")
+)
+
+// frostMaxParticipants bounds the participants of a FROST group
+const frostMaxParticipants = 1 << 16
+
+// frostParticipantError formats errors blaming a participant
+const frostParticipantError = "%w: participant %d"
+
+// FrostPartialSig is the partial signature of a signer (32 bytes)
+type FrostPartialSig [32]byte
+
+// FrostSecNonce are the hiding and binding nonces a signer keeps until it signs (64 bytes). Sign erases them:
+// a nonce must never sign twice
+type FrostSecNonce [64]byte
+
+// FrostKeyShare is the share of a participant of a FROST group key, which signs with threshold-1 other shares
+type FrostKeyShare struct {
+	Index  uint32 // participant identifier, from 1
+	Secret *btcec.PrivateKey
+}
+
+// FrostGroup is the public part of a FROST t-of-n key: the commitments to the coefficients of the polynomial
+// sharing the group secret, the first of which is the group key. It verifies shares and partial signatures
+type FrostGroup struct {
+	Threshold   int
+	Commitments []*btcec.PublicKey
+
+	q    btcec.JacobianPoint // group key, tweaked
+	gacc btcec.ModNScalar
+	tacc btcec.ModNScalar
+}
+
+// NewFrostGroup returns the group of a threshold and the commitments of its polynomial, as shared after key generation
+func NewFrostGroup(commitments []*btcec.PublicKey) (*FrostGroup, error) {
+	if len(commitments) == 0 {
+		return nil, ErrMissingPubKey
+	}
+
+	g := &FrostGroup{Threshold: len(commitments), Commitments: commitments}
+	for _, commitment := range commitments {
+		if !IsValidPublicKey(commitment) {
+			return nil, ErrInvalidPubKey
+		}
+	}
+
+	commitments[0].AsJacobian(&g.q)
+	g.gacc.SetInt(1)
+	return g, nil
+}
+
+// PubKey returns the group key, with the tweaks applied. Untweaked, it spends like a single key, e.g. as the
+// taproot key of GetAddressFromPubKey
+func (g *FrostGroup) PubKey() *btcec.PublicKey {
+	return pointPubKey(&g.q)
+}
+
+// XOnlyPubKey returns the x-only (BIP340) group key that signatures verify against
+func (g *FrostGroup) XOnlyPubKey() []byte {
+	return pointXBytes(&g.q)
+}
+
+// TaprootTweak returns the group tweaked into the output key of a taproot output with the group key as internal key
+// (BIP341), committing to a script tree of merkleRoot. Without a merkle root, the output key is the one of
+// GetAddressFromPubKey(g.PubKey(), Taproot, ...)
+func (g *FrostGroup) TaprootTweak(merkleRoot []byte) (*FrostGroup, error) {
+	tweak := chainhash.TaggedHash(chainhash.TagTapTweak, g.XOnlyPubKey(), merkleRoot)
+	t, ok := parseScalar(tweak[:])
+	if !ok {
+		return nil, ErrInvalidTweak
+	}
+
+	var negate btcec.ModNScalar
+	negate.SetInt(1)
+	if !hasEvenY(&g.q) {
+		negate.Negate()
+	}
+
+	q := addPoints(scalarMult(&negate, &g.q), scalarBaseMult(t))
+	if isInfinity(q) {
+		return nil, ErrInvalidTweak
+	}
+	q.ToAffine()
+
+	tweaked := &FrostGroup{Threshold: g.Threshold, Commitments: g.Commitments, q: *q}
+	tweaked.gacc.Mul2(&negate, &g.gacc)
+	tweaked.tacc.Mul2(&negate, &g.tacc).Add(t)
+	return tweaked, nil
+}
+
+// VerificationShare returns the public key of the share of a participant
+func (g *FrostGroup) VerificationShare(index uint32) (*btcec.PublicKey, error) {
+	point, err := g.verificationShare(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return pointPubKey(point), nil
+}
+
+// VerifyShare checks that a share belongs to the group (Feldman verifiable secret sharing)
+func (g *FrostGroup) VerifyShare(share *FrostKeyShare) error {
+	point, err := g.verificationShare(share.Index)
+	if err != nil {
+		return err
+	}
+
+	if share.Secret == nil || !equalPoints(scalarBaseMult(&share.Secret.Key), point) {
+		return fmt.Errorf(frostParticipantError, ErrInvalidShare, share.Index)
+	}
+
+	return nil
+}
+
+// verificationShare evaluates the committed polynomial at index, in the exponent
+func (g *FrostGroup) verificationShare(index uint32) (*btcec.JacobianPoint, error) {
+	commitments := make([]*btcec.JacobianPoint, 0, len(g.Commitments))
+	for _, commitment := range g.Commitments {
+		var point btcec.JacobianPoint
+		commitment.AsJacobian(&point)
+		commitments = append(commitments, &point)
+	}
+
+	return evaluateCommitments(commitments, index)
+}
+
+// FrostTrustedDealer splits secret (random when nil) into signers shares, threshold of which sign together.
+// The dealer knows the secret, distributed key generation (NewFrostDKGParticipant) avoids that
+func FrostTrustedDealer(secret *btcec.PrivateKey, threshold, signers int) ([]FrostKeyShare, *FrostGroup, error) {
+	if err := validateFrostThreshold(threshold, signers); err != nil {
+		return nil, nil, err
+	}
+
+	coefficients, err := randomPolynomial(threshold)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secret != nil {
+		coefficients[0].Set(&secret.Key)
+	}
+
+	shares := make([]FrostKeyShare, 0, signers)
+	for index := uint32(1); index <= uint32(signers); index++ {
+		shares = append(shares, FrostKeyShare{Index: index, Secret: btcec.PrivKeyFromScalar(evaluatePolynomial(coefficients, index))})
+	}
+
+	group, err := NewFrostGroup(commitPolynomial(coefficients))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return shares, group, nil
+}
+
+// FrostDKGCommitment is what a participant broadcasts in the first round of distributed key generation: the
+// commitments to its polynomial, and a proof it knows the secret of the first one
+type FrostDKGCommitment struct {
+	Index       uint32
+	Commitments []*btcec.PublicKey
+	Proof       *schnorr.Signature
+}
+
+// FrostDKGShare is what a participant sends privately to each other participant in the second round of distributed
+// key generation: its polynomial evaluated at the index of the recipient
+type FrostDKGShare struct {
+	From  uint32
+	To    uint32
+	Share *btcec.PrivateKey
+}
+
+// FrostDKGParticipant is a participant of a distributed key generation (Pedersen DKG with proofs of knowledge, as in
+// FROST): every participant deals a random secret, and the group secret, their sum, is never known by anyone
+type FrostDKGParticipant struct {
+	index        uint32
+	threshold    int
+	signers      int
+	coefficients []btcec.ModNScalar
+}
+
+// NewFrostDKGParticipant creates the participant of index (from 1 to signers) of a threshold of signers key generation
+func NewFrostDKGParticipant(index uint32, threshold, signers int) (*FrostDKGParticipant, error) {
+	if err := validateFrostThreshold(threshold, signers); err != nil {
+		return nil, err
+	}
+	if index < 1 || index > uint32(signers) {
+		return nil, fmt.Errorf(frostParticipantError, ErrUnknownSigner, index)
+	}
+
+	coefficients, err := randomPolynomial(threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FrostDKGParticipant{index: index, threshold: threshold, signers: signers, coefficients: coefficients}, nil
+}
+
+// Commitment returns the commitment to broadcast in the first round
+func (p *FrostDKGParticipant) Commitment() (*FrostDKGCommitment, error) {
+	commitments := commitPolynomial(p.coefficients)
+
+	// Schnorr proof of knowledge of the secret, bound to the index against rogue keys
+	var aux [32]byte
+	if _, err := rand.Read(aux[:]); err != nil {
+		return nil, err
+	}
+	secret := scalarBytes(&p.coefficients[0])
+	k := hashScalar(chainhash.TaggedHash(frostProofNonceTag, aux[:], secret)[:])
+	if k.IsZero() {
+		return nil, ErrInvalidNonce
+	}
+
+	// an even nonce, so the proof serializes as a BIP340 signature
+	r := scalarBaseMult(k)
+	if !hasEvenY(r) {
+		k.Negate()
+		r = scalarBaseMult(k)
+	}
+	c := frostProofChallenge(p.index, commitments[0], r)
+
+	var mu btcec.ModNScalar
+	mu.Mul2(c, &p.coefficients[0]).Add(k)
+	r.ToAffine()
+
+	return &FrostDKGCommitment{Index: p.index, Commitments: commitments, Proof: schnorr.NewSignature(&r.X, &mu)}, nil
+}
+
+// Share returns the share to send privately to the participant of index in the second round
+func (p *FrostDKGParticipant) Share(to uint32) (*FrostDKGShare, error) {
+	if to < 1 || to > uint32(p.signers) {
+		return nil, fmt.Errorf(frostParticipantError, ErrUnknownSigner, to)
+	}
+
+	return &FrostDKGShare{From: p.index, To: to, Share: btcec.PrivKeyFromScalar(evaluatePolynomial(p.coefficients, to))}, nil
+}
+
+// Finalize verifies the commitments of every participant (its own included) and the shares sent to this participant,
+// and returns its key share and the group
+func (p *FrostDKGParticipant) Finalize(commitments []FrostDKGCommitment, shares []FrostDKGShare) (*FrostKeyShare, *FrostGroup, error) {
+	if len(commitments) != p.signers {
+		return nil, nil, fmt.Errorf("%w: %d of %d commitments", ErrInvalidShare, len(commitments), p.signers)
+	}
+
+	byIndex := make(map[uint32]*FrostDKGCommitment, len(commitments))
+	group := make([]*btcec.JacobianPoint, p.threshold)
+	for i := range group {
+		group[i] = &btcec.JacobianPoint{}
+	}
+
+	for i := range commitments {
+		commitment := &commitments[i]
+		if _, ok := byIndex[commitment.Index]; ok || commitment.Index < 1 || commitment.Index > uint32(p.signers) {
+			return nil, nil, fmt.Errorf(frostParticipantError, ErrUnknownSigner, commitment.Index)
+		}
+		byIndex[commitment.Index] = commitment
+
+		points, err := commitment.verify(p.threshold)
+		if err != nil {
+			return nil, nil, err
+		}
+		for j, point := range points {
+			group[j] = addPoints(group[j], point)
+		}
+	}
+
+	var secret btcec.ModNScalar
+	received := make(map[uint32]bool, len(shares))
+	for _, share := range shares {
+		commitment, ok := byIndex[share.From]
+		if !ok || share.To != p.index || received[share.From] || share.Share == nil {
+			return nil, nil, fmt.Errorf(frostParticipantError, ErrInvalidShare, share.From)
+		}
+		received[share.From] = true
+
+		sender, err := NewFrostGroup(commitment.Commitments)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = sender.VerifyShare(&FrostKeyShare{Index: p.index, Secret: share.Share}); err != nil {
+			return nil, nil, fmt.Errorf(frostParticipantError, ErrInvalidShare, share.From)
+		}
+
+		secret.Add(&share.Share.Key)
+	}
+	if len(received) != p.signers {
+		return nil, nil, fmt.Errorf("%w: %d of %d shares", ErrInvalidShare, len(received), p.signers)
+	}
+
+	groupCommitments := make([]*btcec.PublicKey, 0, len(group))
+	for _, point := range group {
+		if isInfinity(point) {
+			return nil, nil, ErrInvalidPubKey
+		}
+		groupCommitments = append(groupCommitments, pointPubKey(point))
+	}
+
+	g, err := NewFrostGroup(groupCommitments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	share := &FrostKeyShare{Index: p.index, Secret: btcec.PrivKeyFromScalar(&secret)}
+	if err = g.VerifyShare(share); err != nil {
+		return nil, nil, err
+	}
+
+	return share, g, nil
+}
+
+// verify checks the number of commitments and the proof of knowledge, and returns the commitments as points
+func (c *FrostDKGCommitment) verify(threshold int) ([]*btcec.JacobianPoint, error) {
+	if len(c.Commitments) != threshold || c.Proof == nil {
+		return nil, fmt.Errorf(frostParticipantError, ErrInvalidProof, c.Index)
+	}
+
+	points := make([]*btcec.JacobianPoint, 0, len(c.Commitments))
+	for _, commitment := range c.Commitments {
+		if !IsValidPublicKey(commitment) {
+			return nil, fmt.Errorf(frostParticipantError, ErrInvalidPubKey, c.Index)
+		}
+		var point btcec.JacobianPoint
+		commitment.AsJacobian(&point)
+		points = append(points, &point)
+	}
+
+	// mu·G == R + c·C0
+	proof := c.Proof.Serialize()
+	r, err := parsePoint(append([]byte{0x02}, proof[:32]...))
+	if err != nil {
+		return nil, fmt.Errorf(frostParticipantError, ErrInvalidProof, c.Index)
+	}
+	mu, ok := parseScalar(proof[32:])
+	if !ok {
+		return nil, fmt.Errorf(frostParticipantError, ErrInvalidProof, c.Index)
+	}
+
+	challenge := frostProofChallenge(c.Index, c.Commitments[0], r)
+	if !equalPoints(scalarBaseMult(mu), addPoints(r, scalarMult(challenge, points[0]))) {
+		return nil, fmt.Errorf(frostParticipantError, ErrInvalidProof, c.Index)
+	}
+
+	return points, nil
+}
+
+// FrostNonceCommitment is the commitment to its nonces a signer sends to the coordinator before signing
+type FrostNonceCommitment struct {
+	Index   uint32
+	Hiding  *btcec.PublicKey
+	Binding *btcec.PublicKey
+}
+
+// FrostNonceGen generates the nonces of a signer for a signing session (first round). The secret nonces stay with
+// the signer, the commitment goes to the coordinator
+func FrostNonceGen(share *FrostKeyShare) (*FrostSecNonce, *FrostNonceCommitment, error) {
+	var random [32]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, nil, err
+	}
+
+	secNonce := &FrostSecNonce{}
+	nonces := make([]*btcec.PublicKey, 2)
+	for i := range nonces {
+		k := hashScalar(chainhash.TaggedHash(frostNonceTag, random[:], scalarBytes(&share.Secret.Key), []byte{byte(i)})[:])
+		if k.IsZero() {
+			return nil, nil, ErrInvalidNonce
+		}
+
+		copy(secNonce[32*i:], scalarBytes(k))
+		nonces[i] = pointPubKey(scalarBaseMult(k))
+	}
+
+	return secNonce, &FrostNonceCommitment{Index: share.Index, Hiding: nonces[0], Binding: nonces[1]}, nil
+}
+
+// FrostSession is the signing of a message by at least threshold signers of a group (second round), once the
+// coordinator collected their nonce commitments
+type FrostSession struct {
+	group       *FrostGroup
+	commitments []FrostNonceCommitment       // by index
+	binding     map[uint32]*btcec.ModNScalar // binding factors
+	r           btcec.JacobianPoint          // group nonce
+	e           btcec.ModNScalar             // challenge
+}
+
+// NewFrostSession starts the signing of a message (e.g. a taproot sighash) by the signers of the commitments, with the
+// group tweaked like the key the signature must verify against
+func NewFrostSession(group *FrostGroup, commitments []FrostNonceCommitment, message []byte) (*FrostSession, error) {
+	if len(commitments) < group.Threshold {
+		return nil, fmt.Errorf("%w: %d of %d signers", ErrNotEnoughSigners, len(commitments), group.Threshold)
+	}
+
+	s := &FrostSession{
+		group:       group,
+		commitments: append([]FrostNonceCommitment(nil), commitments...),
+		binding:     make(map[uint32]*btcec.ModNScalar, len(commitments)),
+	}
+	sort.Slice(s.commitments, func(i, j int) bool {
+		return s.commitments[i].Index < s.commitments[j].Index
+	})
+
+	// binding factors commit to every nonce and the message
+	var list bytes.Buffer
+	for i, commitment := range s.commitments {
+		if commitment.Index < 1 || (i > 0 && commitment.Index == s.commitments[i-1].Index) {
+			return nil, fmt.Errorf(frostParticipantError, ErrUnknownSigner, commitment.Index)
+		}
+		if !IsValidPublicKey(commitment.Hiding) || !IsValidPublicKey(commitment.Binding) {
+			return nil, fmt.Errorf(frostParticipantError, ErrInvalidNonce, commitment.Index)
+		}
+
+		list.Write(frostIndex(commitment.Index))
+		list.Write(commitment.Hiding.SerializeCompressed())
+		list.Write(commitment.Binding.SerializeCompressed())
+	}
+	listHash := chainhash.TaggedHash(frostCommitmentsTag, list.Bytes())
+
+	r := &btcec.JacobianPoint{}
+	for _, commitment := range s.commitments {
+		rho := hashScalar(chainhash.TaggedHash(frostBindingTag, group.XOnlyPubKey(), message, listHash[:], frostIndex(commitment.Index))[:])
+		s.binding[commitment.Index] = rho
+		r = addPoints(r, s.signerNonce(&commitment, rho))
+	}
+	if isInfinity(r) {
+		return nil, ErrInvalidNonce
+	}
+	r.ToAffine()
+	s.r = *r
+
+	s.e.Set(hashScalar(chainhash.TaggedHash(bip340ChallengeTag, pointXBytes(&s.r), group.XOnlyPubKey(), message)[:]))
+	return s, nil
+}
+
+// Sign returns the partial signature of the signer of a share, using and erasing its secret nonces
+func (s *FrostSession) Sign(secNonce *FrostSecNonce, share *FrostKeyShare) (*FrostPartialSig, error) {
+	commitment := s.commitment(share.Index)
+	if commitment == nil {
+		return nil, fmt.Errorf(frostParticipantError, ErrUnknownSigner, share.Index)
+	}
+
+	d, okD := parseScalar(secNonce[:32])
+	e, okE := parseScalar(secNonce[32:])
+	if !okD || !okE || d.IsZero() || e.IsZero() {
+		return nil, ErrInvalidNonce
+	}
+	if !equalPoints(scalarBaseMult(d), jacobian(commitment.Hiding)) || !equalPoints(scalarBaseMult(e), jacobian(commitment.Binding)) {
+		return nil, fmt.Errorf("%w: nonce of another commitment", ErrInvalidNonce)
+	}
+
+	// the nonce is spent, even if signing fails from here
+	for i := range secNonce {
+		secNonce[i] = 0
+	}
+
+	// z = d + rho·e + lambda·c·s, with the nonce negated for an odd group nonce
+	var z, k btcec.ModNScalar
+	k.Mul2(s.binding[share.Index], e).Add(d)
+	if !hasEvenY(&s.r) {
+		k.Negate()
+	}
+	z.Mul2(s.signingKeyFactor(share.Index), &share.Secret.Key).Mul(&s.e).Add(&k)
+
+	partialSig := &FrostPartialSig{}
+	copy(partialSig[:], scalarBytes(&z))
+
+	if err := s.VerifyPartialSig(share.Index, partialSig); err != nil {
+		return nil, err
+	}
+
+	return partialSig, nil
+}
+
+// VerifyPartialSig verifies the partial signature of a signer against its verification share
+func (s *FrostSession) VerifyPartialSig(index uint32, partialSig *FrostPartialSig) error {
+	commitment := s.commitment(index)
+	if commitment == nil {
+		return fmt.Errorf(frostParticipantError, ErrUnknownSigner, index)
+	}
+
+	z, ok := parseScalar(partialSig[:])
+	if !ok {
+		return fmt.Errorf(frostParticipantError, ErrInvalidPartialSignature, index)
+	}
+
+	verificationShare, err := s.group.verificationShare(index)
+	if err != nil {
+		return err
+	}
+
+	// z·G == R_i + c·lambda·Y_i
+	r := s.signerNonce(commitment, s.binding[index])
+	if !hasEvenY(&s.r) {
+		negatePoint(r)
+	}
+	var factor btcec.ModNScalar
+	factor.Mul2(&s.e, s.signingKeyFactor(index))
+
+	if !equalPoints(scalarBaseMult(z), addPoints(r, scalarMult(&factor, verificationShare))) {
+		return fmt.Errorf(frostParticipantError, ErrInvalidPartialSignature, index)
+	}
+
+	return nil
+}
+
+// AggregatePartialSigs verifies the partial signatures of the signers, in the order of their indexes, and combines them
+// into the BIP340 signature of the message for the group key
+func (s *FrostSession) AggregatePartialSigs(partialSigs map[uint32]FrostPartialSig) (*schnorr.Signature, error) {
+	var sum btcec.ModNScalar
+	for _, commitment := range s.commitments {
+		partialSig, ok := partialSigs[commitment.Index]
+		if !ok {
+			return nil, fmt.Errorf(frostParticipantError, ErrInvalidPartialSignature, commitment.Index)
+		}
+		if err := s.VerifyPartialSig(commitment.Index, &partialSig); err != nil {
+			return nil, err
+		}
+
+		z, _ := parseScalar(partialSig[:])
+		sum.Add(z)
+	}
+
+	// s + e·g·tacc
+	var tweak btcec.ModNScalar
+	tweak.Mul2(&s.e, &s.group.tacc)
+	if !hasEvenY(&s.group.q) {
+		tweak.Negate()
+	}
+	sum.Add(&tweak)
+
+	r := s.r
+	return schnorr.NewSignature(&r.X, &sum), nil
+}
+
+// commitment returns the nonce commitment of a signer
+func (s *FrostSession) commitment(index uint32) *FrostNonceCommitment {
+	for i := range s.commitments {
+		if s.commitments[i].Index == index {
+			return &s.commitments[i]
+		}
+	}
+	return nil
+}
+
+// signerNonce returns D + rho·E, the nonce of a signer
+func (s *FrostSession) signerNonce(commitment *FrostNonceCommitment, rho *btcec.ModNScalar) *btcec.JacobianPoint {
+	return addPoints(jacobian(commitment.Hiding), scalarMult(rho, jacobian(commitment.Binding)))
+}
+
+// signingKeyFactor returns lambda·g·gacc, what the share of a signer is multiplied by when signing
+func (s *FrostSession) signingKeyFactor(index uint32) *btcec.ModNScalar {
+
+	// Lagrange coefficient of index at 0 over the signers
+	var lambda, num, den, x btcec.ModNScalar
+	num.SetInt(1)
+	den.SetInt(1)
+	for _, commitment := range s.commitments {
+		if commitment.Index == index {
+			continue
+		}
+		x.SetInt(commitment.Index)
+		num.Mul(&x)
+
+		var diff, xi btcec.ModNScalar
+		xi.SetInt(index)
+		diff.Set(&x).Add(xi.Negate())
+		den.Mul(&diff)
+	}
+	lambda.Mul2(&num, den.InverseNonConst())
+
+	lambda.Mul(&s.group.gacc)
+	if !hasEvenY(&s.group.q) {
+		lambda.Negate()
+	}
+	return &lambda
+}
+
+// frostProofChallenge returns the challenge of a proof of knowledge of the secret of commitment
+func frostProofChallenge(index uint32, commitment *btcec.PublicKey, r *btcec.JacobianPoint) *btcec.ModNScalar {
+	return hashScalar(chainhash.TaggedHash(frostProofTag, frostIndex(index), commitment.SerializeCompressed(), pointXBytes(r))[:])
+}
+
+// frostIndex serializes a participant identifier
+func frostIndex(index uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, index)
+	return b
+}
+
+// validateFrostThreshold checks a threshold of signers
+func validateFrostThreshold(threshold, signers int) error {
+	if signers >= frostMaxParticipants {
+		return ErrTooManySigners
+	}
+	if threshold < 1 || threshold > signers {
+		return fmt.Errorf("%w: %d of %d", ErrInvalidMultisigThreshold, threshold, signers)
+	}
+	return nil
+}
+
+// randomPolynomial returns the random coefficients of a polynomial of degree threshold-1
+func randomPolynomial(threshold int) ([]btcec.ModNScalar, error) {
+	coefficients := make([]btcec.ModNScalar, threshold)
+	for i := range coefficients {
+		privateKey, err := btcec.NewPrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i].Set(&privateKey.Key)
+	}
+	return coefficients, nil
+}
+
+// evaluatePolynomial returns the polynomial at x
+func evaluatePolynomial(coefficients []btcec.ModNScalar, x uint32) *btcec.ModNScalar {
+	var result, scalarX btcec.ModNScalar
+	scalarX.SetInt(x)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result.Mul(&scalarX).Add(&coefficients[i])
+	}
+	return &result
+}
+
+// commitPolynomial returns the commitments to the coefficients of a polynomial
+func commitPolynomial(coefficients []btcec.ModNScalar) []*btcec.PublicKey {
+	commitments := make([]*btcec.PublicKey, 0, len(coefficients))
+	for i := range coefficients {
+		commitments = append(commitments, pointPubKey(scalarBaseMult(&coefficients[i])))
+	}
+	return commitments
+}
+
+// evaluateCommitments returns the committed polynomial at x, in the exponent
+func evaluateCommitments(commitments []*btcec.JacobianPoint, x uint32) (*btcec.JacobianPoint, error) {
+	if x < 1 {
+		return nil, fmt.Errorf(frostParticipantError, ErrUnknownSigner, x)
+	}
+
+	var scalarX btcec.ModNScalar
+	scalarX.SetInt(x)
+	result := &btcec.JacobianPoint{}
+	for i := len(commitments) - 1; i >= 0; i-- {
+		result = addPoints(scalarMult(&scalarX, result), commitments[i])
+	}
+	if isInfinity(result) {
+		return nil, ErrInvalidPubKey
+	}
+	return result, nil
+}
@@ -0,0 +1,299 @@
+package bitcoin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// frostSign runs a signing session of message by shares and returns the aggregate signature
+func frostSign(t *testing.T, group *FrostGroup, shares []FrostKeyShare, message []byte) *schnorr.Signature {
+	var secNonces []*FrostSecNonce
+	var commitments []FrostNonceCommitment
+	for i := range shares {
+		secNonce, commitment, err := FrostNonceGen(&shares[i])
+		require.NoError(t, err)
+		secNonces = append(secNonces, secNonce)
+		commitments = append(commitments, *commitment)
+	}
+
+	session, err := NewFrostSession(group, commitments, message)
+	require.NoError(t, err)
+
+	partialSigs := make(map[uint32]FrostPartialSig, len(shares))
+	for i := range shares {
+		partialSig, err := session.Sign(secNonces[i], &shares[i])
+		require.NoError(t, err)
+		require.NoError(t, session.VerifyPartialSig(shares[i].Index, partialSig))
+		partialSigs[shares[i].Index] = *partialSig
+	}
+
+	signature, err := session.AggregatePartialSigs(partialSigs)
+	require.NoError(t, err)
+	return signature
+}
+
+// frostDKG runs a distributed key generation of threshold of signers
+func frostDKG(t *testing.T, threshold, signers int) ([]FrostKeyShare, *FrostGroup) {
+	var participants []*FrostDKGParticipant
+	var commitments []FrostDKGCommitment
+	for index := uint32(1); index <= uint32(signers); index++ {
+		participant, err := NewFrostDKGParticipant(index, threshold, signers)
+		require.NoError(t, err)
+		participants = append(participants, participant)
+
+		commitment, err := participant.Commitment()
+		require.NoError(t, err)
+		commitments = append(commitments, *commitment)
+	}
+
+	var shares []FrostKeyShare
+	var group *FrostGroup
+	for _, recipient := range participants {
+		var received []FrostDKGShare
+		for _, sender := range participants {
+			share, err := sender.Share(recipient.index)
+			require.NoError(t, err)
+			received = append(received, *share)
+		}
+
+		share, g, err := recipient.Finalize(commitments, received)
+		require.NoError(t, err)
+		shares = append(shares, *share)
+
+		// every participant ends up with the same group
+		if group != nil {
+			assert.Equal(t, group.XOnlyPubKey(), g.XOnlyPubKey())
+		}
+		group = g
+	}
+
+	return shares, group
+}
+
+// assertFrostSignature checks a signature of message against the group key
+func assertFrostSignature(t *testing.T, group *FrostGroup, signature *schnorr.Signature, message []byte) {
+	pubKey, err := schnorr.ParsePubKey(group.XOnlyPubKey())
+	require.NoError(t, err)
+	assert.True(t, signature.Verify(message, pubKey))
+}
+
+// TestFrostTrustedDealer will test the method FrostTrustedDealer()
+func TestFrostTrustedDealer(t *testing.T) {
+	t.Parallel()
+
+	secret, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	shares, group, err := FrostTrustedDealer(secret, 2, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 3)
+	assert.Equal(t, 2, group.Threshold)
+	assert.Equal(t, secret.PubKey().SerializeCompressed(), group.PubKey().SerializeCompressed())
+
+	for i := range shares {
+		assert.Equal(t, uint32(i+1), shares[i].Index)
+		require.NoError(t, group.VerifyShare(&shares[i]))
+
+		verificationShare, err := group.VerificationShare(shares[i].Index)
+		require.NoError(t, err)
+		assert.Equal(t, shares[i].Secret.PubKey().SerializeCompressed(), verificationShare.SerializeCompressed())
+	}
+
+	// a share of another index, or of another group
+	assert.ErrorIs(t, group.VerifyShare(&FrostKeyShare{Index: 2, Secret: shares[0].Secret}), ErrInvalidShare)
+	other, _, err := FrostTrustedDealer(nil, 2, 3)
+	require.NoError(t, err)
+	assert.ErrorIs(t, group.VerifyShare(&other[0]), ErrInvalidShare)
+
+	_, _, err = FrostTrustedDealer(nil, 4, 3)
+	assert.ErrorIs(t, err, ErrInvalidMultisigThreshold)
+	_, _, err = FrostTrustedDealer(nil, 0, 3)
+	assert.ErrorIs(t, err, ErrInvalidMultisigThreshold)
+}
+
+// TestFrostSession will test t-of-n signing sessions: the methods FrostNonceGen(), NewFrostSession(), Sign(),
+// VerifyPartialSig() and AggregatePartialSigs()
+func TestFrostSession(t *testing.T) {
+	t.Parallel()
+
+	message := sha256.Sum256([]byte("cold storage"))
+	tests := []struct {
+		threshold int
+		signers   int
+	}{
+		{1, 1},
+		{2, 2},
+		{2, 3},
+		{3, 5},
+	}
+	for _, test := range tests {
+		shares, group, err := FrostTrustedDealer(nil, test.threshold, test.signers)
+		require.NoError(t, err)
+
+		// any threshold of signers, and more
+		assertFrostSignature(t, group, frostSign(t, group, shares[:test.threshold], message[:]), message[:])
+		assertFrostSignature(t, group, frostSign(t, group, shares[test.signers-test.threshold:], message[:]), message[:])
+		assertFrostSignature(t, group, frostSign(t, group, shares, message[:]), message[:])
+	}
+}
+
+// TestFrostSessionErrors will test the failures of a signing session
+func TestFrostSessionErrors(t *testing.T) {
+	t.Parallel()
+
+	message := sha256.Sum256([]byte("message"))
+	shares, group, err := FrostTrustedDealer(nil, 2, 3)
+	require.NoError(t, err)
+
+	secNonce1, commitment1, err := FrostNonceGen(&shares[0])
+	require.NoError(t, err)
+	secNonce2, commitment2, err := FrostNonceGen(&shares[1])
+	require.NoError(t, err)
+
+	_, err = NewFrostSession(group, []FrostNonceCommitment{*commitment1}, message[:])
+	assert.ErrorIs(t, err, ErrNotEnoughSigners)
+	_, err = NewFrostSession(group, []FrostNonceCommitment{*commitment1, *commitment1}, message[:])
+	assert.ErrorIs(t, err, ErrUnknownSigner)
+
+	session, err := NewFrostSession(group, []FrostNonceCommitment{*commitment2, *commitment1}, message[:])
+	require.NoError(t, err)
+
+	// a signer outside of the session, the nonce of another signer
+	_, err = session.Sign(secNonce1, &shares[2])
+	assert.ErrorIs(t, err, ErrUnknownSigner)
+	_, err = session.Sign(secNonce2, &shares[0])
+	assert.ErrorIs(t, err, ErrInvalidNonce)
+
+	partialSig1, err := session.Sign(secNonce1, &shares[0])
+	require.NoError(t, err)
+
+	// the secret nonce is erased
+	_, err = session.Sign(secNonce1, &shares[0])
+	assert.ErrorIs(t, err, ErrInvalidNonce)
+
+	partialSig2, err := session.Sign(secNonce2, &shares[1])
+	require.NoError(t, err)
+
+	// partial signatures verify against the share of their signer only
+	assert.ErrorIs(t, session.VerifyPartialSig(2, partialSig1), ErrInvalidPartialSignature)
+	_, err = session.AggregatePartialSigs(map[uint32]FrostPartialSig{1: *partialSig1})
+	assert.ErrorIs(t, err, ErrInvalidPartialSignature)
+	_, err = session.AggregatePartialSigs(map[uint32]FrostPartialSig{1: *partialSig2, 2: *partialSig1})
+	assert.ErrorIs(t, err, ErrInvalidPartialSignature)
+
+	signature, err := session.AggregatePartialSigs(map[uint32]FrostPartialSig{1: *partialSig1, 2: *partialSig2})
+	require.NoError(t, err)
+	assertFrostSignature(t, group, signature, message[:])
+}
+
+// TestFrostDKG will test a distributed key generation: the methods NewFrostDKGParticipant(), Commitment(), Share()
+// and Finalize()
+func TestFrostDKG(t *testing.T) {
+	t.Parallel()
+
+	shares, group := frostDKG(t, 2, 3)
+	for i := range shares {
+		require.NoError(t, group.VerifyShare(&shares[i]))
+	}
+
+	message := sha256.Sum256([]byte("distributed"))
+	assertFrostSignature(t, group, frostSign(t, group, []FrostKeyShare{shares[0], shares[2]}, message[:]), message[:])
+
+	_, err := NewFrostDKGParticipant(4, 2, 3)
+	assert.ErrorIs(t, err, ErrUnknownSigner)
+	_, err = NewFrostDKGParticipant(1, 3, 2)
+	assert.ErrorIs(t, err, ErrInvalidMultisigThreshold)
+}
+
+// TestFrostDKGErrors will test that Finalize() catches invalid proofs and shares
+func TestFrostDKGErrors(t *testing.T) {
+	t.Parallel()
+
+	var participants []*FrostDKGParticipant
+	var commitments []FrostDKGCommitment
+	for index := uint32(1); index <= 3; index++ {
+		participant, err := NewFrostDKGParticipant(index, 2, 3)
+		require.NoError(t, err)
+		participants = append(participants, participant)
+		commitment, err := participant.Commitment()
+		require.NoError(t, err)
+		commitments = append(commitments, *commitment)
+	}
+
+	var shares []FrostDKGShare
+	for _, sender := range participants {
+		share, err := sender.Share(1)
+		require.NoError(t, err)
+		shares = append(shares, *share)
+	}
+
+	// a proof replayed for another index
+	forged := append([]FrostDKGCommitment(nil), commitments...)
+	forged[1].Proof = commitments[0].Proof
+	_, _, err := participants[0].Finalize(forged, shares)
+	assert.ErrorIs(t, err, ErrInvalidProof)
+
+	// a share that does not match the commitments of its sender
+	tampered := append([]FrostDKGShare(nil), shares...)
+	tampered[2].Share = shares[1].Share
+	_, _, err = participants[0].Finalize(commitments, tampered)
+	assert.ErrorIs(t, err, ErrInvalidShare)
+
+	// missing commitments and shares, shares for another participant
+	_, _, err = participants[0].Finalize(commitments[:2], shares)
+	assert.ErrorIs(t, err, ErrInvalidShare)
+	_, _, err = participants[0].Finalize(commitments, shares[:2])
+	assert.ErrorIs(t, err, ErrInvalidShare)
+	_, _, err = participants[1].Finalize(commitments, shares)
+	assert.ErrorIs(t, err, ErrInvalidShare)
+
+	_, _, err = participants[0].Finalize(commitments, shares)
+	require.NoError(t, err)
+}
+
+// TestFrostTaproot will test a 2-of-3 key path spend of the P2TR address of a group key
+func TestFrostTaproot(t *testing.T) {
+	t.Parallel()
+
+	shares, group := frostDKG(t, 2, 3)
+
+	// a single-sig address on chain
+	address, err := GetAddressFromPubKey(group.PubKey(), Taproot, Testnet)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(address, "tb1p"))
+
+	outputKey, err := group.TaprootTweak(nil)
+	require.NoError(t, err)
+	assert.Equal(t, schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(group.PubKey())), outputKey.XOnlyPubKey())
+
+	script, err := GetScriptFromAddress(address, Testnet)
+	require.NoError(t, err)
+	pkScript, err := hex.DecodeString(script)
+	require.NoError(t, err)
+
+	prevOutPoint := wire.OutPoint{Hash: chainhash.Hash{2}, Index: 1}
+	prevOut := wire.NewTxOut(50000, pkScript)
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&prevOutPoint, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(49000, pkScript))
+
+	fetcher := txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	sigHash, err := txscript.CalcTaprootSignatureHash(txscript.NewTxSigHashes(tx, fetcher), txscript.SigHashDefault, tx, 0, fetcher)
+	require.NoError(t, err)
+
+	prevOuts := map[wire.OutPoint]*wire.TxOut{prevOutPoint: prevOut}
+	for _, signers := range [][]FrostKeyShare{{shares[0], shares[1]}, {shares[1], shares[2]}} {
+		tx.TxIn[0].Witness = wire.TxWitness{frostSign(t, outputKey, signers, sigHash).Serialize()}
+		require.NoError(t, VerifyTransaction(tx, prevOuts, StandardScriptFlags))
+	}
+}
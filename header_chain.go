@@ -0,0 +1,230 @@
+package bitcoin
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// HeaderChain is a chain of validated block headers growing from a trusted checkpoint header, for
+// SPV verification. Each connected header must link to the tip, meet its target and carry the
+// difficulty the network's retarget rules require. A retarget can only be verified with the whole
+// previous retarget period in the chain, so the checkpoint must be at a retarget boundary (a height
+// multiple of 2016 on mainnet and testnet) for the chain to grow past the next retarget.
+// HeaderChain is safe for concurrent use
+type HeaderChain struct {
+	mu          sync.RWMutex
+	params      *chaincfg.Params
+	startHeight int64
+	headers     []wire.BlockHeader
+	heights     map[chainhash.Hash]int64
+	work        *big.Int
+}
+
+// NewHeaderChain creates a header chain from a trusted checkpoint header at height
+func NewHeaderChain(checkpoint *wire.BlockHeader, height int64, networkType NetworkType) (*HeaderChain, error) {
+	if err := CheckProofOfWork(checkpoint, networkType); err != nil {
+		return nil, err
+	}
+
+	return &HeaderChain{
+		params:      networkType,
+		startHeight: height,
+		headers:     []wire.BlockHeader{*checkpoint},
+		heights:     map[chainhash.Hash]int64{checkpoint.BlockHash(): height},
+		work:        GetBlockWork(checkpoint),
+	}, nil
+}
+
+// Connect validates headers in order and appends them to the chain. Headers already in the chain
+// are skipped; on the first invalid header an error is returned and the headers before it stay connected
+func (c *HeaderChain) Connect(headers ...*wire.BlockHeader) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, header := range headers {
+		hash := header.BlockHash()
+		if _, ok := c.heights[hash]; ok {
+			continue
+		}
+
+		tip := &c.headers[len(c.headers)-1]
+		if header.PrevBlock != tip.BlockHash() {
+			return fmt.Errorf("%w: %s does not extend tip %s", ErrHeaderNotConnected, hash, tip.BlockHash())
+		}
+
+		if err := CheckProofOfWork(header, c.params); err != nil {
+			return err
+		}
+
+		// difficulty, median time and version rules
+		prevNode := &headerNode{chain: c, height: c.tipHeight()}
+		if err := blockchain.CheckBlockHeaderContext(header, prevNode, blockchain.BFNone, headerChainCtx{c.params}, false); err != nil {
+			return fmt.Errorf("header %s: %w", hash, err)
+		}
+
+		c.headers = append(c.headers, *header)
+		c.heights[hash] = c.tipHeight()
+		c.work.Add(c.work, GetBlockWork(header))
+	}
+
+	return nil
+}
+
+// Tip returns the last connected header and its height
+func (c *HeaderChain) Tip() (*wire.BlockHeader, int64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	header := c.headers[len(c.headers)-1]
+	return &header, c.tipHeight()
+}
+
+// Header returns the header at height
+func (c *HeaderChain) Header(height int64) (*wire.BlockHeader, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if height < c.startHeight || height > c.tipHeight() {
+		return nil, fmt.Errorf("%w: height %d", ErrHeaderNotFound, height)
+	}
+
+	header := c.headers[height-c.startHeight]
+	return &header, nil
+}
+
+// Height returns the height of the header with a block hash (hex string)
+func (c *HeaderChain) Height(blockHash string) (int64, error) {
+	hash, err := chainhash.NewHashFromStr(blockHash)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	height, ok := c.heights[*hash]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrHeaderNotFound, blockHash)
+	}
+
+	return height, nil
+}
+
+// Work returns the total work of the headers from the checkpoint to the tip
+func (c *HeaderChain) Work() *big.Int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return new(big.Int).Set(c.work)
+}
+
+// VerifyMerkleProof verifies that a transaction is included in the block at height, of txCount transactions
+// (see MerkleProof.Verify), and returns its number of confirmations at the current tip
+func (c *HeaderChain) VerifyMerkleProof(proof *MerkleProof, height int64, txCount uint32) (int64, error) {
+	header, err := c.Header(height)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = proof.Verify(header, txCount); err != nil {
+		return 0, err
+	}
+
+	_, tipHeight := c.Tip()
+	return tipHeight - height + 1, nil
+}
+
+// tipHeight returns the height of the tip, c.mu must be held
+func (c *HeaderChain) tipHeight() int64 {
+	return c.startHeight + int64(len(c.headers)) - 1
+}
+
+// headerNode is a header of a HeaderChain as seen by the btcd header validation, c.mu must be held
+type headerNode struct {
+	chain  *HeaderChain
+	height int64
+}
+
+// Height implements blockchain.HeaderCtx
+func (n *headerNode) Height() int32 {
+	return int32(n.height)
+}
+
+// Bits implements blockchain.HeaderCtx
+func (n *headerNode) Bits() uint32 {
+	return n.header().Bits
+}
+
+// Timestamp implements blockchain.HeaderCtx
+func (n *headerNode) Timestamp() int64 {
+	return n.header().Timestamp.Unix()
+}
+
+// Parent implements blockchain.HeaderCtx
+func (n *headerNode) Parent() blockchain.HeaderCtx {
+	return n.RelativeAncestorCtx(1)
+}
+
+// RelativeAncestorCtx implements blockchain.HeaderCtx, headers before the checkpoint are unknown
+func (n *headerNode) RelativeAncestorCtx(distance int32) blockchain.HeaderCtx {
+	height := n.height - int64(distance)
+	if distance < 0 || height < n.chain.startHeight {
+		return nil
+	}
+
+	return &headerNode{chain: n.chain, height: height}
+}
+
+// header returns the header of the node
+func (n *headerNode) header() *wire.BlockHeader {
+	return &n.chain.headers[n.height-n.chain.startHeight]
+}
+
+// headerChainCtx provides the network parameters to the btcd header validation
+type headerChainCtx struct {
+	params *chaincfg.Params
+}
+
+// ChainParams implements blockchain.ChainCtx
+func (c headerChainCtx) ChainParams() *chaincfg.Params {
+	return c.params
+}
+
+// BlocksPerRetarget implements blockchain.ChainCtx
+func (c headerChainCtx) BlocksPerRetarget() int32 {
+	return int32(c.params.TargetTimespan / c.params.TargetTimePerBlock)
+}
+
+// MinRetargetTimespan implements blockchain.ChainCtx
+func (c headerChainCtx) MinRetargetTimespan() int64 {
+	return int64(c.params.TargetTimespan/time.Second) / c.params.RetargetAdjustmentFactor
+}
+
+// MaxRetargetTimespan implements blockchain.ChainCtx
+func (c headerChainCtx) MaxRetargetTimespan() int64 {
+	return int64(c.params.TargetTimespan/time.Second) * c.params.RetargetAdjustmentFactor
+}
+
+// VerifyCheckpoint implements blockchain.ChainCtx with the checkpoints of the network
+func (c headerChainCtx) VerifyCheckpoint(height int32, hash *chainhash.Hash) bool {
+	for _, checkpoint := range c.params.Checkpoints {
+		if checkpoint.Height == height {
+			return checkpoint.Hash.IsEqual(hash)
+		}
+	}
+
+	return true
+}
+
+// FindPreviousCheckpoint implements blockchain.ChainCtx, the chain has no fork point to protect
+// as it only ever extends its tip
+func (c headerChainCtx) FindPreviousCheckpoint() (blockchain.HeaderCtx, error) {
+	return nil, nil
+}
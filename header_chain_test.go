@@ -0,0 +1,165 @@
+package bitcoin
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mineHeader returns a header on top of prev meeting the target of bits
+func mineHeader(prev *wire.BlockHeader, bits uint32, timestamp time.Time) *wire.BlockHeader {
+	return mineHeaderWithRoot(prev, chainhash.Hash{}, bits, timestamp)
+}
+
+// mineHeaderWithRoot returns a header committing to merkleRoot on top of prev meeting the target of bits
+func mineHeaderWithRoot(prev *wire.BlockHeader, merkleRoot chainhash.Hash, bits uint32, timestamp time.Time) *wire.BlockHeader {
+	prevHash := prev.BlockHash()
+	header := wire.NewBlockHeader(4, &prevHash, &merkleRoot, bits, 0)
+	header.Timestamp = timestamp
+
+	target := blockchain.CompactToBig(bits)
+	for {
+		hash := header.BlockHash()
+		if blockchain.HashToBig(&hash).Cmp(target) <= 0 {
+			return header
+		}
+		header.Nonce++
+	}
+}
+
+// TestHeaderChain will test connecting mainnet headers to a HeaderChain
+func TestHeaderChain(t *testing.T) {
+	t.Parallel()
+
+	genesis, err := BlockHeaderFromString(genesisHeader)
+	require.NoError(t, err)
+
+	chain, err := NewHeaderChain(genesis, 0, Mainnet)
+	require.NoError(t, err)
+
+	var headers []*wire.BlockHeader
+	for _, rawHeader := range mainnetHeaders {
+		header, err := BlockHeaderFromString(rawHeader)
+		require.NoError(t, err)
+		headers = append(headers, header)
+	}
+
+	// a header that skips one
+	assert.ErrorIs(t, chain.Connect(headers[1]), ErrHeaderNotConnected)
+
+	require.NoError(t, chain.Connect(headers...))
+	tip, height := chain.Tip()
+	assert.Equal(t, int64(2), height)
+	assert.Equal(t, headers[1].BlockHash(), tip.BlockHash())
+	assert.Equal(t, big.NewInt(3*0x100010001), chain.Work())
+
+	// connecting again is a no-op
+	require.NoError(t, chain.Connect(headers...))
+
+	header, err := chain.Header(1)
+	require.NoError(t, err)
+	assert.Equal(t, headers[0].BlockHash(), header.BlockHash())
+
+	_, err = chain.Header(3)
+	assert.ErrorIs(t, err, ErrHeaderNotFound)
+
+	height, err = chain.Height("000000006a625f06636b8bb6ac7b960a8d03705d1ace08b1a19da3fdcc99ddbd")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), height)
+
+	_, err = chain.Height("0000000000000000000000000000000000000000000000000000000000000000")
+	assert.ErrorIs(t, err, ErrHeaderNotFound)
+
+	// an invalid checkpoint
+	tampered := *genesis
+	tampered.Nonce++
+	_, err = NewHeaderChain(&tampered, 0, Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidProofOfWork)
+}
+
+// TestHeaderChainRetarget will test difficulty retargets on a network retargeting every 10 blocks
+func TestHeaderChainRetarget(t *testing.T) {
+	t.Parallel()
+
+	params := chaincfg.RegressionNetParams
+	params.PoWNoRetargeting = false
+	params.TargetTimespan = 10 * params.TargetTimePerBlock
+	networkType := NetworkType(&params)
+
+	// blocks come twice as fast as targeted
+	start := time.Unix(1700000000, 0)
+	interval := params.TargetTimePerBlock / 2
+	bits := blockchain.BigToCompact(params.PowLimit)
+
+	checkpoint := mineHeader(&wire.BlockHeader{}, bits, start)
+	chain, err := NewHeaderChain(checkpoint, 0, networkType)
+	require.NoError(t, err)
+
+	headers := []*wire.BlockHeader{checkpoint}
+	for height := 1; height < 10; height++ {
+		header := mineHeader(headers[height-1], bits, start.Add(time.Duration(height)*interval))
+		headers = append(headers, header)
+	}
+	require.NoError(t, chain.Connect(headers[1:]...))
+
+	// the retarget scales the target by the time the last period took
+	actual := int64(9 * interval / time.Second)
+	target := blockchain.CompactToBig(bits)
+	target.Mul(target, big.NewInt(actual))
+	target.Div(target, big.NewInt(int64(params.TargetTimespan/time.Second)))
+	newBits := blockchain.BigToCompact(target)
+	require.NotEqual(t, bits, newBits)
+
+	timestamp := start.Add(10 * interval)
+	assert.Error(t, chain.Connect(mineHeader(headers[9], bits, timestamp)))
+	require.NoError(t, chain.Connect(mineHeader(headers[9], newBits, timestamp)))
+
+	// the new difficulty holds until the next retarget
+	tip, height := chain.Tip()
+	assert.Equal(t, int64(10), height)
+	assert.Error(t, chain.Connect(mineHeader(tip, bits, timestamp.Add(interval))))
+	require.NoError(t, chain.Connect(mineHeader(tip, newBits, timestamp.Add(interval))))
+
+	// a timestamp before the median of the last blocks
+	tip, _ = chain.Tip()
+	assert.Error(t, chain.Connect(mineHeader(tip, newBits, start)))
+}
+
+// TestHeaderChainMerkleProof will test the method HeaderChain.VerifyMerkleProof()
+func TestHeaderChainMerkleProof(t *testing.T) {
+	t.Parallel()
+
+	params := chaincfg.RegressionNetParams
+	networkType := NetworkType(&params)
+	bits := blockchain.BigToCompact(params.PowLimit)
+
+	block := newTestBlock(3)
+	checkpoint := mineHeader(&wire.BlockHeader{}, bits, time.Unix(1700000000, 0))
+	chain, err := NewHeaderChain(checkpoint, 100, networkType)
+	require.NoError(t, err)
+
+	// the block at height 101, then two more blocks
+	header := mineHeaderWithRoot(checkpoint, block.Header.MerkleRoot, bits, time.Unix(1700000600, 0))
+	next := mineHeader(header, bits, time.Unix(1700001200, 0))
+	require.NoError(t, chain.Connect(header, next, mineHeader(next, bits, time.Unix(1700001800, 0))))
+
+	proof, err := BuildMerkleProof(block, block.Transactions[1].TxHash().String())
+	require.NoError(t, err)
+
+	confirmations, err := chain.VerifyMerkleProof(proof, 101, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), confirmations)
+
+	_, err = chain.VerifyMerkleProof(proof, 102, 3)
+	assert.ErrorIs(t, err, ErrInvalidMerkleProof)
+
+	_, err = chain.VerifyMerkleProof(proof, 99, 3)
+	assert.ErrorIs(t, err, ErrHeaderNotFound)
+}
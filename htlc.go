@@ -0,0 +1,270 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// HTLCPreimageSize is the size of the preimage every HTLC script enforces
+const HTLCPreimageSize = 32
+
+// HTLC is a hash time-locked contract: ClaimKey can spend it by revealing the preimage of
+// PaymentHash, RefundKey can spend it once LockTime (a CLTV lock time) is reached
+type HTLC struct {
+	PaymentHash []byte // 32 bytes for a SHA256 hash lock, 20 bytes for a HASH160 hash lock
+	ClaimKey    *btcec.PublicKey
+	RefundKey   *btcec.PublicKey
+	LockTime    uint32
+}
+
+// NewHTLC creates an HTLC from a payment hash (hex string). The hash lock is SHA256 for a 32 byte
+// payment hash and HASH160 for a 20 byte one
+func NewHTLC(paymentHash string, claimKey, refundKey *btcec.PublicKey, lockTime uint32) (*HTLC, error) {
+
+	hashBytes, err := hex.DecodeString(paymentHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hashBytes) != sha256.Size && len(hashBytes) != 20 {
+		return nil, ErrInvalidPaymentHash
+	}
+
+	if !IsValidPublicKey(claimKey) || !IsValidPublicKey(refundKey) {
+		return nil, ErrInvalidPubKey
+	}
+
+	return &HTLC{
+		PaymentHash: hashBytes,
+		ClaimKey:    claimKey,
+		RefundKey:   refundKey,
+		LockTime:    lockTime,
+	}, nil
+}
+
+// WitnessScript returns the P2WSH witness script (hex) of the HTLC:
+// OP_IF OP_SIZE 32 OP_EQUALVERIFY <hash lock> OP_EQUALVERIFY <claimKey>
+// OP_ELSE <lockTime> OP_CHECKLOCKTIMEVERIFY OP_DROP <refundKey> OP_ENDIF OP_CHECKSIG
+func (h *HTLC) WitnessScript() (string, error) {
+	builder := txscript.NewScriptBuilder().AddOp(txscript.OP_IF)
+	h.addHashLock(builder)
+
+	script, err := builder.
+		AddData(h.ClaimKey.SerializeCompressed()).
+		AddOp(txscript.OP_ELSE).
+		AddInt64(int64(h.LockTime)).
+		AddOp(txscript.OP_CHECKLOCKTIMEVERIFY).
+		AddOp(txscript.OP_DROP).
+		AddData(h.RefundKey.SerializeCompressed()).
+		AddOp(txscript.OP_ENDIF).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(script), nil
+}
+
+// Address returns the P2WSH address of the HTLC
+func (h *HTLC) Address(networkType NetworkType) (string, error) {
+	witnessScript, err := h.WitnessScript()
+	if err != nil {
+		return "", err
+	}
+
+	return GetWitnessScriptAddress(witnessScript, networkType)
+}
+
+// ClaimTapscript returns the tapscript leaf (hex) of the claim path:
+// OP_SIZE 32 OP_EQUALVERIFY <hash lock> OP_EQUALVERIFY <claimKey> OP_CHECKSIG
+func (h *HTLC) ClaimTapscript() (string, error) {
+	builder := txscript.NewScriptBuilder()
+	h.addHashLock(builder)
+
+	script, err := builder.
+		AddData(schnorr.SerializePubKey(h.ClaimKey)).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(script), nil
+}
+
+// RefundTapscript returns the tapscript leaf (hex) of the refund path:
+// <lockTime> OP_CHECKLOCKTIMEVERIFY OP_DROP <refundKey> OP_CHECKSIG
+func (h *HTLC) RefundTapscript() (string, error) {
+	return GetAbsoluteTimelockTapscript(h.RefundKey, h.LockTime)
+}
+
+// Tapscripts returns the claim and refund leaves of the taproot HTLC, in that order
+func (h *HTLC) Tapscripts() ([]string, error) {
+	claim, err := h.ClaimTapscript()
+	if err != nil {
+		return nil, err
+	}
+
+	refund, err := h.RefundTapscript()
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{claim, refund}, nil
+}
+
+// TaprootAddress returns the P2TR address of the HTLC. internalKey can spend through the key path,
+// typically an aggregate of both parties for cooperative closes; TaprootNUMSPubKey disables it
+func (h *HTLC) TaprootAddress(internalKey *btcec.PublicKey, networkType NetworkType) (string, error) {
+	tapscripts, err := h.Tapscripts()
+	if err != nil {
+		return "", err
+	}
+
+	return GetTaprootScriptAddress(internalKey, tapscripts, networkType)
+}
+
+// ClaimWitness returns the witness spending the P2WSH HTLC with the preimage
+func (h *HTLC) ClaimWitness(signature, preimage []byte) (wire.TxWitness, error) {
+	if !h.IsPreimage(preimage) {
+		return nil, ErrInvalidPreimage
+	}
+
+	witnessScript, err := h.witnessScriptBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return wire.TxWitness{signature, preimage, {0x01}, witnessScript}, nil
+}
+
+// RefundWitness returns the witness spending the P2WSH HTLC after its timeout. The spending transaction
+// must have a lock time of at least LockTime and a sequence below the maximum for the input
+func (h *HTLC) RefundWitness(signature []byte) (wire.TxWitness, error) {
+	witnessScript, err := h.witnessScriptBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return wire.TxWitness{signature, nil, witnessScript}, nil
+}
+
+// TaprootClaimWitness returns the witness spending the taproot HTLC through the claim path
+func (h *HTLC) TaprootClaimWitness(internalKey *btcec.PublicKey, signature, preimage []byte) (wire.TxWitness, error) {
+	if !h.IsPreimage(preimage) {
+		return nil, ErrInvalidPreimage
+	}
+
+	return h.tapscriptWitness(internalKey, 0, signature, preimage)
+}
+
+// TaprootRefundWitness returns the witness spending the taproot HTLC through the refund path, with the
+// same lock time requirements as RefundWitness
+func (h *HTLC) TaprootRefundWitness(internalKey *btcec.PublicKey, signature []byte) (wire.TxWitness, error) {
+	return h.tapscriptWitness(internalKey, 1, signature)
+}
+
+// IsPreimage reports whether preimage unlocks the hash lock of the HTLC
+func (h *HTLC) IsPreimage(preimage []byte) bool {
+	return len(preimage) == HTLCPreimageSize && bytes.Equal(hashLock(preimage, len(h.PaymentHash)), h.PaymentHash)
+}
+
+// ExtractPreimage scans the inputs of a spending transaction for the preimage of paymentHash (hex string),
+// as revealed by the claim of an HTLC. Both SHA256 (32 byte) and HASH160 (20 byte) payment hashes are supported
+func ExtractPreimage(tx *wire.MsgTx, paymentHash string) ([]byte, error) {
+
+	hashBytes, err := hex.DecodeString(paymentHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hashBytes) != sha256.Size && len(hashBytes) != 20 {
+		return nil, ErrInvalidPaymentHash
+	}
+
+	for _, in := range tx.TxIn {
+		items := in.Witness
+
+		// legacy and P2SH spends reveal the preimage in the scriptSig
+		if pushes, err := txscript.PushedData(in.SignatureScript); err == nil {
+			items = append(pushes, items...)
+		}
+
+		for _, item := range items {
+			if len(item) == HTLCPreimageSize && bytes.Equal(hashLock(item, len(hashBytes)), hashBytes) {
+				return item, nil
+			}
+		}
+	}
+
+	return nil, ErrPreimageNotFound
+}
+
+// addHashLock adds OP_SIZE 32 OP_EQUALVERIFY <hash opcode> <paymentHash> OP_EQUALVERIFY
+func (h *HTLC) addHashLock(builder *txscript.ScriptBuilder) {
+	hashOpcode := byte(txscript.OP_SHA256)
+	if len(h.PaymentHash) != sha256.Size {
+		hashOpcode = txscript.OP_HASH160
+	}
+
+	builder.
+		AddOp(txscript.OP_SIZE).
+		AddInt64(HTLCPreimageSize).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(hashOpcode).
+		AddData(h.PaymentHash).
+		AddOp(txscript.OP_EQUALVERIFY)
+}
+
+// witnessScriptBytes returns the P2WSH witness script of the HTLC
+func (h *HTLC) witnessScriptBytes() ([]byte, error) {
+	witnessScript, err := h.WitnessScript()
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.DecodeString(witnessScript)
+}
+
+// tapscriptWitness returns the witness spending the leaf at index with the given stack items
+func (h *HTLC) tapscriptWitness(internalKey *btcec.PublicKey, index int, items ...[]byte) (wire.TxWitness, error) {
+	tapscripts, err := h.Tapscripts()
+	if err != nil {
+		return nil, err
+	}
+
+	controlBlock, err := GetTapscriptControlBlock(internalKey, tapscripts, index)
+	if err != nil {
+		return nil, err
+	}
+
+	controlBlockBytes, err := hex.DecodeString(controlBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	tapscript, err := hex.DecodeString(tapscripts[index])
+	if err != nil {
+		return nil, err
+	}
+
+	return append(wire.TxWitness(items), tapscript, controlBlockBytes), nil
+}
+
+// hashLock hashes a preimage with SHA256 or HASH160, depending on the size of the payment hash
+func hashLock(preimage []byte, hashSize int) []byte {
+	if hashSize == sha256.Size {
+		hash := sha256.Sum256(preimage)
+		return hash[:]
+	}
+
+	return btcutil.Hash160(preimage)
+}
@@ -0,0 +1,220 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestHTLC returns an HTLC over a fixed preimage, its keys and the preimage
+func newTestHTLC(t *testing.T, sha bool) (*HTLC, *btcec.PrivateKey, *btcec.PrivateKey, []byte) {
+	claimKey, err := CreatePrivateKey()
+	require.NoError(t, err)
+	refundKey, err := CreatePrivateKey()
+	require.NoError(t, err)
+
+	preimage := bytes.Repeat([]byte{0x42}, HTLCPreimageSize)
+	paymentHash := btcutil.Hash160(preimage)
+	if sha {
+		hash := sha256.Sum256(preimage)
+		paymentHash = hash[:]
+	}
+
+	htlc, err := NewHTLC(hex.EncodeToString(paymentHash), claimKey.PubKey(), refundKey.PubKey(), 800000)
+	require.NoError(t, err)
+
+	return htlc, claimKey, refundKey, preimage
+}
+
+// pkScriptForAddress returns the output script of an address
+func pkScriptForAddress(t *testing.T, address string) []byte {
+	script, err := GetScriptFromAddress(address, Mainnet)
+	require.NoError(t, err)
+
+	pkScript, err := hex.DecodeString(script)
+	require.NoError(t, err)
+	return pkScript
+}
+
+// TestNewHTLC will test the method NewHTLC()
+func TestNewHTLC(t *testing.T) {
+	t.Parallel()
+
+	pubKey, err := PubKeyFromString("022d35c7ede60cb68dee6e60ab9ad5863a7a726b297273d7a99b9dfb032a10e3f8")
+	require.NoError(t, err)
+
+	var tests = []struct {
+		paymentHash   string
+		claimKey      *btcec.PublicKey
+		expectedError error
+	}{
+		{hex.EncodeToString(make([]byte, 32)), pubKey, nil},
+		{hex.EncodeToString(make([]byte, 20)), pubKey, nil},
+		{hex.EncodeToString(make([]byte, 31)), pubKey, ErrInvalidPaymentHash},
+		{"", pubKey, ErrInvalidPaymentHash},
+		{hex.EncodeToString(make([]byte, 32)), nil, ErrInvalidPubKey},
+	}
+
+	for _, test := range tests {
+		_, err := NewHTLC(test.paymentHash, test.claimKey, pubKey, 100)
+		if test.expectedError == nil {
+			require.NoError(t, err)
+		} else {
+			require.ErrorIs(t, err, test.expectedError)
+		}
+	}
+
+	_, err = NewHTLC("zz", pubKey, pubKey, 100)
+	require.Error(t, err)
+}
+
+// TestHTLCWitnessScript will test the P2WSH script and spends of an HTLC
+func TestHTLCWitnessScript(t *testing.T) {
+	t.Parallel()
+
+	for _, sha := range []bool{true, false} {
+		htlc, claimKey, refundKey, preimage := newTestHTLC(t, sha)
+
+		witnessScriptHex, err := htlc.WitnessScript()
+		require.NoError(t, err)
+		witnessScript, err := hex.DecodeString(witnessScriptHex)
+		require.NoError(t, err)
+
+		asm, err := GetAsmFromScript(witnessScriptHex)
+		require.NoError(t, err)
+		if sha {
+			assert.Contains(t, asm, "OP_IF OP_SIZE 32 OP_EQUALVERIFY OP_SHA256 ")
+		} else {
+			assert.Contains(t, asm, "OP_IF OP_SIZE 32 OP_EQUALVERIFY OP_HASH160 ")
+		}
+		assert.Contains(t, asm, "OP_ELSE 800000 OP_CHECKLOCKTIMEVERIFY OP_DROP ")
+
+		address, err := htlc.Address(Mainnet)
+		require.NoError(t, err)
+		pkScript := pkScriptForAddress(t, address)
+
+		sign := func(tx *wire.MsgTx, prevOut *wire.TxOut, key *btcec.PrivateKey) []byte {
+			sigHashes := txscript.NewTxSigHashes(tx, txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value))
+			sig, err := txscript.RawTxInWitnessSignature(tx, sigHashes, 0, prevOut.Value, witnessScript, txscript.SigHashAll, key)
+			require.NoError(t, err)
+			return sig
+		}
+
+		// claim with the preimage
+		tx, prevOut := newSpendingTx(pkScript)
+		tx.TxIn[0].Witness, err = htlc.ClaimWitness(sign(tx, prevOut, claimKey), preimage)
+		require.NoError(t, err)
+		_, err = VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+		require.NoError(t, err)
+
+		revealed, err := ExtractPreimage(tx, hex.EncodeToString(htlc.PaymentHash))
+		require.NoError(t, err)
+		assert.Equal(t, preimage, revealed)
+
+		_, err = htlc.ClaimWitness(nil, bytes.Repeat([]byte{0x43}, HTLCPreimageSize))
+		assert.ErrorIs(t, err, ErrInvalidPreimage)
+
+		// refund after the timeout
+		tx, prevOut = newSpendingTx(pkScript)
+		tx.LockTime = htlc.LockTime
+		tx.TxIn[0].Sequence = wire.MaxTxInSequenceNum - 1
+		tx.TxIn[0].Witness, err = htlc.RefundWitness(sign(tx, prevOut, refundKey))
+		require.NoError(t, err)
+		_, err = VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+		require.NoError(t, err)
+
+		_, err = ExtractPreimage(tx, hex.EncodeToString(htlc.PaymentHash))
+		assert.ErrorIs(t, err, ErrPreimageNotFound)
+
+		// refund before the timeout
+		tx.LockTime = htlc.LockTime - 1
+		tx.TxIn[0].Witness, err = htlc.RefundWitness(sign(tx, prevOut, refundKey))
+		require.NoError(t, err)
+		_, err = VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+		require.Error(t, err)
+	}
+}
+
+// TestHTLCTaproot will test the taproot script paths of an HTLC
+func TestHTLCTaproot(t *testing.T) {
+	t.Parallel()
+
+	htlc, claimKey, refundKey, preimage := newTestHTLC(t, true)
+	internalKey := TaprootNUMSPubKey()
+
+	address, err := htlc.TaprootAddress(internalKey, Mainnet)
+	require.NoError(t, err)
+	pkScript := pkScriptForAddress(t, address)
+
+	tapscripts, err := htlc.Tapscripts()
+	require.NoError(t, err)
+
+	sign := func(tx *wire.MsgTx, prevOut *wire.TxOut, key *btcec.PrivateKey, tapscript string) []byte {
+		script, err := hex.DecodeString(tapscript)
+		require.NoError(t, err)
+
+		sigHashes := txscript.NewTxSigHashes(tx, txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value))
+		sig, err := txscript.RawTxInTapscriptSignature(tx, sigHashes, 0, prevOut.Value, prevOut.PkScript, txscript.NewBaseTapLeaf(script), txscript.SigHashDefault, key)
+		require.NoError(t, err)
+		return sig
+	}
+
+	// claim with the preimage
+	tx, prevOut := newSpendingTx(pkScript)
+	tx.TxIn[0].Witness, err = htlc.TaprootClaimWitness(internalKey, sign(tx, prevOut, claimKey, tapscripts[0]), preimage)
+	require.NoError(t, err)
+	_, err = VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+	require.NoError(t, err)
+
+	revealed, err := ExtractPreimage(tx, hex.EncodeToString(htlc.PaymentHash))
+	require.NoError(t, err)
+	assert.Equal(t, preimage, revealed)
+
+	// refund after the timeout
+	tx, prevOut = newSpendingTx(pkScript)
+	tx.LockTime = htlc.LockTime
+	tx.TxIn[0].Sequence = wire.MaxTxInSequenceNum - 1
+	tx.TxIn[0].Witness, err = htlc.TaprootRefundWitness(internalKey, sign(tx, prevOut, refundKey, tapscripts[1]))
+	require.NoError(t, err)
+	_, err = VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+	require.NoError(t, err)
+
+	// the claim key cannot use the refund path
+	tx.TxIn[0].Witness, err = htlc.TaprootRefundWitness(internalKey, sign(tx, prevOut, claimKey, tapscripts[1]))
+	require.NoError(t, err)
+	_, err = VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+	require.Error(t, err)
+}
+
+// TestExtractPreimage will test the method ExtractPreimage()
+func TestExtractPreimage(t *testing.T) {
+	t.Parallel()
+
+	preimage := bytes.Repeat([]byte{0x07}, HTLCPreimageSize)
+	hash := sha256.Sum256(preimage)
+
+	// preimages revealed in a scriptSig are found as well
+	sigScript, err := txscript.NewScriptBuilder().AddData([]byte{0x01}).AddData(preimage).Script()
+	require.NoError(t, err)
+
+	tx, _ := newSpendingTx(nil)
+	tx.TxIn[0].SignatureScript = sigScript
+
+	revealed, err := ExtractPreimage(tx, hex.EncodeToString(hash[:]))
+	require.NoError(t, err)
+	assert.Equal(t, preimage, revealed)
+
+	_, err = ExtractPreimage(tx, hex.EncodeToString(hash[:31]))
+	assert.ErrorIs(t, err, ErrInvalidPaymentHash)
+
+	_, err = ExtractPreimage(tx, "zz")
+	assert.Error(t, err)
+}
@@ -0,0 +1,350 @@
+package bitcoin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MemoryChainBackend is an in memory ChainBackend for tests. Transactions are added directly or
+// broadcast into its mempool, confirmed by MineBlock and unconfirmed again by Reorg
+type MemoryChainBackend struct {
+	mu           sync.Mutex
+	height       int64
+	blocks       [][]chainhash.Hash // transactions confirmed at each height, starting at 1
+	reorgs       int
+	txs          map[chainhash.Hash]*wire.MsgTx
+	utxos        map[wire.OutPoint]Utxo
+	mempool      []chainhash.Hash
+	feeRate      float64
+	broadcastErr error
+	subscribers  map[chan BlockEvent]struct{}
+}
+
+// NewMemoryChainBackend creates an empty chain at height 0 with a fee rate of 1 sat/vB
+func NewMemoryChainBackend() *MemoryChainBackend {
+	return &MemoryChainBackend{
+		txs:         make(map[chainhash.Hash]*wire.MsgTx),
+		utxos:       make(map[wire.OutPoint]Utxo),
+		feeRate:     1,
+		subscribers: make(map[chan BlockEvent]struct{}),
+	}
+}
+
+// AddTransaction adds a transaction to the mempool like Broadcast, but regardless of SetBroadcastError.
+// Its outputs are confirmed by the next MineBlock
+func (m *MemoryChainBackend) AddTransaction(tx *wire.MsgTx) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.addMempoolTransaction(tx.Copy())
+}
+
+// MineBlock confirms the mempool in a new block and announces it to subscribers
+func (m *MemoryChainBackend) MineBlock() BlockEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.height++
+	for _, txHash := range m.mempool {
+		m.setHeight(txHash, m.height)
+	}
+	m.blocks = append(m.blocks, m.mempool)
+	m.mempool = nil
+
+	event := BlockEvent{Height: m.height, Hash: memoryBlockHash(m.height, m.reorgs)}
+	for events := range m.subscribers {
+		sendLatestBlock(events, event)
+	}
+
+	return event
+}
+
+// Reorg disconnects the last depth blocks, lowering the tip without announcing it. Their transactions return
+// to the mempool, except evicted (txids), dropped along with their outputs as if double spent by the new chain.
+// depth is clamped between 0 and the height of the chain
+func (m *MemoryChainBackend) Reorg(depth int, evicted ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	depth = min(max(depth, 0), len(m.blocks))
+	disconnected := m.blocks[len(m.blocks)-depth:]
+	m.blocks = m.blocks[:len(m.blocks)-depth]
+	m.height -= int64(depth)
+	m.reorgs++
+
+	var mempool []chainhash.Hash
+	for _, txHashes := range disconnected {
+		for _, txHash := range txHashes {
+			m.setHeight(txHash, 0)
+			mempool = append(mempool, txHash)
+		}
+	}
+	m.mempool = append(mempool, m.mempool...)
+
+	for _, txID := range evicted {
+		if txHash, err := chainhash.NewHashFromStr(txID); err == nil {
+			m.removeMempoolTransaction(*txHash)
+		}
+	}
+}
+
+// Mempool returns the broadcast transactions waiting for a block
+func (m *MemoryChainBackend) Mempool() []*wire.MsgTx {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	txs := make([]*wire.MsgTx, 0, len(m.mempool))
+	for _, txHash := range m.mempool {
+		txs = append(txs, m.txs[txHash])
+	}
+
+	return txs
+}
+
+// SetFeeRate sets the fee rate in sat/vB returned by EstimateFee for every confirmation target
+func (m *MemoryChainBackend) SetFeeRate(satPerVByte float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.feeRate = satPerVByte
+}
+
+// SetBroadcastError makes Broadcast fail with err, nil restores successful broadcasts
+func (m *MemoryChainBackend) SetBroadcastError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.broadcastErr = err
+}
+
+// GetUtxos implements ChainBackend
+func (m *MemoryChainBackend) GetUtxos(_ context.Context, scripts []string) ([]Utxo, error) {
+	wanted := make(map[string]bool, len(scripts))
+	for _, script := range scripts {
+		wanted[script] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var utxos []Utxo
+	for _, utxo := range m.utxos {
+		if wanted[utxo.Script] {
+			utxos = append(utxos, utxo)
+		}
+	}
+
+	sort.Slice(utxos, func(i, j int) bool {
+		if utxos[i].TxID != utxos[j].TxID {
+			return utxos[i].TxID < utxos[j].TxID
+		}
+		return utxos[i].Vout < utxos[j].Vout
+	})
+
+	return utxos, nil
+}
+
+// GetTransaction implements ChainBackend
+func (m *MemoryChainBackend) GetTransaction(_ context.Context, txID string) (*wire.MsgTx, error) {
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx, ok := m.txs[*txHash]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+
+	return tx.Copy(), nil
+}
+
+// Broadcast implements ChainBackend, adding the transaction to the mempool. Like a node accepting a
+// replacement (BIP125), it evicts the mempool transactions it conflicts with and their descendants
+func (m *MemoryChainBackend) Broadcast(_ context.Context, tx *wire.MsgTx) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.broadcastErr != nil {
+		return "", m.broadcastErr
+	}
+
+	m.evictConflicts(tx)
+	m.addMempoolTransaction(tx.Copy())
+	return tx.TxHash().String(), nil
+}
+
+// EstimateFee implements ChainBackend
+func (m *MemoryChainBackend) EstimateFee(_ context.Context, _ int) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.feeRate, nil
+}
+
+// TipHeight implements ChainBackend
+func (m *MemoryChainBackend) TipHeight(_ context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.height, nil
+}
+
+// SubscribeBlocks implements ChainBackend
+func (m *MemoryChainBackend) SubscribeBlocks(ctx context.Context) (<-chan BlockEvent, error) {
+	events := make(chan BlockEvent, 1)
+
+	m.mu.Lock()
+	m.subscribers[events] = struct{}{}
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		m.mu.Lock()
+		delete(m.subscribers, events)
+		close(events)
+		m.mu.Unlock()
+	}()
+
+	return events, nil
+}
+
+// addMempoolTransaction records an unconfirmed transaction, m.mu must be held
+func (m *MemoryChainBackend) addMempoolTransaction(tx *wire.MsgTx) {
+	txHash := tx.TxHash()
+	if _, ok := m.txs[txHash]; ok {
+		return
+	}
+	m.txs[txHash] = tx
+	m.mempool = append(m.mempool, txHash)
+
+	for _, in := range tx.TxIn {
+		delete(m.utxos, in.PreviousOutPoint)
+	}
+
+	for i, out := range tx.TxOut {
+		m.utxos[wire.OutPoint{Hash: txHash, Index: uint32(i)}] = Utxo{
+			TxID:   txHash.String(),
+			Vout:   uint32(i),
+			Value:  btcutil.Amount(out.Value),
+			Script: hex.EncodeToString(out.PkScript),
+		}
+	}
+}
+
+// evictConflicts removes the mempool transactions spending the outputs tx spends, with their descendants.
+// m.mu must be held
+func (m *MemoryChainBackend) evictConflicts(tx *wire.MsgTx) {
+	txHash := tx.TxHash()
+	spent := make(map[wire.OutPoint]bool, len(tx.TxIn))
+	for _, in := range tx.TxIn {
+		spent[in.PreviousOutPoint] = true
+	}
+
+	// the mempool is in arrival order, parents come before their children
+	evicted := make(map[chainhash.Hash]bool)
+	mempool := append([]chainhash.Hash(nil), m.mempool...)
+	for _, mempoolHash := range mempool {
+		if mempoolHash == txHash {
+			continue
+		}
+		for _, in := range m.txs[mempoolHash].TxIn {
+			if spent[in.PreviousOutPoint] || evicted[in.PreviousOutPoint.Hash] {
+				evicted[mempoolHash] = true
+				break
+			}
+		}
+	}
+
+	// children first, so the outputs restored are those of the remaining transactions
+	for i := len(mempool) - 1; i >= 0; i-- {
+		if evicted[mempool[i]] {
+			m.removeMempoolTransaction(mempool[i])
+		}
+	}
+}
+
+// removeMempoolTransaction drops an unconfirmed transaction and its outputs, restoring the outputs it
+// spent when their transactions are known. m.mu must be held
+func (m *MemoryChainBackend) removeMempoolTransaction(txHash chainhash.Hash) {
+	tx, ok := m.txs[txHash]
+	if !ok {
+		return
+	}
+
+	for i, mempoolHash := range m.mempool {
+		if mempoolHash == txHash {
+			m.mempool = append(m.mempool[:i], m.mempool[i+1:]...)
+			break
+		}
+	}
+	delete(m.txs, txHash)
+
+	for i := range tx.TxOut {
+		delete(m.utxos, wire.OutPoint{Hash: txHash, Index: uint32(i)})
+	}
+
+	for _, in := range tx.TxIn {
+		prevTx, ok := m.txs[in.PreviousOutPoint.Hash]
+		if !ok || int(in.PreviousOutPoint.Index) >= len(prevTx.TxOut) {
+			continue
+		}
+
+		out := prevTx.TxOut[in.PreviousOutPoint.Index]
+		m.utxos[in.PreviousOutPoint] = Utxo{
+			TxID:   in.PreviousOutPoint.Hash.String(),
+			Vout:   in.PreviousOutPoint.Index,
+			Value:  btcutil.Amount(out.Value),
+			Script: hex.EncodeToString(out.PkScript),
+			Height: m.txHeight(in.PreviousOutPoint.Hash),
+		}
+	}
+}
+
+// setHeight sets the confirmation height of the unspent outputs of a transaction, m.mu must be held
+func (m *MemoryChainBackend) setHeight(txHash chainhash.Hash, height int64) {
+	for i := range m.txs[txHash].TxOut {
+		outPoint := wire.OutPoint{Hash: txHash, Index: uint32(i)}
+		if utxo, ok := m.utxos[outPoint]; ok {
+			utxo.Height = height
+			m.utxos[outPoint] = utxo
+		}
+	}
+}
+
+// txHeight returns the confirmation height of a transaction, 0 while unconfirmed. m.mu must be held
+func (m *MemoryChainBackend) txHeight(txHash chainhash.Hash) int64 {
+	for i, txHashes := range m.blocks {
+		for _, blockTxHash := range txHashes {
+			if blockTxHash == txHash {
+				return int64(i + 1)
+			}
+		}
+	}
+
+	return 0
+}
+
+// memoryBlockHash returns a made up block hash for a height, distinct for every chain a reorg leads to
+func memoryBlockHash(height int64, reorgs int) string {
+	var seed [16]byte
+	binary.LittleEndian.PutUint64(seed[:8], uint64(height))
+	binary.LittleEndian.PutUint64(seed[8:], uint64(reorgs))
+
+	return chainhash.Hash(sha256.Sum256(seed[:])).String()
+}
+
+// compile time check
+var _ ChainBackend = (*MemoryChainBackend)(nil)
@@ -0,0 +1,232 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryChainBackend will test the transaction and utxo handling of MemoryChainBackend
+func TestMemoryChainBackend(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	backend := NewMemoryChainBackend()
+
+	privateKey, err := CreatePrivateKey()
+	require.NoError(t, err)
+	pkScript := scriptForAddressType(t, privateKey, NativeSegwit)
+	script := hex.EncodeToString(pkScript)
+
+	funding, _ := newSpendingTx(nil)
+	funding.TxOut[0] = wire.NewTxOut(50000, pkScript)
+	backend.AddTransaction(funding)
+
+	utxos, err := backend.GetUtxos(ctx, []string{script})
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Equal(t, int64(0), utxos[0].Height)
+	assert.Equal(t, btcutil.Amount(50000), utxos[0].Value)
+	assert.Len(t, backend.Mempool(), 1)
+
+	event := backend.MineBlock()
+	assert.Equal(t, int64(1), event.Height)
+	assert.Empty(t, backend.Mempool())
+
+	height, err := backend.TipHeight(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), height)
+
+	utxos, err = backend.GetUtxos(ctx, []string{script})
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Equal(t, int64(1), utxos[0].Height)
+
+	tx, err := backend.GetTransaction(ctx, funding.TxHash().String())
+	require.NoError(t, err)
+	assert.Equal(t, funding.TxHash(), tx.TxHash())
+
+	_, err = backend.GetTransaction(ctx, "0000000000000000000000000000000000000000000000000000000000000000")
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+
+	// spending the output removes it
+	outPoint, err := utxos[0].OutPoint()
+	require.NoError(t, err)
+	spend := wire.NewMsgTx(2)
+	spend.AddTxIn(wire.NewTxIn(outPoint, nil, nil))
+	spend.AddTxOut(wire.NewTxOut(49000, pkScript))
+
+	backend.SetBroadcastError(errors.New("rejected"))
+	_, err = backend.Broadcast(ctx, spend)
+	require.Error(t, err)
+
+	backend.SetBroadcastError(nil)
+	txID, err := backend.Broadcast(ctx, spend)
+	require.NoError(t, err)
+	assert.Equal(t, spend.TxHash().String(), txID)
+
+	utxos, err = backend.GetUtxos(ctx, []string{script})
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Equal(t, txID, utxos[0].TxID)
+	assert.Equal(t, int64(0), utxos[0].Height)
+
+	backend.SetFeeRate(12.5)
+	feeRate, err := backend.EstimateFee(ctx, 6)
+	require.NoError(t, err)
+	assert.InDelta(t, 12.5, feeRate, 1e-9)
+}
+
+// TestMemoryChainBackendSubscribeBlocks will test the method MemoryChainBackend.SubscribeBlocks()
+func TestMemoryChainBackendSubscribeBlocks(t *testing.T) {
+	t.Parallel()
+
+	backend := NewMemoryChainBackend()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := backend.SubscribeBlocks(ctx)
+	require.NoError(t, err)
+
+	first := backend.MineBlock()
+	assert.Equal(t, first, <-events)
+
+	// a subscriber that falls behind only sees the latest block
+	backend.MineBlock()
+	latest := backend.MineBlock()
+	assert.Equal(t, latest, <-events)
+	assert.NotEqual(t, first.Hash, latest.Hash)
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not closed")
+	}
+}
+
+// TestMemoryChainBackendReorg will test the method MemoryChainBackend.Reorg()
+func TestMemoryChainBackendReorg(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	backend := NewMemoryChainBackend()
+
+	privateKey, err := CreatePrivateKey()
+	require.NoError(t, err)
+	pkScript := scriptForAddressType(t, privateKey, NativeSegwit)
+	script := hex.EncodeToString(pkScript)
+
+	funding, _ := newSpendingTx(nil)
+	funding.TxOut[0] = wire.NewTxOut(50000, pkScript)
+	backend.AddTransaction(funding)
+	first := backend.MineBlock()
+
+	fundingHash := funding.TxHash()
+	spend := wire.NewMsgTx(2)
+	spend.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&fundingHash, 0), nil, nil))
+	spend.AddTxOut(wire.NewTxOut(49000, pkScript))
+	backend.AddTransaction(spend)
+	backend.MineBlock()
+
+	// the disconnected transaction returns to the mempool
+	backend.Reorg(1)
+	height, err := backend.TipHeight(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), height)
+	require.Len(t, backend.Mempool(), 1)
+
+	utxos, err := backend.GetUtxos(ctx, []string{script})
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Equal(t, spend.TxHash().String(), utxos[0].TxID)
+	assert.Equal(t, int64(0), utxos[0].Height)
+
+	// the new chain has other block hashes
+	assert.NotEqual(t, first.Hash, backend.MineBlock().Hash)
+
+	// an evicted spend restores the output it spent
+	backend.Reorg(1, spend.TxHash().String())
+	assert.Empty(t, backend.Mempool())
+
+	utxos, err = backend.GetUtxos(ctx, []string{script})
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Equal(t, funding.TxHash().String(), utxos[0].TxID)
+	assert.Equal(t, int64(1), utxos[0].Height)
+
+	_, err = backend.GetTransaction(ctx, spend.TxHash().String())
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+
+	// depths out of range are clamped
+	backend.Reorg(-1)
+	height, err = backend.TipHeight(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), height)
+
+	backend.Reorg(10)
+	height, err = backend.TipHeight(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), height)
+	require.Len(t, backend.Mempool(), 1)
+}
+
+// TestMemoryChainBackendReplacement will test that Broadcast evicts conflicting transactions
+func TestMemoryChainBackendReplacement(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	backend := NewMemoryChainBackend()
+
+	privateKey, err := CreatePrivateKey()
+	require.NoError(t, err)
+	pkScript := scriptForAddressType(t, privateKey, NativeSegwit)
+	script := hex.EncodeToString(pkScript)
+
+	funding, _ := newSpendingTx(nil)
+	funding.TxOut[0] = wire.NewTxOut(50000, pkScript)
+	backend.AddTransaction(funding)
+	backend.MineBlock()
+
+	fundingHash := funding.TxHash()
+	original := wire.NewMsgTx(2)
+	original.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&fundingHash, 0), nil, nil))
+	original.AddTxOut(wire.NewTxOut(49000, pkScript))
+	_, err = backend.Broadcast(ctx, original)
+	require.NoError(t, err)
+
+	originalHash := original.TxHash()
+	child := wire.NewMsgTx(2)
+	child.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&originalHash, 0), nil, nil))
+	child.AddTxOut(wire.NewTxOut(48000, pkScript))
+	_, err = backend.Broadcast(ctx, child)
+	require.NoError(t, err)
+
+	// the replacement evicts the original and its child
+	replacement := wire.NewMsgTx(2)
+	replacement.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&fundingHash, 0), nil, nil))
+	replacement.AddTxOut(wire.NewTxOut(45000, pkScript))
+	_, err = backend.Broadcast(ctx, replacement)
+	require.NoError(t, err)
+
+	mempool := backend.Mempool()
+	require.Len(t, mempool, 1)
+	assert.Equal(t, replacement.TxHash(), mempool[0].TxHash())
+
+	utxos, err := backend.GetUtxos(ctx, []string{script})
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Equal(t, replacement.TxHash().String(), utxos[0].TxID)
+
+	// broadcasting it again changes nothing
+	_, err = backend.Broadcast(ctx, replacement)
+	require.NoError(t, err)
+	assert.Len(t, backend.Mempool(), 1)
+}
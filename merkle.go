@@ -0,0 +1,128 @@
+package bitcoin
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MerkleProof proves the inclusion of a transaction in a block: the sibling hashes on the path from the
+// transaction to the merkle root. It matches the format of Electrum's blockchain.transaction.get_merkle
+type MerkleProof struct {
+	TxID   string   `json:"txid"`
+	Index  uint32   `json:"pos"`    // position of the transaction in the block
+	Hashes []string `json:"merkle"` // siblings from the transaction up, as hex strings in txid order
+}
+
+// BuildMerkleProof builds the merkle proof of a transaction of a block by txid
+func BuildMerkleProof(block *wire.MsgBlock, txID string) (*MerkleProof, error) {
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, err
+	}
+
+	level := make([]chainhash.Hash, 0, len(block.Transactions))
+	index := -1
+	for i, tx := range block.Transactions {
+		hash := tx.TxHash()
+		if hash == *txHash {
+			index = i
+		}
+		level = append(level, hash)
+	}
+
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %s is not in block %s", ErrTransactionNotFound, txID, block.BlockHash())
+	}
+
+	proof := &MerkleProof{TxID: txID, Index: uint32(index)}
+	for position := index; len(level) > 1; position /= 2 {
+
+		// an odd level pairs its last hash with itself
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		proof.Hashes = append(proof.Hashes, level[position^1].String())
+
+		next := make([]chainhash.Hash, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, merkleParent(&level[i], &level[i+1]))
+		}
+		level = next
+	}
+
+	return proof, nil
+}
+
+// Root returns the merkle root the proof leads to
+func (p *MerkleProof) Root() (*chainhash.Hash, error) {
+	hash, err := chainhash.NewHashFromStr(p.TxID)
+	if err != nil {
+		return nil, err
+	}
+
+	// an index beyond the depth of the proof would be accepted for several positions
+	if len(p.Hashes) < 32 && p.Index>>len(p.Hashes) != 0 {
+		return nil, fmt.Errorf("%w: index %d out of range", ErrInvalidMerkleProof, p.Index)
+	}
+
+	root := *hash
+	for i, sibling := range p.Hashes {
+		siblingHash, err := chainhash.NewHashFromStr(sibling)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.Index>>i&1 == 0 {
+			root = merkleParent(&root, siblingHash)
+		} else {
+			root = merkleParent(siblingHash, &root)
+		}
+	}
+
+	return &root, nil
+}
+
+// Verify checks that the proof leads to the merkle root of header, through the depth of the tree of a block of
+// txCount transactions. txCount must not come from the proof's source unchecked (e.g. use the depth of the
+// coinbase's proof): a shorter path reaching the root proves a 64 byte transaction that is an inner node of
+// the tree (CVE-2017-12842)
+func (p *MerkleProof) Verify(header *wire.BlockHeader, txCount uint32) error {
+	if p.Index >= txCount {
+		return fmt.Errorf("%w: index %d of %d transactions", ErrInvalidMerkleProof, p.Index, txCount)
+	}
+	if depth := merkleDepth(txCount); len(p.Hashes) != depth {
+		return fmt.Errorf("%w: %d hashes for a tree of depth %d", ErrInvalidMerkleProof, len(p.Hashes), depth)
+	}
+
+	root, err := p.Root()
+	if err != nil {
+		return err
+	}
+
+	if *root != header.MerkleRoot {
+		return fmt.Errorf("%w: leads to %s, header has %s", ErrInvalidMerkleProof, root, header.MerkleRoot)
+	}
+
+	return nil
+}
+
+// merkleDepth returns the depth of the merkle tree of txCount transactions
+func merkleDepth(txCount uint32) int {
+	depth := 0
+	for n := uint64(txCount); n > 1; n = (n + 1) / 2 {
+		depth++
+	}
+
+	return depth
+}
+
+// merkleParent returns the double SHA256 of two concatenated merkle tree nodes
+func merkleParent(left, right *chainhash.Hash) chainhash.Hash {
+	var pair [chainhash.HashSize * 2]byte
+	copy(pair[:chainhash.HashSize], left[:])
+	copy(pair[chainhash.HashSize:], right[:])
+
+	return chainhash.DoubleHashH(pair[:])
+}
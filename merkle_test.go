@@ -0,0 +1,98 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBlock returns a block with txCount distinct transactions and a matching merkle root
+func newTestBlock(txCount int) *wire.MsgBlock {
+	block := &wire.MsgBlock{}
+	for i := 0; i < txCount; i++ {
+		tx := wire.NewMsgTx(2)
+		tx.LockTime = uint32(i)
+		block.Transactions = append(block.Transactions, tx)
+	}
+	block.Header.MerkleRoot = blockchain.CalcMerkleRoot(btcutil.NewBlock(block).Transactions(), false)
+
+	return block
+}
+
+// TestMerkleProofInnerNode will test that MerkleProof.Verify() rejects an inner node of the tree proven as a
+// 64 byte transaction (CVE-2017-12842)
+func TestMerkleProofInnerNode(t *testing.T) {
+	t.Parallel()
+
+	block := newTestBlock(4)
+	hashes := make([]chainhash.Hash, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		hashes = append(hashes, tx.TxHash())
+	}
+	left, right := merkleParent(&hashes[0], &hashes[1]), merkleParent(&hashes[2], &hashes[3])
+
+	// the path of the inner node reaches the root
+	forged := &MerkleProof{TxID: left.String(), Index: 0, Hashes: []string{right.String()}}
+	root, err := forged.Root()
+	require.NoError(t, err)
+	assert.Equal(t, block.Header.MerkleRoot, *root)
+
+	assert.ErrorIs(t, forged.Verify(&block.Header, 4), ErrInvalidMerkleProof)
+	assert.ErrorIs(t, forged.Verify(&block.Header, 0), ErrInvalidMerkleProof)
+}
+
+// TestBuildMerkleProof will test the methods BuildMerkleProof() and MerkleProof.Verify()
+func TestBuildMerkleProof(t *testing.T) {
+	t.Parallel()
+
+	for txCount := 1; txCount <= 9; txCount++ {
+		block := newTestBlock(txCount)
+
+		for i, tx := range block.Transactions {
+			proof, err := BuildMerkleProof(block, tx.TxHash().String())
+			require.NoError(t, err)
+			assert.Equal(t, uint32(i), proof.Index)
+			require.NoError(t, proof.Verify(&block.Header, uint32(txCount)), "tx %d of %d", i, txCount)
+
+			// the proof does not hold for another position
+			if txCount > 1 {
+				wrong := *proof
+				wrong.Index = uint32((i + 1) % txCount)
+				assert.ErrorIs(t, wrong.Verify(&block.Header, uint32(txCount)), ErrInvalidMerkleProof)
+			}
+		}
+	}
+
+	block := newTestBlock(4)
+	proof, err := BuildMerkleProof(block, block.Transactions[2].TxHash().String())
+	require.NoError(t, err)
+	require.Len(t, proof.Hashes, 2)
+
+	// an index beyond the depth of the proof
+	wrong := *proof
+	wrong.Index = 6
+	assert.ErrorIs(t, wrong.Verify(&block.Header, 4), ErrInvalidMerkleProof)
+
+	wrong = *proof
+	wrong.Hashes = []string{proof.Hashes[0], "zz"}
+	assert.Error(t, wrong.Verify(&block.Header, 4))
+
+	// the depth of the tree is required
+	require.NoError(t, proof.Verify(&block.Header, 4))
+	assert.ErrorIs(t, proof.Verify(&block.Header, 5), ErrInvalidMerkleProof)
+	assert.ErrorIs(t, proof.Verify(&block.Header, 2), ErrInvalidMerkleProof)
+
+	// Electrum's get_merkle format
+	encoded, err := json.Marshal(proof)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"pos":2`)
+
+	_, err = BuildMerkleProof(block, "0000000000000000000000000000000000000000000000000000000000000000")
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+}
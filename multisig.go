@@ -0,0 +1,542 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// MaxMultisigSigners is the largest number of signers of a standard multisig script
+const MaxMultisigSigners = 15
+
+// MultisigScriptType is the script a multisig wallet pays to
+type MultisigScriptType string
+
+const (
+	MultisigP2SH      MultisigScriptType = "P2SH"
+	MultisigP2SHP2WSH MultisigScriptType = "P2SH-P2WSH"
+	MultisigP2WSH     MultisigScriptType = "P2WSH"
+)
+
+// Descriptor parts of multisig wallets, keys of BSMS descriptor templates range over both chains with /**
+const (
+	multisigKeySuffix   = "/**"
+	multisigMultiPrefix = "sortedmulti("
+)
+
+// multisigWrappers are the script expressions of the multisig script types
+var multisigWrappers = []struct {
+	prefix     string
+	suffix     string
+	scriptType MultisigScriptType
+}{
+	{"sh(wsh(", "))", MultisigP2SHP2WSH},
+	{"wsh(", ")", MultisigP2WSH},
+	{"sh(", ")", MultisigP2SH},
+}
+
+// MultisigSigner is a cosigner of a multisig wallet: its account extended public key and the origin of
+// that key, the fingerprint of its master key and the derivation path from it
+type MultisigSigner struct {
+	Fingerprint string // hex, 4 bytes
+	Path        string // e.g. 48h/0h/0h/2h
+	XPub        string
+	Description string // e.g. the name of the device, not part of the descriptor
+}
+
+// KeyExpression returns the descriptor key expression of the signer, e.g. [d34db33f/48h/0h/0h/2h]xpub...
+func (s *MultisigSigner) KeyExpression() string {
+	origin := s.Fingerprint
+	if len(s.Path) > 0 {
+		origin += "/" + s.Path
+	}
+
+	return "[" + origin + "]" + s.XPub
+}
+
+// ParseMultisigSigner parses a key expression with its key origin, e.g. [d34db33f/48'/0'/0'/2']xpub...
+func ParseMultisigSigner(key string, network NetworkType) (*MultisigSigner, error) {
+	if !strings.HasPrefix(key, "[") {
+		return nil, ErrMissingKeyOrigin
+	}
+	end := strings.Index(key, "]")
+	if end == -1 {
+		return nil, fmt.Errorf("%w: unterminated key origin", ErrInvalidDescriptor)
+	}
+
+	fingerprint, path, _ := strings.Cut(key[1:end], "/")
+	if decoded, err := hex.DecodeString(fingerprint); err != nil || len(decoded) != 4 {
+		return nil, fmt.Errorf("%w: fingerprint %s", ErrInvalidDescriptor, fingerprint)
+	}
+
+	indexes, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := &MultisigSigner{
+		Fingerprint: strings.ToLower(fingerprint),
+		Path:        formatDerivationPath(indexes),
+		XPub:        key[end+1:],
+	}
+	if _, err = signer.accountKey(network); err != nil {
+		return nil, err
+	}
+
+	return signer, nil
+}
+
+// DeriveMultisigSigner derives the account key at path (e.g. from MultisigDerivationPath) of a master key,
+// returning the signer to share with the coordinator and the private account key signing for it
+func DeriveMultisigSigner(masterKey *hdkeychain.ExtendedKey, path string) (*MultisigSigner, *hdkeychain.ExtendedKey, error) {
+	indexes, err := parseDerivationPath(strings.TrimPrefix(path, "m/"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	masterPubKey, err := masterKey.ECPubKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accountKey := masterKey
+	for _, index := range indexes {
+		if accountKey, err = accountKey.Derive(index); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	accountPubKey, err := accountKey.Neuter()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &MultisigSigner{
+		Fingerprint: hex.EncodeToString(btcutil.Hash160(masterPubKey.SerializeCompressed())[:4]),
+		Path:        formatDerivationPath(indexes),
+		XPub:        accountPubKey.String(),
+	}, accountKey, nil
+}
+
+// MultisigDerivationPath returns the derivation path of multisig account keys: m/48h/coin_type'/account'/script_type'
+// (BIP48) for segwit scripts, m/45h (BIP45) for P2SH
+func MultisigDerivationPath(network NetworkType, scriptType MultisigScriptType, account uint32) (string, error) {
+	coinType := 1
+	if network.Net == chaincfg.MainNetParams.Net {
+		coinType = 0
+	}
+
+	switch scriptType {
+	case MultisigP2SH:
+		return "m/45h", nil
+	case MultisigP2SHP2WSH:
+		return fmt.Sprintf("m/48h/%dh/%dh/1h", coinType, account), nil
+	case MultisigP2WSH:
+		return fmt.Sprintf("m/48h/%dh/%dh/2h", coinType, account), nil
+	default:
+		return "", ErrIncorrectAddressType
+	}
+}
+
+// accountKey parses the extended public key of the signer
+func (s *MultisigSigner) accountKey(network NetworkType) (*hdkeychain.ExtendedKey, error) {
+	key, err := hdkeychain.NewKeyFromString(s.XPub)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDescriptor, err)
+	}
+	if key.IsPrivate() {
+		return nil, fmt.Errorf("%w: private key of signer %s", ErrInvalidDescriptor, s.Fingerprint)
+	}
+	if !key.IsForNet(network) {
+		return nil, fmt.Errorf("%w: extended key", ErrWrongNetwork)
+	}
+
+	return key, nil
+}
+
+// Multisig is a sortedmulti wallet of threshold of its signers
+type Multisig struct {
+	Network    NetworkType
+	ScriptType MultisigScriptType
+	Threshold  int
+	Signers    []MultisigSigner
+}
+
+// ParseMultisigDescriptor parses a sortedmulti descriptor, or a descriptor template of BSMS, e.g.
+// wsh(sortedmulti(2,[d34db33f/48h/0h/0h/2h]xpub.../0/*,[...]xpub.../0/*)). Keys are all ranged over
+// the external chain (/0/*, the internal one being /1/*) or both chains (/<0;1>/* or /**), the
+// descriptor of the internal chain alone is rejected
+func ParseMultisigDescriptor(descriptor string, network NetworkType) (*Multisig, error) {
+	if strings.Contains(descriptor, "#") {
+		if _, err := AddDescriptorChecksum(descriptor); err != nil {
+			return nil, err
+		}
+		descriptor, _, _ = strings.Cut(descriptor, "#")
+	}
+
+	m := &Multisig{Network: network}
+	for _, wrapper := range multisigWrappers {
+		if strings.HasPrefix(descriptor, wrapper.prefix) && strings.HasSuffix(descriptor, wrapper.suffix) {
+			m.ScriptType = wrapper.scriptType
+			descriptor = strings.TrimSuffix(strings.TrimPrefix(descriptor, wrapper.prefix), wrapper.suffix)
+			break
+		}
+	}
+	if len(m.ScriptType) == 0 || !strings.HasPrefix(descriptor, multisigMultiPrefix) || !strings.HasSuffix(descriptor, ")") {
+		return nil, fmt.Errorf("%w: expected a sortedmulti descriptor", ErrInvalidDescriptor)
+	}
+
+	args := strings.Split(strings.TrimSuffix(strings.TrimPrefix(descriptor, multisigMultiPrefix), ")"), ",")
+	threshold, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: threshold %s", ErrInvalidDescriptor, args[0])
+	}
+	m.Threshold = threshold
+
+	var keySuffix string
+	for _, key := range args[1:] {
+		var suffix string
+		for _, allowed := range []string{multisigKeySuffix, "/<0;1>/*", "/0/*"} {
+			if strings.HasSuffix(key, allowed) {
+				suffix = allowed
+				break
+			}
+		}
+		switch {
+		case len(suffix) == 0:
+			return nil, fmt.Errorf("%w: expected keys ranged over /0/*, /<0;1>/* or /**", ErrInvalidDescriptor)
+		case len(keySuffix) > 0 && suffix != keySuffix:
+			return nil, fmt.Errorf("%w: keys ranged over %s and %s", ErrInvalidDescriptor, keySuffix, suffix)
+		}
+		keySuffix = suffix
+		key = strings.TrimSuffix(key, suffix)
+
+		signer, err := ParseMultisigSigner(key, network)
+		if err != nil {
+			return nil, err
+		}
+		m.Signers = append(m.Signers, *signer)
+	}
+
+	if err = m.validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Descriptors returns the descriptors of the external and internal chains, with their checksums
+func (m *Multisig) Descriptors() (string, string, error) {
+	external, err := AddDescriptorChecksum(m.descriptor("/0/*"))
+	if err != nil {
+		return "", "", err
+	}
+
+	internal, err := AddDescriptorChecksum(m.descriptor("/1/*"))
+	if err != nil {
+		return "", "", err
+	}
+
+	return external, internal, nil
+}
+
+// DescriptorTemplate returns the descriptor of both chains as a BSMS descriptor template (keys ranged over /**)
+func (m *Multisig) DescriptorTemplate() string {
+	return m.descriptor(multisigKeySuffix)
+}
+
+// Address returns the address at index of a chain
+func (m *Multisig) Address(chain KeyChain, index uint32) (string, error) {
+	if chain != ExternalChain && chain != InternalChain {
+		return "", ErrInvalidKeyChain
+	}
+
+	script, err := m.WitnessScript(chain, index)
+	if err != nil {
+		return "", err
+	}
+
+	var addr btcutil.Address
+	switch m.ScriptType {
+	case MultisigP2SH:
+		addr, err = btcutil.NewAddressScriptHash(script, m.Network)
+	case MultisigP2SHP2WSH:
+		scriptHash := sha256.Sum256(script)
+		addr, err = btcutil.NewAddressScriptHash(append([]byte{txscript.OP_0, txscript.OP_DATA_32}, scriptHash[:]...), m.Network)
+	case MultisigP2WSH:
+		scriptHash := sha256.Sum256(script)
+		addr, err = btcutil.NewAddressWitnessScriptHash(scriptHash[:], m.Network)
+	default:
+		return "", ErrIncorrectAddressType
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return addr.EncodeAddress(), nil
+}
+
+// FirstAddress returns the first receive address, which signers check on their devices before funding the wallet
+func (m *Multisig) FirstAddress() (string, error) {
+	return m.Address(ExternalChain, 0)
+}
+
+// WitnessScript returns the multisig script at index of a chain, the redeem script of P2SH
+func (m *Multisig) WitnessScript(chain KeyChain, index uint32) ([]byte, error) {
+	pubKeys := make([][]byte, 0, len(m.Signers))
+	for _, signer := range m.Signers {
+		key, err := signer.accountKey(m.Network)
+		if err != nil {
+			return nil, err
+		}
+
+		if key, err = key.Derive(uint32(chain)); err != nil {
+			return nil, err
+		}
+		if key, err = key.Derive(index); err != nil {
+			return nil, err
+		}
+
+		var pubKey *btcec.PublicKey
+		if pubKey, err = key.ECPubKey(); err != nil {
+			return nil, err
+		}
+		pubKeys = append(pubKeys, pubKey.SerializeCompressed())
+	}
+
+	// sortedmulti orders the keys lexicographically (BIP67)
+	sort.Slice(pubKeys, func(i, j int) bool {
+		return bytes.Compare(pubKeys[i], pubKeys[j]) < 0
+	})
+
+	builder := txscript.NewScriptBuilder().AddInt64(int64(m.Threshold))
+	for _, pubKey := range pubKeys {
+		builder.AddData(pubKey)
+	}
+
+	return builder.AddInt64(int64(len(pubKeys))).AddOp(txscript.OP_CHECKMULTISIG).Script()
+}
+
+// HasSigner returns whether signer is one of the signers
+func (m *Multisig) HasSigner(signer MultisigSigner) bool {
+	for _, s := range m.Signers {
+		if s.XPub == signer.XPub && s.Fingerprint == signer.Fingerprint && s.Path == signer.Path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// descriptor returns the descriptor with keys ranged over suffix
+func (m *Multisig) descriptor(suffix string) string {
+	keys := make([]string, 0, len(m.Signers))
+	for _, signer := range m.Signers {
+		keys = append(keys, signer.KeyExpression()+suffix)
+	}
+
+	for _, wrapper := range multisigWrappers {
+		if wrapper.scriptType == m.ScriptType {
+			return wrapper.prefix + multisigMultiPrefix + strconv.Itoa(m.Threshold) + "," + strings.Join(keys, ",") + ")" + wrapper.suffix
+		}
+	}
+
+	return ""
+}
+
+// validate checks the threshold and that no signer appears twice
+func (m *Multisig) validate() error {
+	if len(m.Signers) > MaxMultisigSigners {
+		return ErrTooManySigners
+	}
+	if m.Threshold < 1 || m.Threshold > len(m.Signers) {
+		return fmt.Errorf("%w: %d of %d", ErrInvalidMultisigThreshold, m.Threshold, len(m.Signers))
+	}
+
+	seen := make(map[string]bool, len(m.Signers))
+	for _, signer := range m.Signers {
+		if seen[signer.XPub] {
+			return fmt.Errorf("%w: %s", ErrDuplicateSigner, signer.KeyExpression())
+		}
+		seen[signer.XPub] = true
+	}
+
+	return nil
+}
+
+// MultisigConfig holds the settings of a MultisigCoordinator
+type MultisigConfig struct {
+	Network    NetworkType
+	ScriptType MultisigScriptType // defaults to MultisigP2WSH
+	Threshold  int                // signatures required
+	Signers    int                // signers of the wallet
+	Encryption BSMSEncryption     // of the BSMS records, defaults to BSMSStandardEncryption
+}
+
+// MultisigCoordinator sets up a multisig wallet: it collects the key of every signer, then produces
+// the descriptor and the first address for the signers to verify. Keys can be added directly, or
+// through the rounds of BIP129 Bitcoin Secure Multisig Setup: the coordinator hands a token to each
+// signer (NewToken), receives their signed key records (AddKeyRecord) and returns the descriptor
+// record (DescriptorRecord), encrypted with the token of each signer
+type MultisigCoordinator struct {
+	config MultisigConfig
+
+	mu      sync.Mutex
+	signers []MultisigSigner
+	tokens  []string // issued BSMS tokens
+	used    []bool   // tokens with an accepted key record
+}
+
+// NewMultisigCoordinator creates a coordinator of a threshold of signers multisig wallet
+func NewMultisigCoordinator(config MultisigConfig) (*MultisigCoordinator, error) {
+
+	// Missing network
+	if config.Network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	if len(config.ScriptType) == 0 {
+		config.ScriptType = MultisigP2WSH
+	}
+	if len(config.Encryption) == 0 {
+		config.Encryption = BSMSStandardEncryption
+	}
+
+	if config.Signers > MaxMultisigSigners {
+		return nil, ErrTooManySigners
+	}
+	if config.Threshold < 1 || config.Threshold > config.Signers {
+		return nil, fmt.Errorf("%w: %d of %d", ErrInvalidMultisigThreshold, config.Threshold, config.Signers)
+	}
+
+	if _, err := MultisigDerivationPath(config.Network, config.ScriptType, 0); err != nil {
+		return nil, err
+	}
+
+	return &MultisigCoordinator{config: config}, nil
+}
+
+// AddSigner adds the key of a signer, which needs its key origin
+func (c *MultisigCoordinator) AddSigner(signer MultisigSigner) error {
+	if len(signer.Fingerprint) == 0 {
+		return ErrMissingKeyOrigin
+	}
+
+	// normalizes the key origin
+	parsed, err := ParseMultisigSigner(signer.KeyExpression(), c.config.Network)
+	if err != nil {
+		return err
+	}
+	parsed.Description = signer.Description
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.signers) == c.config.Signers {
+		return ErrTooManySigners
+	}
+	for _, s := range c.signers {
+		if s.XPub == parsed.XPub {
+			return fmt.Errorf("%w: %s", ErrDuplicateSigner, parsed.KeyExpression())
+		}
+	}
+
+	c.signers = append(c.signers, *parsed)
+	return nil
+}
+
+// Signers returns the signers added so far
+func (c *MultisigCoordinator) Signers() []MultisigSigner {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]MultisigSigner(nil), c.signers...)
+}
+
+// Multisig returns the wallet once every signer was added
+func (c *MultisigCoordinator) Multisig() (*Multisig, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.signers) < c.config.Signers {
+		return nil, fmt.Errorf("%w: %d of %d signers", ErrIncompleteMultisig, len(c.signers), c.config.Signers)
+	}
+
+	return &Multisig{
+		Network:    c.config.Network,
+		ScriptType: c.config.ScriptType,
+		Threshold:  c.config.Threshold,
+		Signers:    append([]MultisigSigner(nil), c.signers...),
+	}, nil
+}
+
+// Descriptors returns the descriptors of the external and internal chains once every signer was added
+func (c *MultisigCoordinator) Descriptors() (string, string, error) {
+	m, err := c.Multisig()
+	if err != nil {
+		return "", "", err
+	}
+
+	return m.Descriptors()
+}
+
+// FirstAddress returns the first receive address once every signer was added
+func (c *MultisigCoordinator) FirstAddress() (string, error) {
+	m, err := c.Multisig()
+	if err != nil {
+		return "", err
+	}
+
+	return m.FirstAddress()
+}
+
+// formatDerivationPath formats derivation indexes like 48h/0h/0h/2h
+func formatDerivationPath(indexes []uint32) string {
+	parts := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		if index >= hdkeychain.HardenedKeyStart {
+			parts = append(parts, strconv.FormatUint(uint64(index-hdkeychain.HardenedKeyStart), 10)+"h")
+		} else {
+			parts = append(parts, strconv.FormatUint(uint64(index), 10))
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// parseDerivationPath parses derivation indexes like 48h/0h/0h/2h or 48'/0'/0'/2'
+func parseDerivationPath(path string) ([]uint32, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	var indexes []uint32
+	for _, part := range strings.Split(path, "/") {
+		hardened := strings.HasSuffix(part, "h") || strings.HasSuffix(part, "'")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("%w: derivation path %s", ErrInvalidDescriptor, path)
+		}
+		if hardened {
+			index += hdkeychain.HardenedKeyStart
+		}
+		indexes = append(indexes, uint32(index))
+	}
+
+	return indexes, nil
+}
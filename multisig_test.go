@@ -0,0 +1,220 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMultisigSigners derives count P2WSH signers of test master keys, with their private account keys
+func testMultisigSigners(t *testing.T, network NetworkType, count int) ([]MultisigSigner, []*hdkeychain.ExtendedKey) {
+	path, err := MultisigDerivationPath(network, MultisigP2WSH, 0)
+	require.NoError(t, err)
+
+	var signers []MultisigSigner
+	var accountKeys []*hdkeychain.ExtendedKey
+	for i := 0; i < count; i++ {
+		masterKey, err := hdkeychain.NewMaster(bytes.Repeat([]byte{byte(i + 1)}, 32), network)
+		require.NoError(t, err)
+
+		signer, accountKey, err := DeriveMultisigSigner(masterKey, path)
+		require.NoError(t, err)
+		signers = append(signers, *signer)
+		accountKeys = append(accountKeys, accountKey)
+	}
+
+	return signers, accountKeys
+}
+
+// TestMultisigDerivationPath will test the method MultisigDerivationPath()
+func TestMultisigDerivationPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		network    NetworkType
+		scriptType MultisigScriptType
+		account    uint32
+		path       string
+	}{
+		{Mainnet, MultisigP2WSH, 0, "m/48h/0h/0h/2h"},
+		{Mainnet, MultisigP2SHP2WSH, 3, "m/48h/0h/3h/1h"},
+		{Testnet, MultisigP2WSH, 1, "m/48h/1h/1h/2h"},
+		{Testnet, MultisigP2SH, 0, "m/45h"},
+	}
+	for _, test := range tests {
+		path, err := MultisigDerivationPath(test.network, test.scriptType, test.account)
+		require.NoError(t, err)
+		assert.Equal(t, test.path, path)
+	}
+
+	_, err := MultisigDerivationPath(Mainnet, "P2TR", 0)
+	assert.ErrorIs(t, err, ErrIncorrectAddressType)
+}
+
+// TestParseMultisigSigner will test the method ParseMultisigSigner()
+func TestParseMultisigSigner(t *testing.T) {
+	t.Parallel()
+
+	signers, _ := testMultisigSigners(t, Mainnet, 1)
+	signer := signers[0]
+	assert.Len(t, signer.Fingerprint, 8)
+	assert.Equal(t, "48h/0h/0h/2h", signer.Path)
+	assert.True(t, strings.HasPrefix(signer.XPub, "xpub"))
+
+	// apostrophes are normalized
+	key := "[" + strings.ToUpper(signer.Fingerprint) + "/48'/0'/0'/2']" + signer.XPub
+	parsed, err := ParseMultisigSigner(key, Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, signer, *parsed)
+	assert.Equal(t, "["+signer.Fingerprint+"/48h/0h/0h/2h]"+signer.XPub, parsed.KeyExpression())
+
+	_, err = ParseMultisigSigner(signer.XPub, Mainnet)
+	assert.ErrorIs(t, err, ErrMissingKeyOrigin)
+	_, err = ParseMultisigSigner("[d34db3/48h]"+signer.XPub, Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidDescriptor)
+	_, err = ParseMultisigSigner("[d34db33f/48x]"+signer.XPub, Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidDescriptor)
+	_, err = ParseMultisigSigner(key, Testnet)
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+
+	// private keys are never shared
+	masterKey, err := hdkeychain.NewMaster(bytes.Repeat([]byte{1}, 32), Mainnet)
+	require.NoError(t, err)
+	_, err = ParseMultisigSigner("[d34db33f]"+masterKey.String(), Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidDescriptor)
+}
+
+// TestNewMultisigCoordinator will test the method NewMultisigCoordinator()
+func TestNewMultisigCoordinator(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewMultisigCoordinator(MultisigConfig{Threshold: 2, Signers: 3})
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+
+	_, err = NewMultisigCoordinator(MultisigConfig{Network: Mainnet, Threshold: 4, Signers: 3})
+	assert.ErrorIs(t, err, ErrInvalidMultisigThreshold)
+	_, err = NewMultisigCoordinator(MultisigConfig{Network: Mainnet, Threshold: 0, Signers: 3})
+	assert.ErrorIs(t, err, ErrInvalidMultisigThreshold)
+	_, err = NewMultisigCoordinator(MultisigConfig{Network: Mainnet, Threshold: 2, Signers: 16})
+	assert.ErrorIs(t, err, ErrTooManySigners)
+	_, err = NewMultisigCoordinator(MultisigConfig{Network: Mainnet, Threshold: 2, Signers: 3, ScriptType: "P2TR"})
+	assert.ErrorIs(t, err, ErrIncorrectAddressType)
+
+	coordinator, err := NewMultisigCoordinator(MultisigConfig{Network: Mainnet, Threshold: 2, Signers: 3})
+	require.NoError(t, err)
+	assert.Equal(t, MultisigP2WSH, coordinator.config.ScriptType)
+	assert.Equal(t, BSMSStandardEncryption, coordinator.config.Encryption)
+}
+
+// TestMultisigCoordinatorAddSigner will test the method AddSigner()
+func TestMultisigCoordinatorAddSigner(t *testing.T) {
+	t.Parallel()
+
+	signers, _ := testMultisigSigners(t, Mainnet, 3)
+	coordinator, err := NewMultisigCoordinator(MultisigConfig{Network: Mainnet, Threshold: 2, Signers: 2})
+	require.NoError(t, err)
+
+	_, err = coordinator.FirstAddress()
+	assert.ErrorIs(t, err, ErrIncompleteMultisig)
+
+	assert.ErrorIs(t, coordinator.AddSigner(MultisigSigner{XPub: signers[0].XPub}), ErrMissingKeyOrigin)
+	require.NoError(t, coordinator.AddSigner(signers[0]))
+	assert.ErrorIs(t, coordinator.AddSigner(signers[0]), ErrDuplicateSigner)
+	require.NoError(t, coordinator.AddSigner(signers[1]))
+	assert.ErrorIs(t, coordinator.AddSigner(signers[2]), ErrTooManySigners)
+	assert.Equal(t, signers[:2], coordinator.Signers())
+
+	_, err = coordinator.FirstAddress()
+	require.NoError(t, err)
+}
+
+// TestMultisigDescriptors will test the methods Descriptors() and DescriptorTemplate()
+func TestMultisigDescriptors(t *testing.T) {
+	t.Parallel()
+
+	signers, _ := testMultisigSigners(t, Mainnet, 3)
+	m := &Multisig{Network: Mainnet, ScriptType: MultisigP2WSH, Threshold: 2, Signers: signers}
+
+	external, internal, err := m.Descriptors()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(external, "wsh(sortedmulti(2,["+signers[0].Fingerprint+"/48h/0h/0h/2h]"+signers[0].XPub+"/0/*,"))
+	assert.Contains(t, internal, signers[2].XPub+"/1/*))#")
+
+	template := m.DescriptorTemplate()
+	assert.Equal(t, 3, strings.Count(template, "/**"))
+
+	multipath := strings.ReplaceAll(template, "/**", "/<0;1>/*")
+
+	// every form describing both chains parses back to the same wallet
+	for _, descriptor := range []string{external, template, multipath} {
+		parsed, err := ParseMultisigDescriptor(descriptor, Mainnet)
+		require.NoError(t, err)
+		assert.Equal(t, m, parsed)
+	}
+
+	_, err = ParseMultisigDescriptor(strings.Replace(external, "sortedmulti(2", "sortedmulti(4", 1), Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidDescriptorChecksum)
+	_, err = ParseMultisigDescriptor(strings.Replace(template, "sortedmulti(2", "sortedmulti(4", 1), Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidMultisigThreshold)
+	_, err = ParseMultisigDescriptor(strings.Replace(template, "sortedmulti(", "multi(", 1), Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidDescriptor)
+	_, err = ParseMultisigDescriptor(strings.Replace(template, signers[1].XPub, signers[0].XPub, 1), Mainnet)
+	assert.ErrorIs(t, err, ErrDuplicateSigner)
+	_, err = ParseMultisigDescriptor(strings.Replace(template, "/**", "", 1), Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidDescriptor)
+
+	// the internal chain alone, and keys ranged over different chains, are rejected
+	_, err = ParseMultisigDescriptor(internal, Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidDescriptor)
+	_, err = ParseMultisigDescriptor(strings.Replace(template, "/**", "/0/*", 1), Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidDescriptor)
+	_, err = ParseMultisigDescriptor(strings.Replace(template, "/**", "/1/*", 1), Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidDescriptor)
+}
+
+// TestMultisigAddress will test the method Address()
+func TestMultisigAddress(t *testing.T) {
+	t.Parallel()
+
+	signers, _ := testMultisigSigners(t, Mainnet, 3)
+	m := &Multisig{Network: Mainnet, ScriptType: MultisigP2WSH, Threshold: 2, Signers: signers}
+
+	script, err := m.WitnessScript(ExternalChain, 0)
+	require.NoError(t, err)
+	info, err := GetScriptInfo(hex.EncodeToString(script), Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, "multisig", info.Type)
+	assert.Equal(t, 2, info.RequiredSigs)
+
+	first, err := m.FirstAddress()
+	require.NoError(t, err)
+	expected, err := GetWitnessScriptAddress(hex.EncodeToString(script), Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, expected, first)
+	assert.True(t, strings.HasPrefix(first, "bc1q"))
+
+	// sorted keys: the order of the signers does not matter
+	reordered := &Multisig{Network: Mainnet, ScriptType: MultisigP2WSH, Threshold: 2, Signers: []MultisigSigner{signers[2], signers[0], signers[1]}}
+	address, err := reordered.FirstAddress()
+	require.NoError(t, err)
+	assert.Equal(t, first, address)
+
+	change, err := m.Address(InternalChain, 0)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, change)
+	_, err = m.Address(KeyChain(2), 0)
+	assert.ErrorIs(t, err, ErrInvalidKeyChain)
+
+	for scriptType, prefix := range map[MultisigScriptType]string{MultisigP2SH: "3", MultisigP2SHP2WSH: "3"} {
+		m := &Multisig{Network: Mainnet, ScriptType: scriptType, Threshold: 2, Signers: signers}
+		address, err := m.FirstAddress()
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(address, prefix))
+		assert.NotEqual(t, first, address)
+	}
+}
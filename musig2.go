@@ -0,0 +1,412 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// BIP327 tagged hash tags
+var (
+	muSig2KeyAggListTag = []byte("KeyAgg list")
+	muSig2KeyAggCoefTag = []byte("KeyAgg coefficient")
+	muSig2AuxTag        = []byte("MuSig/aux")
+	muSig2NonceTag      = []byte("MuSig/nonce")
+	muSig2NonceCoefTag  = []byte("MuSig/noncecoef")
+	bip340ChallengeTag  = []byte("BIP0340/challenge")
+)
+
+// Sizes of the BIP327 nonces and partial signatures
+const (
+	muSig2PubNonceSize   = 2 * btcec.PubKeyBytesLenCompressed
+	muSig2SecNonceSize   = 2*32 + btcec.PubKeyBytesLenCompressed
+	muSig2PartialSigSize = 32
+)
+
+// MuSig2PubNonce is the public nonce a signer shares before signing (66 bytes)
+type MuSig2PubNonce [muSig2PubNonceSize]byte
+
+// MuSig2SecNonce is the secret nonce a signer keeps until it signs, with its public key (97 bytes).
+// Sign erases it: a nonce must never sign twice
+type MuSig2SecNonce [muSig2SecNonceSize]byte
+
+// MuSig2AggNonce is the aggregate of the public nonces of every signer (66 bytes)
+type MuSig2AggNonce [muSig2PubNonceSize]byte
+
+// MuSig2PartialSig is the partial signature of a signer (32 bytes)
+type MuSig2PartialSig [muSig2PartialSigSize]byte
+
+// MuSig2KeyAgg is a MuSig2 (BIP327) aggregate public key of several signers and the tweaks applied to it
+type MuSig2KeyAgg struct {
+	pubKeys [][]byte // compressed, in the order they were aggregated
+	q       btcec.JacobianPoint
+	gacc    btcec.ModNScalar
+	tacc    btcec.ModNScalar
+}
+
+// MuSig2SortKeys sorts public keys by their compressed serialization (KeySort), for an aggregate key that
+// does not depend on the order signers joined in
+func MuSig2SortKeys(pubKeys []*btcec.PublicKey) []*btcec.PublicKey {
+	sorted := append([]*btcec.PublicKey(nil), pubKeys...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].SerializeCompressed(), sorted[j].SerializeCompressed()) < 0
+	})
+
+	return sorted
+}
+
+// MuSig2AggregateKeys aggregates the public keys of the signers (KeyAgg), in the given order. The aggregate
+// key spends like a single key, e.g. as the taproot key of GetAddressFromPubKey
+func MuSig2AggregateKeys(pubKeys []*btcec.PublicKey) (*MuSig2KeyAgg, error) {
+	if len(pubKeys) == 0 {
+		return nil, ErrMissingPubKey
+	}
+
+	k := &MuSig2KeyAgg{}
+	for _, pubKey := range pubKeys {
+		if !IsValidPublicKey(pubKey) {
+			return nil, ErrInvalidPubKey
+		}
+		k.pubKeys = append(k.pubKeys, pubKey.SerializeCompressed())
+	}
+
+	q := &btcec.JacobianPoint{}
+	for _, pubKey := range k.pubKeys {
+		point, err := parsePoint(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		q = addPoints(q, scalarMult(k.coefficient(pubKey), point))
+	}
+	if isInfinity(q) {
+		return nil, ErrInvalidPubKey
+	}
+	q.ToAffine()
+
+	k.q = *q
+	k.gacc.SetInt(1)
+	return k, nil
+}
+
+// PubKey returns the aggregate public key, tweaked by the tweaks applied
+func (k *MuSig2KeyAgg) PubKey() *btcec.PublicKey {
+	return pointPubKey(&k.q)
+}
+
+// XOnlyPubKey returns the x-only (BIP340) aggregate public key that signatures verify against
+func (k *MuSig2KeyAgg) XOnlyPubKey() []byte {
+	return pointXBytes(&k.q)
+}
+
+// ApplyTweak returns the aggregate key tweaked by adding tweak·G, as a plain (BIP32) or an x-only (BIP341) tweak
+func (k *MuSig2KeyAgg) ApplyTweak(tweak []byte, xOnly bool) (*MuSig2KeyAgg, error) {
+	t, ok := parseScalar(tweak)
+	if !ok {
+		return nil, ErrInvalidTweak
+	}
+
+	var g btcec.ModNScalar
+	g.SetInt(1)
+	if xOnly && !hasEvenY(&k.q) {
+		g.Negate()
+	}
+
+	q := addPoints(scalarMult(&g, &k.q), scalarBaseMult(t))
+	if isInfinity(q) {
+		return nil, ErrInvalidTweak
+	}
+	q.ToAffine()
+
+	tweaked := &MuSig2KeyAgg{pubKeys: k.pubKeys, q: *q}
+	tweaked.gacc.Mul2(&g, &k.gacc)
+	tweaked.tacc.Mul2(&g, &k.tacc).Add(t)
+	return tweaked, nil
+}
+
+// TaprootTweak returns the aggregate key tweaked into the output key of a taproot output with the aggregate key
+// as internal key (BIP341), committing to a script tree of merkleRoot. Without a merkle root, the output key is
+// the one of GetAddressFromPubKey(k.PubKey(), Taproot, ...)
+func (k *MuSig2KeyAgg) TaprootTweak(merkleRoot []byte) (*MuSig2KeyAgg, error) {
+	tweak := chainhash.TaggedHash(chainhash.TagTapTweak, k.XOnlyPubKey(), merkleRoot)
+	return k.ApplyTweak(tweak[:], true)
+}
+
+// coefficient returns the key aggregation coefficient of a public key (KeyAggCoeff)
+func (k *MuSig2KeyAgg) coefficient(pubKey []byte) *btcec.ModNScalar {
+
+	// the second distinct key gets coefficient 1, saving a multiplication
+	for _, other := range k.pubKeys[1:] {
+		if !bytes.Equal(other, k.pubKeys[0]) {
+			if bytes.Equal(pubKey, other) {
+				var one btcec.ModNScalar
+				one.SetInt(1)
+				return &one
+			}
+			break
+		}
+	}
+
+	list := chainhash.TaggedHash(muSig2KeyAggListTag, k.pubKeys...)
+	return hashScalar(chainhash.TaggedHash(muSig2KeyAggCoefTag, list[:], pubKey)[:])
+}
+
+// hasPubKey returns whether a public key is one of the aggregated keys
+func (k *MuSig2KeyAgg) hasPubKey(pubKey []byte) bool {
+	for _, key := range k.pubKeys {
+		if bytes.Equal(key, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// MuSig2NonceOptions are the optional inputs of nonce generation, each one making a nonce more robust to a
+// weak random number generator
+type MuSig2NonceOptions struct {
+	PrivateKey *btcec.PrivateKey
+	AggPubKey  []byte // x-only aggregate public key
+	Message    []byte
+	Extra      []byte
+}
+
+// MuSig2NonceGen generates the nonces of the signer of pubKey for a signing session (NonceGen). The secret
+// nonce stays with the signer, the public nonce goes to the other signers
+func MuSig2NonceGen(pubKey *btcec.PublicKey, options MuSig2NonceOptions) (*MuSig2SecNonce, *MuSig2PubNonce, error) {
+	var random [32]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, nil, err
+	}
+
+	return muSig2NonceGen(random, pubKey, options)
+}
+
+// muSig2NonceGen generates nonces from random bytes
+func muSig2NonceGen(random [32]byte, pubKey *btcec.PublicKey, options MuSig2NonceOptions) (*MuSig2SecNonce, *MuSig2PubNonce, error) {
+	if !IsValidPublicKey(pubKey) {
+		return nil, nil, ErrInvalidPubKey
+	}
+	if len(options.AggPubKey) != 0 && len(options.AggPubKey) != 32 {
+		return nil, nil, ErrInvalidPubKey
+	}
+
+	seed := random[:]
+	if options.PrivateKey != nil {
+		aux := chainhash.TaggedHash(muSig2AuxTag, random[:])
+		privateKey := options.PrivateKey.Key.Bytes()
+		seed = make([]byte, 32)
+		for i := range seed {
+			seed[i] = privateKey[i] ^ aux[i]
+		}
+	}
+
+	pk := pubKey.SerializeCompressed()
+	var prefix bytes.Buffer
+	prefix.Write(seed)
+	prefix.WriteByte(byte(len(pk)))
+	prefix.Write(pk)
+	prefix.WriteByte(byte(len(options.AggPubKey)))
+	prefix.Write(options.AggPubKey)
+	if options.Message == nil {
+		prefix.WriteByte(0)
+	} else {
+		prefix.WriteByte(1)
+		_ = binary.Write(&prefix, binary.BigEndian, uint64(len(options.Message)))
+		prefix.Write(options.Message)
+	}
+	_ = binary.Write(&prefix, binary.BigEndian, uint32(len(options.Extra)))
+	prefix.Write(options.Extra)
+
+	secNonce, pubNonce := &MuSig2SecNonce{}, &MuSig2PubNonce{}
+	for i := 0; i < 2; i++ {
+		k := hashScalar(chainhash.TaggedHash(muSig2NonceTag, prefix.Bytes(), []byte{byte(i)})[:])
+		if k.IsZero() {
+			return nil, nil, ErrInvalidNonce
+		}
+
+		copy(secNonce[32*i:], scalarBytes(k))
+		copy(pubNonce[btcec.PubKeyBytesLenCompressed*i:], pointBytes(scalarBaseMult(k)))
+	}
+	copy(secNonce[64:], pk)
+
+	return secNonce, pubNonce, nil
+}
+
+// MuSig2AggregateNonces aggregates the public nonces of every signer (NonceAgg)
+func MuSig2AggregateNonces(pubNonces []MuSig2PubNonce) (*MuSig2AggNonce, error) {
+	aggNonce := &MuSig2AggNonce{}
+	for j := 0; j < 2; j++ {
+		sum := &btcec.JacobianPoint{}
+		for i, pubNonce := range pubNonces {
+			point, err := parsePoint(pubNonce[btcec.PubKeyBytesLenCompressed*j : btcec.PubKeyBytesLenCompressed*(j+1)])
+			if err != nil {
+				return nil, fmt.Errorf("%w: signer %d", ErrInvalidNonce, i)
+			}
+			sum = addPoints(sum, point)
+		}
+		copy(aggNonce[btcec.PubKeyBytesLenCompressed*j:], pointBytes(sum))
+	}
+
+	return aggNonce, nil
+}
+
+// MuSig2Session is the signing of a message by the signers of an aggregate key, once their nonces were aggregated
+type MuSig2Session struct {
+	keyAgg *MuSig2KeyAgg
+	b      btcec.ModNScalar    // nonce coefficient
+	r      btcec.JacobianPoint // final nonce
+	e      btcec.ModNScalar    // challenge
+}
+
+// NewMuSig2Session starts the signing of a message (e.g. a taproot sighash) with the aggregate key, tweaked like
+// the key the signature must verify against
+func NewMuSig2Session(keyAgg *MuSig2KeyAgg, aggNonce *MuSig2AggNonce, message []byte) (*MuSig2Session, error) {
+	r1, err := parsePointExt(aggNonce[:btcec.PubKeyBytesLenCompressed])
+	if err != nil {
+		return nil, ErrInvalidNonce
+	}
+	r2, err := parsePointExt(aggNonce[btcec.PubKeyBytesLenCompressed:])
+	if err != nil {
+		return nil, ErrInvalidNonce
+	}
+
+	s := &MuSig2Session{keyAgg: keyAgg}
+	s.b.Set(hashScalar(chainhash.TaggedHash(muSig2NonceCoefTag, aggNonce[:], keyAgg.XOnlyPubKey(), message)[:]))
+
+	r := addPoints(r1, scalarMult(&s.b, r2))
+	if isInfinity(r) {
+		// only when the nonces of the signers cancel out, with the same outcome for every signer
+		var one btcec.ModNScalar
+		one.SetInt(1)
+		r = scalarBaseMult(&one)
+	}
+	r.ToAffine()
+	s.r = *r
+
+	s.e.Set(hashScalar(chainhash.TaggedHash(bip340ChallengeTag, pointXBytes(&s.r), keyAgg.XOnlyPubKey(), message)[:]))
+	return s, nil
+}
+
+// Sign returns the partial signature of the signer of privateKey, using and erasing its secret nonce
+func (s *MuSig2Session) Sign(secNonce *MuSig2SecNonce, privateKey *btcec.PrivateKey) (*MuSig2PartialSig, error) {
+	k1, ok1 := parseScalar(secNonce[:32])
+	k2, ok2 := parseScalar(secNonce[32:64])
+	if !ok1 || !ok2 || k1.IsZero() || k2.IsZero() {
+		return nil, ErrInvalidNonce
+	}
+
+	pubKey := privateKey.PubKey().SerializeCompressed()
+	if !bytes.Equal(pubKey, secNonce[64:]) {
+		return nil, fmt.Errorf("%w: nonce of another key", ErrInvalidNonce)
+	}
+	if !s.keyAgg.hasPubKey(pubKey) {
+		return nil, ErrUnknownSigner
+	}
+
+	// the nonce is spent, even if signing fails from here
+	pubNonce := &MuSig2PubNonce{}
+	copy(pubNonce[:], pointBytes(scalarBaseMult(k1)))
+	copy(pubNonce[btcec.PubKeyBytesLenCompressed:], pointBytes(scalarBaseMult(k2)))
+	for i := range secNonce[:64] {
+		secNonce[i] = 0
+	}
+
+	if !hasEvenY(&s.r) {
+		k1.Negate()
+		k2.Negate()
+	}
+
+	// s = k1 + b·k2 + e·a·d
+	var d, sig btcec.ModNScalar
+	d.Mul2(s.signingKeyFactor(pubKey), &privateKey.Key)
+	sig.Mul2(&s.b, k2).Add(k1).Add(d.Mul(&s.e))
+
+	partialSig := &MuSig2PartialSig{}
+	copy(partialSig[:], scalarBytes(&sig))
+
+	if err := s.VerifyPartialSig(partialSig, pubNonce, privateKey.PubKey()); err != nil {
+		return nil, err
+	}
+
+	return partialSig, nil
+}
+
+// VerifyPartialSig verifies the partial signature of a signer, from its public nonce and public key
+func (s *MuSig2Session) VerifyPartialSig(partialSig *MuSig2PartialSig, pubNonce *MuSig2PubNonce, pubKey *btcec.PublicKey) error {
+	sig, ok := parseScalar(partialSig[:])
+	if !ok {
+		return ErrInvalidPartialSignature
+	}
+
+	pk := pubKey.SerializeCompressed()
+	if !s.keyAgg.hasPubKey(pk) {
+		return ErrUnknownSigner
+	}
+
+	r1, err := parsePoint(pubNonce[:btcec.PubKeyBytesLenCompressed])
+	if err != nil {
+		return ErrInvalidNonce
+	}
+	r2, err := parsePoint(pubNonce[btcec.PubKeyBytesLenCompressed:])
+	if err != nil {
+		return ErrInvalidNonce
+	}
+
+	re := addPoints(r1, scalarMult(&s.b, r2))
+	if !hasEvenY(&s.r) {
+		negatePoint(re)
+	}
+
+	// s·G == Re + e·a·g·gacc·P
+	point, err := parsePoint(pk)
+	if err != nil {
+		return err
+	}
+	var factor btcec.ModNScalar
+	factor.Mul2(&s.e, s.signingKeyFactor(pk))
+
+	if !equalPoints(scalarBaseMult(sig), addPoints(re, scalarMult(&factor, point))) {
+		return ErrInvalidPartialSignature
+	}
+
+	return nil
+}
+
+// AggregatePartialSigs combines the partial signatures of every signer into the BIP340 signature of the message
+// for the aggregate key
+func (s *MuSig2Session) AggregatePartialSigs(partialSigs []MuSig2PartialSig) (*schnorr.Signature, error) {
+	var sum btcec.ModNScalar
+	for i := range partialSigs {
+		sig, ok := parseScalar(partialSigs[i][:])
+		if !ok {
+			return nil, fmt.Errorf("%w: signer %d", ErrInvalidPartialSignature, i)
+		}
+		sum.Add(sig)
+	}
+
+	// s + e·g·tacc
+	var tweak btcec.ModNScalar
+	tweak.Mul2(&s.e, &s.keyAgg.tacc)
+	if !hasEvenY(&s.keyAgg.q) {
+		tweak.Negate()
+	}
+	sum.Add(&tweak)
+
+	r := s.r
+	return schnorr.NewSignature(&r.X, &sum), nil
+}
+
+// signingKeyFactor returns a·g·gacc, what the private key of pubKey is multiplied by when signing
+func (s *MuSig2Session) signingKeyFactor(pubKey []byte) *btcec.ModNScalar {
+	var factor btcec.ModNScalar
+	factor.Mul2(s.keyAgg.coefficient(pubKey), &s.keyAgg.gacc)
+	if !hasEvenY(&s.keyAgg.q) {
+		factor.Negate()
+	}
+	return &factor
+}
@@ -0,0 +1,288 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMuSig2Keys returns count private keys
+func testMuSig2Keys(count int) ([]*btcec.PrivateKey, []*btcec.PublicKey) {
+	var privateKeys []*btcec.PrivateKey
+	var pubKeys []*btcec.PublicKey
+	for i := 0; i < count; i++ {
+		privateKey, pubKey := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{byte(i + 7)}, 32))
+		privateKeys = append(privateKeys, privateKey)
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return privateKeys, pubKeys
+}
+
+// muSig2Sign runs a signing session of message by every key and returns the aggregate signature
+func muSig2Sign(t *testing.T, keyAgg *MuSig2KeyAgg, privateKeys []*btcec.PrivateKey, message []byte) *schnorr.Signature {
+	var secNonces []*MuSig2SecNonce
+	var pubNonces []MuSig2PubNonce
+	for _, privateKey := range privateKeys {
+		secNonce, pubNonce, err := MuSig2NonceGen(privateKey.PubKey(), MuSig2NonceOptions{
+			PrivateKey: privateKey,
+			AggPubKey:  keyAgg.XOnlyPubKey(),
+			Message:    message,
+		})
+		require.NoError(t, err)
+		secNonces = append(secNonces, secNonce)
+		pubNonces = append(pubNonces, *pubNonce)
+	}
+
+	aggNonce, err := MuSig2AggregateNonces(pubNonces)
+	require.NoError(t, err)
+
+	var partialSigs []MuSig2PartialSig
+	for i, privateKey := range privateKeys {
+		session, err := NewMuSig2Session(keyAgg, aggNonce, message)
+		require.NoError(t, err)
+
+		partialSig, err := session.Sign(secNonces[i], privateKey)
+		require.NoError(t, err)
+		require.NoError(t, session.VerifyPartialSig(partialSig, &pubNonces[i], privateKey.PubKey()))
+		partialSigs = append(partialSigs, *partialSig)
+	}
+
+	session, err := NewMuSig2Session(keyAgg, aggNonce, message)
+	require.NoError(t, err)
+	signature, err := session.AggregatePartialSigs(partialSigs)
+	require.NoError(t, err)
+
+	return signature
+}
+
+// TestMuSig2AggregateKeys will test the method MuSig2AggregateKeys() with the BIP327 key aggregation vectors
+func TestMuSig2AggregateKeys(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{
+		"02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+		"03dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+		"023590a94e768f8e1815c2f24b4d80a8e3149316c3518ce7b7ad338368d038ca66",
+	}
+	tests := []struct {
+		indexes  []int
+		expected string
+	}{
+		{[]int{0, 1, 2}, "90539eede565f5d054f32cc0c220126889ed1e5d193baf15aef344fe59d4610c"},
+		{[]int{2, 1, 0}, "6204de8b083426dc6eaf9502d27024d53fc826bf7d2012148a0575435df54b2b"},
+		{[]int{0, 0, 0}, "b436e3bad62b8cd409969a224731c193d051162d8c5ae8b109306127da3aa935"},
+		{[]int{0, 0, 1, 1}, "69bc22bfa5d106306e48a20679de1d7389386124d07571d0d872686028c26a3e"},
+	}
+	for _, test := range tests {
+		var pubKeys []*btcec.PublicKey
+		for _, index := range test.indexes {
+			pubKey, err := PubKeyFromString(keys[index])
+			require.NoError(t, err)
+			pubKeys = append(pubKeys, pubKey)
+		}
+
+		keyAgg, err := MuSig2AggregateKeys(pubKeys)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, hex.EncodeToString(keyAgg.XOnlyPubKey()))
+	}
+
+	_, err := MuSig2AggregateKeys(nil)
+	assert.ErrorIs(t, err, ErrMissingPubKey)
+}
+
+// TestMuSig2SortKeys will test the method MuSig2SortKeys()
+func TestMuSig2SortKeys(t *testing.T) {
+	t.Parallel()
+
+	_, pubKeys := testMuSig2Keys(3)
+	sorted := MuSig2SortKeys(pubKeys)
+	require.Len(t, sorted, 3)
+	for i := 1; i < len(sorted); i++ {
+		assert.Negative(t, bytes.Compare(sorted[i-1].SerializeCompressed(), sorted[i].SerializeCompressed()))
+	}
+
+	// sorted, the aggregate key does not depend on the order of the signers
+	first, err := MuSig2AggregateKeys(MuSig2SortKeys(pubKeys))
+	require.NoError(t, err)
+	second, err := MuSig2AggregateKeys(MuSig2SortKeys([]*btcec.PublicKey{pubKeys[2], pubKeys[0], pubKeys[1]}))
+	require.NoError(t, err)
+	assert.Equal(t, first.XOnlyPubKey(), second.XOnlyPubKey())
+}
+
+// TestMuSig2Session will test a signing session: the methods MuSig2NonceGen(), MuSig2AggregateNonces(),
+// Sign(), VerifyPartialSig() and AggregatePartialSigs()
+func TestMuSig2Session(t *testing.T) {
+	t.Parallel()
+
+	message := sha256.Sum256([]byte("collaborative custody"))
+	for count := 1; count <= 3; count++ {
+		privateKeys, pubKeys := testMuSig2Keys(count)
+		keyAgg, err := MuSig2AggregateKeys(MuSig2SortKeys(pubKeys))
+		require.NoError(t, err)
+
+		signature := muSig2Sign(t, keyAgg, privateKeys, message[:])
+		pubKey, err := schnorr.ParsePubKey(keyAgg.XOnlyPubKey())
+		require.NoError(t, err)
+		assert.True(t, signature.Verify(message[:], pubKey), count)
+
+		// plain and x-only tweaks
+		tweak := sha256.Sum256([]byte("tweak"))
+		for _, xOnly := range []bool{false, true} {
+			tweaked, err := keyAgg.ApplyTweak(tweak[:], xOnly)
+			require.NoError(t, err)
+			tweaked, err = tweaked.ApplyTweak(tweak[:], true)
+			require.NoError(t, err)
+
+			signature = muSig2Sign(t, tweaked, privateKeys, message[:])
+			pubKey, err = schnorr.ParsePubKey(tweaked.XOnlyPubKey())
+			require.NoError(t, err)
+			assert.True(t, signature.Verify(message[:], pubKey), count)
+		}
+	}
+}
+
+// TestMuSig2SessionErrors will test the failures of a signing session
+func TestMuSig2SessionErrors(t *testing.T) {
+	t.Parallel()
+
+	message := sha256.Sum256([]byte("message"))
+	privateKeys, pubKeys := testMuSig2Keys(3)
+	keyAgg, err := MuSig2AggregateKeys(pubKeys[:2])
+	require.NoError(t, err)
+
+	secNonce1, pubNonce1, err := MuSig2NonceGen(pubKeys[0], MuSig2NonceOptions{})
+	require.NoError(t, err)
+	secNonce2, pubNonce2, err := MuSig2NonceGen(pubKeys[1], MuSig2NonceOptions{})
+	require.NoError(t, err)
+
+	aggNonce, err := MuSig2AggregateNonces([]MuSig2PubNonce{*pubNonce1, *pubNonce2})
+	require.NoError(t, err)
+	session, err := NewMuSig2Session(keyAgg, aggNonce, message[:])
+	require.NoError(t, err)
+
+	// the nonce of another key, a key outside of the aggregate key
+	_, err = session.Sign(secNonce1, privateKeys[1])
+	assert.ErrorIs(t, err, ErrInvalidNonce)
+	secNonce3, _, err := MuSig2NonceGen(pubKeys[2], MuSig2NonceOptions{})
+	require.NoError(t, err)
+	_, err = session.Sign(secNonce3, privateKeys[2])
+	assert.ErrorIs(t, err, ErrUnknownSigner)
+
+	partialSig1, err := session.Sign(secNonce1, privateKeys[0])
+	require.NoError(t, err)
+
+	// the secret nonce is erased
+	_, err = session.Sign(secNonce1, privateKeys[0])
+	assert.ErrorIs(t, err, ErrInvalidNonce)
+
+	// partial signatures verify against the nonce and key of their signer only
+	assert.ErrorIs(t, session.VerifyPartialSig(partialSig1, pubNonce2, pubKeys[0]), ErrInvalidPartialSignature)
+	assert.ErrorIs(t, session.VerifyPartialSig(partialSig1, pubNonce1, pubKeys[1]), ErrInvalidPartialSignature)
+	assert.ErrorIs(t, session.VerifyPartialSig(partialSig1, pubNonce1, pubKeys[2]), ErrUnknownSigner)
+
+	partialSig2, err := session.Sign(secNonce2, privateKeys[1])
+	require.NoError(t, err)
+	invalid := MuSig2PartialSig{}
+	copy(invalid[:], bytes.Repeat([]byte{0xff}, 32))
+	_, err = session.AggregatePartialSigs([]MuSig2PartialSig{*partialSig1, invalid})
+	assert.ErrorIs(t, err, ErrInvalidPartialSignature)
+
+	signature, err := session.AggregatePartialSigs([]MuSig2PartialSig{*partialSig1, *partialSig2})
+	require.NoError(t, err)
+	pubKey, err := schnorr.ParsePubKey(keyAgg.XOnlyPubKey())
+	require.NoError(t, err)
+	assert.True(t, signature.Verify(message[:], pubKey))
+
+	// malformed nonces
+	var badNonce MuSig2PubNonce
+	_, err = MuSig2AggregateNonces([]MuSig2PubNonce{*pubNonce1, badNonce})
+	assert.ErrorIs(t, err, ErrInvalidNonce)
+
+	_, err = keyAgg.ApplyTweak(bytes.Repeat([]byte{0xff}, 32), false)
+	assert.ErrorIs(t, err, ErrInvalidTweak)
+}
+
+// TestMuSig2NonceGen will test that nonces depend on every input
+func TestMuSig2NonceGen(t *testing.T) {
+	t.Parallel()
+
+	privateKeys, pubKeys := testMuSig2Keys(1)
+	var random [32]byte
+
+	secNonce, pubNonce, err := muSig2NonceGen(random, pubKeys[0], MuSig2NonceOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, pubKeys[0].SerializeCompressed(), secNonce[64:])
+
+	k1, _ := btcec.PrivKeyFromBytes(secNonce[:32])
+	assert.Equal(t, k1.PubKey().SerializeCompressed(), pubNonce[:33])
+
+	seen := map[MuSig2PubNonce]bool{*pubNonce: true}
+	for _, options := range []MuSig2NonceOptions{
+		{PrivateKey: privateKeys[0]},
+		{AggPubKey: bytes.Repeat([]byte{1}, 32)},
+		{Message: []byte{}},
+		{Message: []byte("m")},
+		{Extra: []byte("e")},
+	} {
+		_, pubNonce, err := muSig2NonceGen(random, pubKeys[0], options)
+		require.NoError(t, err)
+		assert.False(t, seen[*pubNonce])
+		seen[*pubNonce] = true
+	}
+
+	_, _, err = muSig2NonceGen(random, pubKeys[0], MuSig2NonceOptions{AggPubKey: []byte{1}})
+	assert.ErrorIs(t, err, ErrInvalidPubKey)
+}
+
+// TestMuSig2Taproot will test a 2-of-2 key path spend of the P2TR address of an aggregate key
+func TestMuSig2Taproot(t *testing.T) {
+	t.Parallel()
+
+	privateKeys, pubKeys := testMuSig2Keys(2)
+	keyAgg, err := MuSig2AggregateKeys(MuSig2SortKeys(pubKeys))
+	require.NoError(t, err)
+
+	// a single-sig address on chain
+	address, err := GetAddressFromPubKey(keyAgg.PubKey(), Taproot, Mainnet)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(address, "bc1p"))
+
+	outputKey, err := keyAgg.TaprootTweak(nil)
+	require.NoError(t, err)
+	assert.Equal(t, schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(keyAgg.PubKey())), outputKey.XOnlyPubKey())
+
+	script, err := GetScriptFromAddress(address, Mainnet)
+	require.NoError(t, err)
+	pkScript, err := hex.DecodeString(script)
+	require.NoError(t, err)
+
+	prevOutPoint := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+	prevOut := wire.NewTxOut(100000, pkScript)
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&prevOutPoint, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(99000, pkScript))
+
+	fetcher := txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	sigHash, err := txscript.CalcTaprootSignatureHash(txscript.NewTxSigHashes(tx, fetcher), txscript.SigHashDefault, tx, 0, fetcher)
+	require.NoError(t, err)
+
+	signature := muSig2Sign(t, outputKey, privateKeys, sigHash)
+	tx.TxIn[0].Witness = wire.TxWitness{signature.Serialize()}
+
+	prevOuts := map[wire.OutPoint]*wire.TxOut{prevOutPoint: prevOut}
+	require.NoError(t, VerifyTransaction(tx, prevOuts, StandardScriptFlags))
+
+	// the untweaked aggregate key cannot spend the output
+	tx.TxIn[0].Witness = wire.TxWitness{muSig2Sign(t, keyAgg, privateKeys, sigHash).Serialize()}
+	assert.Error(t, VerifyTransaction(tx, prevOuts, StandardScriptFlags))
+}
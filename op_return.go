@@ -0,0 +1,111 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MaxDataCarrierSize is the largest OP_RETURN output script (including the OP_RETURN and push opcodes)
+// relayed by nodes running the long-standing -datacarriersize default
+const MaxDataCarrierSize = 83
+
+// GetOpReturnScript returns an OP_RETURN output script (hex) carrying each data item as a separate push.
+// The script must fit within MaxDataCarrierSize to be relayed as standard
+func GetOpReturnScript(data ...[]byte) (string, error) {
+	script, err := opReturnScript(data)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(script), nil
+}
+
+// NewOpReturnTxOut returns a zero value OP_RETURN output carrying data, ready to be added to a transaction
+func NewOpReturnTxOut(data ...[]byte) (*wire.TxOut, error) {
+	script, err := opReturnScript(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return wire.NewTxOut(0, script), nil
+}
+
+// IsOpReturnScript reports whether an output script (hex) is a provably unspendable OP_RETURN script
+func IsOpReturnScript(script string) bool {
+	scriptBytes, err := hex.DecodeString(script)
+	if err != nil {
+		return false
+	}
+
+	return len(scriptBytes) > 0 && scriptBytes[0] == txscript.OP_RETURN
+}
+
+// GetDataFromOpReturnScript extracts the data pushed by an OP_RETURN output script (hex), one item per push.
+// Small integer opcodes are returned as the single byte they push
+func GetDataFromOpReturnScript(script string) ([][]byte, error) {
+
+	// No script?
+	if len(script) == 0 {
+		return nil, ErrMissingScript
+	}
+
+	scriptBytes, err := hex.DecodeString(script)
+	if err != nil {
+		return nil, err
+	}
+
+	if scriptBytes[0] != txscript.OP_RETURN {
+		return nil, ErrNotOpReturnScript
+	}
+
+	var data [][]byte
+	tokenizer := txscript.MakeScriptTokenizer(0, scriptBytes[1:])
+	for tokenizer.Next() {
+		op := tokenizer.Opcode()
+		switch {
+		case op <= txscript.OP_PUSHDATA4:
+			data = append(data, tokenizer.Data())
+		case op == txscript.OP_1NEGATE:
+			data = append(data, []byte{0x81})
+		case op >= txscript.OP_1 && op <= txscript.OP_16:
+			data = append(data, []byte{op - txscript.OP_1 + 1})
+		default:
+			// anything after OP_RETURN but pushes is not a data carrier
+			return nil, ErrNotOpReturnScript
+		}
+	}
+
+	if err = tokenizer.Err(); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// opReturnScript builds OP_RETURN <data>... and checks it against MaxDataCarrierSize
+func opReturnScript(data [][]byte) ([]byte, error) {
+	builder := txscript.NewScriptBuilder(txscript.WithScriptAllocSize(MaxDataCarrierSize))
+	builder.AddOp(txscript.OP_RETURN)
+	for _, item := range data {
+
+		// AddFullData pushes 0x00 as OP_0, which pushes empty data
+		if len(item) == 1 && item[0] == 0 {
+			builder.AddOps([]byte{txscript.OP_DATA_1, 0})
+			continue
+		}
+		builder.AddFullData(item)
+	}
+
+	script, err := builder.Script()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(script) > MaxDataCarrierSize {
+		return nil, ErrDataCarrierTooLarge
+	}
+
+	return script, nil
+}
@@ -0,0 +1,102 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetOpReturnScript will test the method GetOpReturnScript()
+func TestGetOpReturnScript(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		data           [][]byte
+		expectedScript string
+		expectedError  bool
+	}{
+		{[][]byte{[]byte("hello world")}, "6a0b68656c6c6f20776f726c64", false},
+		{[][]byte{{0xde, 0xad}, {0xbe, 0xef}}, "6a02dead02beef", false},
+		{[][]byte{{0x05}, {}}, "6a5500", false},
+		{nil, "6a", false},
+		{[][]byte{bytes.Repeat([]byte{0xaa}, 80)}, "6a4c50" + string(bytes.Repeat([]byte("aa"), 80)), false},
+		{[][]byte{bytes.Repeat([]byte{0xaa}, 81)}, "", true},
+		{[][]byte{bytes.Repeat([]byte{0xaa}, 41), bytes.Repeat([]byte{0xaa}, 40)}, "", true},
+	}
+
+	for _, test := range tests {
+		if script, err := GetOpReturnScript(test.data...); err != nil && !test.expectedError {
+			t.Fatalf("%s Failed: [%x] inputted and error not expected but got: %s", t.Name(), test.data, err.Error())
+		} else if err == nil && test.expectedError {
+			t.Fatalf("%s Failed: [%x] inputted and error was expected", t.Name(), test.data)
+		} else if script != test.expectedScript {
+			t.Fatalf("%s Failed: [%x] inputted and [%s] expected, but got: %s", t.Name(), test.data, test.expectedScript, script)
+		}
+	}
+}
+
+// TestNewOpReturnTxOut will test the method NewOpReturnTxOut()
+func TestNewOpReturnTxOut(t *testing.T) {
+	t.Parallel()
+
+	txOut, err := NewOpReturnTxOut([]byte("anchor"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), txOut.Value)
+
+	info, err := GetScriptInfo("6a06616e63686f72", Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, "nulldata", info.Type)
+
+	_, err = NewOpReturnTxOut(bytes.Repeat([]byte{0x01}, 100))
+	assert.ErrorIs(t, err, ErrDataCarrierTooLarge)
+}
+
+// TestGetDataFromOpReturnScript will test the method GetDataFromOpReturnScript()
+func TestGetDataFromOpReturnScript(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		script        string
+		expectedData  [][]byte
+		expectedError bool
+	}{
+		{"6a0b68656c6c6f20776f726c64", [][]byte{[]byte("hello world")}, false},
+		{"6a02dead02beef", [][]byte{{0xde, 0xad}, {0xbe, 0xef}}, false},
+		{"6a55004f", [][]byte{{0x05}, nil, {0x81}}, false},
+		{"6a", nil, false},
+		{"6a76", nil, true},
+		{"6a4c05ab", nil, true},
+		{"76a914d4fa62e0243e52eeddd60812c9cd421bd337356588ac", nil, true},
+		{"zz", nil, true},
+		{"", nil, true},
+	}
+
+	for _, test := range tests {
+		if data, err := GetDataFromOpReturnScript(test.script); err != nil && !test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error not expected but got: %s", t.Name(), test.script, err.Error())
+		} else if err == nil && test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error was expected", t.Name(), test.script)
+		} else if !assert.Equal(t, test.expectedData, data) {
+			t.Fatalf("%s Failed: [%s] inputted and [%x] expected, but got: %x", t.Name(), test.script, test.expectedData, data)
+		}
+	}
+
+	// data round trips through the builder, single bytes included
+	data := [][]byte{{0x00}, {0x01}, {0x10}, {0x81}, []byte("sha256:"), bytes.Repeat([]byte{0xcd}, 32)}
+	script, err := GetOpReturnScript(data...)
+	require.NoError(t, err)
+	assert.True(t, IsOpReturnScript(script))
+
+	parsed, err := GetDataFromOpReturnScript(script)
+	require.NoError(t, err)
+	assert.Equal(t, data, parsed)
+
+	out, err := NewOpReturnTxOut([]byte{0x00})
+	require.NoError(t, err)
+	parsed, err = GetDataFromOpReturnScript(hex.EncodeToString(out.PkScript))
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{{0x00}}, parsed)
+}
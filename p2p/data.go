@@ -0,0 +1,155 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// broadcast is a transaction announced to the node, waiting for the node to request it
+type broadcast struct {
+	tx        *wire.MsgTx
+	requested chan struct{}
+	once      sync.Once
+}
+
+// GetBlock requests a block with its witness data by hash
+func (p *Peer) GetBlock(ctx context.Context, blockHash string) (*wire.MsgBlock, error) {
+	hash, err := chainhash.NewHashFromStr(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var block *wire.MsgBlock
+	err = p.getData(ctx, wire.NewInvVect(wire.InvTypeWitnessBlock, hash), func(msg wire.Message) bool {
+		resp, ok := msg.(*wire.MsgBlock)
+		if ok && resp.BlockHash() == *hash {
+			block = resp
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+// GetTransaction requests a transaction with its witness data by txid. Nodes only serve the
+// transactions of their mempool this way
+func (p *Peer) GetTransaction(ctx context.Context, txID string) (*wire.MsgTx, error) {
+	hash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx *wire.MsgTx
+	err = p.getData(ctx, wire.NewInvVect(wire.InvTypeWitnessTx, hash), func(msg wire.Message) bool {
+		resp, ok := msg.(*wire.MsgTx)
+		if ok && resp.TxHash() == *hash {
+			tx = resp
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// BroadcastTransaction announces a transaction to the node with an inv message and waits until the node
+// requests it, which means it was not known to the node yet. A node that already has the transaction,
+// or rejects it early, never requests it and the wait ends with ctx
+func (p *Peer) BroadcastTransaction(ctx context.Context, tx *wire.MsgTx) (string, error) {
+	hash := tx.TxHash()
+	b := &broadcast{tx: tx, requested: make(chan struct{})}
+
+	p.mu.Lock()
+	p.broadcasts[hash] = b
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.broadcasts, hash)
+		p.mu.Unlock()
+	}()
+
+	inv := wire.NewMsgInv()
+	if err := inv.AddInvVect(wire.NewInvVect(wire.InvTypeTx, &hash)); err != nil {
+		return "", err
+	}
+	if err := p.write(inv); err != nil {
+		return "", err
+	}
+
+	select {
+	case <-b.requested:
+		return hash.String(), nil
+	case <-p.done:
+		return "", p.Err()
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// getData requests an item and waits until match accepts a message, or the node answers notfound
+func (p *Peer) getData(ctx context.Context, item *wire.InvVect, match func(wire.Message) bool) error {
+	req := wire.NewMsgGetData()
+	if err := req.AddInvVect(item); err != nil {
+		return err
+	}
+
+	return p.request(ctx, req, func(msg wire.Message) (bool, error) {
+		if notFound, ok := msg.(*wire.MsgNotFound); ok {
+			for _, missing := range notFound.InvList {
+				if missing.Hash == item.Hash {
+					return true, fmt.Errorf("%w: %s", ErrNotFound, item.Hash)
+				}
+			}
+		}
+
+		return match(msg), nil
+	})
+}
+
+// serveBroadcasts answers a getdata of the node with the announced transactions it requests
+func (p *Peer) serveBroadcasts(req *wire.MsgGetData) {
+	notFound := wire.NewMsgNotFound()
+	for _, item := range req.InvList {
+		if item.Type != wire.InvTypeTx && item.Type != wire.InvTypeWitnessTx {
+			_ = notFound.AddInvVect(item)
+			continue
+		}
+
+		p.mu.Lock()
+		b, ok := p.broadcasts[item.Hash]
+		p.mu.Unlock()
+		if !ok {
+			_ = notFound.AddInvVect(item)
+			continue
+		}
+
+		// a plain transaction request expects the serialization without witness
+		encoding := wire.WitnessEncoding
+		if item.Type == wire.InvTypeTx {
+			encoding = wire.BaseEncoding
+		}
+		if err := p.writeWithEncoding(b.tx, encoding); err != nil {
+			return
+		}
+
+		b.once.Do(func() {
+			close(b.requested)
+		})
+	}
+
+	if len(notFound.InvList) > 0 {
+		_ = p.write(notFound)
+	}
+}
@@ -0,0 +1,129 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPeerGetBlock will test the method GetBlock()
+func TestPeerGetBlock(t *testing.T) {
+	t.Parallel()
+
+	genesis := chaincfg.MainNetParams.GenesisBlock
+	node := newTestNode(t, wire.SFNodeNetwork, func(msg wire.Message) []wire.Message {
+		req, ok := msg.(*wire.MsgGetData)
+		if !ok || len(req.InvList) != 1 || req.InvList[0].Type != wire.InvTypeWitnessBlock {
+			return nil
+		}
+
+		if req.InvList[0].Hash == genesis.BlockHash() {
+			return []wire.Message{genesis}
+		}
+
+		notFound := wire.NewMsgNotFound()
+		_ = notFound.AddInvVect(req.InvList[0])
+		return []wire.Message{notFound}
+	})
+	peer := newTestPeer(t, node)
+
+	block, err := peer.GetBlock(testContext(t), genesis.BlockHash().String())
+	require.NoError(t, err)
+	assert.Equal(t, genesis.BlockHash(), block.BlockHash())
+	require.Len(t, block.Transactions, 1)
+
+	_, err = peer.GetBlock(testContext(t), chainhash.DoubleHashH([]byte("missing")).String())
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = peer.GetBlock(testContext(t), "zz")
+	assert.Error(t, err)
+}
+
+// TestPeerGetTransaction will test the method GetTransaction()
+func TestPeerGetTransaction(t *testing.T) {
+	t.Parallel()
+
+	tx := chaincfg.MainNetParams.GenesisBlock.Transactions[0]
+	node := newTestNode(t, wire.SFNodeNetwork, func(msg wire.Message) []wire.Message {
+		req, ok := msg.(*wire.MsgGetData)
+		if !ok || len(req.InvList) != 1 || req.InvList[0].Type != wire.InvTypeWitnessTx {
+			return nil
+		}
+
+		if req.InvList[0].Hash == tx.TxHash() {
+			return []wire.Message{tx}
+		}
+
+		notFound := wire.NewMsgNotFound()
+		_ = notFound.AddInvVect(req.InvList[0])
+		return []wire.Message{notFound}
+	})
+	peer := newTestPeer(t, node)
+
+	found, err := peer.GetTransaction(testContext(t), tx.TxHash().String())
+	require.NoError(t, err)
+	assert.Equal(t, tx.TxHash(), found.TxHash())
+
+	_, err = peer.GetTransaction(testContext(t), chainhash.DoubleHashH([]byte("missing")).String())
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestPeerBroadcastTransaction will test the method BroadcastTransaction()
+func TestPeerBroadcastTransaction(t *testing.T) {
+	t.Parallel()
+
+	tx := chaincfg.MainNetParams.GenesisBlock.Transactions[0]
+	unknown := chainhash.DoubleHashH([]byte("unknown"))
+
+	received := make(chan wire.Message, 2)
+	node := newTestNode(t, wire.SFNodeNetwork, func(msg wire.Message) []wire.Message {
+		switch msg := msg.(type) {
+		case *wire.MsgInv:
+			if len(msg.InvList) != 1 || msg.InvList[0].Type != wire.InvTypeTx {
+				return nil
+			}
+
+			// the node requests the announced transaction and one it was never offered
+			getData := wire.NewMsgGetData()
+			_ = getData.AddInvVect(wire.NewInvVect(wire.InvTypeWitnessTx, &msg.InvList[0].Hash))
+			_ = getData.AddInvVect(wire.NewInvVect(wire.InvTypeWitnessTx, &unknown))
+			return []wire.Message{getData}
+		case *wire.MsgTx, *wire.MsgNotFound:
+			received <- msg
+		}
+		return nil
+	})
+	peer := newTestPeer(t, node)
+
+	txID, err := peer.BroadcastTransaction(testContext(t), tx)
+	require.NoError(t, err)
+	assert.Equal(t, tx.TxHash().String(), txID)
+
+	for range 2 {
+		select {
+		case msg := <-received:
+			switch msg := msg.(type) {
+			case *wire.MsgTx:
+				assert.Equal(t, tx.TxHash(), msg.TxHash())
+			case *wire.MsgNotFound:
+				require.Len(t, msg.InvList, 1)
+				assert.Equal(t, unknown, msg.InvList[0].Hash)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("node did not receive the transaction")
+		}
+	}
+
+	// a node that never requests the transaction leaves the broadcast to the context
+	peer = newTestPeer(t, newTestNode(t, wire.SFNodeNetwork, nil))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = peer.BroadcastTransaction(ctx, tx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,28 @@
+package p2p
+
+import (
+	"errors"
+
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// ErrMissingAddress is returned when a peer is dialed without an address
+var ErrMissingAddress = errors.New("missing peer address")
+
+// ErrMissingNetwork is returned when a peer is dialed without a network, the same error as bitcoin.ErrMissingNetwork
+var ErrMissingNetwork = bitcoin.ErrMissingNetwork
+
+// ErrHandshakeFailed is returned when the peer does not complete the version handshake
+var ErrHandshakeFailed = errors.New("p2p handshake failed")
+
+// ErrClosed is returned by requests on a closed peer, or a peer whose connection was lost
+var ErrClosed = errors.New("p2p peer closed")
+
+// ErrNotFound is returned when the peer does not have a requested block or transaction
+var ErrNotFound = errors.New("not found by peer")
+
+// ErrFiltersNotSupported is returned when compact filters are requested from a peer not serving them (BIP157)
+var ErrFiltersNotSupported = errors.New("peer does not serve compact filters")
+
+// ErrUnexpectedFilter is returned when the peer sends filters for other blocks than requested
+var ErrUnexpectedFilter = errors.New("unexpected filter from peer")
@@ -0,0 +1,144 @@
+package p2p
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// GetHeaders requests the headers following the first block of locator (block hashes, newest first) the
+// node knows, up to stopHash or wire.MaxBlockHeadersPerMsg headers. An empty stopHash requests as many as possible
+func (p *Peer) GetHeaders(ctx context.Context, locator []string, stopHash string) ([]*wire.BlockHeader, error) {
+	req := wire.NewMsgGetHeaders()
+	req.ProtocolVersion = p.pver
+
+	for _, blockHash := range locator {
+		hash, err := chainhash.NewHashFromStr(blockHash)
+		if err != nil {
+			return nil, err
+		}
+		if err = req.AddBlockLocatorHash(hash); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(stopHash) > 0 {
+		hash, err := chainhash.NewHashFromStr(stopHash)
+		if err != nil {
+			return nil, err
+		}
+		req.HashStop = *hash
+	}
+
+	var headers []*wire.BlockHeader
+	err := p.request(ctx, req, func(msg wire.Message) (bool, error) {
+		resp, ok := msg.(*wire.MsgHeaders)
+		if ok {
+			headers = resp.Headers
+		}
+		return ok, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return headers, nil
+}
+
+// GetCFilters requests the basic compact filters (BIP157) of consecutive blocks starting at startHeight,
+// given their hashes (at most wire.MaxGetCFiltersReqRange). Filters are hex strings, as checked by
+// bitcoin.MatchBasicFilter and bitcoin.VerifyFilterHeaders
+func (p *Peer) GetCFilters(ctx context.Context, startHeight uint32, blockHashes []string) ([]string, error) {
+	if err := p.checkFilterSupport(); err != nil {
+		return nil, err
+	}
+
+	if len(blockHashes) == 0 || len(blockHashes) > wire.MaxGetCFiltersReqRange {
+		return nil, fmt.Errorf("requesting %d filters, expected 1 to %d", len(blockHashes), wire.MaxGetCFiltersReqRange)
+	}
+
+	hashes := make([]chainhash.Hash, 0, len(blockHashes))
+	for _, blockHash := range blockHashes {
+		hash, err := chainhash.NewHashFromStr(blockHash)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, *hash)
+	}
+
+	// filters arrive in block order, one message per block
+	filters := make([]string, 0, len(hashes))
+	req := wire.NewMsgGetCFilters(wire.GCSFilterRegular, startHeight, &hashes[len(hashes)-1])
+	err := p.request(ctx, req, func(msg wire.Message) (bool, error) {
+		resp, ok := msg.(*wire.MsgCFilter)
+		if !ok || resp.FilterType != wire.GCSFilterRegular {
+			return false, nil
+		}
+
+		if resp.BlockHash != hashes[len(filters)] {
+			return false, fmt.Errorf("%w: got block %s, expected %s", ErrUnexpectedFilter, resp.BlockHash, hashes[len(filters)])
+		}
+
+		filters = append(filters, hex.EncodeToString(resp.Data))
+		return len(filters) == len(hashes), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return filters, nil
+}
+
+// GetCFHeaders requests the basic filter headers (BIP157) of the blocks from startHeight to stopHash
+// (at most wire.MaxCFHeadersPerMsg). It returns the filter header of the block before startHeight and
+// the filter headers of the requested blocks, as hex strings for bitcoin.VerifyFilterHeaders
+func (p *Peer) GetCFHeaders(ctx context.Context, startHeight uint32, stopHash string) (string, []string, error) {
+	if err := p.checkFilterSupport(); err != nil {
+		return "", nil, err
+	}
+
+	stop, err := chainhash.NewHashFromStr(stopHash)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var resp *wire.MsgCFHeaders
+	req := wire.NewMsgGetCFHeaders(wire.GCSFilterRegular, startHeight, stop)
+	err = p.request(ctx, req, func(msg wire.Message) (bool, error) {
+		cfHeaders, ok := msg.(*wire.MsgCFHeaders)
+		if ok && cfHeaders.FilterType == wire.GCSFilterRegular && cfHeaders.StopHash == *stop {
+			resp = cfHeaders
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	// the node sends filter hashes, each header commits to its filter hash and the previous header
+	headers := make([]string, 0, len(resp.FilterHashes))
+	prevHeader := resp.PrevFilterHeader
+	for _, filterHash := range resp.FilterHashes {
+		var pair [chainhash.HashSize * 2]byte
+		copy(pair[:chainhash.HashSize], filterHash[:])
+		copy(pair[chainhash.HashSize:], prevHeader[:])
+
+		prevHeader = chainhash.DoubleHashH(pair[:])
+		headers = append(headers, prevHeader.String())
+	}
+
+	return resp.PrevFilterHeader.String(), headers, nil
+}
+
+// checkFilterSupport checks that the node advertises compact filters
+func (p *Peer) checkFilterSupport() error {
+	if p.version.Services&wire.SFNodeCF == 0 {
+		return ErrFiltersNotSupported
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package p2p
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testHashes returns distinct block hashes for filter requests
+func testHashes(count int) []chainhash.Hash {
+	hashes := make([]chainhash.Hash, count)
+	for i := range hashes {
+		hashes[i] = chainhash.DoubleHashH([]byte{byte(i)})
+	}
+	return hashes
+}
+
+// testContext returns a context with a timeout for requests to the fake node
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+// TestPeerGetHeaders will test the method GetHeaders()
+func TestPeerGetHeaders(t *testing.T) {
+	t.Parallel()
+
+	genesisHash := chaincfg.MainNetParams.GenesisHash
+	node := newTestNode(t, wire.SFNodeNetwork, func(msg wire.Message) []wire.Message {
+		req, ok := msg.(*wire.MsgGetHeaders)
+		if !ok || len(req.BlockLocatorHashes) != 1 || *req.BlockLocatorHashes[0] != *genesisHash {
+			return nil
+		}
+
+		headers := wire.NewMsgHeaders()
+		_ = headers.AddBlockHeader(&chaincfg.MainNetParams.GenesisBlock.Header)
+		return []wire.Message{headers}
+	})
+	peer := newTestPeer(t, node)
+
+	headers, err := peer.GetHeaders(testContext(t), []string{genesisHash.String()}, "")
+	require.NoError(t, err)
+	require.Len(t, headers, 1)
+	assert.Equal(t, *genesisHash, headers[0].BlockHash())
+
+	_, err = peer.GetHeaders(testContext(t), []string{"zz"}, "")
+	assert.Error(t, err)
+
+	_, err = peer.GetHeaders(testContext(t), nil, "zz")
+	assert.Error(t, err)
+}
+
+// TestPeerGetCFilters will test the method GetCFilters()
+func TestPeerGetCFilters(t *testing.T) {
+	t.Parallel()
+
+	hashes := testHashes(3)
+	node := newTestNode(t, wire.SFNodeNetwork|wire.SFNodeCF, func(msg wire.Message) []wire.Message {
+		req, ok := msg.(*wire.MsgGetCFilters)
+		if !ok {
+			return nil
+		}
+
+		// a filter of another block is sent first when the request starts at height 1
+		var replies []wire.Message
+		if req.StartHeight == 1 {
+			replies = append(replies, wire.NewMsgCFilter(wire.GCSFilterRegular, &hashes[2], []byte{0x00}))
+		}
+		for i := range hashes {
+			replies = append(replies, wire.NewMsgCFilter(wire.GCSFilterRegular, &hashes[i], []byte{byte(i)}))
+		}
+		return replies
+	})
+	peer := newTestPeer(t, node)
+
+	blockHashes := []string{hashes[0].String(), hashes[1].String(), hashes[2].String()}
+	filters, err := peer.GetCFilters(testContext(t), 100, blockHashes)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"00", "01", "02"}, filters)
+
+	_, err = peer.GetCFilters(testContext(t), 1, blockHashes)
+	assert.ErrorIs(t, err, ErrUnexpectedFilter)
+
+	_, err = peer.GetCFilters(testContext(t), 100, nil)
+	assert.Error(t, err)
+
+	_, err = peer.GetCFilters(testContext(t), 100, make([]string, wire.MaxGetCFiltersReqRange+1))
+	assert.Error(t, err)
+
+	// nodes without NODE_COMPACT_FILTERS are not asked
+	peer = newTestPeer(t, newTestNode(t, wire.SFNodeNetwork, nil))
+	_, err = peer.GetCFilters(testContext(t), 100, blockHashes)
+	assert.ErrorIs(t, err, ErrFiltersNotSupported)
+}
+
+// TestPeerGetCFHeaders will test the method GetCFHeaders()
+func TestPeerGetCFHeaders(t *testing.T) {
+	t.Parallel()
+
+	filters := []string{"019dfca8", "0100"}
+	prevHeader := chainhash.DoubleHashH([]byte("prev"))
+	hashes := testHashes(len(filters))
+
+	node := newTestNode(t, wire.SFNodeNetwork|wire.SFNodeCF, func(msg wire.Message) []wire.Message {
+		req, ok := msg.(*wire.MsgGetCFHeaders)
+		if !ok {
+			return nil
+		}
+
+		resp := wire.NewMsgCFHeaders()
+		resp.FilterType = wire.GCSFilterRegular
+		resp.StopHash = req.StopHash
+		resp.PrevFilterHeader = prevHeader
+		for _, filter := range filters {
+			data, _ := hex.DecodeString(filter)
+			filterHash := chainhash.DoubleHashH(data)
+			_ = resp.AddCFHash(&filterHash)
+		}
+		return []wire.Message{resp}
+	})
+	peer := newTestPeer(t, node)
+
+	prev, headers, err := peer.GetCFHeaders(testContext(t), 10, hashes[1].String())
+	require.NoError(t, err)
+	assert.Equal(t, prevHeader.String(), prev)
+	require.Len(t, headers, len(filters))
+
+	// the headers chain up as computed from the filters themselves
+	require.NoError(t, bitcoin.VerifyFilterHeaders(prev, filters, headers))
+
+	_, _, err = peer.GetCFHeaders(testContext(t), 10, "zz")
+	assert.Error(t, err)
+
+	peer = newTestPeer(t, newTestNode(t, wire.SFNodeNetwork, nil))
+	_, _, err = peer.GetCFHeaders(testContext(t), 10, hashes[1].String())
+	assert.ErrorIs(t, err, ErrFiltersNotSupported)
+}
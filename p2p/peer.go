@@ -0,0 +1,305 @@
+package p2p
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// ProtocolVersion is the highest protocol version the peer client speaks
+const ProtocolVersion = wire.ProtocolVersion
+
+// Config holds the connection settings of a Peer
+type Config struct {
+	Address     string // host:port, e.g. 127.0.0.1:8333
+	Network     bitcoin.NetworkType
+	UserAgent   string // defaults to go-bitcoin
+	StartHeight int32  // best height announced in the version message
+}
+
+// Peer is a minimal connection to a Bitcoin node over the P2P protocol, safe for concurrent use.
+// It announces itself as a non relaying client, so the node does not push its mempool to it
+type Peer struct {
+	config  Config
+	conn    net.Conn
+	pver    uint32
+	version *wire.MsgVersion
+
+	writeMu sync.Mutex
+
+	mu         sync.Mutex
+	waiters    map[*waiter]struct{}
+	broadcasts map[chainhash.Hash]*broadcast
+	err        error
+	done       chan struct{}
+}
+
+// waiter collects the messages answering a request. handle returns true once the request is complete
+type waiter struct {
+	handle func(msg wire.Message) (bool, error)
+	result chan error
+}
+
+// Dial connects to a node and runs the version handshake
+func Dial(ctx context.Context, config Config) (*Peer, error) {
+
+	// Missing address
+	if len(config.Address) == 0 {
+		return nil, ErrMissingAddress
+	}
+
+	// Missing network
+	if config.Network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	if len(config.UserAgent) == 0 {
+		config.UserAgent = "go-bitcoin"
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", config.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Peer{
+		config:     config,
+		conn:       conn,
+		pver:       ProtocolVersion,
+		waiters:    make(map[*waiter]struct{}),
+		broadcasts: make(map[chainhash.Hash]*broadcast),
+		done:       make(chan struct{}),
+	}
+
+	if err = p.handshake(ctx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	go p.readLoop()
+	return p, nil
+}
+
+// Version returns the version message of the node
+func (p *Peer) Version() *wire.MsgVersion {
+	return p.version
+}
+
+// ProtocolVersion returns the protocol version negotiated with the node
+func (p *Peer) ProtocolVersion() uint32 {
+	return p.pver
+}
+
+// Ping checks the connection with a ping and waits for the matching pong
+func (p *Peer) Ping(ctx context.Context) error {
+	nonce, err := randomNonce()
+	if err != nil {
+		return err
+	}
+
+	return p.request(ctx, wire.NewMsgPing(nonce), func(msg wire.Message) (bool, error) {
+		pong, ok := msg.(*wire.MsgPong)
+		return ok && pong.Nonce == nonce, nil
+	})
+}
+
+// Close closes the connection, pending requests fail with ErrClosed
+func (p *Peer) Close() error {
+	err := p.conn.Close()
+	<-p.done
+	return err
+}
+
+// Done returns a channel closed once the connection is closed or lost
+func (p *Peer) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns why the connection was lost, or ErrClosed after Close
+func (p *Peer) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// handshake exchanges version and verack messages
+func (p *Peer) handshake(ctx context.Context) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = p.conn.SetDeadline(deadline)
+		defer func() {
+			_ = p.conn.SetDeadline(time.Time{})
+		}()
+	}
+
+	nonce, err := randomNonce()
+	if err != nil {
+		return err
+	}
+
+	me := wire.NewNetAddressIPPort(net.IPv4zero, 0, 0)
+	you := wire.NewNetAddressIPPort(net.IPv4zero, 0, 0)
+	if addr, ok := p.conn.RemoteAddr().(*net.TCPAddr); ok {
+		you = wire.NewNetAddress(addr, 0)
+	}
+
+	version := wire.NewMsgVersion(me, you, nonce, p.config.StartHeight)
+	version.ProtocolVersion = int32(ProtocolVersion)
+	version.DisableRelayTx = true
+
+	// witness support makes the node request transactions with their witness
+	version.Services = wire.SFNodeWitness
+	if err = version.AddUserAgent(p.config.UserAgent, ""); err != nil {
+		return err
+	}
+	if err = p.write(version); err != nil {
+		return fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+	}
+
+	for gotVerAck := false; p.version == nil || !gotVerAck; {
+		msg, err := p.read()
+		if errors.Is(err, wire.ErrUnknownMessage) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+		}
+
+		switch msg := msg.(type) {
+		case *wire.MsgVersion:
+			if msg.Nonce == nonce {
+				return fmt.Errorf("%w: connected to self", ErrHandshakeFailed)
+			}
+
+			p.version = msg
+			p.pver = min(ProtocolVersion, uint32(msg.ProtocolVersion))
+			if err = p.write(wire.NewMsgVerAck()); err != nil {
+				return fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+			}
+		case *wire.MsgVerAck:
+			if p.version == nil {
+				return fmt.Errorf("%w: verack before version", ErrHandshakeFailed)
+			}
+			gotVerAck = true
+		}
+	}
+
+	return nil
+}
+
+// request registers a waiter, sends msg and waits until handle completes the request
+func (p *Peer) request(ctx context.Context, msg wire.Message, handle func(wire.Message) (bool, error)) error {
+	w := &waiter{handle: handle, result: make(chan error, 1)}
+
+	p.mu.Lock()
+	if p.err != nil {
+		p.mu.Unlock()
+		return p.err
+	}
+	p.waiters[w] = struct{}{}
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.waiters, w)
+		p.mu.Unlock()
+	}()
+
+	if err := p.write(msg); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-w.result:
+		return err
+	case <-p.done:
+		return p.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// readLoop answers the node's pings and data requests and hands other messages to the waiters
+func (p *Peer) readLoop() {
+	var err error
+	for {
+		var msg wire.Message
+		if msg, err = p.read(); err != nil {
+
+			// the decoder discards the payload of commands it does not know, e.g. sendtxrcncl
+			if errors.Is(err, wire.ErrUnknownMessage) {
+				continue
+			}
+			break
+		}
+
+		switch msg := msg.(type) {
+		case *wire.MsgPing:
+			_ = p.write(wire.NewMsgPong(msg.Nonce))
+			continue
+		case *wire.MsgGetData:
+			p.serveBroadcasts(msg)
+			continue
+		}
+
+		p.mu.Lock()
+		for w := range p.waiters {
+			done, err := w.handle(msg)
+			if done || err != nil {
+				w.result <- err
+				delete(p.waiters, w)
+			}
+		}
+		p.mu.Unlock()
+	}
+
+	p.mu.Lock()
+	if errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) {
+		p.err = ErrClosed
+	} else {
+		p.err = fmt.Errorf("%w: %w", ErrClosed, err)
+	}
+	p.mu.Unlock()
+
+	close(p.done)
+}
+
+// read reads the next message from the node
+func (p *Peer) read() (wire.Message, error) {
+	_, msg, _, err := wire.ReadMessageWithEncodingN(p.conn, p.pver, p.config.Network.Net, wire.WitnessEncoding)
+	return msg, err
+}
+
+// write sends a message to the node
+func (p *Peer) write(msg wire.Message) error {
+	return p.writeWithEncoding(msg, wire.WitnessEncoding)
+}
+
+// writeWithEncoding sends a message to the node, serializing transactions with or without witness
+func (p *Peer) writeWithEncoding(msg wire.Message, encoding wire.MessageEncoding) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	_, err := wire.WriteMessageWithEncodingN(p.conn, msg, p.pver, p.config.Network.Net, encoding)
+	return err
+}
+
+// randomNonce returns a random nonce for version and ping messages
+func randomNonce() (uint64, error) {
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(nonce[:]), nil
+}
@@ -0,0 +1,259 @@
+package p2p
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// handlerFunc answers a message of the client with the messages the fake node sends back
+type handlerFunc func(msg wire.Message) []wire.Message
+
+// testNode is a fake Bitcoin node speaking the P2P protocol over TCP
+type testNode struct {
+	listener net.Listener
+	handler  handlerFunc
+	services wire.ServiceFlag
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+// unknownMessage is a message of a command btcd does not decode, sent like Bitcoin Core around the handshake
+type unknownMessage struct{}
+
+// BtcDecode implements wire.Message
+func (m *unknownMessage) BtcDecode(_ io.Reader, _ uint32, _ wire.MessageEncoding) error {
+	return nil
+}
+
+// BtcEncode implements wire.Message with a BIP330 version and salt
+func (m *unknownMessage) BtcEncode(w io.Writer, _ uint32, _ wire.MessageEncoding) error {
+	_, err := w.Write(make([]byte, 12))
+	return err
+}
+
+// Command implements wire.Message
+func (m *unknownMessage) Command() string {
+	return "sendtxrcncl"
+}
+
+// MaxPayloadLength implements wire.Message
+func (m *unknownMessage) MaxPayloadLength(_ uint32) uint32 {
+	return 12
+}
+
+// newTestNode starts a fake node, the version handshake and pings are answered without calling handler
+func newTestNode(t *testing.T, services wire.ServiceFlag, handler handlerFunc) *testNode {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	node := &testNode{listener: listener, handler: handler, services: services}
+	t.Cleanup(node.close)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			node.mu.Lock()
+			node.conns = append(node.conns, conn)
+			node.mu.Unlock()
+
+			go node.serve(conn)
+		}
+	}()
+
+	return node
+}
+
+// serve runs the handshake and answers the messages of a connection
+func (n *testNode) serve(conn net.Conn) {
+	for {
+		_, msg, _, err := wire.ReadMessageWithEncodingN(conn, ProtocolVersion, bitcoin.Mainnet.Net, wire.WitnessEncoding)
+		if err != nil {
+			return
+		}
+
+		var replies []wire.Message
+		switch msg := msg.(type) {
+		case *wire.MsgVersion:
+			me := wire.NewNetAddressIPPort(net.IPv4(127, 0, 0, 1), 8333, n.services)
+			version := wire.NewMsgVersion(me, me, msg.Nonce+1, 850000)
+			version.Services = n.services
+			replies = []wire.Message{version, &unknownMessage{}, wire.NewMsgVerAck()}
+		case *wire.MsgVerAck:
+		case *wire.MsgPing:
+			replies = []wire.Message{wire.NewMsgPong(msg.Nonce)}
+		default:
+			if n.handler != nil {
+				replies = n.handler(msg)
+			}
+		}
+
+		for _, reply := range replies {
+			n.send(conn, reply)
+		}
+	}
+}
+
+// send sends a message to a connection
+func (n *testNode) send(conn net.Conn, msg wire.Message) {
+	_, _ = wire.WriteMessageWithEncodingN(conn, msg, ProtocolVersion, bitcoin.Mainnet.Net, wire.WitnessEncoding)
+}
+
+// broadcast sends a message to every connected client
+func (n *testNode) broadcast(msg wire.Message) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, conn := range n.conns {
+		n.send(conn, msg)
+	}
+}
+
+// dropConnections closes the connections of the clients
+func (n *testNode) dropConnections() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, conn := range n.conns {
+		_ = conn.Close()
+	}
+	n.conns = nil
+}
+
+// close stops the fake node
+func (n *testNode) close() {
+	_ = n.listener.Close()
+	n.dropConnections()
+}
+
+// newTestPeer dials a fake node
+func newTestPeer(t *testing.T, node *testNode) *Peer {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	peer, err := Dial(ctx, Config{Address: node.listener.Addr().String(), Network: bitcoin.Mainnet})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = peer.Close()
+	})
+
+	return peer
+}
+
+// TestDial will test the method Dial()
+func TestDial(t *testing.T) {
+	t.Parallel()
+
+	_, err := Dial(context.Background(), Config{Network: bitcoin.Mainnet})
+	assert.ErrorIs(t, err, ErrMissingAddress)
+
+	_, err = Dial(context.Background(), Config{Address: "127.0.0.1:8333"})
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+	assert.ErrorIs(t, err, bitcoin.ErrMissingNetwork)
+
+	node := newTestNode(t, wire.SFNodeNetwork|wire.SFNodeWitness, nil)
+	peer := newTestPeer(t, node)
+
+	assert.Equal(t, int32(850000), peer.Version().LastBlock)
+	assert.Equal(t, ProtocolVersion, peer.ProtocolVersion())
+	assert.Equal(t, wire.SFNodeNetwork|wire.SFNodeWitness, peer.Version().Services)
+}
+
+// TestDialHandshakeFailed will test a node closing the connection during the handshake
+func TestDialHandshakeFailed(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			_ = conn.Close()
+		}
+	}()
+
+	_, err = Dial(context.Background(), Config{Address: listener.Addr().String(), Network: bitcoin.Mainnet})
+	assert.ErrorIs(t, err, ErrHandshakeFailed)
+}
+
+// TestPeerPing will test the method Ping()
+func TestPeerPing(t *testing.T) {
+	t.Parallel()
+
+	node := newTestNode(t, wire.SFNodeNetwork, nil)
+	peer := newTestPeer(t, node)
+
+	// pings of the node are answered while a request waits
+	node.broadcast(wire.NewMsgPing(7))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, peer.Ping(ctx))
+}
+
+// TestPeerUnknownMessage will test that commands the peer does not know are skipped after the handshake,
+// the fake node sends one during every handshake
+func TestPeerUnknownMessage(t *testing.T) {
+	t.Parallel()
+
+	node := newTestNode(t, wire.SFNodeNetwork, nil)
+	peer := newTestPeer(t, node)
+
+	node.broadcast(&unknownMessage{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, peer.Ping(ctx))
+}
+
+// TestPeerClose will test the methods Close(), Done() and Err()
+func TestPeerClose(t *testing.T) {
+	t.Parallel()
+
+	node := newTestNode(t, wire.SFNodeNetwork, func(msg wire.Message) []wire.Message {
+		return nil
+	})
+	peer := newTestPeer(t, node)
+
+	// a lost connection fails pending requests
+	errs := make(chan error, 1)
+	go func() {
+		_, err := peer.GetHeaders(context.Background(), nil, "")
+		errs <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	node.dropConnections()
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, ErrClosed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request still pending")
+	}
+
+	<-peer.Done()
+	assert.ErrorIs(t, peer.Err(), ErrClosed)
+	assert.ErrorIs(t, peer.Ping(context.Background()), ErrClosed)
+
+	peer = newTestPeer(t, node)
+	require.NoError(t, peer.Close())
+	assert.ErrorIs(t, peer.Err(), ErrClosed)
+}
@@ -0,0 +1,446 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// BIP47 payment code serialization
+const (
+	paymentCodeVersion     = 0x01
+	paymentCodeBase58      = 0x47 // version byte of the base58check encoding, for "PM8T..." codes
+	paymentCodeLen         = 80
+	paymentCodeKeyOffset   = 2
+	paymentCodeChainOffset = paymentCodeKeyOffset + btcec.PubKeyBytesLenCompressed
+)
+
+// PaymentCodeNotificationValue is the value (sat) of the output of a notification transaction paying
+// the notification address of the recipient
+const PaymentCodeNotificationValue btcutil.Amount = 546
+
+// PaymentCode is a BIP47 (version 1) reusable payment code, the extended public key of a BIP47 account
+type PaymentCode struct {
+	PubKey    *btcec.PublicKey
+	ChainCode [32]byte
+}
+
+// ParsePaymentCode decodes a base58check payment code (PM8T...)
+func ParsePaymentCode(code string) (*PaymentCode, error) {
+	payload, version, err := base58.CheckDecode(code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPaymentCode, err)
+	}
+	if version != paymentCodeBase58 {
+		return nil, fmt.Errorf("%w: prefix %#x", ErrInvalidPaymentCode, version)
+	}
+
+	return parsePaymentCodePayload(payload)
+}
+
+// parsePaymentCodePayload parses the 80 byte serialization of a payment code
+func parsePaymentCodePayload(payload []byte) (*PaymentCode, error) {
+	if len(payload) != paymentCodeLen {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidPaymentCode, len(payload))
+	}
+	if payload[0] != paymentCodeVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrInvalidPaymentCode, payload[0])
+	}
+
+	pubKey, err := btcec.ParsePubKey(payload[paymentCodeKeyOffset:paymentCodeChainOffset])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPaymentCode, err)
+	}
+
+	code := &PaymentCode{PubKey: pubKey}
+	copy(code.ChainCode[:], payload[paymentCodeChainOffset:])
+	return code, nil
+}
+
+// String returns the base58check encoding of the payment code
+func (c *PaymentCode) String() string {
+	return base58.CheckEncode(c.payload(), paymentCodeBase58)
+}
+
+// payload returns the 80 byte serialization of the payment code: version, features, key, chain code
+// and 13 reserved bytes
+func (c *PaymentCode) payload() []byte {
+	payload := make([]byte, paymentCodeLen)
+	payload[0] = paymentCodeVersion
+	copy(payload[paymentCodeKeyOffset:], c.PubKey.SerializeCompressed())
+	copy(payload[paymentCodeChainOffset:], c.ChainCode[:])
+	return payload
+}
+
+// NotificationAddress returns the P2PKH address notification transactions pay to
+func (c *PaymentCode) NotificationAddress(network NetworkType) (string, error) {
+	pubKey, err := c.childPubKey(0)
+	if err != nil {
+		return "", err
+	}
+
+	return GetAddressFromPubKey(pubKey, Legacy, network)
+}
+
+// childPubKey returns the public key of the (non-hardened) child index of the payment code
+func (c *PaymentCode) childPubKey(index uint32) (*btcec.PublicKey, error) {
+	if !IsValidPublicKey(c.PubKey) {
+		return nil, ErrInvalidPubKey
+	}
+
+	// the network of the extended key plays no part in the derivation
+	key := hdkeychain.NewExtendedKey(chaincfg.MainNetParams.HDPublicKeyID[:], c.PubKey.SerializeCompressed(),
+		c.ChainCode[:], []byte{0, 0, 0, 0}, 3, 0, false)
+	child, err := key.Derive(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return child.ECPubKey()
+}
+
+// PaymentCodeAccount is a BIP47 account (m/47'/coin_type'/account') sending to and receiving from payment codes
+type PaymentCodeAccount struct {
+	network NetworkType
+	key     *hdkeychain.ExtendedKey
+	code    *PaymentCode
+}
+
+// NewPaymentCodeAccount derives the BIP47 account of a mnemonic phrase.
+// MnemonicPassword can be an empty string if not required
+func NewPaymentCodeAccount(networkType NetworkType, mnemonic, mnemonicPassword string, account uint32) (*PaymentCodeAccount, error) {
+
+	// Missing network
+	if networkType == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+
+	masterKey, err := hdkeychain.NewMaster(bip39.NewSeed(mnemonic, mnemonicPassword), networkType)
+	if err != nil {
+		return nil, err
+	}
+
+	coinType := uint32(1)
+	if networkType.Net == chaincfg.MainNetParams.Net {
+		coinType = 0
+	}
+
+	key := masterKey
+	for _, index := range []uint32{47, coinType, account} {
+		if key, err = key.Derive(hdkeychain.HardenedKeyStart + index); err != nil {
+			return nil, err
+		}
+	}
+
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+
+	code := &PaymentCode{PubKey: pubKey}
+	copy(code.ChainCode[:], key.ChainCode())
+	return &PaymentCodeAccount{network: networkType, key: key, code: code}, nil
+}
+
+// PaymentCode returns the payment code of the account, the one to publish
+func (a *PaymentCodeAccount) PaymentCode() *PaymentCode {
+	return a.code
+}
+
+// NotificationAddress returns the address the notification transactions of the account's senders pay to
+func (a *PaymentCodeAccount) NotificationAddress() (string, error) {
+	return a.code.NotificationAddress(a.network)
+}
+
+// AddNotificationOutputs appends to tx the outputs notifying the owner of a payment code of the payment code of
+// the account: one paying its notification address and an OP_RETURN output carrying the account's payment code
+// blinded with the designated input, prevOuts holding the outputs the inputs of tx spend. The recipient takes
+// the first input exposing a key as the designated one: the first P2PKH, P2WPKH or P2SH input must be spent
+// with designatedKey (P2SH-P2WPKH). Senders notify a recipient once, before the first payment
+func (a *PaymentCodeAccount) AddNotificationOutputs(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut,
+	designatedKey *btcec.PrivateKey, to *PaymentCode) error {
+
+	// Missing transaction
+	if tx == nil || len(tx.TxIn) == 0 {
+		return ErrMissingTransaction
+	}
+
+	// Missing private key
+	if designatedKey == nil {
+		return ErrPrivateKeyMissing
+	}
+
+	designated, err := designatedInput(tx, prevOuts, designatedKey.PubKey())
+	if err != nil {
+		return err
+	}
+
+	notificationKey, err := to.childPubKey(0)
+	if err != nil {
+		return err
+	}
+
+	payload, err := blindPaymentCode(a.code.payload(), designatedKey, notificationKey, designated.PreviousOutPoint)
+	if err != nil {
+		return err
+	}
+
+	address, err := to.NotificationAddress(a.network)
+	if err != nil {
+		return err
+	}
+	script, err := GetScriptFromAddress(address, a.network)
+	if err != nil {
+		return err
+	}
+	pkScript, err := hex.DecodeString(script)
+	if err != nil {
+		return err
+	}
+
+	opReturn, err := NewOpReturnTxOut(payload)
+	if err != nil {
+		return err
+	}
+
+	tx.AddTxOut(wire.NewTxOut(int64(PaymentCodeNotificationValue), pkScript))
+	tx.AddTxOut(opReturn)
+	return nil
+}
+
+// ParseNotificationTransaction returns the payment code of the sender of a notification transaction paying the
+// account's notification address, unblinding it with the key of the first input exposing one
+func (a *PaymentCodeAccount) ParseNotificationTransaction(tx *wire.MsgTx) (*PaymentCode, error) {
+
+	// Missing transaction
+	if tx == nil {
+		return nil, ErrMissingTransaction
+	}
+
+	address, err := a.NotificationAddress()
+	if err != nil {
+		return nil, err
+	}
+	script, err := GetScriptFromAddress(address, a.network)
+	if err != nil {
+		return nil, err
+	}
+	pkScript, err := hex.DecodeString(script)
+	if err != nil {
+		return nil, err
+	}
+
+	var notified bool
+	var payload []byte
+	for _, out := range tx.TxOut {
+		notified = notified || bytes.Equal(out.PkScript, pkScript)
+
+		if len(out.PkScript) == 0 || out.PkScript[0] != txscript.OP_RETURN {
+			continue
+		}
+		data, err := GetDataFromOpReturnScript(hex.EncodeToString(out.PkScript))
+		if err == nil && len(data) == 1 && len(data[0]) == paymentCodeLen && data[0][0] == paymentCodeVersion {
+			payload = data[0]
+		}
+	}
+	if !notified || payload == nil {
+		return nil, ErrNotNotificationTransaction
+	}
+
+	in, designatedKey := designatedPubKey(tx)
+	if designatedKey == nil {
+		return nil, fmt.Errorf("%w: no input exposes a key", ErrNotNotificationTransaction)
+	}
+
+	notificationKey, err := a.childKey(0)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err = blindPaymentCode(payload, notificationKey, designatedKey, in.PreviousOutPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePaymentCodePayload(payload)
+}
+
+// SendPubKey returns the key of the index-th address paying the owner of a payment code
+func (a *PaymentCodeAccount) SendPubKey(to *PaymentCode, index uint32) (*btcec.PublicKey, error) {
+	pubKey, err := to.childPubKey(index)
+	if err != nil {
+		return nil, err
+	}
+
+	notificationKey, err := a.childKey(0)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := paymentCodeSecret(notificationKey, pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pointPubKey(addPoints(jacobian(pubKey), scalarBaseMult(secret))), nil
+}
+
+// SendAddress returns the index-th address paying the owner of a payment code, of type Legacy, NativeSegwit or
+// Taproot. Each address is paid once, index counting the payments to the payment code from 0
+func (a *PaymentCodeAccount) SendAddress(to *PaymentCode, index uint32, addressType AddressType) (string, error) {
+	pubKey, err := a.SendPubKey(to, index)
+	if err != nil {
+		return "", err
+	}
+
+	return GetAddressFromPubKey(pubKey, addressType, a.network)
+}
+
+// ReceiveKey returns the private key of the index-th address the owner of a payment code pays the account to
+func (a *PaymentCodeAccount) ReceiveKey(from *PaymentCode, index uint32) (*btcec.PrivateKey, error) {
+	privateKey, err := a.childKey(index)
+	if err != nil {
+		return nil, err
+	}
+
+	notificationKey, err := from.childPubKey(0)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := paymentCodeSecret(privateKey, notificationKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return btcec.PrivKeyFromScalar(secret.Add(&privateKey.Key)), nil
+}
+
+// ReceiveAddress returns the index-th address the owner of a payment code pays the account to, of type Legacy,
+// NativeSegwit or Taproot
+func (a *PaymentCodeAccount) ReceiveAddress(from *PaymentCode, index uint32, addressType AddressType) (string, error) {
+	privateKey, err := a.ReceiveKey(from, index)
+	if err != nil {
+		return "", err
+	}
+
+	return GetAddressFromPrivateKey(privateKey, addressType, a.network)
+}
+
+// childKey returns the private key of the (non-hardened) child index of the account
+func (a *PaymentCodeAccount) childKey(index uint32) (*btcec.PrivateKey, error) {
+	child, err := a.key.Derive(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return child.ECPrivKey()
+}
+
+// paymentCodeSecret returns s = SHA256(x(k·P)), the shared secret of the addresses between two payment codes.
+// Secrets that are not below the curve order are invalid, the address of the index is skipped then
+func paymentCodeSecret(privateKey *btcec.PrivateKey, pubKey *btcec.PublicKey) (*btcec.ModNScalar, error) {
+	sharedSecret := scalarMult(&privateKey.Key, jacobian(pubKey))
+	hash := sha256.Sum256(pointXBytes(sharedSecret))
+
+	secret, ok := parseScalar(hash[:])
+	if !ok {
+		return nil, ErrInvalidTweak
+	}
+	return secret, nil
+}
+
+// blindPaymentCode (un)blinds the key and chain code of a payment code payload with the shared secret of a
+// notification transaction: HMAC-SHA512(outpoint, x(k·P)), outpoint being the one spent by the designated input
+func blindPaymentCode(payload []byte, privateKey *btcec.PrivateKey, pubKey *btcec.PublicKey, outPoint wire.OutPoint) ([]byte, error) {
+	sharedSecret := scalarMult(&privateKey.Key, jacobian(pubKey))
+	if isInfinity(sharedSecret) {
+		return nil, ErrInvalidPubKey
+	}
+
+	serialized := make([]byte, chainhash.HashSize+4)
+	copy(serialized, outPoint.Hash[:])
+	binary.LittleEndian.PutUint32(serialized[chainhash.HashSize:], outPoint.Index)
+
+	mac := hmac.New(sha512.New, serialized)
+	mac.Write(pointXBytes(sharedSecret))
+	mask := mac.Sum(nil)
+
+	// the x coordinate and the chain code, the sign byte stays clear
+	blinded := bytes.Clone(payload)
+	for i := range mask {
+		blinded[paymentCodeKeyOffset+1+i] ^= mask[i]
+	}
+	return blinded, nil
+}
+
+// designatedInput returns the input of tx the recipient of a notification will take as the designated one,
+// the first spending an output whose spend exposes a key, checking it is an output of pubKey
+func designatedInput(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut, pubKey *btcec.PublicKey) (*wire.TxIn, error) {
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+	for _, in := range tx.TxIn {
+		prevOut := prevOuts[in.PreviousOutPoint]
+		if prevOut == nil {
+			return nil, fmt.Errorf("%w: %s", ErrMissingPrevOut, in.PreviousOutPoint)
+		}
+
+		pkScript := prevOut.PkScript
+		var owned bool
+		switch {
+		case txscript.IsPayToPubKeyHash(pkScript):
+			owned = bytes.Equal(pkScript[3:23], pubKeyHash)
+		case txscript.IsPayToWitnessPubKeyHash(pkScript):
+			owned = bytes.Equal(pkScript[2:], pubKeyHash)
+		case txscript.IsPayToScriptHash(pkScript):
+			owned = bytes.Equal(pkScript[2:22], btcutil.Hash160(append([]byte{txscript.OP_0, txscript.OP_DATA_20}, pubKeyHash...)))
+		default:
+			continue
+		}
+
+		if !owned {
+			return nil, fmt.Errorf("%w: input %s comes first", ErrInputKeyMismatch, in.PreviousOutPoint)
+		}
+		return in, nil
+	}
+
+	return nil, fmt.Errorf("%w: no input exposes a key", ErrInputKeyMismatch)
+}
+
+// designatedPubKey returns the first input of a notification transaction exposing a public key, in its
+// witness or script sig, and that key
+func designatedPubKey(tx *wire.MsgTx) (*wire.TxIn, *btcec.PublicKey) {
+	for _, in := range tx.TxIn {
+		if len(in.Witness) > 1 {
+			if pubKey, err := btcec.ParsePubKey(in.Witness[len(in.Witness)-1]); err == nil {
+				return in, pubKey
+			}
+		}
+
+		pushes, err := txscript.PushedData(in.SignatureScript)
+		if err == nil && len(pushes) > 1 {
+			if pubKey, err := btcec.ParsePubKey(pushes[len(pushes)-1]); err == nil {
+				return in, pubKey
+			}
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,251 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// BIP47 test vectors
+const (
+	testAliceMnemonic    = "response seminar brave tip suit recall often sound stick owner lottery motion"
+	testAlicePaymentCode = "PM8TJTLJbPRGxSbc8EJi42Wrr6QbNSaSSVJ5Y3E4pbCYiTHUskHg13935Ubb7q8tx9GVbh2UuRnBc3WSyJHhUrw8KhprKnn9eDznYGieTzFcwQRya4GA"
+	testBobMnemonic      = "reward upper indicate eight swift arch injury crystal super wrestle already dentist"
+	testBobPaymentCode   = "PM8TJS2JxQ5ztXUpBBRnpTbcUXbUHy2T1abfrb3KkAAtMEGNbey4oumH7Hc578WgQJhPjBxteQ5GHHToTYHE3A1w6p7tU6KSoFmWBVbFGjKPisZDbP97"
+)
+
+// testPaymentCodeAccounts returns the accounts of Alice and Bob of the BIP47 test vectors
+func testPaymentCodeAccounts(t *testing.T) (*PaymentCodeAccount, *PaymentCodeAccount) {
+	alice, err := NewPaymentCodeAccount(Mainnet, testAliceMnemonic, "", 0)
+	require.NoError(t, err)
+	bob, err := NewPaymentCodeAccount(Mainnet, testBobMnemonic, "", 0)
+	require.NoError(t, err)
+	return alice, bob
+}
+
+// TestNewPaymentCodeAccount will test the method NewPaymentCodeAccount()
+func TestNewPaymentCodeAccount(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := testPaymentCodeAccounts(t)
+	assert.Equal(t, testAlicePaymentCode, alice.PaymentCode().String())
+	assert.Equal(t, testBobPaymentCode, bob.PaymentCode().String())
+
+	address, err := alice.NotificationAddress()
+	require.NoError(t, err)
+	assert.Equal(t, "1JDdmqFLhpzcUwPeinhJbUPw4Co3aWLyzW", address)
+	address, err = bob.NotificationAddress()
+	require.NoError(t, err)
+	assert.Equal(t, "1ChvUUvht2hUQufHBXF8NgLhW8SwE2ecGV", address)
+
+	// accounts and networks have their own payment codes
+	other, err := NewPaymentCodeAccount(Mainnet, testAliceMnemonic, "", 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, testAlicePaymentCode, other.PaymentCode().String())
+	testnet, err := NewPaymentCodeAccount(Testnet, testAliceMnemonic, "", 0)
+	require.NoError(t, err)
+	assert.NotEqual(t, testAlicePaymentCode, testnet.PaymentCode().String())
+	address, err = testnet.NotificationAddress()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(address, "m") || strings.HasPrefix(address, "n"))
+
+	_, err = NewPaymentCodeAccount(Mainnet, "invalid mnemonic", "", 0)
+	assert.ErrorIs(t, err, ErrInvalidMnemonic)
+	_, err = NewPaymentCodeAccount(nil, testAliceMnemonic, "", 0)
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+}
+
+// TestParsePaymentCode will test the method ParsePaymentCode()
+func TestParsePaymentCode(t *testing.T) {
+	t.Parallel()
+
+	code, err := ParsePaymentCode(testBobPaymentCode)
+	require.NoError(t, err)
+	assert.Equal(t, testBobPaymentCode, code.String())
+
+	address, err := code.NotificationAddress(Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, "1ChvUUvht2hUQufHBXF8NgLhW8SwE2ecGV", address)
+
+	payload := code.payload()
+	invalid := []string{
+		"",
+		testBobPaymentCode[:len(testBobPaymentCode)-1],
+		base58.CheckEncode(payload, 0x00),
+		base58.CheckEncode(payload[:79], paymentCodeBase58),
+		base58.CheckEncode(append([]byte{0x02}, payload[1:]...), paymentCodeBase58),
+		base58.CheckEncode(append(bytes.Clone(payload[:2]), append([]byte{0x04}, payload[3:]...)...), paymentCodeBase58),
+	}
+	for _, test := range invalid {
+		_, err = ParsePaymentCode(test)
+		assert.ErrorIs(t, err, ErrInvalidPaymentCode, test)
+	}
+}
+
+// TestPaymentCodeAddresses will test the methods SendAddress() and ReceiveAddress()
+func TestPaymentCodeAddresses(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := testPaymentCodeAccounts(t)
+	aliceCode, err := ParsePaymentCode(testAlicePaymentCode)
+	require.NoError(t, err)
+	bobCode, err := ParsePaymentCode(testBobPaymentCode)
+	require.NoError(t, err)
+
+	expected := []string{
+		"141fi7TY3h936vRUKh1qfUZr8rSBuYbVBK",
+		"12u3Uued2fuko2nY4SoSFGCoGLCBUGPkk6",
+		"1FsBVhT5dQutGwaPePTYMe5qvYqqjxyftc",
+	}
+	for i, address := range expected {
+		send, err := alice.SendAddress(bobCode, uint32(i), Legacy)
+		require.NoError(t, err)
+		assert.Equal(t, address, send)
+
+		receive, err := bob.ReceiveAddress(aliceCode, uint32(i), Legacy)
+		require.NoError(t, err)
+		assert.Equal(t, address, receive)
+	}
+
+	// segwit and taproot addresses of the same keys
+	for _, addressType := range []AddressType{NativeSegwit, Taproot} {
+		for index := uint32(0); index < 3; index++ {
+			send, err := bob.SendAddress(aliceCode, index, addressType)
+			require.NoError(t, err)
+			receive, err := alice.ReceiveAddress(bobCode, index, addressType)
+			require.NoError(t, err)
+			assert.Equal(t, send, receive)
+
+			sendPubKey, err := bob.SendPubKey(aliceCode, index)
+			require.NoError(t, err)
+			receiveKey, err := alice.ReceiveKey(bobCode, index)
+			require.NoError(t, err)
+			assert.Equal(t, sendPubKey.SerializeCompressed(), receiveKey.PubKey().SerializeCompressed())
+		}
+	}
+
+	send, err := alice.SendAddress(bobCode, 0, Taproot)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(send, "bc1p"))
+
+	// the addresses each way differ
+	reverse, err := bob.SendAddress(aliceCode, 0, Legacy)
+	require.NoError(t, err)
+	assert.NotEqual(t, expected[0], reverse)
+
+	_, err = alice.SendAddress(bobCode, 0, "P2WSH")
+	assert.ErrorIs(t, err, ErrIncorrectAddressType)
+	_, err = alice.SendAddress(&PaymentCode{}, 0, Legacy)
+	assert.ErrorIs(t, err, ErrInvalidPubKey)
+}
+
+// TestPaymentCodeNotification will test the methods AddNotificationOutputs() and ParseNotificationTransaction()
+func TestPaymentCodeNotification(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := testPaymentCodeAccounts(t)
+	designatedKey := testSilentPaymentKey(7)
+	pubKey := designatedKey.PubKey().SerializeCompressed()
+	signature := bytes.Repeat([]byte{0x30}, 71)
+
+	p2pkh := append(append([]byte{txscript.OP_DUP, txscript.OP_HASH160, txscript.OP_DATA_20}, btcutil.Hash160(pubKey)...),
+		txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)
+	p2wpkh := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, btcutil.Hash160(pubKey)...)
+	p2tr := append([]byte{txscript.OP_1, txscript.OP_DATA_32}, bytes.Repeat([]byte{0x01}, 32)...)
+
+	for _, segwit := range []bool{false, true} {
+		tx := wire.NewMsgTx(2)
+		prevOuts := map[wire.OutPoint]*wire.TxOut{}
+
+		// a taproot key path spend exposes no key, the designated input comes after it
+		taproot := wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{6}, Index: 0}, nil, wire.TxWitness{bytes.Repeat([]byte{0x40}, 64)})
+		tx.AddTxIn(taproot)
+		prevOuts[taproot.PreviousOutPoint] = wire.NewTxOut(1000, p2tr)
+
+		in := wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{7}, Index: 1}, nil, nil)
+		if segwit {
+			in.Witness = wire.TxWitness{signature, pubKey}
+			prevOuts[in.PreviousOutPoint] = wire.NewTxOut(1000, p2wpkh)
+		} else {
+			script, err := txscript.NewScriptBuilder().AddData(signature).AddData(pubKey).Script()
+			require.NoError(t, err)
+			in.SignatureScript = script
+			prevOuts[in.PreviousOutPoint] = wire.NewTxOut(1000, p2pkh)
+		}
+		tx.AddTxIn(in)
+
+		last := wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{8}, Index: 0}, nil, nil)
+		tx.AddTxIn(last)
+		prevOuts[last.PreviousOutPoint] = wire.NewTxOut(1000, p2wpkh)
+
+		require.NoError(t, alice.AddNotificationOutputs(tx, prevOuts, designatedKey, bob.PaymentCode()))
+		require.Len(t, tx.TxOut, 2)
+		assert.Equal(t, int64(PaymentCodeNotificationValue), tx.TxOut[0].Value)
+		address, err := GetAddressFromScript(hex.EncodeToString(tx.TxOut[0].PkScript), Mainnet)
+		require.NoError(t, err)
+		assert.Equal(t, "1ChvUUvht2hUQufHBXF8NgLhW8SwE2ecGV", address)
+
+		// the payment code is blinded
+		assert.True(t, IsOpReturnScript(hex.EncodeToString(tx.TxOut[1].PkScript)))
+		assert.NotContains(t, string(tx.TxOut[1].PkScript), string(alice.PaymentCode().ChainCode[:]))
+
+		code, err := bob.ParseNotificationTransaction(tx)
+		require.NoError(t, err)
+		assert.Equal(t, testAlicePaymentCode, code.String())
+
+		// only its recipient can unblind it
+		_, err = alice.ParseNotificationTransaction(tx)
+		assert.ErrorIs(t, err, ErrNotNotificationTransaction)
+	}
+
+	// a notification unblinded with another outpoint is garbage
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{7}, Index: 1}, nil, wire.TxWitness{signature, pubKey}))
+	prevOuts := map[wire.OutPoint]*wire.TxOut{tx.TxIn[0].PreviousOutPoint: wire.NewTxOut(1000, p2wpkh)}
+	require.NoError(t, alice.AddNotificationOutputs(tx, prevOuts, designatedKey, bob.PaymentCode()))
+	tx.TxIn[0].PreviousOutPoint.Index = 2
+	code, err := bob.ParseNotificationTransaction(tx)
+	if err == nil {
+		assert.NotEqual(t, testAlicePaymentCode, code.String())
+	}
+
+	// no input exposes a key
+	tx.TxIn[0].Witness = nil
+	_, err = bob.ParseNotificationTransaction(tx)
+	assert.ErrorIs(t, err, ErrNotNotificationTransaction)
+
+	// a payment to the notification address without a payment code
+	tx = wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{7}, Index: 1}, nil, wire.TxWitness{signature, pubKey}))
+	script, err := GetScriptFromAddress("1ChvUUvht2hUQufHBXF8NgLhW8SwE2ecGV", Mainnet)
+	require.NoError(t, err)
+	pkScript, err := hex.DecodeString(script)
+	require.NoError(t, err)
+	tx.AddTxOut(wire.NewTxOut(1000, pkScript))
+	_, err = bob.ParseNotificationTransaction(tx)
+	assert.ErrorIs(t, err, ErrNotNotificationTransaction)
+
+	assert.ErrorIs(t, alice.AddNotificationOutputs(wire.NewMsgTx(2), prevOuts, designatedKey, bob.PaymentCode()), ErrMissingTransaction)
+	assert.ErrorIs(t, alice.AddNotificationOutputs(tx, prevOuts, nil, bob.PaymentCode()), ErrPrivateKeyMissing)
+	assert.ErrorIs(t, alice.AddNotificationOutputs(tx, nil, designatedKey, bob.PaymentCode()), ErrMissingPrevOut)
+
+	// the recipient would take an input of another key as the designated one
+	other := testSilentPaymentKey(8).PubKey().SerializeCompressed()
+	prevOuts[tx.TxIn[0].PreviousOutPoint] = wire.NewTxOut(1000, append([]byte{txscript.OP_0, txscript.OP_DATA_20}, btcutil.Hash160(other)...))
+	assert.ErrorIs(t, alice.AddNotificationOutputs(tx, prevOuts, designatedKey, bob.PaymentCode()), ErrInputKeyMismatch)
+
+	// or no input at all
+	prevOuts[tx.TxIn[0].PreviousOutPoint] = wire.NewTxOut(1000, p2tr)
+	assert.ErrorIs(t, alice.AddNotificationOutputs(tx, prevOuts, designatedKey, bob.PaymentCode()), ErrInputKeyMismatch)
+	_, err = bob.ParseNotificationTransaction(nil)
+	assert.ErrorIs(t, err, ErrMissingTransaction)
+}
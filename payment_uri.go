@@ -0,0 +1,246 @@
+package bitcoin
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// paymentURIScheme is the scheme of BIP21 payment URIs, case insensitive
+const paymentURIScheme = "bitcoin:"
+
+// BIP21 parameters, and the extensions of BOLT11 (lightning), BIP78 (payjoin) and BIP352 (silent payments)
+const (
+	paymentURIAmount        = "amount"
+	paymentURILabel         = "label"
+	paymentURIMessage       = "message"
+	paymentURILightning     = "lightning"
+	paymentURIPayJoin       = "pj"
+	paymentURIPayJoinOS     = "pjos"
+	paymentURISilent        = "sp"
+	paymentURIRequirePrefix = "req-"
+)
+
+// PaymentURI is a BIP21 payment request: bitcoin:<address>?amount=<btc>&label=<label>&message=<message>.
+// Wallets paying through one of the extensions may ignore the address, which can then be left empty
+type PaymentURI struct {
+	Network NetworkType
+	Address string
+	Amount  btcutil.Amount // 0 leaves the amount to the payer
+	Label   string         // e.g. the name of the shop
+	Message string         // e.g. the order being paid
+
+	Lightning                        string // BOLT11 invoice or BOLT12 offer paying the same amount
+	PayJoin                          string // BIP78 endpoint of the receiver, https or http on an onion service
+	PayJoinDisableOutputSubstitution bool   // pjos=0, the payjoin receiver may not change its output
+	SilentPayment                    string // sp1... address of the receiver
+
+	Params map[string]string // other parameters, the req- ones only when the caller understands them
+}
+
+// ParsePaymentURI decodes a BIP21 payment URI, its addresses being of network. Unknown req- parameters make the
+// URI invalid, except the ones listed in required that the caller handles from Params
+func ParsePaymentURI(uri string, network NetworkType, required ...string) (*PaymentURI, error) {
+
+	// Missing network
+	if network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	if len(uri) < len(paymentURIScheme) || !strings.EqualFold(uri[:len(paymentURIScheme)], paymentURIScheme) {
+		return nil, fmt.Errorf("%w: not a bitcoin: uri", ErrInvalidPaymentURI)
+	}
+
+	address, query, _ := strings.Cut(uri[len(paymentURIScheme):], "?")
+	payment := &PaymentURI{Network: network, Address: address}
+
+	seen := make(map[string]bool)
+	for _, param := range strings.Split(query, "&") {
+		if param == "" {
+			continue
+		}
+
+		key, escaped, _ := strings.Cut(param, "=")
+		value, err := url.PathUnescape(escaped)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidPaymentURI, key, err)
+		}
+
+		key = strings.ToLower(key)
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate %s", ErrInvalidPaymentURI, key)
+		}
+		seen[key] = true
+
+		switch key {
+		case paymentURIAmount:
+			if payment.Amount, err = parsePaymentURIAmount(value); err != nil {
+				return nil, err
+			}
+		case paymentURILabel:
+			payment.Label = value
+		case paymentURIMessage:
+			payment.Message = value
+		case paymentURILightning:
+			payment.Lightning = value
+		case paymentURIPayJoin:
+			payment.PayJoin = value
+		case paymentURIPayJoinOS:
+			payment.PayJoinDisableOutputSubstitution = value == "0"
+		case paymentURISilent:
+			payment.SilentPayment = value
+		default:
+			if strings.HasPrefix(key, paymentURIRequirePrefix) && !containsFold(required, key) {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownRequiredParameter, key)
+			}
+			if payment.Params == nil {
+				payment.Params = make(map[string]string)
+			}
+			payment.Params[key] = value
+		}
+	}
+
+	if err := payment.validate(); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// Encode returns the BIP21 URI of the payment request, with its values percent encoded
+func (p *PaymentURI) Encode() (string, error) {
+	if err := p.validate(); err != nil {
+		return "", err
+	}
+
+	params := [][2]string{
+		{paymentURIAmount, formatPaymentURIAmount(p.Amount)},
+		{paymentURILabel, p.Label},
+		{paymentURIMessage, p.Message},
+		{paymentURILightning, p.Lightning},
+		{paymentURISilent, p.SilentPayment},
+		{paymentURIPayJoin, p.PayJoin},
+	}
+	if p.PayJoin != "" && p.PayJoinDisableOutputSubstitution {
+		params = append(params, [2]string{paymentURIPayJoinOS, "0"})
+	}
+
+	keys := make([]string, 0, len(p.Params))
+	for key := range p.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		params = append(params, [2]string{key, p.Params[key]})
+	}
+
+	var query []string
+	for _, param := range params {
+		if param[1] != "" {
+			query = append(query, param[0]+"="+escapePaymentURIValue(param[1]))
+		}
+	}
+
+	uri := paymentURIScheme + p.Address
+	if len(query) > 0 {
+		uri += "?" + strings.Join(query, "&")
+	}
+	return uri, nil
+}
+
+// validate checks the addresses of the payment request against its network
+func (p *PaymentURI) validate() error {
+
+	// Missing network
+	if p.Network == nil {
+		return ErrMissingNetwork
+	}
+
+	if p.Address == "" && p.Lightning == "" && p.SilentPayment == "" {
+		return ErrMissingAddress
+	}
+
+	if p.Address != "" {
+		_, err := DecodeAddressForNetwork(p.Address, p.Network)
+		if errors.Is(err, ErrWrongNetwork) {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidPaymentURI, err)
+		}
+	}
+
+	if p.SilentPayment != "" {
+		if _, err := ParseSilentPaymentAddress(p.SilentPayment, p.Network); err != nil {
+			return err
+		}
+	}
+
+	if p.PayJoin != "" {
+		endpoint, err := url.Parse(p.PayJoin)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidPaymentURI, paymentURIPayJoin, err)
+		}
+
+		// the original transaction is sent in the clear unless the endpoint is encrypted
+		onion := endpoint.Scheme == "http" && strings.HasSuffix(endpoint.Hostname(), ".onion")
+		if endpoint.Scheme != "https" && !onion {
+			return fmt.Errorf("%w: %s endpoint is not https", ErrInvalidPaymentURI, paymentURIPayJoin)
+		}
+	}
+
+	if p.Amount < 0 || p.Amount > btcutil.MaxSatoshi {
+		return fmt.Errorf("%w: amount %d sat", ErrInvalidPaymentURI, p.Amount)
+	}
+
+	return nil
+}
+
+// parsePaymentURIAmount parses a decimal amount of bitcoin, with at most 8 decimals and no exponent
+func parsePaymentURIAmount(value string) (btcutil.Amount, error) {
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole+fraction == "" || len(fraction) > 8 || strings.Trim(whole+fraction, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: amount %q", ErrInvalidPaymentURI, value)
+	}
+
+	btc, err := strconv.ParseInt("0"+whole, 10, 64)
+	if err != nil || btc > btcutil.MaxSatoshi/btcutil.SatoshiPerBitcoin {
+		return 0, fmt.Errorf("%w: amount %q", ErrInvalidPaymentURI, value)
+	}
+	sat, err := strconv.ParseInt(fraction+strings.Repeat("0", 8-len(fraction)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: amount %q", ErrInvalidPaymentURI, value)
+	}
+
+	return btcutil.Amount(btc*btcutil.SatoshiPerBitcoin + sat), nil
+}
+
+// formatPaymentURIAmount formats an amount in bitcoin without trailing zeros, "" for 0
+func formatPaymentURIAmount(amount btcutil.Amount) string {
+	if amount == 0 {
+		return ""
+	}
+
+	value := fmt.Sprintf("%d.%08d", amount/btcutil.SatoshiPerBitcoin, amount%btcutil.SatoshiPerBitcoin)
+	return strings.TrimSuffix(strings.TrimRight(value, "0"), ".")
+}
+
+// escapePaymentURIValue percent encodes a value, spaces as %20 as "+" is a literal plus in BIP21 URIs
+func escapePaymentURIValue(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// containsFold reports whether values holds value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package bitcoin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testURIAddress   = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	testURILightning = "lnbc10u1p3pj257pp5yztkwjcz5ftl5laxkav23zmzekaw37zk6kmv80pk4xaev5qhtz7qdpdwd3xger9wd5kwm36yprx7u3qd36kucmgyp282etnv3shjcqzpgxqyz5vqsp5usyc4lk9chsfp53kvcnvq456ganh60d89reykdngsmtj6yw3nhvq9qyyssqjcewm5cjwz4a6rfjx77c490yced6pemk0upkxhy89cmm7sct66k8gneanwykzgdrwrfje69h9u5u0w57rrcsysas7gadwmzxc8c6t0spjazup6"
+)
+
+// TestParsePaymentURI will test the method ParsePaymentURI()
+func TestParsePaymentURI(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		uri      string
+		expected PaymentURI
+	}{
+		{"bitcoin:" + testURIAddress, PaymentURI{Address: testURIAddress}},
+		{"BITCOIN:" + strings.ToUpper(testURIAddress), PaymentURI{Address: strings.ToUpper(testURIAddress)}},
+		{"bitcoin:1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH?amount=20.3&label=Luke-Jr", PaymentURI{
+			Address: "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH", Amount: 2030000000, Label: "Luke-Jr",
+		}},
+		{"bitcoin:" + testURIAddress + "?amount=50&label=Luke-Jr&message=Donation%20for%20project%20xyz", PaymentURI{
+			Address: testURIAddress, Amount: 50 * btcutil.SatoshiPerBitcoin, Label: "Luke-Jr", Message: "Donation for project xyz",
+		}},
+		{"bitcoin:" + testURIAddress + "?amount=.00000001&message=1+1%3D2", PaymentURI{
+			Address: testURIAddress, Amount: 1, Message: "1+1=2",
+		}},
+		{"bitcoin:" + testURIAddress + "?somethingyoudontunderstand=50&somethingelseyoudontget=999", PaymentURI{
+			Address: testURIAddress, Params: map[string]string{"somethingyoudontunderstand": "50", "somethingelseyoudontget": "999"},
+		}},
+		{"bitcoin:" + testURIAddress + "?amount=0.001&lightning=" + testURILightning, PaymentURI{
+			Address: testURIAddress, Amount: 100000, Lightning: testURILightning,
+		}},
+		{"bitcoin:?lightning=" + testURILightning, PaymentURI{Lightning: testURILightning}},
+		{"bitcoin:" + testURIAddress + "?pj=https://example.com/pj&pjos=0", PaymentURI{
+			Address: testURIAddress, PayJoin: "https://example.com/pj", PayJoinDisableOutputSubstitution: true,
+		}},
+		{"bitcoin:" + testURIAddress + "?PJ=http%3A%2F%2Fpayjoinexample.onion%2Fpj", PaymentURI{
+			Address: testURIAddress, PayJoin: "http://payjoinexample.onion/pj",
+		}},
+	}
+	for _, test := range tests {
+		payment, err := ParsePaymentURI(test.uri, Mainnet)
+		require.NoError(t, err, test.uri)
+
+		test.expected.Network = Mainnet
+		assert.Equal(t, &test.expected, payment, test.uri)
+	}
+}
+
+// TestParsePaymentURIErrors will test the failures of the method ParsePaymentURI()
+func TestParsePaymentURIErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		uri      string
+		expected error
+	}{
+		{"", ErrInvalidPaymentURI},
+		{"bitcoin", ErrInvalidPaymentURI},
+		{"litecoin:" + testURIAddress, ErrInvalidPaymentURI},
+		{"bitcoin:", ErrMissingAddress},
+		{"bitcoin:?amount=1", ErrMissingAddress},
+		{"bitcoin:notanaddress", ErrInvalidPaymentURI},
+		{"bitcoin:tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", ErrWrongNetwork},
+		{"bitcoin:mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", ErrInvalidPaymentURI},
+		{"bitcoin:" + testURIAddress + "?amount=", ErrInvalidPaymentURI},
+		{"bitcoin:" + testURIAddress + "?amount=.", ErrInvalidPaymentURI},
+		{"bitcoin:" + testURIAddress + "?amount=1,5", ErrInvalidPaymentURI},
+		{"bitcoin:" + testURIAddress + "?amount=1e3", ErrInvalidPaymentURI},
+		{"bitcoin:" + testURIAddress + "?amount=-1", ErrInvalidPaymentURI},
+		{"bitcoin:" + testURIAddress + "?amount=0.000000001", ErrInvalidPaymentURI},
+		{"bitcoin:" + testURIAddress + "?amount=21000001", ErrInvalidPaymentURI},
+		{"bitcoin:" + testURIAddress + "?amount=1&amount=2", ErrInvalidPaymentURI},
+		{"bitcoin:" + testURIAddress + "?label=%zz", ErrInvalidPaymentURI},
+		{"bitcoin:" + testURIAddress + "?req-somethingyoudontunderstand=50", ErrUnknownRequiredParameter},
+		{"bitcoin:" + testURIAddress + "?pj=http://example.com/pj", ErrInvalidPaymentURI},
+		{"bitcoin:" + testURIAddress + "?sp=sp1qinvalid", ErrInvalidSilentPaymentAddress},
+	}
+	for _, test := range tests {
+		_, err := ParsePaymentURI(test.uri, Mainnet)
+		assert.ErrorIs(t, err, test.expected, test.uri)
+	}
+
+	_, err := ParsePaymentURI("bitcoin:"+testURIAddress, nil)
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+
+	// required parameters the caller understands
+	payment, err := ParsePaymentURI("bitcoin:"+testURIAddress+"?req-expires=1700000000", Mainnet, "req-expires")
+	require.NoError(t, err)
+	assert.Equal(t, "1700000000", payment.Params["req-expires"])
+}
+
+// TestPaymentURIEncode will test the method Encode()
+func TestPaymentURIEncode(t *testing.T) {
+	t.Parallel()
+
+	receiver, _ := testSilentPaymentReceiver(t, 1)
+	silentPayment, err := receiver.Address()
+	require.NoError(t, err)
+
+	tests := []struct {
+		payment  PaymentURI
+		expected string
+	}{
+		{PaymentURI{Address: testURIAddress}, "bitcoin:" + testURIAddress},
+		{PaymentURI{Address: testURIAddress, Amount: 2030000000, Label: "Luke-Jr"}, "bitcoin:" + testURIAddress + "?amount=20.3&label=Luke-Jr"},
+		{PaymentURI{Address: testURIAddress, Amount: 1, Message: "Order #42 & more: 1+1"},
+			"bitcoin:" + testURIAddress + "?amount=0.00000001&message=Order%20%2342%20%26%20more%3A%201%2B1"},
+		{PaymentURI{Address: testURIAddress, Amount: btcutil.SatoshiPerBitcoin, Lightning: testURILightning},
+			"bitcoin:" + testURIAddress + "?amount=1&lightning=" + testURILightning},
+		{PaymentURI{Lightning: testURILightning}, "bitcoin:?lightning=" + testURILightning},
+		{PaymentURI{Address: testURIAddress, SilentPayment: silentPayment}, "bitcoin:" + testURIAddress + "?sp=" + silentPayment},
+		{PaymentURI{Address: testURIAddress, PayJoin: "https://example.com/pj?v=1", PayJoinDisableOutputSubstitution: true},
+			"bitcoin:" + testURIAddress + "?pj=https%3A%2F%2Fexample.com%2Fpj%3Fv%3D1&pjos=0"},
+		{PaymentURI{Address: testURIAddress, Params: map[string]string{"req-expires": "1700000000", "id": "a b"}},
+			"bitcoin:" + testURIAddress + "?id=a%20b&req-expires=1700000000"},
+	}
+	for _, test := range tests {
+		test.payment.Network = Mainnet
+		uri, err := test.payment.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, uri)
+
+		// checkout pages and wallets agree on the payment
+		payment, err := ParsePaymentURI(uri, Mainnet, "req-expires")
+		require.NoError(t, err)
+		assert.Equal(t, &test.payment, payment)
+	}
+
+	_, err = (&PaymentURI{Network: Testnet, Address: testURIAddress}).Encode()
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+	_, err = (&PaymentURI{Network: Mainnet}).Encode()
+	assert.ErrorIs(t, err, ErrMissingAddress)
+	_, err = (&PaymentURI{Network: Mainnet, Address: testURIAddress, Amount: -1}).Encode()
+	assert.ErrorIs(t, err, ErrInvalidPaymentURI)
+	_, err = (&PaymentURI{Network: Testnet, Address: "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", SilentPayment: silentPayment}).Encode()
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+	_, err = (&PaymentURI{Address: testURIAddress}).Encode()
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+}
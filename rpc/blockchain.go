@@ -0,0 +1,263 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// EstimateMode is the estimate mode of estimatesmartfee
+type EstimateMode string
+
+const (
+	EstimateModeEconomical   EstimateMode = "ECONOMICAL"
+	EstimateModeConservative EstimateMode = "CONSERVATIVE"
+)
+
+// BlockchainInfo is the result of getblockchaininfo
+type BlockchainInfo struct {
+	Chain                string  `json:"chain"`
+	Blocks               int64   `json:"blocks"`
+	Headers              int64   `json:"headers"`
+	BestBlockHash        string  `json:"bestblockhash"`
+	Difficulty           float64 `json:"difficulty"`
+	MedianTime           int64   `json:"mediantime"`
+	VerificationProgress float64 `json:"verificationprogress"`
+	InitialBlockDownload bool    `json:"initialblockdownload"`
+	ChainWork            string  `json:"chainwork"`
+	SizeOnDisk           int64   `json:"size_on_disk"`
+	Pruned               bool    `json:"pruned"`
+}
+
+// FeeEstimate is the result of estimatesmartfee
+type FeeEstimate struct {
+	FeeRate btcutil.Amount // per 1000 virtual bytes
+	Blocks  int64
+	Errors  []string
+}
+
+// SatPerVByte returns the estimated fee rate in satoshis per virtual byte
+func (f *FeeEstimate) SatPerVByte() float64 {
+	return float64(f.FeeRate) / 1000
+}
+
+// ScanResult is the result of scantxoutset
+type ScanResult struct {
+	Success     bool
+	Height      int64
+	BestBlock   string
+	Unspents    []Unspent
+	TotalAmount btcutil.Amount
+}
+
+// MempoolAcceptResult is the result of testmempoolaccept for one transaction
+type MempoolAcceptResult struct {
+	TxID         string
+	WTxID        string
+	Allowed      bool
+	VSize        int64
+	Fee          btcutil.Amount
+	RejectReason string
+}
+
+// GetBlockchainInfo returns the state of the node's chain
+func (c *Client) GetBlockchainInfo(ctx context.Context) (*BlockchainInfo, error) {
+	var info BlockchainInfo
+	if err := c.Call(ctx, "getblockchaininfo", nil, &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// GetBlockCount returns the height of the best block
+func (c *Client) GetBlockCount(ctx context.Context) (int64, error) {
+	var height int64
+	if err := c.Call(ctx, "getblockcount", nil, &height); err != nil {
+		return 0, err
+	}
+
+	return height, nil
+}
+
+// GetRawTransaction returns a transaction by txid. Unless the node runs with -txindex, only
+// mempool transactions and transactions of the wallet are found
+func (c *Client) GetRawTransaction(ctx context.Context, txID string) (*wire.MsgTx, error) {
+	var rawTx string
+	if err := c.Call(ctx, "getrawtransaction", []any{txID, false}, &rawTx); err != nil {
+		return nil, err
+	}
+
+	return bitcoin.TransactionFromString(rawTx)
+}
+
+// GetDecodedTransaction returns a transaction by txid, decoded for the client's network
+func (c *Client) GetDecodedTransaction(ctx context.Context, txID string) (*bitcoin.DecodedTransaction, error) {
+	tx, err := c.GetRawTransaction(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bitcoin.DecodeTransaction(tx, c.config.Network), nil
+}
+
+// SendRawTransaction broadcasts a signed transaction and returns its txid
+func (c *Client) SendRawTransaction(ctx context.Context, tx *wire.MsgTx) (string, error) {
+	rawTx, err := serializeTx(tx)
+	if err != nil {
+		return "", err
+	}
+
+	var txID string
+	if err = c.Call(ctx, "sendrawtransaction", []any{rawTx}, &txID); err != nil {
+		return "", err
+	}
+
+	return txID, nil
+}
+
+// EstimateSmartFee estimates the fee rate needed for a transaction to confirm within confTarget blocks
+func (c *Client) EstimateSmartFee(ctx context.Context, confTarget int, mode EstimateMode) (*FeeEstimate, error) {
+	var result struct {
+		FeeRate float64  `json:"feerate"`
+		Blocks  int64    `json:"blocks"`
+		Errors  []string `json:"errors"`
+	}
+	if err := c.Call(ctx, "estimatesmartfee", []any{confTarget, mode}, &result); err != nil {
+		return nil, err
+	}
+
+	feeRate, err := btcutil.NewAmount(result.FeeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeeEstimate{FeeRate: feeRate, Blocks: result.Blocks, Errors: result.Errors}, nil
+}
+
+// ScanTxOutSet scans the UTXO set for outputs paying to addresses, which are validated against the
+// client's network. The scan does not need a wallet but can take minutes on mainnet
+func (c *Client) ScanTxOutSet(ctx context.Context, addresses []string) (*ScanResult, error) {
+	if err := c.validateAddresses(addresses); err != nil {
+		return nil, err
+	}
+
+	descriptors := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		descriptor, err := bitcoin.AddDescriptorChecksum("addr(" + address + ")")
+		if err != nil {
+			return nil, err
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+
+	return c.scanTxOutSet(ctx, descriptors)
+}
+
+// TestMempoolAccept checks whether the node would accept transactions into its mempool, without broadcasting them
+func (c *Client) TestMempoolAccept(ctx context.Context, txs []*wire.MsgTx) ([]MempoolAcceptResult, error) {
+	rawTxs := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		rawTx, err := serializeTx(tx)
+		if err != nil {
+			return nil, err
+		}
+		rawTxs = append(rawTxs, rawTx)
+	}
+
+	var results []struct {
+		TxID         string `json:"txid"`
+		WTxID        string `json:"wtxid"`
+		Allowed      bool   `json:"allowed"`
+		VSize        int64  `json:"vsize"`
+		RejectReason string `json:"reject-reason"`
+		Fees         struct {
+			Base float64 `json:"base"`
+		} `json:"fees"`
+	}
+	if err := c.Call(ctx, "testmempoolaccept", []any{rawTxs}, &results); err != nil {
+		return nil, err
+	}
+
+	accepted := make([]MempoolAcceptResult, 0, len(results))
+	for _, result := range results {
+		fee, err := btcutil.NewAmount(result.Fees.Base)
+		if err != nil {
+			return nil, err
+		}
+
+		accepted = append(accepted, MempoolAcceptResult{
+			TxID:         result.TxID,
+			WTxID:        result.WTxID,
+			Allowed:      result.Allowed,
+			VSize:        result.VSize,
+			Fee:          fee,
+			RejectReason: result.RejectReason,
+		})
+	}
+
+	return accepted, nil
+}
+
+// scanTxOutSet scans the UTXO set for outputs matching descriptors
+func (c *Client) scanTxOutSet(ctx context.Context, descriptors []string) (*ScanResult, error) {
+	var result struct {
+		Success     bool      `json:"success"`
+		Height      int64     `json:"height"`
+		BestBlock   string    `json:"bestblock"`
+		Unspents    []Unspent `json:"unspents"`
+		TotalAmount float64   `json:"total_amount"`
+	}
+	if err := c.Call(ctx, "scantxoutset", []any{"start", descriptors}, &result); err != nil {
+		return nil, err
+	}
+
+	totalAmount, err := btcutil.NewAmount(result.TotalAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	c.resolveAddresses(result.Unspents)
+
+	return &ScanResult{
+		Success:     result.Success,
+		Height:      result.Height,
+		BestBlock:   result.BestBlock,
+		Unspents:    result.Unspents,
+		TotalAmount: totalAmount,
+	}, nil
+}
+
+// resolveAddresses fills in the address of unspent outputs the node did not label with one
+func (c *Client) resolveAddresses(unspents []Unspent) {
+	for i := range unspents {
+		if len(unspents[i].Address) == 0 {
+			unspents[i].Address, _ = bitcoin.GetAddressFromScript(unspents[i].ScriptPubKey, c.config.Network)
+		}
+	}
+}
+
+// validateAddresses checks that addresses are valid for the client's network
+func (c *Client) validateAddresses(addresses []string) error {
+	for _, address := range addresses {
+		if _, err := bitcoin.DecodeAddressForNetwork(address, c.config.Network); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serializeTx hex encodes a transaction
+func serializeTx(tx *wire.MsgTx) (string, error) {
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// genesisCoinbaseTx is the coinbase transaction of the mainnet genesis block
+const genesisCoinbaseTx = "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+// genesisCoinbaseTxID is the txid of genesisCoinbaseTx
+const genesisCoinbaseTxID = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+
+// TestClientGetBlockchainInfo will test the method GetBlockchainInfo()
+func TestClientGetBlockchainInfo(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(_, method string, _ []json.RawMessage) (any, *Error) {
+		require.Equal(t, "getblockchaininfo", method)
+		return map[string]any{"chain": "test", "blocks": 2500000, "headers": 2500001, "initialblockdownload": false}, nil
+	})
+
+	info, err := client.GetBlockchainInfo(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "test", info.Chain)
+	assert.Equal(t, int64(2500000), info.Blocks)
+	assert.Equal(t, int64(2500001), info.Headers)
+}
+
+// TestClientRawTransactions will test the methods GetRawTransaction(), GetDecodedTransaction() and SendRawTransaction()
+func TestClientRawTransactions(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(_, method string, params []json.RawMessage) (any, *Error) {
+		switch method {
+		case "getrawtransaction":
+			var txID string
+			require.NoError(t, json.Unmarshal(params[0], &txID))
+			if txID != genesisCoinbaseTxID {
+				return nil, &Error{Code: -5, Message: "No such mempool or blockchain transaction"}
+			}
+			return genesisCoinbaseTx, nil
+		case "sendrawtransaction":
+			var rawTx string
+			require.NoError(t, json.Unmarshal(params[0], &rawTx))
+			require.Equal(t, genesisCoinbaseTx, rawTx)
+			return genesisCoinbaseTxID, nil
+		}
+		return nil, &Error{Code: -32601, Message: "Method not found"}
+	})
+
+	tx, err := client.GetRawTransaction(context.Background(), genesisCoinbaseTxID)
+	require.NoError(t, err)
+	assert.Equal(t, genesisCoinbaseTxID, tx.TxHash().String())
+
+	decoded, err := client.GetDecodedTransaction(context.Background(), genesisCoinbaseTxID)
+	require.NoError(t, err)
+	assert.Equal(t, genesisCoinbaseTxID, decoded.TxID)
+	assert.Equal(t, "pubkey", decoded.Vout[0].ScriptPubKey.Type)
+
+	_, err = client.GetRawTransaction(context.Background(), "00")
+	var rpcErr *Error
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, -5, rpcErr.Code)
+
+	txID, err := client.SendRawTransaction(context.Background(), tx)
+	require.NoError(t, err)
+	assert.Equal(t, genesisCoinbaseTxID, txID)
+}
+
+// TestClientEstimateSmartFee will test the method EstimateSmartFee()
+func TestClientEstimateSmartFee(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(_, _ string, params []json.RawMessage) (any, *Error) {
+		var mode EstimateMode
+		require.NoError(t, json.Unmarshal(params[1], &mode))
+		require.Equal(t, EstimateModeConservative, mode)
+		return map[string]any{"feerate": 0.00012345, "blocks": 6}, nil
+	})
+
+	estimate, err := client.EstimateSmartFee(context.Background(), 6, EstimateModeConservative)
+	require.NoError(t, err)
+	assert.Equal(t, btcutil.Amount(12345), estimate.FeeRate)
+	assert.Equal(t, int64(6), estimate.Blocks)
+	assert.InDelta(t, 12.345, estimate.SatPerVByte(), 1e-9)
+}
+
+// TestClientScanTxOutSet will test the method ScanTxOutSet()
+func TestClientScanTxOutSet(t *testing.T) {
+	t.Parallel()
+
+	const address = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+	script, err := bitcoin.GetScriptFromAddress(address, bitcoin.Testnet)
+	require.NoError(t, err)
+
+	client := newTestClient(t, func(_, _ string, params []json.RawMessage) (any, *Error) {
+		var descriptors []string
+		require.NoError(t, json.Unmarshal(params[1], &descriptors))
+		require.Equal(t, []string{"addr(" + address + ")#" + mustChecksum(t, "addr("+address+")")}, descriptors)
+
+		return map[string]any{
+			"success":      true,
+			"height":       2500000,
+			"bestblock":    "00",
+			"total_amount": 0.5,
+			"unspents": []map[string]any{
+				{"txid": genesisCoinbaseTxID, "vout": 1, "scriptPubKey": script, "amount": 0.5, "height": 2400000},
+			},
+		}, nil
+	})
+
+	result, err := client.ScanTxOutSet(context.Background(), []string{address})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, btcutil.Amount(50000000), result.TotalAmount)
+	require.Len(t, result.Unspents, 1)
+	assert.Equal(t, address, result.Unspents[0].Address)
+	assert.Equal(t, btcutil.Amount(50000000), result.Unspents[0].Amount)
+	assert.Equal(t, int64(2400000), result.Unspents[0].Height)
+
+	// mainnet address on a testnet client
+	_, err = client.ScanTxOutSet(context.Background(), []string{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"})
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+}
+
+// TestClientTestMempoolAccept will test the method TestMempoolAccept()
+func TestClientTestMempoolAccept(t *testing.T) {
+	t.Parallel()
+
+	tx, err := bitcoin.TransactionFromString(genesisCoinbaseTx)
+	require.NoError(t, err)
+
+	client := newTestClient(t, func(_, _ string, params []json.RawMessage) (any, *Error) {
+		var rawTxs []string
+		require.NoError(t, json.Unmarshal(params[0], &rawTxs))
+		require.Equal(t, []string{genesisCoinbaseTx}, rawTxs)
+
+		return []map[string]any{
+			{"txid": genesisCoinbaseTxID, "wtxid": genesisCoinbaseTxID, "allowed": true, "vsize": 204, "fees": map[string]any{"base": 0.00001}},
+			{"txid": genesisCoinbaseTxID, "allowed": false, "reject-reason": "coinbase"},
+		}, nil
+	})
+
+	results, err := client.TestMempoolAccept(context.Background(), []*wire.MsgTx{tx})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Allowed)
+	assert.Equal(t, btcutil.Amount(1000), results[0].Fee)
+	assert.Equal(t, int64(204), results[0].VSize)
+	assert.False(t, results[1].Allowed)
+	assert.Equal(t, "coinbase", results[1].RejectReason)
+}
+
+// mustChecksum returns the descriptor checksum of descriptor
+func mustChecksum(t *testing.T, descriptor string) string {
+	checksum, err := bitcoin.GetDescriptorChecksum(descriptor)
+	require.NoError(t, err)
+	return checksum
+}
@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// rpcErrInvalidAddressOrKey is the code the node returns for unknown transactions
+const rpcErrInvalidAddressOrKey = -5
+
+// ChainBackend adapts a Client to bitcoin.ChainBackend. Unspent outputs come from scantxoutset
+// and therefore only include confirmed outputs
+type ChainBackend struct {
+	client       *Client
+	pollInterval time.Duration
+}
+
+// NewChainBackend creates a chain backend polling the node for new blocks every pollInterval, bitcoin.DefaultPollInterval when not positive
+func NewChainBackend(client *Client, pollInterval time.Duration) *ChainBackend {
+	if pollInterval <= 0 {
+		pollInterval = bitcoin.DefaultPollInterval
+	}
+
+	return &ChainBackend{client: client, pollInterval: pollInterval}
+}
+
+// GetUtxos implements bitcoin.ChainBackend
+func (b *ChainBackend) GetUtxos(ctx context.Context, scripts []string) ([]bitcoin.Utxo, error) {
+	if len(scripts) == 0 {
+		return nil, nil
+	}
+
+	descriptors := make([]string, 0, len(scripts))
+	for _, script := range scripts {
+		descriptor, err := bitcoin.AddDescriptorChecksum("raw(" + script + ")")
+		if err != nil {
+			return nil, err
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+
+	result, err := b.client.scanTxOutSet(ctx, descriptors)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]bitcoin.Utxo, 0, len(result.Unspents))
+	for _, unspent := range result.Unspents {
+		utxos = append(utxos, bitcoin.Utxo{
+			TxID:   unspent.TxID,
+			Vout:   unspent.Vout,
+			Value:  unspent.Amount,
+			Script: unspent.ScriptPubKey,
+			Height: unspent.Height,
+		})
+	}
+
+	return utxos, nil
+}
+
+// GetTransaction implements bitcoin.ChainBackend
+func (b *ChainBackend) GetTransaction(ctx context.Context, txID string) (*wire.MsgTx, error) {
+	tx, err := b.client.GetRawTransaction(ctx, txID)
+
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) && rpcErr.Code == rpcErrInvalidAddressOrKey {
+		return nil, fmt.Errorf("%w: %w", bitcoin.ErrTransactionNotFound, err)
+	}
+
+	return tx, err
+}
+
+// Broadcast implements bitcoin.ChainBackend
+func (b *ChainBackend) Broadcast(ctx context.Context, tx *wire.MsgTx) (string, error) {
+	return b.client.SendRawTransaction(ctx, tx)
+}
+
+// EstimateFee implements bitcoin.ChainBackend with the conservative mode of estimatesmartfee
+func (b *ChainBackend) EstimateFee(ctx context.Context, confTarget int) (float64, error) {
+	estimate, err := b.client.EstimateSmartFee(ctx, confTarget, EstimateModeConservative)
+	if err != nil {
+		return 0, err
+	}
+
+	// the node reports errors instead of a fee rate until it has seen enough blocks
+	if estimate.FeeRate <= 0 {
+		return 0, fmt.Errorf("%w: %v", bitcoin.ErrFeeEstimateUnavailable, estimate.Errors)
+	}
+
+	return estimate.SatPerVByte(), nil
+}
+
+// TipHeight implements bitcoin.ChainBackend
+func (b *ChainBackend) TipHeight(ctx context.Context) (int64, error) {
+	return b.client.GetBlockCount(ctx)
+}
+
+// SubscribeBlocks implements bitcoin.ChainBackend by polling getblockchaininfo
+func (b *ChainBackend) SubscribeBlocks(ctx context.Context) (<-chan bitcoin.BlockEvent, error) {
+	return bitcoin.PollBlocks(ctx, b.pollInterval, func(ctx context.Context) (bitcoin.BlockEvent, error) {
+		info, err := b.client.GetBlockchainInfo(ctx)
+		if err != nil {
+			return bitcoin.BlockEvent{}, err
+		}
+
+		return bitcoin.BlockEvent{Height: info.Blocks, Hash: info.BestBlockHash}, nil
+	}), nil
+}
+
+// compile time check
+var _ bitcoin.ChainBackend = (*ChainBackend)(nil)
@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChainBackend will test the bitcoin.ChainBackend adapter of the client
+func TestChainBackend(t *testing.T) {
+	t.Parallel()
+
+	const script = "0014751e76e8199196d454941c45d1b3a323f1433bd6"
+
+	var height atomic.Int64
+	height.Store(100)
+
+	client := newTestClient(t, func(_, method string, params []json.RawMessage) (any, *Error) {
+		switch method {
+		case "scantxoutset":
+			var descriptors []string
+			require.NoError(t, json.Unmarshal(params[1], &descriptors))
+			require.Equal(t, []string{"raw(" + script + ")#" + mustChecksum(t, "raw("+script+")")}, descriptors)
+
+			return map[string]any{
+				"success": true, "total_amount": 0.001,
+				"unspents": []map[string]any{{"txid": genesisCoinbaseTxID, "vout": 2, "scriptPubKey": script, "amount": 0.001, "height": 99}},
+			}, nil
+		case "getrawtransaction":
+			return nil, &Error{Code: -5, Message: "No such mempool or blockchain transaction"}
+		case "estimatesmartfee":
+			var target int
+			require.NoError(t, json.Unmarshal(params[0], &target))
+			if target == 1 {
+				return map[string]any{"errors": []string{"Insufficient data or no feerate found"}, "blocks": 0}, nil
+			}
+			return map[string]any{"feerate": 0.00002, "blocks": target}, nil
+		case "getblockcount":
+			return height.Load(), nil
+		case "getblockchaininfo":
+			return map[string]any{"blocks": height.Load(), "bestblockhash": "hash"}, nil
+		}
+		return nil, &Error{Code: -32601, Message: "Method not found"}
+	})
+
+	var backend bitcoin.ChainBackend = NewChainBackend(client, time.Millisecond)
+	ctx := context.Background()
+
+	utxos, err := backend.GetUtxos(ctx, []string{script})
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Equal(t, bitcoin.Utxo{TxID: genesisCoinbaseTxID, Vout: 2, Value: btcutil.Amount(100000), Script: script, Height: 99}, utxos[0])
+
+	utxos, err = backend.GetUtxos(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, utxos)
+
+	_, err = backend.GetTransaction(ctx, genesisCoinbaseTxID)
+	assert.ErrorIs(t, err, bitcoin.ErrTransactionNotFound)
+
+	feeRate, err := backend.EstimateFee(ctx, 6)
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0, feeRate, 1e-9)
+
+	_, err = backend.EstimateFee(ctx, 1)
+	assert.ErrorIs(t, err, bitcoin.ErrFeeEstimateUnavailable)
+
+	tipHeight, err := backend.TipHeight(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), tipHeight)
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := backend.SubscribeBlocks(subCtx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), (<-events).Height)
+
+	height.Store(101)
+	assert.Equal(t, int64(101), (<-events).Height)
+}
+
+// TestNewChainBackend will test that NewChainBackend() without a poll interval polls at the default one
+func TestNewChainBackend(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(_, method string, _ []json.RawMessage) (any, *Error) {
+		return map[string]any{"blocks": 100, "bestblockhash": "hash"}, nil
+	})
+	backend := NewChainBackend(client, 0)
+	assert.Equal(t, bitcoin.DefaultPollInterval, backend.pollInterval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := backend.SubscribeBlocks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, bitcoin.BlockEvent{Height: 100, Hash: "hash"}, <-events)
+
+	cancel()
+	for range events {
+	}
+}
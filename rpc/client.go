@@ -0,0 +1,226 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// Config holds the connection settings of a Client. Either User and Password or CookieFile
+// (the .cookie file bitcoind writes to its data directory) must be set
+type Config struct {
+	URL        string // e.g. http://127.0.0.1:8332
+	Wallet     string // wallet used by wallet calls, left empty for the default wallet
+	User       string
+	Password   string
+	CookieFile string
+	Network    bitcoin.NetworkType
+	HTTPClient *http.Client // defaults to http.DefaultClient
+}
+
+// Client is a Bitcoin Core JSON-RPC client, safe for concurrent use
+type Client struct {
+	config Config
+	nextID atomic.Uint64
+}
+
+// Call is a single call of a batch. Result must be a pointer the result is decoded into,
+// or nil to discard it. Err is set once the batch has run
+type Call struct {
+	Method string
+	Params []any
+	Result any
+	Err    error
+}
+
+// request is a JSON-RPC request
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+// response is a JSON-RPC response
+type response struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+// New creates a new client for the node at config.URL
+func New(config Config) (*Client, error) {
+
+	// Missing url
+	if len(config.URL) == 0 {
+		return nil, ErrMissingURL
+	}
+
+	// Missing network
+	if config.Network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	config.URL = strings.TrimSuffix(config.URL, "/")
+
+	return &Client{config: config}, nil
+}
+
+// Network returns the network the client was configured for
+func (c *Client) Network() bitcoin.NetworkType {
+	return c.config.Network
+}
+
+// Call runs a node call and decodes its result into result (a pointer, or nil to discard it)
+func (c *Client) Call(ctx context.Context, method string, params []any, result any) error {
+	return c.call(ctx, c.config.URL, method, params, result)
+}
+
+// BatchCall sends several calls in a single request. The error returned is for the request
+// as a whole, each call carries its own Err
+func (c *Client) BatchCall(ctx context.Context, calls []*Call) error {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	requests := make([]request, 0, len(calls))
+	byID := make(map[uint64]*Call, len(calls))
+	for _, call := range calls {
+		req := c.newRequest(call.Method, call.Params)
+		requests = append(requests, req)
+		byID[req.ID] = call
+	}
+
+	var responses []response
+	if err := c.post(ctx, c.config.URL, requests, &responses); err != nil {
+		return err
+	}
+
+	// responses are matched by id as the node does not have to keep the order
+	for _, resp := range responses {
+		call, ok := byID[resp.ID]
+		if !ok {
+			continue
+		}
+		call.Err = decodeResult(resp, call.Result)
+		delete(byID, resp.ID)
+	}
+
+	for id, call := range byID {
+		call.Err = fmt.Errorf("missing response for call %d (%s)", id, call.Method)
+	}
+
+	return nil
+}
+
+// walletCall runs a call against the wallet endpoint of the configured wallet
+func (c *Client) walletCall(ctx context.Context, method string, params []any, result any) error {
+	endpoint := c.config.URL
+	if len(c.config.Wallet) > 0 {
+		endpoint += "/wallet/" + url.PathEscape(c.config.Wallet)
+	}
+
+	return c.call(ctx, endpoint, method, params, result)
+}
+
+// call runs a single call against url
+func (c *Client) call(ctx context.Context, url, method string, params []any, result any) error {
+	var resp response
+	if err := c.post(ctx, url, c.newRequest(method, params), &resp); err != nil {
+		return err
+	}
+
+	return decodeResult(resp, result)
+}
+
+// newRequest returns a request with the next id
+func (c *Client) newRequest(method string, params []any) request {
+	if params == nil {
+		params = []any{}
+	}
+
+	return request{JSONRPC: "1.0", ID: c.nextID.Add(1), Method: method, Params: params}
+}
+
+// post sends a JSON body to url and decodes the JSON response into out
+func (c *Client) post(ctx context.Context, url string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	user, password, err := c.credentials()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(user, password)
+
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return ErrUnauthorized
+	}
+
+	// the node answers call errors with a non 200 status but still sends a JSON body
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("rpc status %d: %w", resp.StatusCode, err)
+	}
+
+	return nil
+}
+
+// credentials returns the configured user and password, reading the cookie file on every
+// call since bitcoind writes a new one each time it starts
+func (c *Client) credentials() (string, string, error) {
+	if len(c.config.CookieFile) == 0 {
+		return c.config.User, c.config.Password, nil
+	}
+
+	cookie, err := os.ReadFile(c.config.CookieFile)
+	if err != nil {
+		return "", "", err
+	}
+
+	user, password, found := strings.Cut(strings.TrimSpace(string(cookie)), ":")
+	if !found {
+		return "", "", ErrInvalidCookie
+	}
+
+	return user, password, nil
+}
+
+// decodeResult returns the error of a response, or decodes its result into result
+func decodeResult(resp response, result any) error {
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(resp.Result, result)
+}
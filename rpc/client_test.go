@@ -0,0 +1,201 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testUser     = "user"
+	testPassword = "password"
+)
+
+// handlerFunc answers a single call of the fake node
+type handlerFunc func(path, method string, params []json.RawMessage) (any, *Error)
+
+// newTestServer starts a fake node answering single and batched calls with handler
+func newTestServer(t *testing.T, handler handlerFunc) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != testUser || password != testPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		answer := func(raw json.RawMessage) map[string]any {
+			var req struct {
+				ID     uint64            `json:"id"`
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+			}
+			require.NoError(t, json.Unmarshal(raw, &req))
+
+			result, rpcErr := handler(r.URL.Path, req.Method, req.Params)
+			return map[string]any{"id": req.ID, "result": result, "error": rpcErr}
+		}
+
+		if body[0] != '[' {
+			resp := answer(body)
+			if resp["error"].(*Error) != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+			return
+		}
+
+		var batch []json.RawMessage
+		require.NoError(t, json.Unmarshal(body, &batch))
+
+		// answer in reverse order, the client has to match ids
+		responses := make([]map[string]any, 0, len(batch))
+		for i := len(batch) - 1; i >= 0; i-- {
+			responses = append(responses, answer(batch[i]))
+		}
+		_ = json.NewEncoder(w).Encode(responses)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newTestClient returns a client for the fake node
+func newTestClient(t *testing.T, handler handlerFunc) *Client {
+	server := newTestServer(t, handler)
+
+	client, err := New(Config{URL: server.URL, User: testUser, Password: testPassword, Network: bitcoin.Testnet})
+	require.NoError(t, err)
+	return client
+}
+
+// TestNew will test the method New()
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(Config{Network: bitcoin.Mainnet})
+	assert.ErrorIs(t, err, ErrMissingURL)
+
+	_, err = New(Config{URL: "http://127.0.0.1:8332"})
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+	assert.ErrorIs(t, err, bitcoin.ErrMissingNetwork)
+
+	client, err := New(Config{URL: "http://127.0.0.1:8332/", Network: bitcoin.Mainnet})
+	require.NoError(t, err)
+	assert.Equal(t, bitcoin.Mainnet, client.Network())
+}
+
+// TestClientCall will test the method Call()
+func TestClientCall(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(_, method string, params []json.RawMessage) (any, *Error) {
+		switch method {
+		case "getblockcount":
+			return 840000, nil
+		case "echo":
+			return params, nil
+		default:
+			return nil, &Error{Code: -32601, Message: "Method not found"}
+		}
+	})
+
+	var count int64
+	require.NoError(t, client.Call(context.Background(), "getblockcount", nil, &count))
+	assert.Equal(t, int64(840000), count)
+
+	var echoed []string
+	require.NoError(t, client.Call(context.Background(), "echo", []any{"a", "b"}, &echoed))
+	assert.Equal(t, []string{"a", "b"}, echoed)
+
+	err := client.Call(context.Background(), "nope", nil, nil)
+	var rpcErr *Error
+	require.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, -32601, rpcErr.Code)
+}
+
+// TestClientBatchCall will test the method BatchCall()
+func TestClientBatchCall(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(_, method string, params []json.RawMessage) (any, *Error) {
+		if method == "getblockhash" {
+			var height int
+			require.NoError(t, json.Unmarshal(params[0], &height))
+			return height * 2, nil
+		}
+		return nil, &Error{Code: -32601, Message: "Method not found"}
+	})
+
+	var first, second int
+	calls := []*Call{
+		{Method: "getblockhash", Params: []any{1}, Result: &first},
+		{Method: "getblockhash", Params: []any{2}, Result: &second},
+		{Method: "nope"},
+	}
+	require.NoError(t, client.BatchCall(context.Background(), calls))
+
+	assert.NoError(t, calls[0].Err)
+	assert.Equal(t, 2, first)
+	assert.NoError(t, calls[1].Err)
+	assert.Equal(t, 4, second)
+	assert.Error(t, calls[2].Err)
+
+	require.NoError(t, client.BatchCall(context.Background(), nil))
+}
+
+// TestClientAuth will test user/password and cookie authentication
+func TestClientAuth(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(_, _ string, _ []json.RawMessage) (any, *Error) {
+		return true, nil
+	})
+
+	client, err := New(Config{URL: server.URL, User: testUser, Password: "wrong", Network: bitcoin.Mainnet})
+	require.NoError(t, err)
+	assert.ErrorIs(t, client.Call(context.Background(), "ping", nil, nil), ErrUnauthorized)
+
+	cookieFile := filepath.Join(t.TempDir(), ".cookie")
+	client, err = New(Config{URL: server.URL, CookieFile: cookieFile, Network: bitcoin.Mainnet})
+	require.NoError(t, err)
+
+	// missing cookie file
+	assert.Error(t, client.Call(context.Background(), "ping", nil, nil))
+
+	require.NoError(t, os.WriteFile(cookieFile, []byte("garbage"), 0o600))
+	assert.ErrorIs(t, client.Call(context.Background(), "ping", nil, nil), ErrInvalidCookie)
+
+	require.NoError(t, os.WriteFile(cookieFile, []byte(testUser+":"+testPassword+"\n"), 0o600))
+	assert.NoError(t, client.Call(context.Background(), "ping", nil, nil))
+}
+
+// TestClientContext will test that calls are cancelled with their context
+func TestClientContext(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t, func(_, _ string, _ []json.RawMessage) (any, *Error) {
+		time.Sleep(time.Second)
+		return true, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := client.Call(ctx, "ping", nil, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,34 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// ErrMissingURL is returned when a client is created without a node URL
+var ErrMissingURL = errors.New("missing rpc url")
+
+// ErrMissingNetwork is returned when a client is created without a network, the same error as bitcoin.ErrMissingNetwork
+var ErrMissingNetwork = bitcoin.ErrMissingNetwork
+
+// ErrUnauthorized is returned when the node rejects the credentials
+var ErrUnauthorized = errors.New("rpc unauthorized")
+
+// ErrInvalidCookie is returned when the cookie file is not in the user:password format
+var ErrInvalidCookie = errors.New("invalid rpc cookie")
+
+// Error is an error returned by the node for a call, e.g. code -5 for an unknown transaction
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// ErrWrongNetwork is returned when an address is not for the client's network, the same error as bitcoin.ErrWrongNetwork
+var ErrWrongNetwork = bitcoin.ErrWrongNetwork
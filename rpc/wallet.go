@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// Unspent is an unspent output returned by listunspent or scantxoutset
+type Unspent struct {
+	TxID          string
+	Vout          uint32
+	Address       string
+	ScriptPubKey  string // hex encoded
+	Amount        btcutil.Amount
+	Confirmations int64 // listunspent only
+	Height        int64 // scantxoutset only
+	Descriptor    string
+	Spendable     bool
+	Coinbase      bool
+}
+
+// UnmarshalJSON decodes an unspent output, converting its amount from BTC to satoshis
+func (u *Unspent) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		TxID          string  `json:"txid"`
+		Vout          uint32  `json:"vout"`
+		Address       string  `json:"address"`
+		ScriptPubKey  string  `json:"scriptPubKey"`
+		Amount        float64 `json:"amount"`
+		Confirmations int64   `json:"confirmations"`
+		Height        int64   `json:"height"`
+		Descriptor    string  `json:"desc"`
+		Spendable     bool    `json:"spendable"`
+		Coinbase      bool    `json:"coinbase"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	amount, err := btcutil.NewAmount(raw.Amount)
+	if err != nil {
+		return err
+	}
+
+	*u = Unspent{
+		TxID:          raw.TxID,
+		Vout:          raw.Vout,
+		Address:       raw.Address,
+		ScriptPubKey:  raw.ScriptPubKey,
+		Amount:        amount,
+		Confirmations: raw.Confirmations,
+		Height:        raw.Height,
+		Descriptor:    raw.Descriptor,
+		Spendable:     raw.Spendable,
+		Coinbase:      raw.Coinbase,
+	}
+	return nil
+}
+
+// ImportDescriptorRequest is a descriptor to import with importdescriptors. The checksum of
+// Descriptor is added when missing
+type ImportDescriptorRequest struct {
+	Descriptor string `json:"desc"`
+	Active     bool   `json:"active,omitempty"`
+	Range      []int  `json:"range,omitempty"`
+	NextIndex  int    `json:"next_index,omitempty"`
+	Timestamp  any    `json:"timestamp"` // unix time to rescan from, or "now" to skip the rescan
+	Internal   bool   `json:"internal,omitempty"`
+	Label      string `json:"label,omitempty"`
+}
+
+// ImportDescriptorResult is the result of importing one descriptor
+type ImportDescriptorResult struct {
+	Success  bool     `json:"success"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    *Error   `json:"error,omitempty"`
+}
+
+// ListUnspent returns the wallet's unspent outputs with between minConf and maxConf confirmations,
+// optionally only those paying to addresses (validated against the client's network)
+func (c *Client) ListUnspent(ctx context.Context, minConf, maxConf int, addresses []string) ([]Unspent, error) {
+	if err := c.validateAddresses(addresses); err != nil {
+		return nil, err
+	}
+
+	if addresses == nil {
+		addresses = []string{}
+	}
+
+	var unspents []Unspent
+	if err := c.walletCall(ctx, "listunspent", []any{minConf, maxConf, addresses}, &unspents); err != nil {
+		return nil, err
+	}
+
+	c.resolveAddresses(unspents)
+	return unspents, nil
+}
+
+// ImportDescriptors imports descriptors into the wallet, returning one result per request
+func (c *Client) ImportDescriptors(ctx context.Context, requests []ImportDescriptorRequest) ([]ImportDescriptorResult, error) {
+	withChecksums := make([]ImportDescriptorRequest, 0, len(requests))
+	for _, req := range requests {
+		descriptor, err := bitcoin.AddDescriptorChecksum(req.Descriptor)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Descriptor = descriptor
+		if req.Timestamp == nil {
+			req.Timestamp = "now"
+		}
+		withChecksums = append(withChecksums, req)
+	}
+
+	var results []ImportDescriptorResult
+	if err := c.walletCall(ctx, "importdescriptors", []any{withChecksums}, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
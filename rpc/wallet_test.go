@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClientListUnspent will test the method ListUnspent()
+func TestClientListUnspent(t *testing.T) {
+	t.Parallel()
+
+	const address = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+
+	server := newTestServer(t, func(path, method string, params []json.RawMessage) (any, *Error) {
+		require.Equal(t, "/wallet/hot", path)
+		require.Equal(t, "listunspent", method)
+
+		var addresses []string
+		require.NoError(t, json.Unmarshal(params[2], &addresses))
+		require.Equal(t, []string{address}, addresses)
+
+		return []map[string]any{
+			{"txid": genesisCoinbaseTxID, "vout": 0, "address": address, "amount": 1.23456789, "confirmations": 3, "spendable": true},
+		}, nil
+	})
+
+	client, err := New(Config{URL: server.URL, Wallet: "hot", User: testUser, Password: testPassword, Network: bitcoin.Testnet})
+	require.NoError(t, err)
+
+	unspents, err := client.ListUnspent(context.Background(), 1, 9999999, []string{address})
+	require.NoError(t, err)
+	require.Len(t, unspents, 1)
+	assert.Equal(t, btcutil.Amount(123456789), unspents[0].Amount)
+	assert.Equal(t, int64(3), unspents[0].Confirmations)
+	assert.True(t, unspents[0].Spendable)
+
+	_, err = client.ListUnspent(context.Background(), 1, 9999999, []string{"invalid"})
+	assert.Error(t, err)
+}
+
+// TestClientWalletName will test that wallet names are escaped in the wallet endpoint
+func TestClientWalletName(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(path, method string, params []json.RawMessage) (any, *Error) {
+		require.Equal(t, "/wallet/cold storage/#2?", path)
+		return []map[string]any{}, nil
+	})
+
+	client, err := New(Config{URL: server.URL, Wallet: "cold storage/#2?", User: testUser, Password: testPassword, Network: bitcoin.Testnet})
+	require.NoError(t, err)
+
+	unspents, err := client.ListUnspent(context.Background(), 1, 9999999, nil)
+	require.NoError(t, err)
+	assert.Empty(t, unspents)
+}
+
+// TestClientImportDescriptors will test the method ImportDescriptors()
+func TestClientImportDescriptors(t *testing.T) {
+	t.Parallel()
+
+	const descriptor = "addr(mkmZxiEcEd8ZqjQWVZuC6so5dFMKEFpN2j)"
+
+	client := newTestClient(t, func(_, _ string, params []json.RawMessage) (any, *Error) {
+		var requests []map[string]any
+		require.NoError(t, json.Unmarshal(params[0], &requests))
+		require.Len(t, requests, 2)
+		assert.Equal(t, descriptor+"#02wpgw69", requests[0]["desc"])
+		assert.Equal(t, "now", requests[0]["timestamp"])
+		assert.Equal(t, float64(1700000000), requests[1]["timestamp"])
+
+		return []map[string]any{
+			{"success": true},
+			{"success": false, "error": map[string]any{"code": -5, "message": "Invalid descriptor"}},
+		}, nil
+	})
+
+	results, err := client.ImportDescriptors(context.Background(), []ImportDescriptorRequest{
+		{Descriptor: descriptor},
+		{Descriptor: descriptor + "#02wpgw69", Timestamp: 1700000000, Label: "cold"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Success)
+	assert.False(t, results[1].Success)
+	assert.Equal(t, -5, results[1].Error.Code)
+
+	_, err = client.ImportDescriptors(context.Background(), []ImportDescriptorRequest{{Descriptor: descriptor + "#00000000"}})
+	assert.ErrorIs(t, err, bitcoin.ErrInvalidDescriptorChecksum)
+}
@@ -1,6 +1,7 @@
 package bitcoin
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
 
 	"github.com/btcsuite/btcd/btcutil"
@@ -29,3 +30,105 @@ func GetScriptFromAddress(address string, networkType NetworkType) (string, erro
 
 	return hex.EncodeToString(script), nil
 }
+
+// GetWitnessScriptAddress returns the P2WSH address paying to a witness script (hex string)
+func GetWitnessScriptAddress(witnessScript string, networkType NetworkType) (string, error) {
+
+	// No script?
+	if len(witnessScript) == 0 {
+		return "", ErrMissingScript
+	}
+
+	scriptBytes, err := hex.DecodeString(witnessScript)
+	if err != nil {
+		return "", err
+	}
+
+	scriptHash := sha256.Sum256(scriptBytes)
+	addr, err := btcutil.NewAddressWitnessScriptHash(scriptHash[:], networkType)
+	if err != nil {
+		return "", err
+	}
+
+	return addr.EncodeAddress(), nil
+}
+
+// GetElectrumScriptHash returns the script hash an Electrum server indexes an output script (hex string) by:
+// the SHA256 of the script, in reversed byte order
+func GetElectrumScriptHash(script string) (string, error) {
+
+	// No script?
+	if len(script) == 0 {
+		return "", ErrMissingScript
+	}
+
+	scriptBytes, err := hex.DecodeString(script)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(scriptBytes)
+	for i, j := 0, len(hash)-1; i < j; i, j = i+1, j-1 {
+		hash[i], hash[j] = hash[j], hash[i]
+	}
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// GetElectrumScriptHashFromAddress returns the Electrum script hash of the output script of an address
+func GetElectrumScriptHashFromAddress(address string, networkType NetworkType) (string, error) {
+	script, err := GetScriptFromAddress(address, networkType)
+	if err != nil {
+		return "", err
+	}
+
+	return GetElectrumScriptHash(script)
+}
+
+// ScriptInfo describes an output script, including nonstandard ones that do not resolve to an address
+type ScriptInfo struct {
+	Type         string   `json:"type"`
+	Asm          string   `json:"asm"`
+	RequiredSigs int      `json:"reqSigs"`
+	Addresses    []string `json:"addresses,omitempty"`
+	PushedData   []string `json:"pushedData,omitempty"`
+}
+
+// GetScriptInfo classifies an output script (hex string) and returns its standard type, the number of
+// required signatures, the addresses it pays to and every data push it contains
+func GetScriptInfo(script string, networkType NetworkType) (*ScriptInfo, error) {
+
+	// No script?
+	if len(script) == 0 {
+		return nil, ErrMissingScript
+	}
+
+	scriptBytes, err := hex.DecodeString(script)
+	if err != nil {
+		return nil, err
+	}
+
+	class, addresses, requiredSigs, err := txscript.ExtractPkScriptAddrs(scriptBytes, networkType)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ScriptInfo{
+		Type:         class.String(),
+		Asm:          scriptToAsm(scriptBytes, false),
+		RequiredSigs: requiredSigs,
+	}
+
+	for _, address := range addresses {
+		info.Addresses = append(info.Addresses, address.EncodeAddress())
+	}
+
+	// a script that fails to parse has no pushed data, its asm ends with [error] instead
+	if pushes, err := txscript.PushedData(scriptBytes); err == nil {
+		for _, push := range pushes {
+			info.PushedData = append(info.PushedData, hex.EncodeToString(push))
+		}
+	}
+
+	return info, nil
+}
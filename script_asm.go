@@ -0,0 +1,267 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// sigHashTypeNames are the sighash suffixes Bitcoin Core decodes in scriptSig asm
+var sigHashTypeNames = map[txscript.SigHashType]string{
+	txscript.SigHashAll: "ALL",
+	txscript.SigHashAll | txscript.SigHashAnyOneCanPay: "ALL|ANYONECANPAY",
+	txscript.SigHashNone: "NONE",
+	txscript.SigHashNone | txscript.SigHashAnyOneCanPay:   "NONE|ANYONECANPAY",
+	txscript.SigHashSingle:                                "SINGLE",
+	txscript.SigHashSingle | txscript.SigHashAnyOneCanPay: "SINGLE|ANYONECANPAY",
+}
+
+// opcodeNames holds the Bitcoin Core name of every non-push opcode
+var opcodeNames = make(map[byte]string)
+
+func init() {
+	for name, op := range txscript.OpcodeByName {
+		switch name {
+		case "OP_FALSE", "OP_TRUE", "OP_NOP2", "OP_NOP3":
+			// aliases, the canonical names are kept instead
+			continue
+		}
+
+		switch {
+		case op == txscript.OP_0:
+			name = "0"
+		case op == txscript.OP_1NEGATE:
+			name = "-1"
+		case op >= txscript.OP_1 && op <= txscript.OP_16:
+			name = strconv.Itoa(int(op-txscript.OP_1) + 1)
+		case strings.HasPrefix(name, "OP_UNKNOWN"), op >= txscript.OP_SMALLINTEGER && op < txscript.OP_INVALIDOPCODE:
+			name = "OP_UNKNOWN"
+		}
+		opcodeNames[op] = name
+	}
+}
+
+// GetAsmFromScript will take a script (hex string) and return its asm in the format used by Bitcoin Core.
+// Pushes of up to 4 bytes are shown as numbers, larger pushes as hex, and a malformed
+// script ends with "[error]" like it does in Bitcoin Core
+func GetAsmFromScript(script string) (string, error) {
+	scriptBytes, err := hex.DecodeString(script)
+	if err != nil {
+		return "", err
+	}
+
+	return scriptToAsm(scriptBytes, false), nil
+}
+
+// GetAsmFromScriptSig is like GetAsmFromScript but also decodes the sighash type of
+// signatures (e.g. "<sig>[ALL]"), which is how Bitcoin Core shows input scripts
+func GetAsmFromScriptSig(scriptSig string) (string, error) {
+	scriptBytes, err := hex.DecodeString(scriptSig)
+	if err != nil {
+		return "", err
+	}
+
+	return scriptToAsm(scriptBytes, true), nil
+}
+
+// GetScriptFromAsm assembles a script (hex string) from its asm. It accepts the output of
+// GetAsmFromScript: opcode names (with or without the OP_ prefix), decimal numbers, hex data
+// and signatures with a sighash suffix. Tokens are numbers when GetAsmFromScript could have
+// printed them as one (pushes of up to 4 bytes) and are encoded minimally, so a script using
+// non-minimal pushes will not round trip byte for byte
+func GetScriptFromAsm(asm string) (string, error) {
+
+	tokens := strings.Fields(asm)
+	if len(tokens) == 0 {
+		return "", ErrMissingScript
+	}
+
+	builder := txscript.NewScriptBuilder()
+	for _, token := range tokens {
+		if num, ok := asmTokenNumber(token); ok {
+			builder.AddInt64(num)
+			continue
+		}
+
+		if op, ok := opcodeFromName(token); ok {
+			builder.AddOp(op)
+			continue
+		}
+
+		data, err := asmTokenData(token)
+		if err != nil {
+			return "", err
+		}
+		builder.AddFullData(data)
+	}
+
+	script, err := builder.Script()
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(script), nil
+}
+
+// asmTokenNumber parses a number of the asm, which is a push of up to 4 bytes printed in decimal.
+// Digit-only hex pushes of 5 bytes and more are data: those with a leading zero, as no number is
+// printed with one, and those outside of the range of 4 byte numbers
+func asmTokenNumber(token string) (int64, bool) {
+	num, err := strconv.ParseInt(token, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	digits := strings.TrimPrefix(token, "-")
+	if len(digits) > 1 && digits[0] == '0' {
+		return 0, false
+	}
+
+	return num, true
+}
+
+// scriptToAsm converts a script to Bitcoin Core's asm format
+func scriptToAsm(script []byte, attemptSighashDecode bool) string {
+	var parts []string
+
+	// sighash decoding is skipped for unspendable scripts, as in Bitcoin Core
+	attemptSighashDecode = attemptSighashDecode && !txscript.IsUnspendable(script)
+
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	for tokenizer.Next() {
+		op, data := tokenizer.Opcode(), tokenizer.Data()
+		if op > txscript.OP_PUSHDATA4 {
+			parts = append(parts, opcodeNames[op])
+			continue
+		}
+
+		switch {
+		case len(data) <= 4:
+			parts = append(parts, strconv.FormatInt(scriptNumValue(data), 10))
+		case attemptSighashDecode && isValidSignatureEncoding(data):
+			hashType := txscript.SigHashType(data[len(data)-1])
+			if name, ok := sigHashTypeNames[hashType]; ok {
+				parts = append(parts, hex.EncodeToString(data[:len(data)-1])+"["+name+"]")
+				continue
+			}
+			parts = append(parts, hex.EncodeToString(data))
+		default:
+			parts = append(parts, hex.EncodeToString(data))
+		}
+	}
+
+	if tokenizer.Err() != nil {
+		parts = append(parts, "[error]")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// opcodeFromName looks up a non-push opcode by its name, the OP_ prefix being optional
+func opcodeFromName(name string) (byte, bool) {
+	if !strings.HasPrefix(name, "OP_") {
+		name = "OP_" + name
+	}
+
+	op, ok := txscript.OpcodeByName[name]
+	if !ok || (op > txscript.OP_0 && op <= txscript.OP_PUSHDATA4) {
+		return 0, false
+	}
+	return op, true
+}
+
+// asmTokenData decodes a hex data token, which may carry a sighash suffix such as [ALL]
+func asmTokenData(token string) ([]byte, error) {
+	var suffix []byte
+	if start := strings.IndexByte(token, '['); start != -1 && strings.HasSuffix(token, "]") {
+		name := token[start+1 : len(token)-1]
+
+		found := false
+		for hashType, hashName := range sigHashTypeNames {
+			if hashName == name {
+				suffix, found = []byte{byte(hashType)}, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: unknown sighash type %s", ErrInvalidAsm, name)
+		}
+		token = token[:start]
+	}
+
+	data, err := hex.DecodeString(strings.TrimPrefix(token, "0x"))
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("%w: unexpected token %s", ErrInvalidAsm, token)
+	}
+
+	return append(data, suffix...), nil
+}
+
+// scriptNumValue decodes a little endian, sign-magnitude script number without
+// any minimal encoding checks, saturating to int32 like CScriptNum::getint
+func scriptNumValue(data []byte) int64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	var result int64
+	for i, b := range data {
+		result |= int64(b) << uint8(8*i)
+	}
+
+	// the most significant bit of the last byte is the sign
+	if data[len(data)-1]&0x80 != 0 {
+		result &= ^(int64(0x80) << uint8(8*(len(data)-1)))
+		result = -result
+	}
+
+	if result > 1<<31-1 {
+		return 1<<31 - 1
+	} else if result < -(1 << 31) {
+		return -(1 << 31)
+	}
+	return result
+}
+
+// isValidSignatureEncoding reports whether sig is a strict DER signature with a
+// trailing sighash byte (BIP66)
+func isValidSignatureEncoding(sig []byte) bool {
+
+	// format: 0x30 [total-length] 0x02 [R-length] [R] 0x02 [S-length] [S] [sighash]
+	if len(sig) < 9 || len(sig) > 73 {
+		return false
+	}
+	if sig[0] != 0x30 || int(sig[1]) != len(sig)-3 {
+		return false
+	}
+
+	lenR := int(sig[3])
+	if 5+lenR >= len(sig) {
+		return false
+	}
+	lenS := int(sig[5+lenR])
+	if lenR+lenS+7 != len(sig) {
+		return false
+	}
+
+	// R must be a positive integer without excess padding
+	if sig[2] != 0x02 || lenR == 0 || sig[4]&0x80 != 0 {
+		return false
+	}
+	if lenR > 1 && sig[4] == 0x00 && sig[5]&0x80 == 0 {
+		return false
+	}
+
+	// S must be a positive integer without excess padding
+	if sig[lenR+4] != 0x02 || lenS == 0 || sig[lenR+6]&0x80 != 0 {
+		return false
+	}
+	if lenS > 1 && sig[lenR+6] == 0x00 && sig[lenR+7]&0x80 == 0 {
+		return false
+	}
+
+	return true
+}
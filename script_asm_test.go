@@ -0,0 +1,138 @@
+package bitcoin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSignature is a strict DER signature (without the sighash byte) used in scriptSig tests
+var testSignature = "30440220" + strings.Repeat("11", 32) + "0220" + strings.Repeat("22", 32)
+
+// TestGetAsmFromScript will test the method GetAsmFromScript()
+func TestGetAsmFromScript(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		script        string
+		expectedAsm   string
+		expectedError bool
+	}{
+		{"76a91443c1c9de50e52e35546084083363b4586782cbf388ac", "OP_DUP OP_HASH160 43c1c9de50e52e35546084083363b4586782cbf3 OP_EQUALVERIFY OP_CHECKSIG", false},
+		{"5120c63ae2b830aee511ff6b3c606b53d6ca0e22d6a7516ad506b5346bf74fa5e3ae", "1 c63ae2b830aee511ff6b3c606b53d6ca0e22d6a7516ad506b5346bf74fa5e3ae", false},
+		{"0014f60834ef165253c571b11ce9fa74e46692fc5ec1", "0 f60834ef165253c571b11ce9fa74e46692fc5ec1", false},
+		{"20" + strings.Repeat("aa", 32) + "ac20" + strings.Repeat("bb", 32) + "ba529c", strings.Repeat("aa", 32) + " OP_CHECKSIG " + strings.Repeat("bb", 32) + " OP_CHECKSIGADD 2 OP_NUMEQUAL", false},
+		{"6a04deadbeef", "OP_RETURN -1874767326", false},
+		{"03a08601b1754f", "100000 OP_CHECKLOCKTIMEVERIFY OP_DROP -1", false},
+		{"02e803b2bbff", "1000 OP_CHECKSEQUENCEVERIFY OP_UNKNOWN OP_INVALIDOPCODE", false},
+		{"76a94c05ab", "OP_DUP OP_HASH160 [error]", false},
+		{"", "", false},
+		{"zz", "", true},
+	}
+
+	for _, test := range tests {
+		if asm, err := GetAsmFromScript(test.script); err != nil && !test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error not expected but got: %s", t.Name(), test.script, err.Error())
+		} else if err == nil && test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error was expected", t.Name(), test.script)
+		} else if asm != test.expectedAsm {
+			t.Fatalf("%s Failed: [%s] inputted and [%s] expected, but got: %s", t.Name(), test.script, test.expectedAsm, asm)
+		}
+	}
+}
+
+// TestGetAsmFromScriptSig will test the method GetAsmFromScriptSig()
+func TestGetAsmFromScriptSig(t *testing.T) {
+	t.Parallel()
+
+	pubKey := "02" + strings.Repeat("33", 32)
+
+	var tests = []struct {
+		scriptSig   string
+		expectedAsm string
+	}{
+		{"47" + testSignature + "0121" + pubKey, testSignature + "[ALL] " + pubKey},
+		{"47" + testSignature + "8321" + pubKey, testSignature + "[SINGLE|ANYONECANPAY] " + pubKey},
+		{"47" + testSignature + "0521" + pubKey, testSignature + "05 " + pubKey},
+	}
+
+	for _, test := range tests {
+		asm, err := GetAsmFromScriptSig(test.scriptSig)
+		require.NoError(t, err)
+		assert.Equal(t, test.expectedAsm, asm)
+
+		// sighash types are only decoded for input scripts
+		asm, err = GetAsmFromScript(test.scriptSig)
+		require.NoError(t, err)
+		assert.NotContains(t, asm, "[")
+	}
+}
+
+// TestGetScriptFromAsm will test the method GetScriptFromAsm()
+func TestGetScriptFromAsm(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		asm            string
+		expectedScript string
+		expectedError  bool
+	}{
+		{"OP_DUP OP_HASH160 43c1c9de50e52e35546084083363b4586782cbf3 OP_EQUALVERIFY OP_CHECKSIG", "76a91443c1c9de50e52e35546084083363b4586782cbf388ac", false},
+		{"1 c63ae2b830aee511ff6b3c606b53d6ca0e22d6a7516ad506b5346bf74fa5e3ae", "5120c63ae2b830aee511ff6b3c606b53d6ca0e22d6a7516ad506b5346bf74fa5e3ae", false},
+		{"100000 CHECKLOCKTIMEVERIFY DROP -1", "03a08601b1754f", false},
+		{"OP_RETURN -1874767326", "6a04deadbeef", false},
+		{"OP_NOP2 OP_NOP3", "b1b2", false},
+		{"0000008000 OP_CHECKLOCKTIMEVERIFY OP_DROP", "050000008000b175", false},
+		{"1700000000 OP_CHECKLOCKTIMEVERIFY OP_DROP", "0400f15365b175", false},
+		{"0123456789", "050123456789", false},
+		{testSignature + "[ALL]", "47" + testSignature + "01", false},
+		{testSignature + "[FOO]", "", true},
+		{"OP_DUP OP_UNKNOWN", "", true},
+		{"OP_DATA_1", "", true},
+		{"xyz", "", true},
+		{"", "", true},
+	}
+
+	for _, test := range tests {
+		if script, err := GetScriptFromAsm(test.asm); err != nil && !test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error not expected but got: %s", t.Name(), test.asm, err.Error())
+		} else if err == nil && test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error was expected", t.Name(), test.asm)
+		} else if script != test.expectedScript {
+			t.Fatalf("%s Failed: [%s] inputted and [%s] expected, but got: %s", t.Name(), test.asm, test.expectedScript, script)
+		}
+	}
+}
+
+// TestGetScriptFromAsmRoundTrip will test that GetScriptFromAsm() assembles the asm of GetAsmFromScript()
+// back to the same script
+func TestGetScriptFromAsmRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	pubKey, err := PubKeyFromString("022d35c7ede60cb68dee6e60ab9ad5863a7a726b297273d7a99b9dfb032a10e3f8")
+	require.NoError(t, err)
+
+	scripts := []string{
+		"050000008000b175",
+		"0400f15365b175",
+		"0501234567896a",
+		"6a0a12345678901234567890",
+		"76a91443c1c9de50e52e35546084083363b4586782cbf388ac",
+	}
+	for _, lockTime := range []uint32{500000, 1700000000, 1 << 31, 1<<32 - 1} {
+		script, err := GetAbsoluteTimelockScript(pubKey, lockTime)
+		require.NoError(t, err)
+		scripts = append(scripts, script)
+	}
+
+	for _, script := range scripts {
+		asm, err := GetAsmFromScript(script)
+		require.NoError(t, err)
+
+		assembled, err := GetScriptFromAsm(asm)
+		require.NoError(t, err)
+		assert.Equal(t, script, assembled, asm)
+	}
+}
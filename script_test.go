@@ -28,3 +28,100 @@ func TestGetScriptFromAddress(t *testing.T) {
 		}
 	}
 }
+
+// TestGetScriptInfo will test the method GetScriptInfo()
+func TestGetScriptInfo(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		script            string
+		expectedType      string
+		expectedSigs      int
+		expectedAddresses int
+		expectedPushes    int
+		expectedError     bool
+	}{
+		{"76a914d4fa62e0243e52eeddd60812c9cd421bd337356588ac", "pubkeyhash", 1, 1, 1, false},
+		{"5120c63ae2b830aee511ff6b3c606b53d6ca0e22d6a7516ad506b5346bf74fa5e3ae", "witness_v1_taproot", 1, 1, 1, false},
+		{"5121022d35c7ede60cb68dee6e60ab9ad5863a7a726b297273d7a99b9dfb032a10e3f8210309a1ede55bcb4d7ecbf45f015ea8e2f43cd71be97291d314f3be6871733f541b52ae", "multisig", 1, 2, 2, false},
+		{"6a0b68656c6c6f20776f726c64", "nulldata", 0, 0, 1, false},
+		{"a820" + "0000000000000000000000000000000000000000000000000000000000000000" + "87", "nonstandard", 0, 0, 1, false},
+		{"76a94c05ab", "nonstandard", 0, 0, 0, false},
+		{"", "", 0, 0, 0, true},
+		{"zz", "", 0, 0, 0, true},
+	}
+
+	for _, test := range tests {
+		info, err := GetScriptInfo(test.script, Mainnet)
+		if err != nil && !test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error not expected but got: %s", t.Name(), test.script, err.Error())
+		} else if err == nil && test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error was expected", t.Name(), test.script)
+		} else if info == nil {
+			continue
+		} else if info.Type != test.expectedType || info.RequiredSigs != test.expectedSigs ||
+			len(info.Addresses) != test.expectedAddresses || len(info.PushedData) != test.expectedPushes {
+			t.Fatalf("%s Failed: [%s] inputted and [%s] expected, but got: %+v", t.Name(), test.script, test.expectedType, info)
+		}
+	}
+}
+
+// TestGetWitnessScriptAddress will test the method GetWitnessScriptAddress()
+func TestGetWitnessScriptAddress(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		witnessScript   string
+		networkType     NetworkType
+		expectedAddress string
+		expectedError   bool
+	}{
+		{"210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798ac", Mainnet, "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3", false},
+		{"210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798ac", Testnet, "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", false},
+		{"zz", Mainnet, "", true},
+		{"", Mainnet, "", true},
+	}
+
+	for _, test := range tests {
+		if address, err := GetWitnessScriptAddress(test.witnessScript, test.networkType); err != nil && !test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error not expected but got: %s", t.Name(), test.witnessScript, err.Error())
+		} else if err == nil && test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error was expected", t.Name(), test.witnessScript)
+		} else if address != test.expectedAddress {
+			t.Fatalf("%s Failed: [%s] inputted and [%s] expected, but got: %s", t.Name(), test.witnessScript, test.expectedAddress, address)
+		}
+	}
+}
+
+// TestGetElectrumScriptHash will test the methods GetElectrumScriptHash() and GetElectrumScriptHashFromAddress()
+func TestGetElectrumScriptHash(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		address            string
+		expectedScriptHash string
+		expectedError      bool
+	}{
+		// vector from the Electrum protocol documentation
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161", false},
+		{"invalid", "", true},
+		{"", "", true},
+	}
+
+	for _, test := range tests {
+		if scriptHash, err := GetElectrumScriptHashFromAddress(test.address, Mainnet); err != nil && !test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error not expected but got: %s", t.Name(), test.address, err.Error())
+		} else if err == nil && test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error was expected", t.Name(), test.address)
+		} else if scriptHash != test.expectedScriptHash {
+			t.Fatalf("%s Failed: [%s] inputted and [%s] expected, but got: %s", t.Name(), test.address, test.expectedScriptHash, scriptHash)
+		}
+	}
+
+	if _, err := GetElectrumScriptHash("zz"); err == nil {
+		t.Fatalf("%s Failed: invalid hex inputted and error was expected", t.Name())
+	}
+	if _, err := GetElectrumScriptHash(""); err == nil {
+		t.Fatalf("%s Failed: empty script inputted and error was expected", t.Name())
+	}
+}
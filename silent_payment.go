@@ -0,0 +1,628 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Silent payment (BIP352) address parameters
+const (
+	silentPaymentVersion     = 0
+	silentPaymentKeysLen     = 2 * btcec.PubKeyBytesLenCompressed
+	silentPaymentMaxLength   = 1023
+	silentPaymentMaxPayments = 2323 // per scan key of a transaction, K_max
+)
+
+// SilentPaymentChangeLabel is the label reserved for change, its address must never be shared
+const SilentPaymentChangeLabel uint32 = 0
+
+// BIP352 tagged hashes
+var (
+	silentPaymentInputsTag       = []byte("BIP0352/Inputs")
+	silentPaymentSharedSecretTag = []byte("BIP0352/SharedSecret")
+	silentPaymentLabelTag        = []byte("BIP0352/Label")
+)
+
+// SilentPaymentAddress is a reusable BIP352 address (sp1...), paid through a new taproot output for every transaction
+type SilentPaymentAddress struct {
+	Network  NetworkType
+	ScanKey  *btcec.PublicKey
+	SpendKey *btcec.PublicKey // B_m, the spend key tweaked by the label for labelled addresses
+}
+
+// ParseSilentPaymentAddress decodes a silent payment address of the network
+func ParseSilentPaymentAddress(address string, network NetworkType) (*SilentPaymentAddress, error) {
+
+	// Missing network
+	if network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	if len(address) > silentPaymentMaxLength {
+		return nil, fmt.Errorf("%w: too long", ErrInvalidSilentPaymentAddress)
+	}
+
+	hrp, data, encoding, err := bech32.DecodeNoLimitWithVersion(address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSilentPaymentAddress, err)
+	}
+	if encoding != bech32.VersionM || len(data) == 0 {
+		return nil, fmt.Errorf("%w: not bech32m", ErrInvalidSilentPaymentAddress)
+	}
+
+	expected, err := silentPaymentHRP(network)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != expected {
+		return nil, fmt.Errorf("%w: %s", ErrWrongNetwork, address)
+	}
+
+	keys, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSilentPaymentAddress, err)
+	}
+
+	// version 0 carries the keys only, later versions may append data that version 0 readers ignore
+	switch version := data[0]; {
+	case version == silentPaymentVersion && len(keys) != silentPaymentKeysLen:
+		return nil, fmt.Errorf("%w: %d bytes of keys", ErrInvalidSilentPaymentAddress, len(keys))
+	case version == 31 || len(keys) < silentPaymentKeysLen:
+		return nil, fmt.Errorf("%w: version %d", ErrInvalidSilentPaymentAddress, version)
+	}
+
+	scanKey, err := btcec.ParsePubKey(keys[:btcec.PubKeyBytesLenCompressed])
+	if err != nil {
+		return nil, fmt.Errorf("%w: scan key", ErrInvalidSilentPaymentAddress)
+	}
+	spendKey, err := btcec.ParsePubKey(keys[btcec.PubKeyBytesLenCompressed:silentPaymentKeysLen])
+	if err != nil {
+		return nil, fmt.Errorf("%w: spend key", ErrInvalidSilentPaymentAddress)
+	}
+
+	return &SilentPaymentAddress{Network: network, ScanKey: scanKey, SpendKey: spendKey}, nil
+}
+
+// Encode returns the bech32m encoding of the address
+func (a *SilentPaymentAddress) Encode() (string, error) {
+	if !IsValidPublicKey(a.ScanKey) || !IsValidPublicKey(a.SpendKey) {
+		return "", ErrInvalidPubKey
+	}
+
+	hrp, err := silentPaymentHRP(a.Network)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := bech32.ConvertBits(append(a.ScanKey.SerializeCompressed(), a.SpendKey.SerializeCompressed()...), 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	return bech32.EncodeM(hrp, append([]byte{silentPaymentVersion}, data...))
+}
+
+// silentPaymentHRP returns the human readable part of the silent payment addresses of a network
+func silentPaymentHRP(network NetworkType) (string, error) {
+
+	// Missing network
+	if network == nil {
+		return "", ErrMissingNetwork
+	}
+
+	switch network.Net {
+	case chaincfg.MainNetParams.Net:
+		return "sp", nil
+	case chaincfg.RegressionNetParams.Net:
+		return "sprt", nil
+	default:
+		return "tsp", nil
+	}
+}
+
+// DeriveSilentPaymentKeys derives the scan (m/352h/coin_type'/account'/1h/0) and spend (m/352h/coin_type'/account'/0h/0)
+// keys of a master key
+func DeriveSilentPaymentKeys(masterKey *hdkeychain.ExtendedKey, network NetworkType, account uint32) (*btcec.PrivateKey, *btcec.PrivateKey, error) {
+
+	// Missing network
+	if network == nil {
+		return nil, nil, ErrMissingNetwork
+	}
+
+	coinType := 1
+	if network.Net == chaincfg.MainNetParams.Net {
+		coinType = 0
+	}
+
+	keys := make([]*btcec.PrivateKey, 2)
+	for i, branch := range []int{1, 0} {
+		indexes, err := parseDerivationPath(fmt.Sprintf("352h/%dh/%dh/%dh/0", coinType, account, branch))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key := masterKey
+		for _, index := range indexes {
+			if key, err = key.Derive(index); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if keys[i], err = key.ECPrivKey(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return keys[0], keys[1], nil
+}
+
+// AddSilentPaymentOutputs appends to tx an output paying each payout to a silent payment address, in order.
+// The outputs are derived from the private keys of the inputs of tx, which must all be added already, prevOuts
+// holding the outputs they spend. Every P2PKH, P2WPKH, P2SH-P2WPKH and P2TR input needs its key in privateKeys
+// (the tweaked output key for P2TR), the receiver leaves the other scripts out. Before signing, P2SH inputs are
+// only known to be P2SH-P2WPKH from their script sig, and P2TR script path spends of the unspendable key
+// (TaprootNUMSPubKey) from the control block of their witness
+func AddSilentPaymentOutputs(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut,
+	privateKeys map[wire.OutPoint]*btcec.PrivateKey, payouts []Payout, network NetworkType) error {
+
+	// Missing transaction
+	if tx == nil {
+		return ErrMissingTransaction
+	}
+
+	var recipients []*SilentPaymentAddress
+	for _, payout := range payouts {
+		recipient, err := ParseSilentPaymentAddress(payout.Address, network)
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	var sum btcec.ModNScalar
+	for _, in := range tx.TxIn {
+		prevOut := prevOuts[in.PreviousOutPoint]
+		if prevOut == nil {
+			return fmt.Errorf("%w: %s", ErrMissingPrevOut, in.PreviousOutPoint)
+		}
+		if err := checkSilentPaymentInput(prevOut.PkScript); err != nil {
+			return err
+		}
+
+		privateKey := privateKeys[in.PreviousOutPoint]
+		if privateKey == nil {
+			if silentPaymentInputNeedsKey(in, prevOut.PkScript) {
+				return fmt.Errorf("%w: input %s", ErrPrivateKeyMissing, in.PreviousOutPoint)
+			}
+			continue
+		}
+
+		key, err := silentPaymentInputKey(privateKey, prevOut.PkScript)
+		if err != nil {
+			return fmt.Errorf("%w: input %s", err, in.PreviousOutPoint)
+		}
+		sum.Add(key)
+	}
+	if sum.IsZero() {
+		return ErrNoEligibleInputs
+	}
+
+	inputHash := silentPaymentInputHash(tx, scalarBaseMult(&sum))
+	secret := new(btcec.ModNScalar).Mul2(inputHash, &sum)
+
+	// the outputs paying the same scan key are numbered with k, sharing one ecdh secret
+	counts := make(map[string]uint32)
+	for i, recipient := range recipients {
+		scanKey := string(recipient.ScanKey.SerializeCompressed())
+		k := counts[scanKey]
+		if k == silentPaymentMaxPayments {
+			return fmt.Errorf("%w: %d payments to a scan key", ErrTooManySilentPayments, k+1)
+		}
+		counts[scanKey]++
+
+		sharedSecret := scalarMult(secret, jacobian(recipient.ScanKey))
+		outputKey := addPoints(jacobian(recipient.SpendKey), scalarBaseMult(silentPaymentTweak(sharedSecret, k)))
+		if isInfinity(outputKey) {
+			return ErrInvalidTweak
+		}
+
+		pkScript, err := txscript.PayToTaprootScript(pointPubKey(outputKey))
+		if err != nil {
+			return err
+		}
+		if limit := dustLimit(pkScript); payouts[i].Value < limit {
+			return fmt.Errorf("%w: %d sat below %d sat", ErrDustOutput, payouts[i].Value, limit)
+		}
+		tx.AddTxOut(wire.NewTxOut(int64(payouts[i].Value), pkScript))
+	}
+
+	return nil
+}
+
+// silentPaymentInputKey returns the private key an input adds to the silent payment secret, checking it owns the
+// output spent
+func silentPaymentInputKey(privateKey *btcec.PrivateKey, pkScript []byte) (*btcec.ModNScalar, error) {
+	pubKey := privateKey.PubKey()
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+	key := privateKey.Key
+
+	var owned bool
+	switch {
+	case txscript.IsPayToTaproot(pkScript):
+		owned = bytes.Equal(pkScript[2:], schnorr.SerializePubKey(pubKey))
+
+		// taproot keys are x-only, the receiver uses the even one
+		if !hasEvenY(jacobian(pubKey)) {
+			key.Negate()
+		}
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		owned = bytes.Equal(pkScript[2:], pubKeyHash)
+	case txscript.IsPayToPubKeyHash(pkScript):
+		owned = bytes.Equal(pkScript[3:23], pubKeyHash)
+	case txscript.IsPayToScriptHash(pkScript):
+		owned = bytes.Equal(pkScript[2:22], btcutil.Hash160(append([]byte{txscript.OP_0, txscript.OP_DATA_20}, pubKeyHash...)))
+	default:
+		return nil, ErrIncorrectAddressType
+	}
+
+	if !owned {
+		return nil, ErrInputKeyMismatch
+	}
+	return &key, nil
+}
+
+// silentPaymentInputNeedsKey reports whether the receiver counts the key of an input, which may not be signed yet
+func silentPaymentInputNeedsKey(in *wire.TxIn, pkScript []byte) bool {
+	switch {
+	case txscript.IsPayToWitnessPubKeyHash(pkScript), txscript.IsPayToPubKeyHash(pkScript):
+		return true
+	case txscript.IsPayToScriptHash(pkScript):
+		redeemScript := in.SignatureScript
+		return len(redeemScript) > 0 && redeemScript[0] == txscript.OP_DATA_22 && txscript.IsPayToWitnessPubKeyHash(redeemScript[1:])
+	case txscript.IsPayToTaproot(pkScript):
+		return len(in.Witness) == 0 || silentPaymentInputPubKey(in, pkScript) != nil
+	default:
+		return false
+	}
+}
+
+// checkSilentPaymentInput fails for inputs that make a transaction ineligible for silent payments: spends of
+// future segwit versions, whose keys a receiver could not know of
+func checkSilentPaymentInput(pkScript []byte) error {
+	if !txscript.IsWitnessProgram(pkScript) {
+		return nil
+	}
+
+	version, _, err := txscript.ExtractWitnessProgramInfo(pkScript)
+	if err != nil {
+		return err
+	}
+	if version > 1 {
+		return fmt.Errorf("%w: spends a segwit v%d output", ErrNoEligibleInputs, version)
+	}
+	return nil
+}
+
+// silentPaymentInputHash returns hash_BIP0352/Inputs(outpoint_L || A), outpoint_L being the smallest outpoint
+// spent by tx and A the sum of the keys of its eligible inputs
+func silentPaymentInputHash(tx *wire.MsgTx, sum *btcec.JacobianPoint) *btcec.ModNScalar {
+	var smallest []byte
+	for _, in := range tx.TxIn {
+		outPoint := make([]byte, chainhash.HashSize+4)
+		copy(outPoint, in.PreviousOutPoint.Hash[:])
+		binary.LittleEndian.PutUint32(outPoint[chainhash.HashSize:], in.PreviousOutPoint.Index)
+
+		if smallest == nil || bytes.Compare(outPoint, smallest) < 0 {
+			smallest = outPoint
+		}
+	}
+
+	return hashScalar(chainhash.TaggedHash(silentPaymentInputsTag, smallest, pointBytes(sum))[:])
+}
+
+// silentPaymentTweak returns t_k = hash_BIP0352/SharedSecret(ecdh_shared_secret || k)
+func silentPaymentTweak(sharedSecret *btcec.JacobianPoint, k uint32) *btcec.ModNScalar {
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], k)
+	return hashScalar(chainhash.TaggedHash(silentPaymentSharedSecretTag, pointBytes(sharedSecret), index[:])[:])
+}
+
+// silentPaymentLabelTweak returns hash_BIP0352/Label(b_scan || m)
+func silentPaymentLabelTweak(scanKey *btcec.PrivateKey, label uint32) *btcec.ModNScalar {
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], label)
+	return hashScalar(chainhash.TaggedHash(silentPaymentLabelTag, scalarBytes(&scanKey.Key), index[:])[:])
+}
+
+// SilentPaymentTweakData returns input_hash·A of a transaction, the data a receiver multiplies by its scan key to
+// find the outputs paying it. Indexes serve it to light clients that do not fetch the spent outputs.
+// It returns ErrNoEligibleInputs for transactions that cannot carry silent payments
+func SilentPaymentTweakData(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut) (*btcec.PublicKey, error) {
+
+	// Missing transaction
+	if tx == nil {
+		return nil, ErrMissingTransaction
+	}
+
+	// silent payments are taproot outputs
+	var taproot bool
+	for _, out := range tx.TxOut {
+		taproot = taproot || txscript.IsPayToTaproot(out.PkScript)
+	}
+	if !taproot {
+		return nil, fmt.Errorf("%w: no taproot output", ErrNoEligibleInputs)
+	}
+
+	sum := &btcec.JacobianPoint{}
+	for _, in := range tx.TxIn {
+		prevOut := prevOuts[in.PreviousOutPoint]
+		if prevOut == nil {
+			return nil, fmt.Errorf("%w: %s", ErrMissingPrevOut, in.PreviousOutPoint)
+		}
+		if err := checkSilentPaymentInput(prevOut.PkScript); err != nil {
+			return nil, err
+		}
+
+		if pubKey := silentPaymentInputPubKey(in, prevOut.PkScript); pubKey != nil {
+			sum = addPoints(sum, jacobian(pubKey))
+		}
+	}
+	if isInfinity(sum) {
+		return nil, ErrNoEligibleInputs
+	}
+
+	return pointPubKey(scalarMult(silentPaymentInputHash(tx, sum), sum)), nil
+}
+
+// silentPaymentInputPubKey returns the public key an input adds to the silent payment secret, nil for the inputs
+// left out: other scripts, uncompressed keys and taproot script path spends of an unspendable internal key
+func silentPaymentInputPubKey(in *wire.TxIn, pkScript []byte) *btcec.PublicKey {
+	compressed := func(pubKey []byte) *btcec.PublicKey {
+		if len(pubKey) != btcec.PubKeyBytesLenCompressed {
+			return nil
+		}
+		key, err := btcec.ParsePubKey(pubKey)
+		if err != nil {
+			return nil
+		}
+		return key
+	}
+
+	witness := in.Witness
+	switch {
+	case txscript.IsPayToTaproot(pkScript):
+		if len(witness) > 1 && len(witness[len(witness)-1]) > 0 && witness[len(witness)-1][0] == txscript.TaprootAnnexTag {
+			witness = witness[:len(witness)-1]
+		}
+		if len(witness) > 1 {
+			controlBlock := witness[len(witness)-1]
+			if len(controlBlock) >= 33 && bytes.Equal(controlBlock[1:33], schnorr.SerializePubKey(TaprootNUMSPubKey())) {
+				return nil
+			}
+		}
+
+		key, err := schnorr.ParsePubKey(pkScript[2:])
+		if err != nil {
+			return nil
+		}
+		return key
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		if len(witness) == 0 {
+			return nil
+		}
+		return compressed(witness[len(witness)-1])
+	case txscript.IsPayToScriptHash(pkScript):
+		redeemScript := in.SignatureScript
+		if len(redeemScript) == 0 || redeemScript[0] != txscript.OP_DATA_22 || len(witness) == 0 ||
+			!txscript.IsPayToWitnessPubKeyHash(redeemScript[1:]) {
+			return nil
+		}
+		return compressed(witness[len(witness)-1])
+	case txscript.IsPayToPubKeyHash(pkScript):
+
+		// the key is the last 33 bytes of the script sig hashing to the key hash, whatever pushes precede it
+		scriptSig := in.SignatureScript
+		for end := len(scriptSig); end >= btcec.PubKeyBytesLenCompressed; end-- {
+			pubKey := scriptSig[end-btcec.PubKeyBytesLenCompressed : end]
+			if bytes.Equal(btcutil.Hash160(pubKey), pkScript[3:23]) {
+				return compressed(pubKey)
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// SilentPaymentOutput is an output paying a silent payment receiver
+type SilentPaymentOutput struct {
+	OutPoint wire.OutPoint
+	TxOut    *wire.TxOut
+	Tweak    [32]byte // added to the spend private key to spend the output
+	Label    *uint32  // nil when paid to the unlabelled address
+}
+
+// PrivateKey returns the private key spending the output, signing for its key path with no taproot tweak
+func (o *SilentPaymentOutput) PrivateKey(spendKey *btcec.PrivateKey) (*btcec.PrivateKey, error) {
+
+	// Missing private key
+	if spendKey == nil {
+		return nil, ErrPrivateKeyMissing
+	}
+
+	tweak, ok := parseScalar(o.Tweak[:])
+	if !ok {
+		return nil, ErrInvalidTweak
+	}
+
+	key := new(btcec.ModNScalar).Add2(&spendKey.Key, tweak)
+	privateKey := btcec.PrivKeyFromScalar(key)
+	if !bytes.Equal(o.TxOut.PkScript[2:], schnorr.SerializePubKey(privateKey.PubKey())) {
+		return nil, ErrInputKeyMismatch
+	}
+
+	return privateKey, nil
+}
+
+// SilentPaymentReceiverConfig is the configuration of a SilentPaymentReceiver
+type SilentPaymentReceiverConfig struct {
+	Network  NetworkType
+	ScanKey  *btcec.PrivateKey
+	SpendKey *btcec.PublicKey // only its public key is needed to find payments
+	Labels   []uint32         // labels handed out earlier, scanned for along with the unlabelled address
+}
+
+// SilentPaymentReceiver finds the outputs paying its silent payment addresses
+type SilentPaymentReceiver struct {
+	config SilentPaymentReceiverConfig
+	labels map[string]uint32 // of the compressed label tweak points
+}
+
+// NewSilentPaymentReceiver creates a receiver scanning for the payments to its addresses
+func NewSilentPaymentReceiver(config SilentPaymentReceiverConfig) (*SilentPaymentReceiver, error) {
+
+	// Missing network
+	if config.Network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	// Missing keys
+	if config.ScanKey == nil {
+		return nil, ErrPrivateKeyMissing
+	}
+	if config.SpendKey == nil {
+		return nil, ErrMissingPubKey
+	}
+	if !IsValidPublicKey(config.SpendKey) {
+		return nil, ErrInvalidPubKey
+	}
+
+	receiver := &SilentPaymentReceiver{config: config, labels: make(map[string]uint32)}
+	for _, label := range config.Labels {
+		if _, err := receiver.AddLabel(label); err != nil {
+			return nil, err
+		}
+	}
+
+	return receiver, nil
+}
+
+// Address returns the unlabelled silent payment address of the receiver
+func (r *SilentPaymentReceiver) Address() (string, error) {
+	address := SilentPaymentAddress{Network: r.config.Network, ScanKey: r.config.ScanKey.PubKey(), SpendKey: r.config.SpendKey}
+	return address.Encode()
+}
+
+// AddLabel returns the address of a label, scanning for its payments from now on. Labels tell apart the payments of
+// addresses published for different purposes, SilentPaymentChangeLabel being reserved for change
+func (r *SilentPaymentReceiver) AddLabel(label uint32) (string, error) {
+	tweak := scalarBaseMult(silentPaymentLabelTweak(r.config.ScanKey, label))
+	spendKey := addPoints(jacobian(r.config.SpendKey), tweak)
+	if isInfinity(spendKey) {
+		return "", ErrInvalidTweak
+	}
+
+	r.labels[string(pointBytes(tweak))] = label
+
+	address := SilentPaymentAddress{Network: r.config.Network, ScanKey: r.config.ScanKey.PubKey(), SpendKey: pointPubKey(spendKey)}
+	return address.Encode()
+}
+
+// Scan returns the outputs of tx paying the receiver, prevOuts holding the outputs spent by tx.
+// Transactions that cannot carry silent payments have none
+func (r *SilentPaymentReceiver) Scan(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut) ([]SilentPaymentOutput, error) {
+	tweakData, err := SilentPaymentTweakData(tx, prevOuts)
+	if errors.Is(err, ErrNoEligibleInputs) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ScanTweakData(tx, tweakData)
+}
+
+// ScanTweakData returns the outputs of tx paying the receiver, from the tweak data of tx (see SilentPaymentTweakData)
+func (r *SilentPaymentReceiver) ScanTweakData(tx *wire.MsgTx, tweakData *btcec.PublicKey) ([]SilentPaymentOutput, error) {
+
+	// Missing transaction
+	if tx == nil {
+		return nil, ErrMissingTransaction
+	}
+	if !IsValidPublicKey(tweakData) {
+		return nil, ErrInvalidPubKey
+	}
+
+	sharedSecret := scalarMult(&r.config.ScanKey.Key, jacobian(tweakData))
+	spendKey := jacobian(r.config.SpendKey)
+	found := make(map[int]bool)
+
+	var outputs []SilentPaymentOutput
+	for k := uint32(0); k < silentPaymentMaxPayments; k++ {
+		tweak := silentPaymentTweak(sharedSecret, k)
+		outputKey := addPoints(spendKey, scalarBaseMult(tweak))
+		negated := *outputKey
+		negatePoint(&negated)
+
+		var match bool
+		for i, out := range tx.TxOut {
+			if found[i] || !txscript.IsPayToTaproot(out.PkScript) {
+				continue
+			}
+
+			output, err := schnorr.ParsePubKey(out.PkScript[2:])
+			if err != nil {
+				continue
+			}
+
+			var label *uint32
+			if !bytes.Equal(out.PkScript[2:], pointXBytes(outputKey)) {
+
+				// a labelled payment is output - P_k, or -output - P_k as outputs are x-only
+				labelTweak, ok := r.labels[string(pointBytes(addPoints(jacobian(output), &negated)))]
+				if !ok {
+					negatedOutput := jacobian(output)
+					negatePoint(negatedOutput)
+					if labelTweak, ok = r.labels[string(pointBytes(addPoints(negatedOutput, &negated)))]; !ok {
+						continue
+					}
+				}
+				label = &labelTweak
+			}
+
+			outputTweak := *tweak
+			if label != nil {
+				outputTweak.Add(silentPaymentLabelTweak(r.config.ScanKey, *label))
+			}
+
+			outputs = append(outputs, SilentPaymentOutput{
+				OutPoint: wire.OutPoint{Hash: tx.TxHash(), Index: uint32(i)},
+				TxOut:    out,
+				Tweak:    outputTweak.Bytes(),
+				Label:    label,
+			})
+			found[i] = true
+			match = true
+			break
+		}
+
+		// the sender numbers its outputs from 0, a gap ends them
+		if !match {
+			break
+		}
+	}
+
+	return outputs, nil
+}
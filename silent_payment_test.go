@@ -0,0 +1,502 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// testSilentPaymentKey returns the private key of seed
+func testSilentPaymentKey(seed byte) *btcec.PrivateKey {
+	privateKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{seed}, 32))
+	return privateKey
+}
+
+// testSilentPaymentReceiver returns a receiver of the keys of seed and seed+1
+func testSilentPaymentReceiver(t *testing.T, seed byte, labels ...uint32) (*SilentPaymentReceiver, *btcec.PrivateKey) {
+	spendKey := testSilentPaymentKey(seed + 1)
+	receiver, err := NewSilentPaymentReceiver(SilentPaymentReceiverConfig{
+		Network:  Mainnet,
+		ScanKey:  testSilentPaymentKey(seed),
+		SpendKey: spendKey.PubKey(),
+		Labels:   labels,
+	})
+	require.NoError(t, err)
+	return receiver, spendKey
+}
+
+// testSilentPaymentInputs returns a transaction spending a P2WPKH, P2TR (with an odd key), P2PKH, P2SH-P2WPKH and
+// a P2WSH input, the witnesses and script sigs carrying the keys a receiver reads but placeholder signatures
+func testSilentPaymentInputs(t *testing.T) (*wire.MsgTx, map[wire.OutPoint]*wire.TxOut, map[wire.OutPoint]*btcec.PrivateKey) {
+	tx := wire.NewMsgTx(2)
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+	privateKeys := make(map[wire.OutPoint]*btcec.PrivateKey)
+	signature := bytes.Repeat([]byte{0x30}, 71)
+
+	addInput := func(index uint32, pkScript []byte, privateKey *btcec.PrivateKey) *wire.TxIn {
+		outPoint := wire.OutPoint{Hash: chainhash.Hash{byte(10 - index)}, Index: index}
+		in := wire.NewTxIn(&outPoint, nil, nil)
+		tx.AddTxIn(in)
+		prevOuts[outPoint] = wire.NewTxOut(100000, pkScript)
+		if privateKey != nil {
+			privateKeys[outPoint] = privateKey
+		}
+		return in
+	}
+
+	// P2WPKH
+	key := testSilentPaymentKey(20)
+	pubKey := key.PubKey().SerializeCompressed()
+	pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(btcutil.Hash160(pubKey)).Script()
+	require.NoError(t, err)
+	addInput(0, pkScript, key).Witness = wire.TxWitness{signature, pubKey}
+
+	// P2TR key path, of a key with an odd y the sender negates
+	seed := byte(21)
+	for key = testSilentPaymentKey(seed); hasEvenY(jacobian(key.PubKey())); key = testSilentPaymentKey(seed) {
+		seed++
+	}
+	pkScript, err = txscript.PayToTaprootScript(key.PubKey())
+	require.NoError(t, err)
+	addInput(1, pkScript, key).Witness = wire.TxWitness{signature[:64]}
+
+	// P2PKH
+	key = testSilentPaymentKey(40)
+	pubKey = key.PubKey().SerializeCompressed()
+	pkScript, err = txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).
+		AddData(btcutil.Hash160(pubKey)).AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).Script()
+	require.NoError(t, err)
+	in := addInput(2, pkScript, key)
+	in.SignatureScript, err = txscript.NewScriptBuilder().AddData(signature).AddData(pubKey).Script()
+	require.NoError(t, err)
+
+	// P2SH-P2WPKH
+	key = testSilentPaymentKey(41)
+	pubKey = key.PubKey().SerializeCompressed()
+	redeemScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(btcutil.Hash160(pubKey)).Script()
+	require.NoError(t, err)
+	pkScript, err = txscript.NewScriptBuilder().AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(redeemScript)).
+		AddOp(txscript.OP_EQUAL).Script()
+	require.NoError(t, err)
+	in = addInput(3, pkScript, key)
+	in.SignatureScript, err = txscript.NewScriptBuilder().AddData(redeemScript).Script()
+	require.NoError(t, err)
+	in.Witness = wire.TxWitness{signature, pubKey}
+
+	// P2WSH, left out
+	witnessScript := []byte{txscript.OP_TRUE}
+	scriptHash := chainhash.HashB(witnessScript)
+	pkScript, err = txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(scriptHash).Script()
+	require.NoError(t, err)
+	addInput(4, pkScript, nil).Witness = wire.TxWitness{witnessScript}
+
+	return tx, prevOuts, privateKeys
+}
+
+// TestSilentPaymentAddress will test the methods Encode() and ParseSilentPaymentAddress()
+func TestSilentPaymentAddress(t *testing.T) {
+	t.Parallel()
+
+	address := SilentPaymentAddress{Network: Mainnet, ScanKey: testSilentPaymentKey(1).PubKey(), SpendKey: testSilentPaymentKey(2).PubKey()}
+	encoded, err := address.Encode()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "sp1q"))
+	assert.Len(t, encoded, 116)
+
+	parsed, err := ParseSilentPaymentAddress(encoded, Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, address.ScanKey.SerializeCompressed(), parsed.ScanKey.SerializeCompressed())
+	assert.Equal(t, address.SpendKey.SerializeCompressed(), parsed.SpendKey.SerializeCompressed())
+
+	// upper case addresses fit QR codes better
+	_, err = ParseSilentPaymentAddress(strings.ToUpper(encoded), Mainnet)
+	require.NoError(t, err)
+
+	_, err = ParseSilentPaymentAddress(encoded, Testnet)
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+
+	for network, prefix := range map[NetworkType]string{
+		Testnet:                       "tsp1q",
+		&chaincfg.SigNetParams:        "tsp1q",
+		&chaincfg.RegressionNetParams: "sprt1q",
+		&chaincfg.SimNetParams:        "tsp1q",
+		&chaincfg.MainNetParams:       "sp1q",
+	} {
+		address.Network = network
+		encoded, err := address.Encode()
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encoded, prefix))
+
+		_, err = ParseSilentPaymentAddress(encoded, network)
+		require.NoError(t, err)
+	}
+
+	// bech32 instead of bech32m, a single key, version 31
+	keys, err := bech32.ConvertBits(append(address.ScanKey.SerializeCompressed(), address.SpendKey.SerializeCompressed()...), 8, 5, true)
+	require.NoError(t, err)
+	invalid, err := bech32.Encode("sp", append([]byte{0}, keys...))
+	require.NoError(t, err)
+	_, err = ParseSilentPaymentAddress(invalid, Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidSilentPaymentAddress)
+
+	single, err := bech32.ConvertBits(address.ScanKey.SerializeCompressed(), 8, 5, true)
+	require.NoError(t, err)
+	invalid, err = bech32.EncodeM("sp", append([]byte{0}, single...))
+	require.NoError(t, err)
+	_, err = ParseSilentPaymentAddress(invalid, Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidSilentPaymentAddress)
+
+	invalid, err = bech32.EncodeM("sp", append([]byte{31}, keys...))
+	require.NoError(t, err)
+	_, err = ParseSilentPaymentAddress(invalid, Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidSilentPaymentAddress)
+
+	// later versions may append data
+	extended, err := bech32.ConvertBits(append(append(address.ScanKey.SerializeCompressed(), address.SpendKey.SerializeCompressed()...), 1, 2, 3), 8, 5, true)
+	require.NoError(t, err)
+	future, err := bech32.EncodeM("sp", append([]byte{1}, extended...))
+	require.NoError(t, err)
+	parsed, err = ParseSilentPaymentAddress(future, Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, address.SpendKey.SerializeCompressed(), parsed.SpendKey.SerializeCompressed())
+
+	_, err = ParseSilentPaymentAddress("bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidSilentPaymentAddress)
+	_, err = ParseSilentPaymentAddress("sp1qinvalid", Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidSilentPaymentAddress)
+	_, err = ParseSilentPaymentAddress(encoded, nil)
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+}
+
+// TestDeriveSilentPaymentKeys will test the method DeriveSilentPaymentKeys()
+func TestDeriveSilentPaymentKeys(t *testing.T) {
+	t.Parallel()
+
+	masterKey, err := hdkeychain.NewMaster(bip39.NewSeed(TestMnemonicPhrase, ""), Mainnet)
+	require.NoError(t, err)
+
+	scanKey, spendKey, err := DeriveSilentPaymentKeys(masterKey, Mainnet, 0)
+	require.NoError(t, err)
+
+	derive := func(path ...uint32) *btcec.PrivateKey {
+		key := masterKey
+		for _, index := range path {
+			key, err = key.Derive(index)
+			require.NoError(t, err)
+		}
+		privateKey, err := key.ECPrivKey()
+		require.NoError(t, err)
+		return privateKey
+	}
+	hardened := uint32(hdkeychain.HardenedKeyStart)
+	assert.Equal(t, derive(hardened+352, hardened, hardened, hardened+1, 0).Serialize(), scanKey.Serialize())
+	assert.Equal(t, derive(hardened+352, hardened, hardened, hardened, 0).Serialize(), spendKey.Serialize())
+
+	testnetScanKey, _, err := DeriveSilentPaymentKeys(masterKey, Testnet, 0)
+	require.NoError(t, err)
+	assert.Equal(t, derive(hardened+352, hardened+1, hardened, hardened+1, 0).Serialize(), testnetScanKey.Serialize())
+
+	_, _, err = DeriveSilentPaymentKeys(masterKey, nil, 0)
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+}
+
+// TestSilentPayment will test paying silent payment addresses with AddSilentPaymentOutputs() and finding the
+// payments with Scan()
+func TestSilentPayment(t *testing.T) {
+	t.Parallel()
+
+	receiver, spendKey := testSilentPaymentReceiver(t, 1)
+	donations, err := receiver.Address()
+	require.NoError(t, err)
+	labelled, err := receiver.AddLabel(7)
+	require.NoError(t, err)
+	change, err := receiver.AddLabel(SilentPaymentChangeLabel)
+	require.NoError(t, err)
+
+	other, _ := testSilentPaymentReceiver(t, 3)
+	otherAddress, err := other.Address()
+	require.NoError(t, err)
+
+	tx, prevOuts, privateKeys := testSilentPaymentInputs(t)
+	payouts := []Payout{
+		{Address: donations, Value: 10000},
+		{Address: otherAddress, Value: 20000},
+		{Address: labelled, Value: 30000},
+		{Address: donations, Value: 40000},
+		{Address: change, Value: 50000},
+	}
+	require.NoError(t, AddSilentPaymentOutputs(tx, prevOuts, privateKeys, payouts, Mainnet))
+	require.Len(t, tx.TxOut, 5)
+
+	// every output is a new taproot key
+	seen := make(map[string]bool)
+	for _, out := range tx.TxOut {
+		assert.True(t, txscript.IsPayToTaproot(out.PkScript))
+		assert.False(t, seen[string(out.PkScript)])
+		seen[string(out.PkScript)] = true
+	}
+
+	outputs, err := receiver.Scan(tx, prevOuts)
+	require.NoError(t, err)
+	require.Len(t, outputs, 4)
+
+	labels := make(map[uint32]*uint32)
+	for _, output := range outputs {
+		assert.Equal(t, tx.TxHash(), output.OutPoint.Hash)
+		assert.Equal(t, tx.TxOut[output.OutPoint.Index], output.TxOut)
+		labels[output.OutPoint.Index] = output.Label
+
+		privateKey, err := output.PrivateKey(spendKey)
+		require.NoError(t, err)
+		assert.Equal(t, output.TxOut.PkScript[2:], schnorr.SerializePubKey(privateKey.PubKey()))
+	}
+	assert.Nil(t, labels[0])
+	assert.Nil(t, labels[3])
+	require.NotNil(t, labels[2])
+	assert.Equal(t, uint32(7), *labels[2])
+	require.NotNil(t, labels[4])
+	assert.Equal(t, SilentPaymentChangeLabel, *labels[4])
+
+	otherOutputs, err := other.Scan(tx, prevOuts)
+	require.NoError(t, err)
+	require.Len(t, otherOutputs, 1)
+	assert.Equal(t, uint32(1), otherOutputs[0].OutPoint.Index)
+
+	// k numbers the payments to every address of a scan key, a receiver that forgot a label stops at its payment
+	forgetful, _ := testSilentPaymentReceiver(t, 1, SilentPaymentChangeLabel)
+	outputs, err = forgetful.Scan(tx, prevOuts)
+	require.NoError(t, err)
+	require.Len(t, outputs, 1)
+	assert.Equal(t, uint32(0), outputs[0].OutPoint.Index)
+
+	// light clients scan with the tweak data of an index
+	tweakData, err := SilentPaymentTweakData(tx, prevOuts)
+	require.NoError(t, err)
+	outputs, err = receiver.ScanTweakData(tx, tweakData)
+	require.NoError(t, err)
+	assert.Len(t, outputs, 4)
+
+	// the spend key is not needed to find payments
+	_, err = receiver.ScanTweakData(nil, tweakData)
+	assert.ErrorIs(t, err, ErrMissingTransaction)
+	_, err = outputs[0].PrivateKey(testSilentPaymentKey(9))
+	assert.ErrorIs(t, err, ErrInputKeyMismatch)
+}
+
+// TestSilentPaymentSpend will test spending a silent payment output with the key of PrivateKey()
+func TestSilentPaymentSpend(t *testing.T) {
+	t.Parallel()
+
+	receiver, spendKey := testSilentPaymentReceiver(t, 5)
+	address, err := receiver.Address()
+	require.NoError(t, err)
+
+	tx, prevOuts, privateKeys := testSilentPaymentInputs(t)
+	require.NoError(t, AddSilentPaymentOutputs(tx, prevOuts, privateKeys, []Payout{{Address: address, Value: 90000}}, Mainnet))
+
+	outputs, err := receiver.Scan(tx, prevOuts)
+	require.NoError(t, err)
+	require.Len(t, outputs, 1)
+	privateKey, err := outputs[0].PrivateKey(spendKey)
+	require.NoError(t, err)
+
+	spend := wire.NewMsgTx(2)
+	spend.AddTxIn(wire.NewTxIn(&outputs[0].OutPoint, nil, nil))
+	spend.AddTxOut(wire.NewTxOut(89000, outputs[0].TxOut.PkScript))
+
+	fetcher := txscript.NewCannedPrevOutputFetcher(outputs[0].TxOut.PkScript, outputs[0].TxOut.Value)
+	sigHash, err := txscript.CalcTaprootSignatureHash(txscript.NewTxSigHashes(spend, fetcher), txscript.SigHashDefault, spend, 0, fetcher)
+	require.NoError(t, err)
+
+	// silent payment outputs have no taproot tweak
+	signature, err := schnorr.Sign(privateKey, sigHash)
+	require.NoError(t, err)
+	spend.TxIn[0].Witness = wire.TxWitness{signature.Serialize()}
+	require.NoError(t, VerifyTransaction(spend, map[wire.OutPoint]*wire.TxOut{outputs[0].OutPoint: outputs[0].TxOut}, StandardScriptFlags))
+}
+
+// TestSilentPaymentInputs will test which inputs take part in silent payments
+func TestSilentPaymentInputs(t *testing.T) {
+	t.Parallel()
+
+	receiver, _ := testSilentPaymentReceiver(t, 1)
+	address, err := receiver.Address()
+	require.NoError(t, err)
+	payouts := []Payout{{Address: address, Value: 10000}}
+
+	// a taproot script path spend of the unspendable key is left out
+	tx, prevOuts, privateKeys := testSilentPaymentInputs(t)
+	outPoint := wire.OutPoint{Hash: chainhash.Hash{11}, Index: 5}
+	pkScript, err := txscript.PayToTaprootScript(testSilentPaymentKey(50).PubKey())
+	require.NoError(t, err)
+	prevOuts[outPoint] = wire.NewTxOut(100000, pkScript)
+	controlBlock := append([]byte{byte(txscript.BaseLeafVersion)}, schnorr.SerializePubKey(TaprootNUMSPubKey())...)
+	in := wire.NewTxIn(&outPoint, nil, wire.TxWitness{{txscript.OP_TRUE}, controlBlock, {txscript.TaprootAnnexTag}})
+	tx.AddTxIn(in)
+
+	require.NoError(t, AddSilentPaymentOutputs(tx, prevOuts, privateKeys, payouts, Mainnet))
+	outputs, err := receiver.Scan(tx, prevOuts)
+	require.NoError(t, err)
+	assert.Len(t, outputs, 1)
+
+	// a key path spend counts, the receiver misses a payment the sender made without its key
+	in.Witness = wire.TxWitness{bytes.Repeat([]byte{1}, 64)}
+	outputs, err = receiver.Scan(tx, prevOuts)
+	require.NoError(t, err)
+	assert.Empty(t, outputs)
+
+	// so the sender needs the key of every input the receiver counts, signed or not
+	for i := 0; i < 4; i++ {
+		tx, prevOuts, privateKeys = testSilentPaymentInputs(t)
+		missing := tx.TxIn[i].PreviousOutPoint
+		delete(privateKeys, missing)
+		err = AddSilentPaymentOutputs(tx, prevOuts, privateKeys, payouts, Mainnet)
+		assert.ErrorIs(t, err, ErrPrivateKeyMissing, "input %d", i)
+		assert.ErrorContains(t, err, missing.String())
+
+		tx.TxIn[i].SignatureScript, tx.TxIn[i].Witness = nil, nil
+		err = AddSilentPaymentOutputs(tx, prevOuts, privateKeys, payouts, Mainnet)
+		if i == 3 {
+			// an unsigned P2SH input may be any script
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, ErrPrivateKeyMissing, "unsigned input %d", i)
+		}
+	}
+
+	// the keys must own the outputs they spend
+	tx, prevOuts, privateKeys = testSilentPaymentInputs(t)
+	privateKeys[tx.TxIn[0].PreviousOutPoint] = testSilentPaymentKey(60)
+	err = AddSilentPaymentOutputs(tx, prevOuts, privateKeys, payouts, Mainnet)
+	assert.ErrorIs(t, err, ErrInputKeyMismatch)
+
+	// a P2WSH key
+	tx, prevOuts, privateKeys = testSilentPaymentInputs(t)
+	privateKeys[tx.TxIn[4].PreviousOutPoint] = testSilentPaymentKey(60)
+	err = AddSilentPaymentOutputs(tx, prevOuts, privateKeys, payouts, Mainnet)
+	assert.ErrorIs(t, err, ErrIncorrectAddressType)
+
+	// no keys, and only inputs the receiver leaves out
+	tx, prevOuts, _ = testSilentPaymentInputs(t)
+	err = AddSilentPaymentOutputs(tx, prevOuts, nil, payouts, Mainnet)
+	assert.ErrorIs(t, err, ErrPrivateKeyMissing)
+	tx.TxIn = tx.TxIn[4:]
+	err = AddSilentPaymentOutputs(tx, prevOuts, nil, payouts, Mainnet)
+	assert.ErrorIs(t, err, ErrNoEligibleInputs)
+
+	// a segwit v2 input
+	tx, prevOuts, privateKeys = testSilentPaymentInputs(t)
+	prevOuts[tx.TxIn[4].PreviousOutPoint].PkScript = append([]byte{txscript.OP_2, txscript.OP_DATA_32}, bytes.Repeat([]byte{1}, 32)...)
+	err = AddSilentPaymentOutputs(tx, prevOuts, privateKeys, payouts, Mainnet)
+	assert.ErrorIs(t, err, ErrNoEligibleInputs)
+
+	// dust, missing prevouts, wrong network
+	tx, prevOuts, privateKeys = testSilentPaymentInputs(t)
+	err = AddSilentPaymentOutputs(tx, prevOuts, privateKeys, []Payout{{Address: address, Value: 100}}, Mainnet)
+	assert.ErrorIs(t, err, ErrDustOutput)
+	err = AddSilentPaymentOutputs(tx, nil, privateKeys, payouts, Mainnet)
+	assert.ErrorIs(t, err, ErrMissingPrevOut)
+	err = AddSilentPaymentOutputs(tx, prevOuts, privateKeys, payouts, Testnet)
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+
+	// a transaction without taproot outputs has no payments
+	tx, prevOuts, _ = testSilentPaymentInputs(t)
+	tx.AddTxOut(wire.NewTxOut(1000, prevOuts[tx.TxIn[0].PreviousOutPoint].PkScript))
+	outputs, err = receiver.Scan(tx, prevOuts)
+	require.NoError(t, err)
+	assert.Empty(t, outputs)
+}
+
+// TestNewSilentPaymentReceiver will test the method NewSilentPaymentReceiver()
+func TestNewSilentPaymentReceiver(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewSilentPaymentReceiver(SilentPaymentReceiverConfig{ScanKey: testSilentPaymentKey(1), SpendKey: testSilentPaymentKey(2).PubKey()})
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+	_, err = NewSilentPaymentReceiver(SilentPaymentReceiverConfig{Network: Mainnet, SpendKey: testSilentPaymentKey(2).PubKey()})
+	assert.ErrorIs(t, err, ErrPrivateKeyMissing)
+	_, err = NewSilentPaymentReceiver(SilentPaymentReceiverConfig{Network: Mainnet, ScanKey: testSilentPaymentKey(1)})
+	assert.ErrorIs(t, err, ErrMissingPubKey)
+
+	// the address is the one of the keys
+	receiver, spendKey := testSilentPaymentReceiver(t, 1)
+	address, err := receiver.Address()
+	require.NoError(t, err)
+	parsed, err := ParseSilentPaymentAddress(address, Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, testSilentPaymentKey(1).PubKey().SerializeCompressed(), parsed.ScanKey.SerializeCompressed())
+	assert.Equal(t, spendKey.PubKey().SerializeCompressed(), parsed.SpendKey.SerializeCompressed())
+
+	// labelled addresses share the scan key
+	labelled, err := receiver.AddLabel(1)
+	require.NoError(t, err)
+	parsedLabelled, err := ParseSilentPaymentAddress(labelled, Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, parsed.ScanKey.SerializeCompressed(), parsedLabelled.ScanKey.SerializeCompressed())
+	assert.NotEqual(t, parsed.SpendKey.SerializeCompressed(), parsedLabelled.SpendKey.SerializeCompressed())
+}
+
+// TestSilentPaymentVector will test the BIP352 vector "Simple send: two inputs", the receiver keys encoding its
+// address and finding the output the sender derives
+func TestSilentPaymentVector(t *testing.T) {
+	t.Parallel()
+
+	const address = "sp1qqgste7k9hx0qftg6qmwlkqtwuy6cycyavzmzj85c6qdfhjdpdjtdgqjuexzk6murw56suy3e0rd2cgqvycxttddwsvgxe2usfpxumr70xc9pkqwv"
+
+	scanKey, err := PrivateKeyFromString("0f694e068028a717f8af6b9411f9a133dd3565258714cc226594b34db90c1f2c")
+	require.NoError(t, err)
+	spendKey, err := PrivateKeyFromString("9d6ad855ce3417ef84e836892e5a56392bfba05fa5d97ccea30e266f540e08b3")
+	require.NoError(t, err)
+	receiver, err := NewSilentPaymentReceiver(SilentPaymentReceiverConfig{Network: Mainnet, ScanKey: scanKey, SpendKey: spendKey.PubKey()})
+	require.NoError(t, err)
+	receiverAddress, err := receiver.Address()
+	require.NoError(t, err)
+	assert.Equal(t, address, receiverAddress)
+
+	tx := wire.NewMsgTx(2)
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+	privateKeys := make(map[wire.OutPoint]*btcec.PrivateKey)
+	for txID, key := range map[string]string{
+		"f4184fc596403b9d638783cf57adfe4c75c605f6356fbc91338530e9831e9e16": "eadc78165ff1f8ea94ad7cfdc54990738a4c53f6e0507b42154201b8e5dff3b1",
+		"a1075db55d416d3ca199f55b6084e2115b9345e16c5cf302fc80e9d5fbf5d48d": "93f5ed907ad5b2bdbbdcb5d9116ebc0a4e1f92f910d5260237fa45a9408aad16",
+	} {
+		hash, err := chainhash.NewHashFromStr(txID)
+		require.NoError(t, err)
+		privateKey, err := PrivateKeyFromString(key)
+		require.NoError(t, err)
+
+		pubKey := privateKey.PubKey().SerializeCompressed()
+		pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(btcutil.Hash160(pubKey)).Script()
+		require.NoError(t, err)
+
+		outPoint := wire.OutPoint{Hash: *hash, Index: 0}
+		tx.AddTxIn(wire.NewTxIn(&outPoint, nil, wire.TxWitness{bytes.Repeat([]byte{0x30}, 71), pubKey}))
+		prevOuts[outPoint] = wire.NewTxOut(100000, pkScript)
+		privateKeys[outPoint] = privateKey
+	}
+
+	require.NoError(t, AddSilentPaymentOutputs(tx, prevOuts, privateKeys, []Payout{{Address: address, Value: 10000}}, Mainnet))
+	require.Len(t, tx.TxOut, 1)
+	assert.Equal(t, "3e9fce73d4e77a4809908e3c3a2e54ee147b9312dc5044a193d1fc85de46e3c1", hex.EncodeToString(tx.TxOut[0].PkScript[2:]))
+
+	outputs, err := receiver.Scan(tx, prevOuts)
+	require.NoError(t, err)
+	require.Len(t, outputs, 1)
+	privateKey, err := outputs[0].PrivateKey(spendKey)
+	require.NoError(t, err)
+	assert.Equal(t, tx.TxOut[0].PkScript[2:], schnorr.SerializePubKey(privateKey.PubKey()))
+}
@@ -0,0 +1,91 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// taprootNUMSKey is the BIP341 "nothing up my sleeve" point H, a key nobody knows the private key of
+const taprootNUMSKey = "0250929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0"
+
+// TaprootNUMSPubKey returns the BIP341 unspendable internal key, used to create taproot outputs
+// that can only be spent through their script paths
+func TaprootNUMSPubKey() *btcec.PublicKey {
+	pubKey, _ := PubKeyFromString(taprootNUMSKey)
+	return pubKey
+}
+
+// GetTaprootScriptAddress returns the P2TR address of internalKey committing to a tree of tapscripts (hex strings).
+// The key path stays spendable by internalKey while each tapscript is a script path
+func GetTaprootScriptAddress(internalKey *btcec.PublicKey, tapscripts []string, networkType NetworkType) (string, error) {
+
+	valid := IsValidPublicKey(internalKey)
+	if !valid {
+		return "", ErrInvalidPubKey
+	}
+
+	tree, err := tapscriptTree(tapscripts)
+	if err != nil {
+		return "", err
+	}
+
+	rootHash := tree.RootNode.TapHash()
+	taprootKey := txscript.ComputeTaprootOutputKey(internalKey, rootHash[:])
+
+	addr, err := btcutil.NewAddressTaproot(taprootKey.SerializeCompressed()[1:], networkType)
+	if err != nil {
+		return "", err
+	}
+
+	return addr.EncodeAddress(), nil
+}
+
+// GetTapscriptControlBlock returns the control block (hex encoded) needed in the witness to spend
+// tapscripts[index] of an output created by GetTaprootScriptAddress
+func GetTapscriptControlBlock(internalKey *btcec.PublicKey, tapscripts []string, index int) (string, error) {
+
+	valid := IsValidPublicKey(internalKey)
+	if !valid {
+		return "", ErrInvalidPubKey
+	}
+
+	tree, err := tapscriptTree(tapscripts)
+	if err != nil {
+		return "", err
+	}
+
+	if index < 0 || index >= len(tree.LeafMerkleProofs) {
+		return "", ErrInvalidTapscriptIndex
+	}
+
+	controlBlock := tree.LeafMerkleProofs[index].ToControlBlock(internalKey)
+	controlBlockBytes, err := controlBlock.ToBytes()
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(controlBlockBytes), nil
+}
+
+// tapscriptTree assembles the tapscript tree of the given leaf scripts (hex strings)
+func tapscriptTree(tapscripts []string) (*txscript.IndexedTapScriptTree, error) {
+
+	// No scripts?
+	if len(tapscripts) == 0 {
+		return nil, ErrMissingScript
+	}
+
+	leaves := make([]txscript.TapLeaf, 0, len(tapscripts))
+	for _, tapscript := range tapscripts {
+		script, err := hex.DecodeString(tapscript)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, txscript.NewBaseTapLeaf(script))
+	}
+
+	return txscript.AssembleTaprootScriptTree(leaves...), nil
+}
@@ -0,0 +1,70 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetTaprootScriptAddress will test the methods GetTaprootScriptAddress() and GetTapscriptControlBlock()
+func TestGetTaprootScriptAddress(t *testing.T) {
+	t.Parallel()
+
+	internalKey, err := PubKeyFromString("022d35c7ede60cb68dee6e60ab9ad5863a7a726b297273d7a99b9dfb032a10e3f8")
+	require.NoError(t, err)
+
+	tapscripts := []string{"51", "52", "53"}
+
+	address, err := GetTaprootScriptAddress(internalKey, tapscripts, Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1p", address[:4])
+
+	// the output key differs from the key path only address
+	keyPathAddress, err := GetAddressFromPubKey(internalKey, Taproot, Mainnet)
+	require.NoError(t, err)
+	assert.NotEqual(t, keyPathAddress, address)
+
+	script, err := GetScriptFromAddress(address, Mainnet)
+	require.NoError(t, err)
+	outputKey, err := hex.DecodeString(script[4:])
+	require.NoError(t, err)
+
+	for i, tapscript := range tapscripts {
+		controlBlockHex, err := GetTapscriptControlBlock(internalKey, tapscripts, i)
+		require.NoError(t, err)
+
+		controlBlockBytes, err := hex.DecodeString(controlBlockHex)
+		require.NoError(t, err)
+		controlBlock, err := txscript.ParseControlBlock(controlBlockBytes)
+		require.NoError(t, err)
+
+		leafScript, err := hex.DecodeString(tapscript)
+		require.NoError(t, err)
+		require.NoError(t, txscript.VerifyTaprootLeafCommitment(controlBlock, outputKey, leafScript))
+	}
+
+	_, err = GetTapscriptControlBlock(internalKey, tapscripts, 3)
+	assert.ErrorIs(t, err, ErrInvalidTapscriptIndex)
+
+	_, err = GetTaprootScriptAddress(internalKey, nil, Mainnet)
+	assert.ErrorIs(t, err, ErrMissingScript)
+
+	_, err = GetTaprootScriptAddress(internalKey, []string{"zz"}, Mainnet)
+	assert.Error(t, err)
+
+	_, err = GetTaprootScriptAddress(&btcec.PublicKey{}, tapscripts, Mainnet)
+	assert.ErrorIs(t, err, ErrInvalidPubKey)
+}
+
+// TestTaprootNUMSPubKey will test the method TaprootNUMSPubKey()
+func TestTaprootNUMSPubKey(t *testing.T) {
+	t.Parallel()
+
+	pubKey := TaprootNUMSPubKey()
+	require.NotNil(t, pubKey)
+	assert.Equal(t, "50929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0", hex.EncodeToString(pubKey.SerializeCompressed()[1:]))
+}
@@ -0,0 +1,181 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// SequenceSecondsGranularity is the number of seconds in one unit of a time based relative lock time (BIP68)
+const SequenceSecondsGranularity = 1 << wire.SequenceLockTimeGranularity
+
+// RelativeLockTime is a decoded BIP68 sequence number
+type RelativeLockTime struct {
+	Disabled bool   // the sequence does not encode a relative lock time
+	Seconds  bool   // Value counts seconds instead of blocks
+	Value    uint32 // blocks, or seconds (a multiple of SequenceSecondsGranularity)
+}
+
+// SequenceFromBlocks encodes a relative lock time of a number of blocks as a sequence (BIP68)
+func SequenceFromBlocks(blocks uint32) (uint32, error) {
+	if blocks > wire.SequenceLockTimeMask {
+		return 0, ErrInvalidSequence
+	}
+
+	return blocks, nil
+}
+
+// SequenceFromSeconds encodes a relative lock time of a number of seconds as a sequence (BIP68).
+// The lock is rounded up to the next 512 second unit, so it never ends early
+func SequenceFromSeconds(seconds uint32) (uint32, error) {
+	units := (uint64(seconds) + SequenceSecondsGranularity - 1) / SequenceSecondsGranularity
+	if units > wire.SequenceLockTimeMask {
+		return 0, ErrInvalidSequence
+	}
+
+	return wire.SequenceLockTimeIsSeconds | uint32(units), nil
+}
+
+// DecodeSequence decodes the relative lock time of a sequence (BIP68)
+func DecodeSequence(sequence uint32) RelativeLockTime {
+	if sequence&wire.SequenceLockTimeDisabled != 0 {
+		return RelativeLockTime{Disabled: true}
+	}
+
+	value := sequence & wire.SequenceLockTimeMask
+	if sequence&wire.SequenceLockTimeIsSeconds != 0 {
+		return RelativeLockTime{Seconds: true, Value: value * SequenceSecondsGranularity}
+	}
+
+	return RelativeLockTime{Value: value}
+}
+
+// LockTimeFromHeight returns the transaction lock time of a block height
+func LockTimeFromHeight(height uint32) (uint32, error) {
+	if height >= txscript.LockTimeThreshold {
+		return 0, ErrInvalidLockTime
+	}
+
+	return height, nil
+}
+
+// LockTimeFromTime returns the transaction lock time of a point in time (compared against median time past)
+func LockTimeFromTime(t time.Time) (uint32, error) {
+	unix := t.Unix()
+	if unix < txscript.LockTimeThreshold || unix > int64(^uint32(0)) {
+		return 0, ErrInvalidLockTime
+	}
+
+	return uint32(unix), nil
+}
+
+// IsLockTimeHeight reports whether a lock time is a block height, it is a unix timestamp otherwise
+func IsLockTimeHeight(lockTime uint32) bool {
+	return lockTime < txscript.LockTimeThreshold
+}
+
+// GetAbsoluteTimelockScript returns a witness script (hex) that pubKey can spend once lockTime is reached:
+// <lockTime> OP_CHECKLOCKTIMEVERIFY OP_DROP <pubKey> OP_CHECKSIG
+func GetAbsoluteTimelockScript(pubKey *btcec.PublicKey, lockTime uint32) (string, error) {
+	return timelockScript(pubKey, int64(lockTime), txscript.OP_CHECKLOCKTIMEVERIFY, false)
+}
+
+// GetRelativeTimelockScript returns a witness script (hex) that pubKey can spend once the input is
+// as old as sequence: <sequence> OP_CHECKSEQUENCEVERIFY OP_DROP <pubKey> OP_CHECKSIG
+func GetRelativeTimelockScript(pubKey *btcec.PublicKey, sequence uint32) (string, error) {
+	if sequence&wire.SequenceLockTimeDisabled != 0 {
+		return "", ErrInvalidSequence
+	}
+
+	return timelockScript(pubKey, int64(sequence), txscript.OP_CHECKSEQUENCEVERIFY, false)
+}
+
+// GetAbsoluteTimelockTapscript is GetAbsoluteTimelockScript for a tapscript leaf, using an x-only pubKey
+func GetAbsoluteTimelockTapscript(pubKey *btcec.PublicKey, lockTime uint32) (string, error) {
+	return timelockScript(pubKey, int64(lockTime), txscript.OP_CHECKLOCKTIMEVERIFY, true)
+}
+
+// GetRelativeTimelockTapscript is GetRelativeTimelockScript for a tapscript leaf, using an x-only pubKey
+func GetRelativeTimelockTapscript(pubKey *btcec.PublicKey, sequence uint32) (string, error) {
+	if sequence&wire.SequenceLockTimeDisabled != 0 {
+		return "", ErrInvalidSequence
+	}
+
+	return timelockScript(pubKey, int64(sequence), txscript.OP_CHECKSEQUENCEVERIFY, true)
+}
+
+// GetTimelockedRecoveryScript returns a witness script (hex) spendable by primary at any time, or by
+// recovery once the input is as old as sequence:
+// OP_IF <primary> OP_CHECKSIG OP_ELSE <sequence> OP_CHECKSEQUENCEVERIFY OP_DROP <recovery> OP_CHECKSIG OP_ENDIF
+// The primary path is spent with the witness <sig> 1 <script>, the recovery path with <sig> 0 <script>
+func GetTimelockedRecoveryScript(primary, recovery *btcec.PublicKey, sequence uint32) (string, error) {
+
+	if !IsValidPublicKey(primary) || !IsValidPublicKey(recovery) {
+		return "", ErrInvalidPubKey
+	}
+
+	if sequence&wire.SequenceLockTimeDisabled != 0 {
+		return "", ErrInvalidSequence
+	}
+
+	script, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_IF).
+		AddData(primary.SerializeCompressed()).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_ELSE).
+		AddInt64(int64(sequence)).
+		AddOp(txscript.OP_CHECKSEQUENCEVERIFY).
+		AddOp(txscript.OP_DROP).
+		AddData(recovery.SerializeCompressed()).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_ENDIF).
+		Script()
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(script), nil
+}
+
+// GetTimelockedRecoveryTaprootAddress returns the P2TR address spendable by primary through the key path
+// at any time, or by recovery through a relative timelock tapscript once the input is as old as sequence
+func GetTimelockedRecoveryTaprootAddress(primary, recovery *btcec.PublicKey, sequence uint32, networkType NetworkType) (string, error) {
+	tapscript, err := GetRelativeTimelockTapscript(recovery, sequence)
+	if err != nil {
+		return "", err
+	}
+
+	return GetTaprootScriptAddress(primary, []string{tapscript}, networkType)
+}
+
+// timelockScript builds <lock> <lockOpcode> OP_DROP <pubKey> OP_CHECKSIG
+func timelockScript(pubKey *btcec.PublicKey, lock int64, lockOpcode byte, tapscript bool) (string, error) {
+
+	valid := IsValidPublicKey(pubKey)
+	if !valid {
+		return "", ErrInvalidPubKey
+	}
+
+	// tapscript keys are x-only (BIP340)
+	keyBytes := pubKey.SerializeCompressed()
+	if tapscript {
+		keyBytes = schnorr.SerializePubKey(pubKey)
+	}
+
+	script, err := txscript.NewScriptBuilder().
+		AddInt64(lock).
+		AddOp(lockOpcode).
+		AddOp(txscript.OP_DROP).
+		AddData(keyBytes).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(script), nil
+}
@@ -0,0 +1,197 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSequenceEncoding will test the methods SequenceFromBlocks(), SequenceFromSeconds() and DecodeSequence()
+func TestSequenceEncoding(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		blocks           bool
+		value            uint32
+		expectedSequence uint32
+		expectedDecoded  uint32
+		expectedError    bool
+	}{
+		{true, 144, 144, 144, false},
+		{true, 0xffff, 0xffff, 0xffff, false},
+		{true, 0x10000, 0, 0, true},
+		{false, 512, 0x00400001, 512, false},
+		{false, 1000, 0x00400002, 1024, false},
+		{false, 0, 0x00400000, 0, false},
+		{false, 0xffff * 512, 0x0040ffff, 0xffff * 512, false},
+		{false, 0xffff*512 + 1, 0, 0, true},
+	}
+
+	for _, test := range tests {
+		var sequence uint32
+		var err error
+		if test.blocks {
+			sequence, err = SequenceFromBlocks(test.value)
+		} else {
+			sequence, err = SequenceFromSeconds(test.value)
+		}
+
+		if err != nil && !test.expectedError {
+			t.Fatalf("%s Failed: [%d] inputted and error not expected but got: %s", t.Name(), test.value, err.Error())
+		} else if err == nil && test.expectedError {
+			t.Fatalf("%s Failed: [%d] inputted and error was expected", t.Name(), test.value)
+		} else if sequence != test.expectedSequence {
+			t.Fatalf("%s Failed: [%d] inputted and [%x] expected, but got: %x", t.Name(), test.value, test.expectedSequence, sequence)
+		} else if err == nil {
+			decoded := DecodeSequence(sequence)
+			assert.Equal(t, RelativeLockTime{Seconds: !test.blocks, Value: test.expectedDecoded}, decoded)
+		}
+	}
+
+	assert.True(t, DecodeSequence(wire.MaxTxInSequenceNum).Disabled)
+}
+
+// TestLockTime will test the methods LockTimeFromHeight(), LockTimeFromTime() and IsLockTimeHeight()
+func TestLockTime(t *testing.T) {
+	t.Parallel()
+
+	lockTime, err := LockTimeFromHeight(800000)
+	require.NoError(t, err)
+	assert.True(t, IsLockTimeHeight(lockTime))
+
+	_, err = LockTimeFromHeight(txscript.LockTimeThreshold)
+	assert.ErrorIs(t, err, ErrInvalidLockTime)
+
+	lockTime, err = LockTimeFromTime(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1893456000), lockTime)
+	assert.False(t, IsLockTimeHeight(lockTime))
+
+	_, err = LockTimeFromTime(time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrInvalidLockTime)
+}
+
+// TestTimelockScripts will test the asm of the timelock script builders
+func TestTimelockScripts(t *testing.T) {
+	t.Parallel()
+
+	pubKey, err := PubKeyFromString("022d35c7ede60cb68dee6e60ab9ad5863a7a726b297273d7a99b9dfb032a10e3f8")
+	require.NoError(t, err)
+
+	var tests = []struct {
+		build       func() (string, error)
+		expectedAsm string
+	}{
+		{func() (string, error) { return GetAbsoluteTimelockScript(pubKey, 800000) }, "800000 OP_CHECKLOCKTIMEVERIFY OP_DROP 022d35c7ede60cb68dee6e60ab9ad5863a7a726b297273d7a99b9dfb032a10e3f8 OP_CHECKSIG"},
+		{func() (string, error) { return GetRelativeTimelockScript(pubKey, 144) }, "144 OP_CHECKSEQUENCEVERIFY OP_DROP 022d35c7ede60cb68dee6e60ab9ad5863a7a726b297273d7a99b9dfb032a10e3f8 OP_CHECKSIG"},
+		{func() (string, error) { return GetAbsoluteTimelockTapscript(pubKey, 1893456000) }, "1893456000 OP_CHECKLOCKTIMEVERIFY OP_DROP 2d35c7ede60cb68dee6e60ab9ad5863a7a726b297273d7a99b9dfb032a10e3f8 OP_CHECKSIG"},
+		{func() (string, error) { return GetRelativeTimelockTapscript(pubKey, 6) }, "6 OP_CHECKSEQUENCEVERIFY OP_DROP 2d35c7ede60cb68dee6e60ab9ad5863a7a726b297273d7a99b9dfb032a10e3f8 OP_CHECKSIG"},
+	}
+
+	for _, test := range tests {
+		script, err := test.build()
+		require.NoError(t, err)
+
+		asm, err := GetAsmFromScript(script)
+		require.NoError(t, err)
+		assert.Equal(t, test.expectedAsm, asm)
+	}
+
+	_, err = GetRelativeTimelockScript(pubKey, wire.MaxTxInSequenceNum)
+	assert.ErrorIs(t, err, ErrInvalidSequence)
+
+	_, err = GetAbsoluteTimelockScript(&btcec.PublicKey{}, 800000)
+	assert.ErrorIs(t, err, ErrInvalidPubKey)
+}
+
+// TestTimelockedRecoveryScript will spend both paths of a P2WSH recovery script
+func TestTimelockedRecoveryScript(t *testing.T) {
+	t.Parallel()
+
+	primary, err := CreatePrivateKey()
+	require.NoError(t, err)
+	recovery, err := CreatePrivateKey()
+	require.NoError(t, err)
+
+	witnessScriptHex, err := GetTimelockedRecoveryScript(primary.PubKey(), recovery.PubKey(), 144)
+	require.NoError(t, err)
+	witnessScript, err := hex.DecodeString(witnessScriptHex)
+	require.NoError(t, err)
+
+	address, err := GetWitnessScriptAddress(witnessScriptHex, Mainnet)
+	require.NoError(t, err)
+	pkScriptHex, err := GetScriptFromAddress(address, Mainnet)
+	require.NoError(t, err)
+	pkScript, err := hex.DecodeString(pkScriptHex)
+	require.NoError(t, err)
+
+	spend := func(key *btcec.PrivateKey, branch []byte, sequence uint32) error {
+		tx, prevOut := newSpendingTx(pkScript)
+		tx.TxIn[0].Sequence = sequence
+
+		sigHashes := txscript.NewTxSigHashes(tx, txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value))
+		sig, err := txscript.RawTxInWitnessSignature(tx, sigHashes, 0, prevOut.Value, witnessScript, txscript.SigHashAll, key)
+		require.NoError(t, err)
+		tx.TxIn[0].Witness = wire.TxWitness{sig, branch, witnessScript}
+
+		_, err = VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+		return err
+	}
+
+	// the primary key can spend at any time
+	assert.NoError(t, spend(primary, []byte{1}, wire.MaxTxInSequenceNum))
+
+	// the recovery key has to wait for the relative lock time
+	assert.NoError(t, spend(recovery, nil, 144))
+	assert.Error(t, spend(recovery, nil, 143))
+	assert.Error(t, spend(primary, nil, 144))
+}
+
+// TestTimelockedRecoveryTaprootAddress will spend the recovery path of a taproot recovery output
+func TestTimelockedRecoveryTaprootAddress(t *testing.T) {
+	t.Parallel()
+
+	primary, err := CreatePrivateKey()
+	require.NoError(t, err)
+	recovery, err := CreatePrivateKey()
+	require.NoError(t, err)
+
+	sequence, err := SequenceFromSeconds(7 * 24 * 60 * 60)
+	require.NoError(t, err)
+
+	address, err := GetTimelockedRecoveryTaprootAddress(primary.PubKey(), recovery.PubKey(), sequence, Testnet)
+	require.NoError(t, err)
+	assert.Equal(t, "tb1p", address[:4])
+
+	pkScriptHex, err := GetScriptFromAddress(address, Testnet)
+	require.NoError(t, err)
+	pkScript, err := hex.DecodeString(pkScriptHex)
+	require.NoError(t, err)
+
+	tapscriptHex, err := GetRelativeTimelockTapscript(recovery.PubKey(), sequence)
+	require.NoError(t, err)
+	tapscript, err := hex.DecodeString(tapscriptHex)
+	require.NoError(t, err)
+
+	controlBlockHex, err := GetTapscriptControlBlock(primary.PubKey(), []string{tapscriptHex}, 0)
+	require.NoError(t, err)
+	controlBlock, err := hex.DecodeString(controlBlockHex)
+	require.NoError(t, err)
+
+	tx, prevOut := newSpendingTx(pkScript)
+	tx.TxIn[0].Sequence = sequence
+
+	sigHashes := txscript.NewTxSigHashes(tx, txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value))
+	sig, err := txscript.RawTxInTapscriptSignature(tx, sigHashes, 0, prevOut.Value, prevOut.PkScript, txscript.NewBaseTapLeaf(tapscript), txscript.SigHashDefault, recovery)
+	require.NoError(t, err)
+	tx.TxIn[0].Witness = wire.TxWitness{sig, tapscript, controlBlock}
+
+	_, err = VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+	require.NoError(t, err)
+}
@@ -0,0 +1,173 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// DecodedTransaction is the decoded form of a raw transaction, laid out like
+// the result of Bitcoin Core's decoderawtransaction
+type DecodedTransaction struct {
+	TxID     string          `json:"txid"`
+	Hash     string          `json:"hash"`
+	Version  int32           `json:"version"`
+	Size     int             `json:"size"`
+	VSize    int             `json:"vsize"`
+	Weight   int             `json:"weight"`
+	LockTime uint32          `json:"locktime"`
+	Vin      []DecodedInput  `json:"vin"`
+	Vout     []DecodedOutput `json:"vout"`
+}
+
+// DecodedInput is a single decoded transaction input
+type DecodedInput struct {
+	Coinbase    string         `json:"coinbase,omitempty"`
+	TxID        string         `json:"txid,omitempty"`
+	Vout        uint32         `json:"vout"`
+	ScriptSig   *DecodedScript `json:"scriptSig,omitempty"`
+	TxInWitness []string       `json:"txinwitness,omitempty"`
+	Sequence    uint32         `json:"sequence"`
+}
+
+// DecodedOutput is a single decoded transaction output
+type DecodedOutput struct {
+	Value        float64       `json:"value"`
+	N            int           `json:"n"`
+	ScriptPubKey DecodedScript `json:"scriptPubKey"`
+}
+
+// DecodedScript is a script in both its hex and asm forms. Address and Type are
+// only set for output scripts
+type DecodedScript struct {
+	Asm     string `json:"asm"`
+	Hex     string `json:"hex"`
+	Address string `json:"address,omitempty"`
+	Type    string `json:"type,omitempty"`
+}
+
+// TransactionFromString will convert a raw transaction (hex string) into a transaction (*wire.MsgTx)
+func TransactionFromString(rawTx string) (*wire.MsgTx, error) {
+
+	// missing transaction
+	if len(rawTx) == 0 {
+		return nil, ErrMissingTransaction
+	}
+
+	txBytes, err := hex.DecodeString(rawTx)
+	if err != nil {
+		return nil, err
+	}
+
+	// deserialize handles both the legacy and the segwit (BIP144) encoding
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err = tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// TransactionToString serializes a transaction (*wire.MsgTx) into a raw transaction (hex string),
+// with its witness data if any
+func TransactionToString(tx *wire.MsgTx) (string, error) {
+
+	// missing transaction
+	if tx == nil {
+		return "", ErrMissingTransaction
+	}
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// GetTransactionVSize returns the virtual size of a transaction in vbytes, its weight divided by 4 rounded up
+func GetTransactionVSize(tx *wire.MsgTx) int64 {
+	weight := blockchain.GetTransactionWeight(btcutil.NewTx(tx))
+	return (weight + blockchain.WitnessScaleFactor - 1) / blockchain.WitnessScaleFactor
+}
+
+// DecodeRawTransaction decodes a raw transaction (hex string) into a DecodedTransaction,
+// resolving output addresses for the given network
+func DecodeRawTransaction(rawTx string, networkType NetworkType) (*DecodedTransaction, error) {
+	tx, err := TransactionFromString(rawTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeTransaction(tx, networkType), nil
+}
+
+// DecodeTransaction decodes a transaction (*wire.MsgTx) into a DecodedTransaction,
+// resolving output addresses for the given network
+func DecodeTransaction(tx *wire.MsgTx, networkType NetworkType) *DecodedTransaction {
+	weight := int(blockchain.GetTransactionWeight(btcutil.NewTx(tx)))
+
+	decoded := &DecodedTransaction{
+		TxID:     tx.TxHash().String(),
+		Hash:     tx.WitnessHash().String(),
+		Version:  tx.Version,
+		Size:     tx.SerializeSize(),
+		VSize:    (weight + blockchain.WitnessScaleFactor - 1) / blockchain.WitnessScaleFactor,
+		Weight:   weight,
+		LockTime: tx.LockTime,
+		Vin:      make([]DecodedInput, 0, len(tx.TxIn)),
+		Vout:     make([]DecodedOutput, 0, len(tx.TxOut)),
+	}
+
+	coinbase := blockchain.IsCoinBaseTx(tx)
+	for _, in := range tx.TxIn {
+		input := DecodedInput{Sequence: in.Sequence}
+		if coinbase {
+			input.Coinbase = hex.EncodeToString(in.SignatureScript)
+		} else {
+			input.TxID = in.PreviousOutPoint.Hash.String()
+			input.Vout = in.PreviousOutPoint.Index
+			input.ScriptSig = &DecodedScript{
+				Asm: scriptToAsm(in.SignatureScript, true),
+				Hex: hex.EncodeToString(in.SignatureScript),
+			}
+		}
+
+		for _, item := range in.Witness {
+			input.TxInWitness = append(input.TxInWitness, hex.EncodeToString(item))
+		}
+
+		decoded.Vin = append(decoded.Vin, input)
+	}
+
+	for n, out := range tx.TxOut {
+		script := hex.EncodeToString(out.PkScript)
+
+		// like Core, only the standard address types get an address: not OP_RETURN, nor the pubkey and
+		// bare multisig outputs, whose keys are not addresses
+		class := txscript.GetScriptClass(out.PkScript)
+		var address string
+		switch class {
+		case txscript.PubKeyHashTy, txscript.ScriptHashTy, txscript.WitnessV0PubKeyHashTy,
+			txscript.WitnessV0ScriptHashTy, txscript.WitnessV1TaprootTy:
+			address, _ = GetAddressFromScript(script, networkType)
+		}
+
+		decoded.Vout = append(decoded.Vout, DecodedOutput{
+			Value: btcutil.Amount(out.Value).ToBTC(),
+			N:     n,
+			ScriptPubKey: DecodedScript{
+				Asm:     scriptToAsm(out.PkScript, false),
+				Hex:     script,
+				Address: address,
+				Type:    class.String(),
+			},
+		})
+	}
+
+	return decoded
+}
@@ -0,0 +1,159 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// genesisCoinbaseTx is the coinbase transaction of the mainnet genesis block
+const genesisCoinbaseTx = "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+// TestDecodeRawTransaction will test the method DecodeRawTransaction()
+func TestDecodeRawTransaction(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		rawTx         string
+		expectedTxID  string
+		expectedSize  int
+		expectedError bool
+	}{
+		{genesisCoinbaseTx, "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b", 204, false},
+		{"", "", 0, true},
+		{"0100000001", "", 0, true},
+		{"zz", "", 0, true},
+	}
+
+	for _, test := range tests {
+		if decoded, err := DecodeRawTransaction(test.rawTx, Mainnet); err != nil && !test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error not expected but got: %s", t.Name(), test.rawTx, err.Error())
+		} else if err == nil && test.expectedError {
+			t.Fatalf("%s Failed: [%s] inputted and error was expected", t.Name(), test.rawTx)
+		} else if decoded != nil && (decoded.TxID != test.expectedTxID || decoded.Size != test.expectedSize) {
+			t.Fatalf("%s Failed: [%s] inputted and [%s] expected, but got: %s", t.Name(), test.rawTx, test.expectedTxID, decoded.TxID)
+		}
+	}
+}
+
+// TestDecodeRawTransactionCoinbase will test the decoded fields of a legacy coinbase transaction
+func TestDecodeRawTransactionCoinbase(t *testing.T) {
+	t.Parallel()
+
+	decoded, err := DecodeRawTransaction(genesisCoinbaseTx, Mainnet)
+	require.NoError(t, err)
+
+	assert.Equal(t, decoded.TxID, decoded.Hash)
+	assert.Equal(t, 816, decoded.Weight)
+	assert.Equal(t, 204, decoded.VSize)
+	require.Len(t, decoded.Vin, 1)
+	assert.NotEmpty(t, decoded.Vin[0].Coinbase)
+	assert.Nil(t, decoded.Vin[0].ScriptSig)
+	require.Len(t, decoded.Vout, 1)
+	assert.Equal(t, float64(50), decoded.Vout[0].Value)
+	assert.Equal(t, "pubkey", decoded.Vout[0].ScriptPubKey.Type)
+	assert.Empty(t, decoded.Vout[0].ScriptPubKey.Address)
+}
+
+// TestDecodeTransactionMultisig will test that DecodeTransaction() leaves bare multisig outputs without an address
+func TestDecodeTransactionMultisig(t *testing.T) {
+	t.Parallel()
+
+	pkScript, err := hex.DecodeString("5121" + "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798" +
+		"21" + "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5" + "52ae")
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{0x01}}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, pkScript))
+
+	decoded := DecodeTransaction(tx, Mainnet)
+	require.Len(t, decoded.Vout, 1)
+	assert.Equal(t, "multisig", decoded.Vout[0].ScriptPubKey.Type)
+	assert.Empty(t, decoded.Vout[0].ScriptPubKey.Address)
+}
+
+// TestDecodeRawTransactionSegwit will test the decoded fields of a segwit transaction
+func TestDecodeRawTransactionSegwit(t *testing.T) {
+	t.Parallel()
+
+	script, err := GetScriptFromAddress("bc1qr8063yn4gk44elj8sy6zk59y32v5t9jwjyv080", Mainnet)
+	require.NoError(t, err)
+	pkScript, err := hex.DecodeString(script)
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 3},
+		Witness:          wire.TxWitness{bytes.Repeat([]byte{0xaa}, 71), bytes.Repeat([]byte{0x02}, 33)},
+		Sequence:         wire.MaxTxInSequenceNum - 2,
+	})
+	tx.AddTxOut(wire.NewTxOut(12345, pkScript))
+	tx.AddTxOut(wire.NewTxOut(0, []byte{0x6a, 0x02, 0xbe, 0xef}))
+	tx.LockTime = 800000
+
+	var buf bytes.Buffer
+	require.NoError(t, tx.Serialize(&buf))
+
+	decoded, err := DecodeRawTransaction(hex.EncodeToString(buf.Bytes()), Mainnet)
+	require.NoError(t, err)
+
+	assert.Equal(t, tx.TxHash().String(), decoded.TxID)
+	assert.Equal(t, tx.WitnessHash().String(), decoded.Hash)
+	assert.NotEqual(t, decoded.TxID, decoded.Hash)
+	assert.Equal(t, tx.SerializeSizeStripped()*3+tx.SerializeSize(), decoded.Weight)
+	assert.Equal(t, (decoded.Weight+3)/4, decoded.VSize)
+	assert.Equal(t, uint32(800000), decoded.LockTime)
+
+	require.Len(t, decoded.Vin, 1)
+	assert.Equal(t, chainhash.Hash{0x01}.String(), decoded.Vin[0].TxID)
+	assert.Equal(t, uint32(3), decoded.Vin[0].Vout)
+	assert.Len(t, decoded.Vin[0].TxInWitness, 2)
+
+	require.Len(t, decoded.Vout, 2)
+	assert.Equal(t, 0.00012345, decoded.Vout[0].Value)
+	assert.Equal(t, "bc1qr8063yn4gk44elj8sy6zk59y32v5t9jwjyv080", decoded.Vout[0].ScriptPubKey.Address)
+	assert.Equal(t, "witness_v0_keyhash", decoded.Vout[0].ScriptPubKey.Type)
+	assert.Equal(t, "", decoded.Vout[1].ScriptPubKey.Address)
+	assert.Equal(t, "nulldata", decoded.Vout[1].ScriptPubKey.Type)
+
+	// the json layout follows decoderawtransaction
+	raw, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"txinwitness":[`)
+	assert.Contains(t, string(raw), `"scriptPubKey":{`)
+}
+
+// TestTransactionToString will test the method TransactionToString()
+func TestTransactionToString(t *testing.T) {
+	t.Parallel()
+
+	tx, err := TransactionFromString(genesisCoinbaseTx)
+	require.NoError(t, err)
+
+	rawTx, err := TransactionToString(tx)
+	require.NoError(t, err)
+	assert.Equal(t, genesisCoinbaseTx, rawTx)
+
+	_, err = TransactionToString(nil)
+	assert.ErrorIs(t, err, ErrMissingTransaction)
+}
+
+// TestGetTransactionVSize will test the method GetTransactionVSize()
+func TestGetTransactionVSize(t *testing.T) {
+	t.Parallel()
+
+	tx, err := TransactionFromString(genesisCoinbaseTx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(204), GetTransactionVSize(tx))
+
+	// witness bytes count for a quarter
+	tx.TxIn[0].Witness = wire.TxWitness{bytes.Repeat([]byte{0x01}, 100)}
+	assert.Equal(t, int64(204+(2+1+1+100+3)/4), GetTransactionVSize(tx))
+}
@@ -0,0 +1,158 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ScriptFlags wraps txscript.ScriptFlags to select the rules enforced by the script interpreter
+type ScriptFlags txscript.ScriptFlags
+
+const (
+	// ConsensusScriptFlags are the rules every block must follow (P2SH, BIP66, BIP65, BIP112, segwit, BIP147 and taproot)
+	ConsensusScriptFlags = ScriptFlags(txscript.ScriptBip16 |
+		txscript.ScriptVerifyDERSignatures |
+		txscript.ScriptVerifyCheckLockTimeVerify |
+		txscript.ScriptVerifyCheckSequenceVerify |
+		txscript.ScriptVerifyWitness |
+		txscript.ScriptStrictMultiSig |
+		txscript.ScriptVerifyTaproot)
+
+	// StandardScriptFlags are the stricter rules nodes apply before relaying a transaction
+	StandardScriptFlags = ScriptFlags(txscript.StandardVerifyFlags)
+)
+
+// ScriptTrace is the record of a script execution, one step per executed opcode
+type ScriptTrace struct {
+	Steps []ScriptStep `json:"steps"`
+}
+
+// ScriptStep is the state of the interpreter before running Opcode. ScriptIndex 0 is the
+// scriptSig, 1 the scriptPubKey and any higher index a redeem or witness script. Opcode is
+// empty once execution has finished
+type ScriptStep struct {
+	ScriptIndex int      `json:"scriptIndex"`
+	OpcodeIndex int      `json:"opcodeIndex"`
+	Opcode      string   `json:"opcode,omitempty"`
+	Stack       []string `json:"stack"`
+	AltStack    []string `json:"altStack,omitempty"`
+}
+
+// VerifyInput runs the script interpreter for a single input of tx spending prevOut. The trace of the
+// execution is returned alongside any error, the last step pointing at the opcode that failed.
+// Taproot inputs commit to every spent output, so a transaction with more than one input
+// spending a taproot output must be verified with VerifyInputWithPrevOuts
+func VerifyInput(tx *wire.MsgTx, inputIndex int, prevOut *wire.TxOut, flags ScriptFlags) (*ScriptTrace, error) {
+
+	// Missing prevOut
+	if prevOut == nil {
+		return nil, ErrMissingPrevOut
+	}
+
+	if inputIndex < 0 || inputIndex >= len(tx.TxIn) {
+		return nil, ErrInvalidInputIndex
+	}
+
+	prevOuts := map[wire.OutPoint]*wire.TxOut{tx.TxIn[inputIndex].PreviousOutPoint: prevOut}
+	return VerifyInputWithPrevOuts(tx, inputIndex, prevOuts, flags)
+}
+
+// VerifyInputWithPrevOuts is like VerifyInput but takes the outputs spent by every input of tx
+func VerifyInputWithPrevOuts(tx *wire.MsgTx, inputIndex int, prevOuts map[wire.OutPoint]*wire.TxOut, flags ScriptFlags) (*ScriptTrace, error) {
+
+	if inputIndex < 0 || inputIndex >= len(tx.TxIn) {
+		return nil, ErrInvalidInputIndex
+	}
+
+	prevOut, ok := prevOuts[tx.TxIn[inputIndex].PreviousOutPoint]
+	if !ok || prevOut == nil {
+		return nil, ErrMissingPrevOut
+	}
+
+	// the taproot sighash covers the amounts and scripts of all the spent outputs
+	if txscript.IsPayToTaproot(prevOut.PkScript) {
+		for _, in := range tx.TxIn {
+			if prevOuts[in.PreviousOutPoint] == nil {
+				return nil, fmt.Errorf("%w: %s", ErrMissingPrevOut, in.PreviousOutPoint)
+			}
+		}
+	}
+
+	fetcher := knownPrevOutFetcher{txscript.NewMultiPrevOutFetcher(prevOuts)}
+
+	var vm *txscript.Engine
+	trace := &ScriptTrace{}
+	stepCallback := func(step *txscript.StepInfo) error {
+		trace.Steps = append(trace.Steps, newScriptStep(vm, step))
+		return nil
+	}
+
+	vm, err := txscript.NewDebugEngine(
+		prevOut.PkScript, tx, inputIndex, txscript.ScriptFlags(flags), nil,
+		txscript.NewTxSigHashes(tx, fetcher), prevOut.Value, fetcher, stepCallback,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return trace, vm.Execute()
+}
+
+// VerifyTransaction runs the script interpreter for every input of tx
+func VerifyTransaction(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut, flags ScriptFlags) error {
+	for i := range tx.TxIn {
+		if _, err := VerifyInputWithPrevOuts(tx, i, prevOuts, flags); err != nil {
+			return fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// newScriptStep records the interpreter state reported by the debug engine
+func newScriptStep(vm *txscript.Engine, info *txscript.StepInfo) ScriptStep {
+	step := ScriptStep{
+		ScriptIndex: info.ScriptIndex,
+		OpcodeIndex: info.OpcodeIndex,
+		Stack:       hexItems(info.Stack),
+		AltStack:    hexItems(info.AltStack),
+	}
+
+	// DisasmPC is formatted as "<script>:<opcode>: <disassembly>" and fails once the last script has run
+	if vm != nil {
+		if pc, err := vm.DisasmPC(); err == nil {
+			if parts := strings.SplitN(pc, ": ", 2); len(parts) == 2 {
+				step.Opcode = parts[1]
+			}
+		}
+	}
+
+	return step
+}
+
+// hexItems hex encodes each item of a stack, bottom first
+func hexItems(items [][]byte) []string {
+	encoded := make([]string, 0, len(items))
+	for _, item := range items {
+		encoded = append(encoded, hex.EncodeToString(item))
+	}
+	return encoded
+}
+
+// knownPrevOutFetcher returns an empty output for outpoints it does not know about, which
+// only matters to the sighash of taproot inputs that are checked beforehand
+type knownPrevOutFetcher struct {
+	*txscript.MultiPrevOutFetcher
+}
+
+// FetchPrevOutput implements txscript.PrevOutputFetcher
+func (f knownPrevOutFetcher) FetchPrevOutput(op wire.OutPoint) *wire.TxOut {
+	if prevOut := f.MultiPrevOutFetcher.FetchPrevOutput(op); prevOut != nil {
+		return prevOut
+	}
+	return &wire.TxOut{}
+}
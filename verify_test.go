@@ -0,0 +1,188 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSpendingTx returns a transaction with a single input spending outpoint 0 of a fake
+// transaction, and the output it spends
+func newSpendingTx(pkScript []byte) (*wire.MsgTx, *wire.TxOut) {
+	prevOut := wire.NewTxOut(100000, pkScript)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 0}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(90000, []byte{txscript.OP_TRUE}))
+
+	return tx, prevOut
+}
+
+// scriptForAddressType returns the output script of privateKey for the given address type
+func scriptForAddressType(t *testing.T, privateKey *btcec.PrivateKey, addressType AddressType) []byte {
+	address, err := GetAddressFromPrivateKey(privateKey, addressType, Mainnet)
+	require.NoError(t, err)
+
+	script, err := GetScriptFromAddress(address, Mainnet)
+	require.NoError(t, err)
+
+	pkScript, err := hex.DecodeString(script)
+	require.NoError(t, err)
+	return pkScript
+}
+
+// TestVerifyInput will test the method VerifyInput() with inputs signed for each address type
+func TestVerifyInput(t *testing.T) {
+	t.Parallel()
+
+	privateKey, err := PrivateKeyFromString("fff9f5137145b7609070fcaf13ab2db3974230699c74b1a3ca5479fb506b5de9")
+	require.NoError(t, err)
+
+	t.Run("P2PKH", func(t *testing.T) {
+		tx, prevOut := newSpendingTx(scriptForAddressType(t, privateKey, Legacy))
+
+		sigScript, err := txscript.SignatureScript(tx, 0, prevOut.PkScript, txscript.SigHashAll, privateKey, true)
+		require.NoError(t, err)
+		tx.TxIn[0].SignatureScript = sigScript
+
+		trace, err := VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+		require.NoError(t, err)
+		require.NotEmpty(t, trace.Steps)
+		assert.Equal(t, "OP_DUP", trace.Steps[2].Opcode)
+		assert.Len(t, trace.Steps[2].Stack, 2)
+	})
+
+	t.Run("P2WPKH", func(t *testing.T) {
+		tx, prevOut := newSpendingTx(scriptForAddressType(t, privateKey, NativeSegwit))
+
+		sigHashes := txscript.NewTxSigHashes(tx, txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value))
+		witness, err := txscript.WitnessSignature(tx, sigHashes, 0, prevOut.Value, prevOut.PkScript, txscript.SigHashAll, privateKey, true)
+		require.NoError(t, err)
+		tx.TxIn[0].Witness = witness
+
+		_, err = VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+		require.NoError(t, err)
+
+		// the amount is covered by the signature
+		prevOut.Value++
+		_, err = VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+		require.Error(t, err)
+	})
+
+	t.Run("P2TR key path", func(t *testing.T) {
+		tx, prevOut := newSpendingTx(scriptForAddressType(t, privateKey, Taproot))
+
+		sigHashes := txscript.NewTxSigHashes(tx, txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value))
+		witness, err := txscript.TaprootWitnessSignature(tx, sigHashes, 0, prevOut.Value, prevOut.PkScript, txscript.SigHashDefault, privateKey)
+		require.NoError(t, err)
+		tx.TxIn[0].Witness = witness
+
+		_, err = VerifyInput(tx, 0, prevOut, ConsensusScriptFlags)
+		require.NoError(t, err)
+
+		// a second input makes the sighash depend on another spent output
+		tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{0x02}, Index: 1}, nil, nil))
+		_, err = VerifyInput(tx, 0, prevOut, ConsensusScriptFlags)
+		require.ErrorIs(t, err, ErrMissingPrevOut)
+	})
+
+	t.Run("P2TR script path", func(t *testing.T) {
+		leafScript, err := txscript.NewScriptBuilder().
+			AddData(schnorr.SerializePubKey(privateKey.PubKey())).
+			AddOp(txscript.OP_CHECKSIG).
+			Script()
+		require.NoError(t, err)
+
+		leaf := txscript.NewBaseTapLeaf(leafScript)
+		tree := txscript.AssembleTaprootScriptTree(leaf)
+		rootHash := tree.RootNode.TapHash()
+		outputKey := txscript.ComputeTaprootOutputKey(privateKey.PubKey(), rootHash[:])
+
+		pkScript, err := txscript.PayToTaprootScript(outputKey)
+		require.NoError(t, err)
+		tx, prevOut := newSpendingTx(pkScript)
+
+		controlBlock := tree.LeafMerkleProofs[0].ToControlBlock(privateKey.PubKey())
+		controlBlockBytes, err := controlBlock.ToBytes()
+		require.NoError(t, err)
+
+		sigHashes := txscript.NewTxSigHashes(tx, txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value))
+		sig, err := txscript.RawTxInTapscriptSignature(tx, sigHashes, 0, prevOut.Value, prevOut.PkScript, leaf, txscript.SigHashDefault, privateKey)
+		require.NoError(t, err)
+		tx.TxIn[0].Witness = wire.TxWitness{sig, leafScript, controlBlockBytes}
+
+		trace, err := VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+		require.NoError(t, err)
+		require.NotEmpty(t, trace.Steps)
+
+		// a broken signature fails in the tapscript on OP_CHECKSIG
+		sig[0] ^= 0xff
+		trace, err = VerifyInput(tx, 0, prevOut, StandardScriptFlags)
+		require.Error(t, err)
+		require.NotEmpty(t, trace.Steps)
+		assert.Equal(t, "OP_CHECKSIG", trace.Steps[len(trace.Steps)-1].Opcode)
+	})
+}
+
+// TestVerifyInputTrace will test the trace of a failing custom script
+func TestVerifyInputTrace(t *testing.T) {
+	t.Parallel()
+
+	// <3> | OP_2 OP_EQUALVERIFY
+	tx, prevOut := newSpendingTx([]byte{txscript.OP_2, txscript.OP_EQUALVERIFY})
+	tx.TxIn[0].SignatureScript = []byte{txscript.OP_3}
+
+	trace, err := VerifyInput(tx, 0, prevOut, ConsensusScriptFlags)
+	require.Error(t, err)
+	assert.True(t, txscript.IsErrorCode(err, txscript.ErrEqualVerify))
+
+	var lastStep = trace.Steps[len(trace.Steps)-1]
+	assert.Equal(t, 1, lastStep.ScriptIndex)
+	assert.Equal(t, 1, lastStep.OpcodeIndex)
+	assert.Equal(t, "OP_EQUALVERIFY", lastStep.Opcode)
+	assert.Equal(t, []string{"03", "02"}, lastStep.Stack)
+}
+
+// TestVerifyInputErrors will test the argument checks of VerifyInput()
+func TestVerifyInputErrors(t *testing.T) {
+	t.Parallel()
+
+	tx, prevOut := newSpendingTx([]byte{txscript.OP_TRUE})
+
+	_, err := VerifyInput(tx, 0, nil, StandardScriptFlags)
+	assert.True(t, errors.Is(err, ErrMissingPrevOut))
+
+	_, err = VerifyInput(tx, 1, prevOut, StandardScriptFlags)
+	assert.True(t, errors.Is(err, ErrInvalidInputIndex))
+
+	_, err = VerifyInput(tx, -1, prevOut, StandardScriptFlags)
+	assert.True(t, errors.Is(err, ErrInvalidInputIndex))
+}
+
+// TestVerifyTransaction will test the method VerifyTransaction()
+func TestVerifyTransaction(t *testing.T) {
+	t.Parallel()
+
+	tx, prevOut := newSpendingTx([]byte{txscript.OP_TRUE})
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{0x02}, Index: 0}, nil, nil))
+
+	prevOuts := map[wire.OutPoint]*wire.TxOut{
+		tx.TxIn[0].PreviousOutPoint: prevOut,
+		tx.TxIn[1].PreviousOutPoint: wire.NewTxOut(5000, []byte{txscript.OP_FALSE}),
+	}
+
+	err := VerifyTransaction(tx, prevOuts, ConsensusScriptFlags)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "input 1")
+
+	prevOuts[tx.TxIn[1].PreviousOutPoint].PkScript = []byte{txscript.OP_1}
+	require.NoError(t, VerifyTransaction(tx, prevOuts, ConsensusScriptFlags))
+}
@@ -0,0 +1,984 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// KeyChain is a derivation chain of a wallet account
+type KeyChain uint32
+
+const (
+	ExternalChain KeyChain = 0 // receive addresses
+	InternalChain KeyChain = 1 // change addresses
+)
+
+// DefaultGapLimit is the number of unused addresses a wallet looks ahead on each chain (BIP44)
+const DefaultGapLimit = 20
+
+// WalletConfig holds the settings of a Wallet, built either from Mnemonic or from ExternalDescriptor
+// and InternalDescriptor
+type WalletConfig struct {
+	Network          NetworkType
+	AddressType      AddressType // derives BIP44 (Legacy), BIP49 (Segwit), BIP84 (NativeSegwit) or BIP86 (Taproot) accounts
+	Mnemonic         string
+	MnemonicPassword string
+	Account          uint32
+
+	// single key descriptors with a ranged extended key, e.g. wpkh([d34db33f/84h/0h/0h]xpub.../0/*),
+	// as returned by Wallet.Descriptors or Bitcoin Core's listdescriptors. A single multipath descriptor
+	// (wpkh(xpub.../<0;1>/*)) can be set as ExternalDescriptor alone
+	ExternalDescriptor string
+	InternalDescriptor string
+
+	Store    WalletStore  // defaults to a MemoryWalletStore
+	Backend  ChainBackend // needed by Sync and Broadcast
+	GapLimit uint32       // defaults to DefaultGapLimit
+}
+
+// WalletAddress is an address derived by a wallet
+type WalletAddress struct {
+	Address string   `json:"address"`
+	Script  string   `json:"script"` // output script (hex string)
+	Chain   KeyChain `json:"chain"`
+	Index   uint32   `json:"index"`
+}
+
+// WalletUtxo is an unspent output of a wallet
+type WalletUtxo struct {
+	TxID    string         `json:"txid"`
+	Vout    uint32         `json:"vout"`
+	Value   btcutil.Amount `json:"value"`
+	Height  int64          `json:"height"` // 0 while unconfirmed
+	Address string         `json:"address"`
+	Script  string         `json:"script"`
+	Chain   KeyChain       `json:"chain"`
+	Index   uint32         `json:"index"`
+}
+
+// outPoint returns the outpoint of the output
+func (u *WalletUtxo) outPoint() (*wire.OutPoint, error) {
+	return wire.NewOutPointFromString(u.TxID + ":" + strconv.FormatUint(uint64(u.Vout), 10))
+}
+
+// txOut returns the output
+func (u *WalletUtxo) txOut() (*wire.TxOut, error) {
+	script, err := hex.DecodeString(u.Script)
+	if err != nil {
+		return nil, err
+	}
+
+	return wire.NewTxOut(int64(u.Value), script), nil
+}
+
+// WalletTransaction is a transaction of a wallet's history, with the amounts it moved in and out of the wallet
+type WalletTransaction struct {
+	TxID     string         `json:"txid"`
+	Received btcutil.Amount `json:"received"`         // paid to the wallet, change included
+	Sent     btcutil.Amount `json:"sent"`             // spent from the wallet's outputs
+	Fee      btcutil.Amount `json:"fee"`              // set when every input spent the wallet's outputs
+	Height   int64          `json:"height"`           // 0 while unconfirmed
+	RawTx    string         `json:"raw_tx,omitempty"` // kept for transactions broadcast through the wallet, to bump their fee
+	Spent    []WalletUtxo   `json:"spent,omitempty"`  // the wallet outputs spent, kept along with RawTx
+}
+
+// Net returns the effect of the transaction on the balance
+func (t *WalletTransaction) Net() btcutil.Amount {
+	return t.Received - t.Sent
+}
+
+// WalletBalance is the balance of a wallet
+type WalletBalance struct {
+	Confirmed   btcutil.Amount
+	Unconfirmed btcutil.Amount
+}
+
+// Total returns the confirmed and unconfirmed balance
+func (b WalletBalance) Total() btcutil.Amount {
+	return b.Confirmed + b.Unconfirmed
+}
+
+// Wallet is an HD wallet account tracking its addresses, unspent outputs, balance and history,
+// persisted through a WalletStore. It is safe for concurrent use.
+//
+// A ChainBackend only lists unspent outputs, so Sync only learns of what is still unspent. An address
+// whose outputs were all spent before a sync saw them does not count as used: a wallet restored from its
+// mnemonic stops looking ahead after GapLimit such addresses and misses the funds past them, which a
+// larger GapLimit works around. The height of a transaction is the one of the last sync finding one of
+// its outputs unspent, it stays 0 when its outputs were spent before a sync saw it confirmed
+type Wallet struct {
+	config      WalletConfig
+	addressType AddressType
+	keys        [2]*hdkeychain.ExtendedKey // public keys of the external and internal chains
+	privateKeys [2]*hdkeychain.ExtendedKey // private keys of the chains, nil for watch-only wallets
+	descriptors [2]string
+
+	syncMu sync.Mutex // serializes syncs
+
+	mu        sync.Mutex
+	state     WalletState
+	addresses [2][]WalletAddress
+	scripts   map[string]WalletAddress
+}
+
+// NewWallet creates a wallet from config and loads its state from config.Store
+func NewWallet(config WalletConfig) (*Wallet, error) {
+
+	// Missing network
+	if config.Network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	if config.Store == nil {
+		config.Store = NewMemoryWalletStore()
+	}
+	if config.GapLimit == 0 {
+		config.GapLimit = DefaultGapLimit
+	}
+
+	w := &Wallet{config: config, scripts: make(map[string]WalletAddress)}
+
+	var err error
+	switch {
+	case len(config.Mnemonic) > 0:
+		err = w.initFromMnemonic()
+	case len(config.ExternalDescriptor) > 0:
+		err = w.initFromDescriptors()
+	default:
+		err = ErrMissingWalletKeys
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := config.Store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if state != nil {
+		if state.Descriptor != w.descriptors[ExternalChain] {
+			return nil, ErrWalletMismatch
+		}
+		w.state = *state
+	} else {
+		w.state = WalletState{Descriptor: w.descriptors[ExternalChain]}
+	}
+
+	for _, chain := range []KeyChain{ExternalChain, InternalChain} {
+		if err = w.deriveUpTo(chain, w.state.NextIndex[chain]+config.GapLimit); err != nil {
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+// Descriptors returns the watch-only descriptors (with checksums) of the external and internal chains
+func (w *Wallet) Descriptors() (string, string) {
+	return w.descriptors[ExternalChain], w.descriptors[InternalChain]
+}
+
+// DeriveAddress returns the address at index of chain, without changing the next unused index.
+// Addresses are non-hardened children, index must be below hdkeychain.HardenedKeyStart
+func (w *Wallet) DeriveAddress(chain KeyChain, index uint32) (*WalletAddress, error) {
+	if index >= hdkeychain.HardenedKeyStart {
+		return nil, ErrInvalidAddressIndex
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.deriveUpTo(chain, index+1); err != nil {
+		return nil, err
+	}
+
+	address := w.addresses[chain][index]
+	return &address, nil
+}
+
+// NextAddress returns the next unused receive address and moves past it, so each call returns a new address
+func (w *Wallet) NextAddress() (*WalletAddress, error) {
+	return w.nextAddress(ExternalChain)
+}
+
+// ChangeAddress returns the next unused change address and moves past it
+func (w *Wallet) ChangeAddress() (*WalletAddress, error) {
+	return w.nextAddress(InternalChain)
+}
+
+// NextIndex returns the next unused index of chain
+func (w *Wallet) NextIndex(chain KeyChain) (uint32, error) {
+	if chain != ExternalChain && chain != InternalChain {
+		return 0, ErrInvalidKeyChain
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.state.NextIndex[chain], nil
+}
+
+// Addresses returns the derived addresses of chain, looking GapLimit addresses past the next unused index
+func (w *Wallet) Addresses(chain KeyChain) ([]WalletAddress, error) {
+	if chain != ExternalChain && chain != InternalChain {
+		return nil, ErrInvalidKeyChain
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]WalletAddress(nil), w.addresses[chain]...), nil
+}
+
+// Utxos returns the unspent outputs of the wallet
+func (w *Wallet) Utxos() []WalletUtxo {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]WalletUtxo(nil), w.state.Utxos...)
+}
+
+// Transactions returns the history of the wallet, in the order transactions were first seen
+func (w *Wallet) Transactions() []WalletTransaction {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]WalletTransaction(nil), w.state.Transactions...)
+}
+
+// Balance returns the confirmed and unconfirmed balance of the wallet
+func (w *Wallet) Balance() WalletBalance {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var balance WalletBalance
+	for _, utxo := range w.state.Utxos {
+		if utxo.Height > 0 {
+			balance.Confirmed += utxo.Value
+		} else {
+			balance.Unconfirmed += utxo.Value
+		}
+	}
+
+	return balance
+}
+
+// Height returns the tip height of the last sync
+func (w *Wallet) Height() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.state.Height
+}
+
+// Sync fetches the unspent outputs of the wallet's addresses from the backend, extending the addresses
+// while outputs are found within the gap limit, records new transactions and saves the state.
+// Transactions spending all their inputs to other wallets leave no output to find, they are only
+// recorded when broadcast through the wallet. Spent addresses are not discovered, see Wallet.
+// Backends reporting confirmed outputs only still see the outputs spent by the wallet's unconfirmed
+// transactions: those stay spent, and their outputs to the wallet unconfirmed, until they confirm or
+// the backend no longer knows of them
+func (w *Wallet) Sync(ctx context.Context) error {
+
+	// Missing backend
+	if w.config.Backend == nil {
+		return ErrMissingChainBackend
+	}
+
+	w.syncMu.Lock()
+	defer w.syncMu.Unlock()
+
+	tip, err := w.config.Backend.TipHeight(ctx)
+	if err != nil {
+		return err
+	}
+
+	// look ahead until the gap limit holds on both chains
+	var utxos []Utxo
+	for {
+		w.mu.Lock()
+		scripts := make([]string, 0, len(w.scripts))
+		for script := range w.scripts {
+			scripts = append(scripts, script)
+		}
+		w.mu.Unlock()
+
+		if utxos, err = w.config.Backend.GetUtxos(ctx, scripts); err != nil {
+			return err
+		}
+
+		extended, err := w.markUsed(utxos)
+		if err != nil {
+			return err
+		}
+		if !extended {
+			break
+		}
+	}
+
+	w.mu.Lock()
+	knownTxs := make(map[string]bool, len(w.state.Transactions))
+	for _, tx := range w.state.Transactions {
+		knownTxs[tx.TxID] = true
+	}
+	pending := w.pendingTransactions(utxos)
+	w.mu.Unlock()
+
+	// pending transactions the backend no longer knows of were dropped from the mempool
+	dropped := make(map[string]bool)
+	for txID := range pending {
+		_, err := w.config.Backend.GetTransaction(ctx, txID)
+		switch {
+		case errors.Is(err, ErrTransactionNotFound):
+			dropped[txID] = true
+		case err != nil:
+			return err
+		}
+	}
+
+	// new transactions are fetched to account for what they spent from the wallet
+	var newTxs []*wire.MsgTx
+	var unavailable []string
+	for _, utxo := range utxos {
+		if knownTxs[utxo.TxID] {
+			continue
+		}
+		knownTxs[utxo.TxID] = true
+
+		tx, err := w.config.Backend.GetTransaction(ctx, utxo.TxID)
+		switch {
+		case errors.Is(err, ErrTransactionNotFound):
+			unavailable = append(unavailable, utxo.TxID)
+		case err != nil:
+			return err
+		default:
+			newTxs = append(newTxs, tx)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	walletUtxos := make([]WalletUtxo, 0, len(utxos))
+	heights := make(map[string]int64, len(utxos))
+	received := make(map[string]btcutil.Amount, len(utxos))
+	for _, utxo := range utxos {
+		address := w.scripts[utxo.Script]
+		walletUtxos = append(walletUtxos, WalletUtxo{
+			TxID:    utxo.TxID,
+			Vout:    utxo.Vout,
+			Value:   utxo.Value,
+			Height:  utxo.Height,
+			Address: address.Address,
+			Script:  utxo.Script,
+			Chain:   address.Chain,
+			Index:   address.Index,
+		})
+		heights[utxo.TxID] = utxo.Height
+		received[utxo.TxID] += utxo.Value
+	}
+
+	// outputs spent by the wallet's pending transactions stay spent, their outputs to the wallet stay unconfirmed
+	spent := make(map[string]bool)
+	for txID, tx := range pending {
+		if dropped[txID] {
+			continue
+		}
+		for _, in := range tx.TxIn {
+			spent[in.PreviousOutPoint.String()] = true
+		}
+	}
+
+	synced := make(map[string]bool, len(walletUtxos))
+	unspent := walletUtxos[:0]
+	for _, utxo := range walletUtxos {
+		outPoint := utxo.TxID + ":" + strconv.FormatUint(uint64(utxo.Vout), 10)
+		synced[outPoint] = true
+		if !spent[outPoint] {
+			unspent = append(unspent, utxo)
+		}
+	}
+	walletUtxos = unspent
+
+	var transactions []WalletTransaction
+	for _, record := range w.state.Transactions {
+		tx, ok := pending[record.TxID]
+		switch {
+		case !ok:
+		case dropped[record.TxID]:
+			continue
+		default:
+			for vout, out := range tx.TxOut {
+				outPoint := record.TxID + ":" + strconv.FormatUint(uint64(vout), 10)
+				address, ok := w.scripts[hex.EncodeToString(out.PkScript)]
+				if !ok || spent[outPoint] || synced[outPoint] {
+					continue
+				}
+
+				walletUtxos = append(walletUtxos, WalletUtxo{
+					TxID:    record.TxID,
+					Vout:    uint32(vout),
+					Value:   btcutil.Amount(out.Value),
+					Address: address.Address,
+					Script:  address.Script,
+					Chain:   address.Chain,
+					Index:   address.Index,
+				})
+			}
+		}
+		transactions = append(transactions, record)
+	}
+	w.state.Transactions = transactions
+
+	// spent amounts are resolved against the utxos known before this sync
+	for _, tx := range newTxs {
+		if err = w.recordTransaction(tx, heights[tx.TxHash().String()], false); err != nil {
+			return err
+		}
+	}
+
+	// backends without a transaction index only tell what is still unspent
+	for _, txID := range unavailable {
+		w.state.Transactions = append(w.state.Transactions, WalletTransaction{
+			TxID:     txID,
+			Received: received[txID],
+			Height:   heights[txID],
+		})
+	}
+
+	// transactions still holding unspent outputs get their current height
+	for i, tx := range w.state.Transactions {
+		if height, ok := heights[tx.TxID]; ok {
+			w.state.Transactions[i].Height = height
+		}
+	}
+
+	w.state.Utxos = walletUtxos
+	w.state.Height = tip
+
+	return w.config.Store.Save(&w.state)
+}
+
+// Broadcast broadcasts a signed transaction spending the wallet's outputs and records it right away:
+// the outputs it spends are removed and its outputs to the wallet are added as unconfirmed. Unconfirmed
+// transactions of the wallet it replaces (BIP125) are dropped along with their descendants
+func (w *Wallet) Broadcast(ctx context.Context, tx *wire.MsgTx) (string, error) {
+
+	// Missing backend
+	if w.config.Backend == nil {
+		return "", ErrMissingChainBackend
+	}
+
+	txID, err := w.config.Backend.Broadcast(ctx, tx)
+	if err != nil {
+		return "", err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.dropReplaced(tx)
+	if err = w.recordTransaction(tx, 0, true); err != nil {
+		return txID, err
+	}
+
+	spent := make(map[string]bool, len(tx.TxIn))
+	for _, in := range tx.TxIn {
+		spent[in.PreviousOutPoint.String()] = true
+	}
+
+	var utxos []WalletUtxo
+	for _, utxo := range w.state.Utxos {
+		if !spent[utxo.TxID+":"+strconv.FormatUint(uint64(utxo.Vout), 10)] {
+			utxos = append(utxos, utxo)
+		}
+	}
+
+	for vout, out := range tx.TxOut {
+		address, ok := w.scripts[hex.EncodeToString(out.PkScript)]
+		if !ok {
+			continue
+		}
+
+		utxos = append(utxos, WalletUtxo{
+			TxID:    txID,
+			Vout:    uint32(vout),
+			Value:   btcutil.Amount(out.Value),
+			Address: address.Address,
+			Script:  address.Script,
+			Chain:   address.Chain,
+			Index:   address.Index,
+		})
+		w.advance(address)
+	}
+	w.state.Utxos = utxos
+
+	if err = w.config.Store.Save(&w.state); err != nil {
+		return txID, err
+	}
+
+	return txID, nil
+}
+
+// pendingTransactions returns the unconfirmed transactions broadcast through the wallet that still spend
+// outputs the backend reports as unspent, as backends without a mempool view do until they confirm,
+// along with their descendants. w.mu must be held
+func (w *Wallet) pendingTransactions(utxos []Utxo) map[string]*wire.MsgTx {
+	unspent := make(map[string]bool, len(utxos))
+	for _, utxo := range utxos {
+		unspent[utxo.TxID+":"+strconv.FormatUint(uint64(utxo.Vout), 10)] = true
+	}
+
+	broadcast := make(map[string]*wire.MsgTx)
+	for _, record := range w.state.Transactions {
+		if record.Height > 0 || len(record.RawTx) == 0 {
+			continue
+		}
+		if tx, err := TransactionFromString(record.RawTx); err == nil {
+			broadcast[record.TxID] = tx
+		}
+	}
+
+	pending := make(map[string]*wire.MsgTx)
+	for changed := true; changed; {
+		changed = false
+		for txID, tx := range broadcast {
+			if pending[txID] != nil {
+				continue
+			}
+
+			for _, in := range tx.TxIn {
+				if unspent[in.PreviousOutPoint.String()] || pending[in.PreviousOutPoint.Hash.String()] != nil {
+					pending[txID] = tx
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	return pending
+}
+
+// nextAddress returns the next unused address of chain and moves past it
+func (w *Wallet) nextAddress(chain KeyChain) (*WalletAddress, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	index := w.state.NextIndex[chain]
+	if err := w.deriveUpTo(chain, index+1+w.config.GapLimit); err != nil {
+		return nil, err
+	}
+
+	w.state.NextIndex[chain] = index + 1
+	if err := w.config.Store.Save(&w.state); err != nil {
+		w.state.NextIndex[chain] = index
+		return nil, err
+	}
+
+	address := w.addresses[chain][index]
+	return &address, nil
+}
+
+// markUsed moves the next unused indexes past the addresses holding utxos, and reports whether more
+// addresses were derived
+func (w *Wallet) markUsed(utxos []Utxo) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	derived := [2]int{len(w.addresses[ExternalChain]), len(w.addresses[InternalChain])}
+	for _, utxo := range utxos {
+		if address, ok := w.scripts[utxo.Script]; ok {
+			w.advance(address)
+		}
+	}
+
+	for _, chain := range []KeyChain{ExternalChain, InternalChain} {
+		if err := w.deriveUpTo(chain, w.state.NextIndex[chain]+w.config.GapLimit); err != nil {
+			return false, err
+		}
+	}
+
+	return len(w.addresses[ExternalChain]) > derived[0] || len(w.addresses[InternalChain]) > derived[1], nil
+}
+
+// advance moves the next unused index of the address's chain past it, w.mu must be held
+func (w *Wallet) advance(address WalletAddress) {
+	if address.Index >= w.state.NextIndex[address.Chain] {
+		w.state.NextIndex[address.Chain] = address.Index + 1
+	}
+}
+
+// recordTransaction adds a transaction to the history with the amounts it received and spent,
+// spent amounts being known for the wallet's current utxos. w.mu must be held
+func (w *Wallet) recordTransaction(tx *wire.MsgTx, height int64, keepRaw bool) error {
+	txID := tx.TxHash().String()
+	for _, known := range w.state.Transactions {
+		if known.TxID == txID {
+			return nil
+		}
+	}
+
+	record := WalletTransaction{TxID: txID, Height: height}
+	var outputs btcutil.Amount
+	for _, out := range tx.TxOut {
+		outputs += btcutil.Amount(out.Value)
+		if _, ok := w.scripts[hex.EncodeToString(out.PkScript)]; ok {
+			record.Received += btcutil.Amount(out.Value)
+		}
+	}
+
+	var spent []WalletUtxo
+	for _, in := range tx.TxIn {
+		for _, utxo := range w.state.Utxos {
+			if utxo.TxID == in.PreviousOutPoint.Hash.String() && utxo.Vout == in.PreviousOutPoint.Index {
+				record.Sent += utxo.Value
+				spent = append(spent, utxo)
+			}
+		}
+	}
+	if len(spent) == len(tx.TxIn) {
+		record.Fee = record.Sent - outputs
+	}
+
+	if keepRaw {
+		rawTx, err := TransactionToString(tx)
+		if err != nil {
+			return err
+		}
+		record.RawTx = rawTx
+		record.Spent = spent
+	}
+
+	w.state.Transactions = append(w.state.Transactions, record)
+	return nil
+}
+
+// dropReplaced removes the unconfirmed transactions conflicting with tx from the history, with their
+// descendants, along with their outputs. The outputs they spent are unspent again until tx spends them.
+// w.mu must be held
+func (w *Wallet) dropReplaced(tx *wire.MsgTx) {
+	replaced := w.replacedBy(tx)
+	if len(replaced) == 0 {
+		return
+	}
+
+	var transactions []WalletTransaction
+	var restored []WalletUtxo
+	for _, record := range w.state.Transactions {
+		if !replaced[record.TxID] {
+			transactions = append(transactions, record)
+			continue
+		}
+		for _, utxo := range record.Spent {
+			if !replaced[utxo.TxID] {
+				restored = append(restored, utxo)
+			}
+		}
+	}
+	w.state.Transactions = transactions
+
+	var utxos []WalletUtxo
+	for _, utxo := range w.state.Utxos {
+		if !replaced[utxo.TxID] {
+			utxos = append(utxos, utxo)
+		}
+	}
+	w.state.Utxos = append(utxos, restored...)
+}
+
+// replacedBy returns the txids of the unconfirmed transactions broadcast through the wallet that tx
+// conflicts with, and of their descendants. w.mu must be held
+func (w *Wallet) replacedBy(tx *wire.MsgTx) map[string]bool {
+	spent := make(map[wire.OutPoint]bool, len(tx.TxIn))
+	for _, in := range tx.TxIn {
+		spent[in.PreviousOutPoint] = true
+	}
+
+	replaced := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, record := range w.state.Transactions {
+			if record.Height > 0 || len(record.RawTx) == 0 || replaced[record.TxID] {
+				continue
+			}
+
+			recorded, err := TransactionFromString(record.RawTx)
+			if err != nil {
+				continue
+			}
+
+			// conflicting with tx, or spending a replaced transaction
+			for _, in := range recorded.TxIn {
+				if spent[in.PreviousOutPoint] || replaced[in.PreviousOutPoint.Hash.String()] {
+					replaced[record.TxID] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	return replaced
+}
+
+// deriveUpTo derives the addresses of chain up to count, w.mu must be held unless called from NewWallet
+func (w *Wallet) deriveUpTo(chain KeyChain, count uint32) error {
+	if chain != ExternalChain && chain != InternalChain {
+		return ErrInvalidKeyChain
+	}
+
+	for index := uint32(len(w.addresses[chain])); index < count; index++ {
+		child, err := w.keys[chain].Derive(index)
+		if err != nil {
+			return err
+		}
+
+		pubKey, err := child.ECPubKey()
+		if err != nil {
+			return err
+		}
+
+		address, err := GetAddressFromPubKey(pubKey, w.addressType, w.config.Network)
+		if err != nil {
+			return err
+		}
+
+		script, err := GetScriptFromAddress(address, w.config.Network)
+		if err != nil {
+			return err
+		}
+
+		walletAddress := WalletAddress{Address: address, Script: script, Chain: chain, Index: index}
+		w.addresses[chain] = append(w.addresses[chain], walletAddress)
+		w.scripts[script] = walletAddress
+	}
+
+	return nil
+}
+
+// initFromMnemonic derives the account keys of config.Mnemonic
+func (w *Wallet) initFromMnemonic() error {
+	if !bip39.IsMnemonicValid(w.config.Mnemonic) {
+		return ErrInvalidMnemonic
+	}
+
+	purpose, ok := walletPurposes[w.config.AddressType]
+	if !ok {
+		return ErrIncorrectAddressType
+	}
+	w.addressType = w.config.AddressType
+
+	masterKey, err := hdkeychain.NewMaster(bip39.NewSeed(w.config.Mnemonic, w.config.MnemonicPassword), w.config.Network)
+	if err != nil {
+		return err
+	}
+
+	masterPubKey, err := masterKey.ECPubKey()
+	if err != nil {
+		return err
+	}
+	fingerprint := btcutil.Hash160(masterPubKey.SerializeCompressed())[:4]
+
+	coinType := uint32(1)
+	if w.config.Network.Net == chaincfg.MainNetParams.Net {
+		coinType = 0
+	}
+
+	// m/purpose'/coin_type'/account'
+	path := []uint32{purpose, coinType, w.config.Account}
+	accountKey := masterKey
+	for _, index := range path {
+		if accountKey, err = accountKey.Derive(hdkeychain.HardenedKeyStart + index); err != nil {
+			return err
+		}
+	}
+
+	origin := hex.EncodeToString(fingerprint)
+	for _, index := range path {
+		origin += "/" + strconv.FormatUint(uint64(index), 10) + "h"
+	}
+
+	for _, chain := range []KeyChain{ExternalChain, InternalChain} {
+		if err = w.setChainKey(chain, accountKey, "["+origin+"]", uint32(chain)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// initFromDescriptors parses config.ExternalDescriptor and config.InternalDescriptor
+func (w *Wallet) initFromDescriptors() error {
+	external, err := parseWalletDescriptor(w.config.ExternalDescriptor, w.config.Network)
+	if err != nil {
+		return err
+	}
+	w.addressType = external.addressType
+
+	internal := external
+	if len(external.chains) == 1 {
+		if len(w.config.InternalDescriptor) == 0 {
+			return fmt.Errorf("%w: missing internal descriptor", ErrInvalidDescriptor)
+		}
+
+		if internal, err = parseWalletDescriptor(w.config.InternalDescriptor, w.config.Network); err != nil {
+			return err
+		}
+		if internal.addressType != external.addressType || len(internal.chains) != 1 {
+			return fmt.Errorf("%w: internal descriptor does not match the external one", ErrInvalidDescriptor)
+		}
+	}
+
+	for _, chain := range []KeyChain{ExternalChain, InternalChain} {
+		d, chainIndex := external, external.chains[0]
+		if chain == InternalChain {
+			d, chainIndex = internal, internal.chains[len(internal.chains)-1]
+		}
+
+		if err = w.setChainKey(chain, d.key, d.origin, chainIndex); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setChainKey derives the key of chain from an account key, keeping the private key of private account
+// keys for signing, and sets the watch-only descriptor of the chain
+func (w *Wallet) setChainKey(chain KeyChain, accountKey *hdkeychain.ExtendedKey, origin string, chainIndex uint32) error {
+	chainKey, err := accountKey.Derive(chainIndex)
+	if err != nil {
+		return err
+	}
+
+	if chainKey.IsPrivate() {
+		w.privateKeys[chain] = chainKey
+	}
+	if w.keys[chain], err = chainKey.Neuter(); err != nil {
+		return err
+	}
+
+	accountPubKey, err := accountKey.Neuter()
+	if err != nil {
+		return err
+	}
+
+	key := origin + accountPubKey.String() + "/" + strconv.FormatUint(uint64(chainIndex), 10) + "/*"
+	w.descriptors[chain], err = AddDescriptorChecksum(wrapDescriptorKey(w.addressType, key))
+	return err
+}
+
+// walletPurposes are the BIP43 purposes of the address types
+var walletPurposes = map[AddressType]uint32{
+	Legacy:       44,
+	Segwit:       49,
+	NativeSegwit: 84,
+	Taproot:      86,
+}
+
+// walletDescriptor is a parsed single key descriptor with a ranged extended key
+type walletDescriptor struct {
+	addressType AddressType
+	origin      string                  // [fingerprint/path], if any
+	key         *hdkeychain.ExtendedKey // account key, public or private
+	chains      []uint32                // the chain index, or both of a <a;b> multipath
+}
+
+// descriptorWrappers are the script expressions of the address types
+var descriptorWrappers = []struct {
+	prefix      string
+	suffix      string
+	addressType AddressType
+}{
+	{"sh(wpkh(", "))", Segwit},
+	{"wpkh(", ")", NativeSegwit},
+	{"pkh(", ")", Legacy},
+	{"tr(", ")", Taproot},
+}
+
+// wrapDescriptorKey wraps a key expression in the script expression of an address type
+func wrapDescriptorKey(addressType AddressType, key string) string {
+	for _, wrapper := range descriptorWrappers {
+		if wrapper.addressType == addressType {
+			return wrapper.prefix + key + wrapper.suffix
+		}
+	}
+
+	return key
+}
+
+// parseWalletDescriptor parses descriptors like wpkh([fingerprint/84h/0h/0h]xpub.../0/*) or .../<0;1>/*
+func parseWalletDescriptor(descriptor string, network NetworkType) (*walletDescriptor, error) {
+	if strings.Contains(descriptor, "#") {
+		if _, err := AddDescriptorChecksum(descriptor); err != nil {
+			return nil, err
+		}
+		descriptor, _, _ = strings.Cut(descriptor, "#")
+	}
+
+	d := &walletDescriptor{}
+	var key string
+	for _, wrapper := range descriptorWrappers {
+		if strings.HasPrefix(descriptor, wrapper.prefix) && strings.HasSuffix(descriptor, wrapper.suffix) {
+			d.addressType = wrapper.addressType
+			key = strings.TrimSuffix(strings.TrimPrefix(descriptor, wrapper.prefix), wrapper.suffix)
+			break
+		}
+	}
+	if len(d.addressType) == 0 || strings.ContainsAny(key, "(),") {
+		return nil, fmt.Errorf("%w: unsupported script expression", ErrInvalidDescriptor)
+	}
+
+	if strings.HasPrefix(key, "[") {
+		end := strings.Index(key, "]")
+		if end == -1 {
+			return nil, fmt.Errorf("%w: unterminated key origin", ErrInvalidDescriptor)
+		}
+		d.origin, key = key[:end+1], key[end+1:]
+	}
+
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 || parts[2] != "*" {
+		return nil, fmt.Errorf("%w: expected <extended key>/<chain>/*", ErrInvalidDescriptor)
+	}
+
+	extendedKey, err := hdkeychain.NewKeyFromString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDescriptor, err)
+	}
+	if !extendedKey.IsForNet(network) {
+		return nil, fmt.Errorf("%w: extended key", ErrWrongNetwork)
+	}
+	d.key = extendedKey
+
+	chains := []string{parts[1]}
+	if strings.HasPrefix(parts[1], "<") && strings.HasSuffix(parts[1], ">") {
+		chains = strings.Split(parts[1][1:len(parts[1])-1], ";")
+		if len(chains) != 2 {
+			return nil, fmt.Errorf("%w: expected <external;internal>", ErrInvalidDescriptor)
+		}
+	}
+
+	for _, chain := range chains {
+		index, err := strconv.ParseUint(chain, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("%w: hardened or invalid chain %s", ErrInvalidDescriptor, chain)
+		}
+		d.chains = append(d.chains, uint32(index))
+	}
+
+	return d, nil
+}
@@ -0,0 +1,141 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Sizes of the signatures added by a wallet, ECDSA signatures being DER encoded with their sighash byte
+const (
+	maxECDSASignatureSize  = 73
+	schnorrSignatureSize   = 64
+	compressedPubKeySize   = 33
+	nestedRedeemScriptSize = 22 // OP_0 <20 byte key hash>
+)
+
+// IsWatchOnly reports whether the wallet was built without private keys (from xpub descriptors)
+// and cannot sign
+func (w *Wallet) IsWatchOnly() bool {
+	return w.privateKeys[ExternalChain] == nil || w.privateKeys[InternalChain] == nil
+}
+
+// SignTransaction signs the inputs of tx spending the wallet's addresses, prevOuts holding the outputs
+// spent by every input of tx. Inputs of other wallets are left untouched
+func (w *Wallet) SignTransaction(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut) error {
+
+	// Missing transaction
+	if tx == nil {
+		return ErrMissingTransaction
+	}
+
+	if w.IsWatchOnly() {
+		return ErrWatchOnlyWallet
+	}
+
+	// every sighash but the legacy one commits to the amount, the taproot one to all the spent outputs
+	for _, in := range tx.TxIn {
+		if prevOuts[in.PreviousOutPoint] == nil {
+			return fmt.Errorf("%w: %s", ErrMissingPrevOut, in.PreviousOutPoint)
+		}
+	}
+	fetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(tx, fetcher)
+
+	for i, in := range tx.TxIn {
+		prevOut := prevOuts[in.PreviousOutPoint]
+
+		w.mu.Lock()
+		address, ok := w.scripts[hex.EncodeToString(prevOut.PkScript)]
+		w.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		if err := w.signInput(tx, i, prevOut, address, sigHashes); err != nil {
+			return fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// signInput signs input i of tx spending prevOut, paid to address of the wallet
+func (w *Wallet) signInput(tx *wire.MsgTx, i int, prevOut *wire.TxOut, address WalletAddress, sigHashes *txscript.TxSigHashes) error {
+	child, err := w.privateKeys[address.Chain].Derive(address.Index)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := child.ECPrivKey()
+	if err != nil {
+		return err
+	}
+
+	switch w.addressType {
+	case Legacy:
+		tx.TxIn[i].SignatureScript, err = txscript.SignatureScript(tx, i, prevOut.PkScript, txscript.SigHashAll, privateKey, true)
+		return err
+
+	case Segwit:
+		pubKeyHash := btcutil.Hash160(privateKey.PubKey().SerializeCompressed())
+		witnessProgram, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(pubKeyHash).Script()
+		if err != nil {
+			return err
+		}
+
+		// the witness signs the P2WPKH program the redeem script commits to
+		tx.TxIn[i].Witness, err = txscript.WitnessSignature(tx, sigHashes, i, prevOut.Value, witnessProgram, txscript.SigHashAll, privateKey, true)
+		if err != nil {
+			return err
+		}
+
+		tx.TxIn[i].SignatureScript, err = txscript.NewScriptBuilder().AddData(witnessProgram).Script()
+		return err
+
+	case NativeSegwit:
+		tx.TxIn[i].Witness, err = txscript.WitnessSignature(tx, sigHashes, i, prevOut.Value, prevOut.PkScript, txscript.SigHashAll, privateKey, true)
+		return err
+
+	case Taproot:
+		// key path spend of a BIP86 output, tweaked without a script tree
+		tx.TxIn[i].Witness, err = txscript.TaprootWitnessSignature(tx, sigHashes, i, prevOut.Value, prevOut.PkScript, txscript.SigHashDefault, privateKey)
+		return err
+	}
+
+	return fmt.Errorf("%w: %s", ErrIncorrectAddressType, w.addressType)
+}
+
+// estimateVSize returns the virtual size tx will have once its inputs, all spending the wallet's
+// addresses, are signed, counting ECDSA signatures at their largest
+func (w *Wallet) estimateVSize(tx *wire.MsgTx) int64 {
+	return (w.estimateWeight(tx) + blockchain.WitnessScaleFactor - 1) / blockchain.WitnessScaleFactor
+}
+
+// estimateWeight returns the weight tx will have once signed, like estimateVSize
+func (w *Wallet) estimateWeight(tx *wire.MsgTx) int64 {
+	signed := tx.Copy()
+
+	signature := bytes.Repeat([]byte{0x01}, maxECDSASignatureSize)
+	pubKey := bytes.Repeat([]byte{0x02}, compressedPubKeySize)
+	for _, in := range signed.TxIn {
+		switch w.addressType {
+		case Legacy:
+			in.SignatureScript, _ = txscript.NewScriptBuilder().AddData(signature).AddData(pubKey).Script()
+		case Segwit:
+			in.SignatureScript, _ = txscript.NewScriptBuilder().AddData(make([]byte, nestedRedeemScriptSize)).Script()
+			in.Witness = wire.TxWitness{signature, pubKey}
+		case NativeSegwit:
+			in.Witness = wire.TxWitness{signature, pubKey}
+		case Taproot:
+			in.Witness = wire.TxWitness{make([]byte, schnorrSignatureSize)}
+		}
+	}
+
+	return blockchain.GetTransactionWeight(btcutil.NewTx(signed))
+}
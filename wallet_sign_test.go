@@ -0,0 +1,121 @@
+package bitcoin
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// TestWalletSignTransaction will test the method SignTransaction() for every address type
+func TestWalletSignTransaction(t *testing.T) {
+	t.Parallel()
+
+	for _, addressType := range []AddressType{Legacy, Segwit, NativeSegwit, Taproot} {
+		t.Run(string(addressType), func(t *testing.T) {
+			t.Parallel()
+
+			wallet, err := NewWallet(WalletConfig{Network: Mainnet, AddressType: addressType, Mnemonic: testVectorMnemonic})
+			require.NoError(t, err)
+			assert.False(t, wallet.IsWatchOnly())
+
+			receive, err := wallet.NextAddress()
+			require.NoError(t, err)
+			change, err := wallet.ChangeAddress()
+			require.NoError(t, err)
+
+			tx := wire.NewMsgTx(2)
+			prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+			for _, address := range []*WalletAddress{receive, change} {
+				funding := payTo(t, address.Address, 30000)
+				fundingHash := funding.TxHash()
+				outPoint := wire.NewOutPoint(&fundingHash, 0)
+				tx.AddTxIn(wire.NewTxIn(outPoint, nil, nil))
+				prevOuts[*outPoint] = funding.TxOut[0]
+			}
+			tx.AddTxOut(wire.NewTxOut(59000, pkScriptForAddress(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")))
+
+			estimated := wallet.estimateVSize(tx)
+			require.NoError(t, wallet.SignTransaction(tx, prevOuts))
+			require.NoError(t, VerifyTransaction(tx, prevOuts, StandardScriptFlags))
+
+			// the estimate counts the largest signatures
+			vsize := GetTransactionVSize(tx)
+			assert.GreaterOrEqual(t, estimated, vsize)
+			assert.LessOrEqual(t, estimated, vsize+2*int64(len(tx.TxIn)))
+		})
+	}
+}
+
+// TestWalletSignTransactionErrors will test the method SignTransaction() with invalid inputs
+func TestWalletSignTransactionErrors(t *testing.T) {
+	t.Parallel()
+
+	wallet, _ := newTestWallet(t, nil)
+	address, err := wallet.NextAddress()
+	require.NoError(t, err)
+
+	funding := payTo(t, address.Address, 30000)
+	fundingHash := funding.TxHash()
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&fundingHash, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(29000, funding.TxOut[0].PkScript))
+
+	assert.ErrorIs(t, wallet.SignTransaction(nil, nil), ErrMissingTransaction)
+	assert.ErrorIs(t, wallet.SignTransaction(tx, nil), ErrMissingPrevOut)
+
+	// inputs of other wallets are left unsigned
+	foreign := map[wire.OutPoint]*wire.TxOut{
+		tx.TxIn[0].PreviousOutPoint: wire.NewTxOut(30000, pkScriptForAddress(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")),
+	}
+	require.NoError(t, wallet.SignTransaction(tx, foreign))
+	assert.Empty(t, tx.TxIn[0].Witness)
+
+	// watch-only wallets cannot sign
+	external, internal := wallet.Descriptors()
+	watchOnly, err := NewWallet(WalletConfig{Network: Mainnet, ExternalDescriptor: external, InternalDescriptor: internal})
+	require.NoError(t, err)
+	assert.True(t, watchOnly.IsWatchOnly())
+
+	prevOuts := map[wire.OutPoint]*wire.TxOut{tx.TxIn[0].PreviousOutPoint: funding.TxOut[0]}
+	assert.ErrorIs(t, watchOnly.SignTransaction(tx, prevOuts), ErrWatchOnlyWallet)
+}
+
+// TestWalletSignTransactionFromXPrv will test signing with a wallet built from a private descriptor
+func TestWalletSignTransactionFromXPrv(t *testing.T) {
+	t.Parallel()
+
+	seed, err := bip39.NewSeedWithErrorChecking(testVectorMnemonic, "")
+	require.NoError(t, err)
+	master, err := hdkeychain.NewMaster(seed, Mainnet)
+	require.NoError(t, err)
+
+	account := master
+	for _, index := range []uint32{84, 0, 0} {
+		account, err = account.Derive(hdkeychain.HardenedKeyStart + index)
+		require.NoError(t, err)
+	}
+
+	wallet, err := NewWallet(WalletConfig{Network: Mainnet, ExternalDescriptor: "wpkh(" + account.String() + "/<0;1>/*)"})
+	require.NoError(t, err)
+	assert.False(t, wallet.IsWatchOnly())
+
+	// the descriptors it shares stay watch-only
+	external, _ := wallet.Descriptors()
+	assert.Contains(t, external, testVectorXPub)
+
+	address, err := wallet.NextAddress()
+	require.NoError(t, err)
+	funding := payTo(t, address.Address, 30000)
+	fundingHash := funding.TxHash()
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&fundingHash, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(29000, funding.TxOut[0].PkScript))
+
+	prevOuts := map[wire.OutPoint]*wire.TxOut{tx.TxIn[0].PreviousOutPoint: funding.TxOut[0]}
+	require.NoError(t, wallet.SignTransaction(tx, prevOuts))
+	require.NoError(t, VerifyTransaction(tx, prevOuts, StandardScriptFlags))
+}
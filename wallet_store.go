@@ -0,0 +1,143 @@
+package bitcoin
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// WalletState is the persisted state of a Wallet. Addresses are derived again when the wallet is loaded
+type WalletState struct {
+	Descriptor   string              `json:"descriptor"` // external descriptor, identifying the wallet
+	NextIndex    [2]uint32           `json:"next_index"` // next unused index of the external and internal chains
+	Utxos        []WalletUtxo        `json:"utxos"`
+	Transactions []WalletTransaction `json:"transactions"`
+	Height       int64               `json:"height"` // tip height of the last sync
+}
+
+// WalletStore persists the state of a single wallet
+type WalletStore interface {
+
+	// Load returns the saved state, or nil if nothing was saved yet
+	Load() (*WalletState, error)
+
+	// Save replaces the saved state
+	Save(state *WalletState) error
+}
+
+// MemoryWalletStore keeps the state of a wallet in memory, for tests and short lived wallets
+type MemoryWalletStore struct {
+	mu    sync.Mutex
+	state []byte
+}
+
+// NewMemoryWalletStore creates an empty store
+func NewMemoryWalletStore() *MemoryWalletStore {
+	return &MemoryWalletStore{}
+}
+
+// Load implements WalletStore
+func (s *MemoryWalletStore) Load() (*WalletState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state == nil {
+		return nil, nil
+	}
+
+	// the state is kept encoded so callers never share slices with the store
+	var state WalletState
+	if err := json.Unmarshal(s.state, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// Save implements WalletStore
+func (s *MemoryWalletStore) Save(state *WalletState) error {
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = encoded
+	return nil
+}
+
+// FileWalletStore keeps the state of a wallet in a JSON file. Saves write a temporary file next to it
+// and rename it over the previous state, so a crash never leaves a partial file
+type FileWalletStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileWalletStore creates a store for the file at path, which is created by the first Save
+func NewFileWalletStore(path string) *FileWalletStore {
+	return &FileWalletStore{path: path}
+}
+
+// Load implements WalletStore
+func (s *FileWalletStore) Load() (*WalletState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	encoded, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state WalletState
+	if err = json.Unmarshal(encoded, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// Save implements WalletStore
+func (s *FileWalletStore) Save(state *WalletState) error {
+	encoded, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err = tmp.Write(encoded); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// compile time checks
+var (
+	_ WalletStore = (*MemoryWalletStore)(nil)
+	_ WalletStore = (*FileWalletStore)(nil)
+)
@@ -0,0 +1,77 @@
+package bitcoin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryWalletStore will test the methods of MemoryWalletStore
+func TestMemoryWalletStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryWalletStore()
+	state, err := store.Load()
+	require.NoError(t, err)
+	assert.Nil(t, state)
+
+	saved := &WalletState{Descriptor: "wpkh()", Utxos: []WalletUtxo{{TxID: "aa", Value: 1000}}}
+	require.NoError(t, store.Save(saved))
+
+	// loaded states do not share memory with the saved one
+	saved.Utxos[0].Value = 2000
+	state, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "wpkh()", state.Descriptor)
+	assert.Equal(t, int64(1000), int64(state.Utxos[0].Value))
+}
+
+// TestFileWalletStore will test the methods of FileWalletStore with a wallet
+func TestFileWalletStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "wallet.json")
+	store := NewFileWalletStore(path)
+
+	state, err := store.Load()
+	require.NoError(t, err)
+	assert.Nil(t, state)
+
+	wallet, backend := newTestWallet(t, store)
+	address, err := wallet.NextAddress()
+	require.NoError(t, err)
+	backend.AddTransaction(payTo(t, address.Address, 30000))
+	backend.MineBlock()
+	require.NoError(t, wallet.Sync(context.Background()))
+
+	// a wallet opened on the same file starts where the previous one stopped
+	reloaded, err := NewWallet(WalletConfig{
+		Network:     Mainnet,
+		AddressType: NativeSegwit,
+		Mnemonic:    testVectorMnemonic,
+		Store:       NewFileWalletStore(path),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, wallet.Balance(), reloaded.Balance())
+	assert.Equal(t, wallet.Transactions(), reloaded.Transactions())
+	next, err := reloaded.NextIndex(ExternalChain)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), next)
+	assert.Equal(t, int64(1), reloaded.Height())
+
+	// no temporary file is left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, err = store.Load()
+	assert.Error(t, err)
+
+	_, err = NewFileWalletStore(filepath.Join(path, "missing", "wallet.json")).Load()
+	assert.Error(t, err)
+}
@@ -0,0 +1,358 @@
+package bitcoin
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVectorMnemonic is the mnemonic of the BIP44/49/84/86 test vectors
+const testVectorMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// testVectorXPub is the BIP84 account key of testVectorMnemonic, in xpub encoding
+const testVectorXPub = "xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V"
+
+// newTestWallet returns a BIP84 wallet of testVectorMnemonic over a memory backend
+func newTestWallet(t *testing.T, store WalletStore) (*Wallet, *MemoryChainBackend) {
+	backend := NewMemoryChainBackend()
+	wallet, err := NewWallet(WalletConfig{
+		Network:     Mainnet,
+		AddressType: NativeSegwit,
+		Mnemonic:    testVectorMnemonic,
+		Store:       store,
+		Backend:     backend,
+	})
+	require.NoError(t, err)
+
+	return wallet, backend
+}
+
+// TestNewWallet will test the method NewWallet() against the BIP44, BIP49, BIP84 and BIP86 test vectors
+func TestNewWallet(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		addressType AddressType
+		receive     string
+	}{
+		{Legacy, "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{Segwit, "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf"},
+		{NativeSegwit, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{Taproot, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+	}
+
+	for _, test := range tests {
+		wallet, err := NewWallet(WalletConfig{Network: Mainnet, AddressType: test.addressType, Mnemonic: testVectorMnemonic})
+		require.NoError(t, err)
+
+		address, err := wallet.DeriveAddress(ExternalChain, 0)
+		require.NoError(t, err)
+		assert.Equal(t, test.receive, address.Address, test.addressType)
+	}
+
+	wallet, _ := newTestWallet(t, nil)
+	external, internal := wallet.Descriptors()
+	assert.Contains(t, external, "wpkh([73c5da0a/84h/0h/0h]"+testVectorXPub+"/0/*)#")
+	assert.Contains(t, internal, "wpkh([73c5da0a/84h/0h/0h]"+testVectorXPub+"/1/*)#")
+
+	address, err := wallet.DeriveAddress(ExternalChain, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g", address.Address)
+
+	address, err = wallet.DeriveAddress(InternalChain, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el", address.Address)
+
+	_, err = wallet.DeriveAddress(KeyChain(2), 0)
+	assert.ErrorIs(t, err, ErrInvalidKeyChain)
+
+	_, err = wallet.DeriveAddress(ExternalChain, hdkeychain.HardenedKeyStart)
+	assert.ErrorIs(t, err, ErrInvalidAddressIndex)
+	_, err = wallet.DeriveAddress(ExternalChain, math.MaxUint32)
+	assert.ErrorIs(t, err, ErrInvalidAddressIndex)
+
+	_, err = NewWallet(WalletConfig{AddressType: NativeSegwit, Mnemonic: testVectorMnemonic})
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+
+	_, err = NewWallet(WalletConfig{Network: Mainnet, AddressType: NativeSegwit})
+	assert.ErrorIs(t, err, ErrMissingWalletKeys)
+
+	_, err = NewWallet(WalletConfig{Network: Mainnet, AddressType: NativeSegwit, Mnemonic: "abandon abandon"})
+	assert.ErrorIs(t, err, ErrInvalidMnemonic)
+
+	_, err = NewWallet(WalletConfig{Network: Mainnet, AddressType: "P2SH-P2WSH", Mnemonic: testVectorMnemonic})
+	assert.ErrorIs(t, err, ErrIncorrectAddressType)
+}
+
+// TestNewWalletFromDescriptors will test creating a watch-only wallet from descriptors
+func TestNewWalletFromDescriptors(t *testing.T) {
+	t.Parallel()
+
+	wallet, _ := newTestWallet(t, nil)
+	external, internal := wallet.Descriptors()
+
+	watchOnly, err := NewWallet(WalletConfig{Network: Mainnet, ExternalDescriptor: external, InternalDescriptor: internal})
+	require.NoError(t, err)
+	for _, chain := range []KeyChain{ExternalChain, InternalChain} {
+		addresses, err := wallet.Addresses(chain)
+		require.NoError(t, err)
+		watchOnlyAddresses, err := watchOnly.Addresses(chain)
+		require.NoError(t, err)
+		assert.Equal(t, addresses, watchOnlyAddresses)
+	}
+
+	watchExternal, watchInternal := watchOnly.Descriptors()
+	assert.Equal(t, external, watchExternal)
+	assert.Equal(t, internal, watchInternal)
+
+	// a multipath descriptor without origin or checksum
+	multipath, err := NewWallet(WalletConfig{Network: Mainnet, ExternalDescriptor: "wpkh(" + testVectorXPub + "/<0;1>/*)"})
+	require.NoError(t, err)
+	address, err := multipath.DeriveAddress(InternalChain, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el", address.Address)
+
+	var tests = []struct {
+		external      string
+		internal      string
+		expectedError error
+	}{
+		{"wpkh(" + testVectorXPub + "/0/*)", "", ErrInvalidDescriptor},
+		{"wpkh(" + testVectorXPub + "/0/*)", "pkh(" + testVectorXPub + "/1/*)", ErrInvalidDescriptor},
+		{"wsh(" + testVectorXPub + "/0/*)", "", ErrInvalidDescriptor},
+		{"wpkh(" + testVectorXPub + "/0h/*)", "", ErrInvalidDescriptor},
+		{"wpkh(" + testVectorXPub + "/0/1)", "", ErrInvalidDescriptor},
+		{"wpkh(xpub/0/*)", "", ErrInvalidDescriptor},
+		{"wpkh(" + testVectorXPub + "/<0;1>/*)#00000000", "", ErrInvalidDescriptorChecksum},
+	}
+
+	for _, test := range tests {
+		_, err = NewWallet(WalletConfig{Network: Mainnet, ExternalDescriptor: test.external, InternalDescriptor: test.internal})
+		assert.ErrorIs(t, err, test.expectedError, test.external)
+	}
+
+	_, err = NewWallet(WalletConfig{Network: Testnet, ExternalDescriptor: "wpkh(" + testVectorXPub + "/<0;1>/*)"})
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+}
+
+// TestWalletNextAddress will test the methods NextAddress() and ChangeAddress()
+func TestWalletNextAddress(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryWalletStore()
+	wallet, _ := newTestWallet(t, store)
+
+	first, err := wallet.NextAddress()
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", first.Address)
+
+	second, err := wallet.NextAddress()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), second.Index)
+
+	change, err := wallet.ChangeAddress()
+	require.NoError(t, err)
+	assert.Equal(t, InternalChain, change.Chain)
+	assert.Equal(t, uint32(0), change.Index)
+
+	// the gap limit stays ahead of the next index
+	addresses, err := wallet.Addresses(ExternalChain)
+	require.NoError(t, err)
+	assert.Len(t, addresses, 2+DefaultGapLimit)
+
+	// the next indexes are persisted
+	reloaded, _ := newTestWallet(t, store)
+	next, err := reloaded.NextIndex(ExternalChain)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), next)
+	next, err = reloaded.NextIndex(InternalChain)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), next)
+
+	_, err = reloaded.NextIndex(KeyChain(2))
+	assert.ErrorIs(t, err, ErrInvalidKeyChain)
+	_, err = reloaded.Addresses(KeyChain(2))
+	assert.ErrorIs(t, err, ErrInvalidKeyChain)
+
+	// the store belongs to another wallet
+	_, err = NewWallet(WalletConfig{Network: Mainnet, AddressType: Taproot, Mnemonic: testVectorMnemonic, Store: store})
+	assert.ErrorIs(t, err, ErrWalletMismatch)
+}
+
+// TestWalletSync will test the methods Sync(), Balance() and Transactions()
+func TestWalletSync(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	wallet, backend := newTestWallet(t, nil)
+
+	// payments to index 15, then to index 30 which is only derived once index 15 is found
+	far, err := wallet.DeriveAddress(ExternalChain, 15)
+	require.NoError(t, err)
+	farther, err := wallet.DeriveAddress(ExternalChain, 30)
+	require.NoError(t, err)
+
+	funding := payTo(t, far.Address, 40000)
+	backend.AddTransaction(funding)
+	backend.MineBlock()
+	backend.AddTransaction(payTo(t, farther.Address, 10000))
+
+	require.NoError(t, wallet.Sync(ctx))
+	next, err := wallet.NextIndex(ExternalChain)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(31), next)
+	assert.Equal(t, WalletBalance{Confirmed: 40000, Unconfirmed: 10000}, wallet.Balance())
+	assert.Equal(t, btcutil.Amount(50000), wallet.Balance().Total())
+	assert.Equal(t, int64(1), wallet.Height())
+
+	require.Len(t, wallet.Transactions(), 2)
+	for _, tx := range wallet.Transactions() {
+		if tx.TxID == funding.TxHash().String() {
+			assert.Equal(t, btcutil.Amount(40000), tx.Net())
+		}
+	}
+
+	backend.MineBlock()
+	require.NoError(t, wallet.Sync(ctx))
+	assert.Equal(t, WalletBalance{Confirmed: 50000}, wallet.Balance())
+	for _, tx := range wallet.Transactions() {
+		assert.Positive(t, tx.Height)
+	}
+
+	// spending with change is recorded right away
+	change, err := wallet.ChangeAddress()
+	require.NoError(t, err)
+
+	fundingHash := funding.TxHash()
+	spend := wire.NewMsgTx(2)
+	spend.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&fundingHash, 0), nil, nil))
+	spend.AddTxOut(wire.NewTxOut(25000, pkScriptForAddress(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")))
+	spend.AddTxOut(wire.NewTxOut(14000, pkScriptForAddress(t, change.Address)))
+
+	txID, err := wallet.Broadcast(ctx, spend)
+	require.NoError(t, err)
+	assert.Equal(t, WalletBalance{Confirmed: 10000, Unconfirmed: 14000}, wallet.Balance())
+
+	transactions := wallet.Transactions()
+	require.Len(t, transactions, 3)
+	assert.Equal(t, txID, transactions[2].TxID)
+	assert.Equal(t, btcutil.Amount(-26000), transactions[2].Net())
+
+	// and stays consistent with the backend
+	backend.MineBlock()
+	require.NoError(t, wallet.Sync(ctx))
+	assert.Equal(t, WalletBalance{Confirmed: 24000}, wallet.Balance())
+	require.Len(t, wallet.Transactions(), 3)
+
+	// no backend
+	offline, err := NewWallet(WalletConfig{Network: Mainnet, AddressType: NativeSegwit, Mnemonic: testVectorMnemonic})
+	require.NoError(t, err)
+	assert.ErrorIs(t, offline.Sync(ctx), ErrMissingChainBackend)
+	_, err = offline.Broadcast(ctx, spend)
+	assert.ErrorIs(t, err, ErrMissingChainBackend)
+}
+
+// TestWalletSyncWithoutTransactions will test syncing against a backend that cannot return transactions
+func TestWalletSyncWithoutTransactions(t *testing.T) {
+	t.Parallel()
+
+	wallet, backend := newTestWallet(t, nil)
+	address, err := wallet.NextAddress()
+	require.NoError(t, err)
+	backend.AddTransaction(payTo(t, address.Address, 7000))
+
+	noTxs := &noTransactionBackend{backend}
+	wallet.config.Backend = noTxs
+
+	require.NoError(t, wallet.Sync(context.Background()))
+	require.Len(t, wallet.Transactions(), 1)
+	assert.Equal(t, btcutil.Amount(7000), wallet.Transactions()[0].Received)
+}
+
+// noTransactionBackend is a backend without a transaction index
+type noTransactionBackend struct {
+	*MemoryChainBackend
+}
+
+// GetTransaction implements ChainBackend
+func (b *noTransactionBackend) GetTransaction(context.Context, string) (*wire.MsgTx, error) {
+	return nil, ErrTransactionNotFound
+}
+
+// TestWalletSyncConfirmedOnly will test syncing against a backend reporting confirmed outputs only
+func TestWalletSyncConfirmedOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	wallet, memory := fundedTestWallet(t, 40000)
+	backend := &confirmedOnlyBackend{MemoryChainBackend: memory, mempool: make(map[string]*wire.MsgTx)}
+	wallet.config.Backend = backend
+
+	// the spent output stays spent and the change stays unconfirmed
+	external := pkScriptForAddress(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+	spend := signedSpend(t, wallet, utxoOf(t, wallet, 40000), wire.MaxTxInSequenceNum,
+		wire.NewTxOut(25000, external), changeTxOut(t, wallet, 14000))
+	spendID, err := wallet.Broadcast(ctx, spend)
+	require.NoError(t, err)
+
+	require.NoError(t, wallet.Sync(ctx))
+	assert.Equal(t, WalletBalance{Unconfirmed: 14000}, wallet.Balance())
+	require.Len(t, wallet.Utxos(), 1)
+	assert.Equal(t, spendID, wallet.Utxos()[0].TxID)
+
+	// until it confirms
+	backend.confirm(spend)
+	require.NoError(t, wallet.Sync(ctx))
+	assert.Equal(t, WalletBalance{Confirmed: 14000}, wallet.Balance())
+
+	// a spend dropped from the mempool gives its output back
+	drop := signedSpend(t, wallet, utxoOf(t, wallet, 14000), wire.MaxTxInSequenceNum, wire.NewTxOut(13000, external))
+	dropID, err := wallet.Broadcast(ctx, drop)
+	require.NoError(t, err)
+
+	require.NoError(t, wallet.Sync(ctx))
+	assert.Equal(t, WalletBalance{}, wallet.Balance())
+	require.Len(t, wallet.Transactions(), 3)
+
+	delete(backend.mempool, dropID)
+	require.NoError(t, wallet.Sync(ctx))
+	assert.Equal(t, WalletBalance{Confirmed: 14000}, wallet.Balance())
+	require.Len(t, wallet.Transactions(), 2)
+	for _, tx := range wallet.Transactions() {
+		assert.NotEqual(t, dropID, tx.TxID)
+	}
+}
+
+// confirmedOnlyBackend is a backend reporting confirmed outputs only, like scantxoutset, with its own mempool
+type confirmedOnlyBackend struct {
+	*MemoryChainBackend
+	mempool map[string]*wire.MsgTx
+}
+
+// confirm mines a transaction of the mempool
+func (b *confirmedOnlyBackend) confirm(tx *wire.MsgTx) {
+	delete(b.mempool, tx.TxHash().String())
+	b.AddTransaction(tx)
+	b.MineBlock()
+}
+
+// GetTransaction implements ChainBackend
+func (b *confirmedOnlyBackend) GetTransaction(ctx context.Context, txID string) (*wire.MsgTx, error) {
+	if tx, ok := b.mempool[txID]; ok {
+		return tx, nil
+	}
+	return b.MemoryChainBackend.GetTransaction(ctx, txID)
+}
+
+// Broadcast implements ChainBackend
+func (b *confirmedOnlyBackend) Broadcast(_ context.Context, tx *wire.MsgTx) (string, error) {
+	txID := tx.TxHash().String()
+	b.mempool[txID] = tx
+	return txID, nil
+}
@@ -0,0 +1,25 @@
+package zmq
+
+import (
+	"errors"
+
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// ErrMissingAddress is returned when a subscriber is dialed without an endpoint address
+var ErrMissingAddress = errors.New("missing zmq endpoint address")
+
+// ErrMissingNetwork is returned when a watcher is created without a network, the same error as bitcoin.ErrMissingNetwork
+var ErrMissingNetwork = bitcoin.ErrMissingNetwork
+
+// ErrWrongNetwork is returned when an address is not for the watcher's network, the same error as bitcoin.ErrWrongNetwork
+var ErrWrongNetwork = bitcoin.ErrWrongNetwork
+
+// ErrHandshakeFailed is returned when the publisher does not complete the ZMTP handshake
+var ErrHandshakeFailed = errors.New("zmq handshake failed")
+
+// ErrClosed is returned after Close, or wraps the error the connection was lost with
+var ErrClosed = errors.New("zmq subscriber closed")
+
+// ErrInvalidMessage is returned when the publisher sends a notification that cannot be decoded
+var ErrInvalidMessage = errors.New("invalid zmq notification")
@@ -0,0 +1,144 @@
+package zmq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Topic is a notification topic published by bitcoind, enabled with -zmqpub<topic>=<address>
+type Topic string
+
+const (
+	TopicRawTx     Topic = "rawtx"
+	TopicRawBlock  Topic = "rawblock"
+	TopicHashTx    Topic = "hashtx"
+	TopicHashBlock Topic = "hashblock"
+	TopicSequence  Topic = "sequence"
+)
+
+// Topics are all the topics decoded by a Subscriber
+var Topics = []Topic{TopicRawTx, TopicRawBlock, TopicHashTx, TopicHashBlock, TopicSequence}
+
+// SequenceLabel is the kind of change announced on the sequence topic
+type SequenceLabel byte
+
+const (
+	SequenceBlockConnected    SequenceLabel = 'C'
+	SequenceBlockDisconnected SequenceLabel = 'D'
+	SequenceTxAdded           SequenceLabel = 'A' // added to the mempool
+	SequenceTxRemoved         SequenceLabel = 'R' // removed from the mempool for another reason than a block
+)
+
+// Event is a decoded notification: a *RawTxEvent, *RawBlockEvent, *HashTxEvent, *HashBlockEvent or *SequenceEvent
+type Event interface {
+	notification() *Notification
+}
+
+// Notification holds what every notification carries
+type Notification struct {
+	Topic    Topic
+	Sequence uint32 // message number on the topic, incremented by the publisher for every notification
+	Missed   uint32 // notifications of the topic missed since the previous one, e.g. dropped by the publisher
+}
+
+// notification implements Event
+func (n *Notification) notification() *Notification {
+	return n
+}
+
+// RawTxEvent announces a transaction added to the mempool or included in a connected block
+type RawTxEvent struct {
+	Notification
+	Tx *wire.MsgTx
+}
+
+// RawBlockEvent announces a connected block
+type RawBlockEvent struct {
+	Notification
+	Block *wire.MsgBlock
+}
+
+// HashTxEvent announces the txid of a transaction added to the mempool or included in a connected block
+type HashTxEvent struct {
+	Notification
+	TxID string
+}
+
+// HashBlockEvent announces the hash of a connected block
+type HashBlockEvent struct {
+	Notification
+	BlockHash string
+}
+
+// SequenceEvent announces a block connection or disconnection, or a mempool change
+type SequenceEvent struct {
+	Notification
+	Hash            string // block hash or txid, depending on Label
+	Label           SequenceLabel
+	MempoolSequence uint64 // mempool sequence number, only set for SequenceTxAdded and SequenceTxRemoved
+}
+
+// decodeEvent decodes the body of a notification, returning nil for topics it does not know
+func decodeEvent(topic Topic, body []byte, sequence uint32) (Event, error) {
+	n := Notification{Topic: topic, Sequence: sequence}
+
+	switch topic {
+	case TopicRawTx:
+		tx := wire.NewMsgTx(wire.TxVersion)
+		if err := tx.Deserialize(bytes.NewReader(body)); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMessage, topic, err)
+		}
+		return &RawTxEvent{Notification: n, Tx: tx}, nil
+
+	case TopicRawBlock:
+		var block wire.MsgBlock
+		if err := block.Deserialize(bytes.NewReader(body)); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMessage, topic, err)
+		}
+		return &RawBlockEvent{Notification: n, Block: &block}, nil
+
+	// hashes are published in display order
+	case TopicHashTx, TopicHashBlock:
+		if len(body) != chainhash.HashSize {
+			return nil, fmt.Errorf("%w: %s of %d bytes", ErrInvalidMessage, topic, len(body))
+		}
+		if topic == TopicHashTx {
+			return &HashTxEvent{Notification: n, TxID: hex.EncodeToString(body)}, nil
+		}
+		return &HashBlockEvent{Notification: n, BlockHash: hex.EncodeToString(body)}, nil
+
+	case TopicSequence:
+		if len(body) < chainhash.HashSize+1 {
+			return nil, fmt.Errorf("%w: %s of %d bytes", ErrInvalidMessage, topic, len(body))
+		}
+
+		event := &SequenceEvent{
+			Notification: n,
+			Hash:         hex.EncodeToString(body[:chainhash.HashSize]),
+			Label:        SequenceLabel(body[chainhash.HashSize]),
+		}
+
+		switch event.Label {
+		case SequenceBlockConnected, SequenceBlockDisconnected:
+			if len(body) != chainhash.HashSize+1 {
+				return nil, fmt.Errorf("%w: %s of %d bytes", ErrInvalidMessage, topic, len(body))
+			}
+		case SequenceTxAdded, SequenceTxRemoved:
+			if len(body) != chainhash.HashSize+9 {
+				return nil, fmt.Errorf("%w: %s of %d bytes", ErrInvalidMessage, topic, len(body))
+			}
+			event.MempoolSequence = binary.LittleEndian.Uint64(body[chainhash.HashSize+1:])
+		default:
+			return nil, fmt.Errorf("%w: unknown sequence label %q", ErrInvalidMessage, event.Label)
+		}
+
+		return event, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,58 @@
+package zmq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecodeEvent will test the method decodeEvent()
+func TestDecodeEvent(t *testing.T) {
+	t.Parallel()
+
+	hash := bytes.Repeat([]byte{0xab}, 32)
+	hashHex := "abababababababababababababababababababababababababababababababab"
+
+	event, err := decodeEvent(TopicHashTx, hash, 7)
+	require.NoError(t, err)
+	assert.Equal(t, &HashTxEvent{Notification: Notification{Topic: TopicHashTx, Sequence: 7}, TxID: hashHex}, event)
+
+	// mempool changes carry the mempool sequence number
+	event, err = decodeEvent(TopicSequence, binary.LittleEndian.AppendUint64(append(hash, 'A'), 42), 1)
+	require.NoError(t, err)
+	sequenceEvent, ok := event.(*SequenceEvent)
+	require.True(t, ok)
+	assert.Equal(t, SequenceTxAdded, sequenceEvent.Label)
+	assert.Equal(t, uint64(42), sequenceEvent.MempoolSequence)
+	assert.Equal(t, hashHex, sequenceEvent.Hash)
+
+	event, err = decodeEvent(TopicSequence, append(hash, 'D'), 2)
+	require.NoError(t, err)
+	assert.Equal(t, SequenceBlockDisconnected, event.(*SequenceEvent).Label)
+
+	// unknown topics are skipped
+	event, err = decodeEvent(Topic("pubrawtx"), hash, 0)
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	var tests = []struct {
+		topic Topic
+		body  []byte
+	}{
+		{TopicHashBlock, hash[:31]},
+		{TopicSequence, hash},
+		{TopicSequence, append(hash, 'C', 0x00)},
+		{TopicSequence, append(hash, 'R')},
+		{TopicSequence, append(hash, 'X')},
+		{TopicRawTx, []byte{0x01, 0x02}},
+		{TopicRawBlock, hash},
+	}
+
+	for _, test := range tests {
+		_, err = decodeEvent(test.topic, test.body, 0)
+		assert.ErrorIs(t, err, ErrInvalidMessage, "%s %x", test.topic, test.body)
+	}
+}
@@ -0,0 +1,231 @@
+package zmq
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config holds the connection settings of a Subscriber
+type Config struct {
+	Address    string  // publisher endpoint as configured in bitcoind, e.g. tcp://127.0.0.1:28332
+	Topics     []Topic // defaults to Topics
+	BufferSize int     // events buffered before the subscriber stops reading, defaults to 100
+}
+
+// Subscriber receives the notifications bitcoind publishes on a ZMQ endpoint and decodes them
+// into events. bitcoind can publish each topic on its own endpoint, one Subscriber is needed per endpoint
+type Subscriber struct {
+	config Config
+	conn   net.Conn
+	events chan Event
+
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	mu   sync.Mutex
+	err  error
+	done chan struct{}
+}
+
+// Dial connects to a publisher, runs the ZMTP handshake and subscribes to config.Topics
+func Dial(ctx context.Context, config Config) (*Subscriber, error) {
+
+	// Missing address
+	if len(config.Address) == 0 {
+		return nil, ErrMissingAddress
+	}
+
+	if len(config.Topics) == 0 {
+		config.Topics = Topics
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 100
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", strings.TrimPrefix(config.Address, "tcp://"))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Subscriber{
+		config:  config,
+		conn:    conn,
+		events:  make(chan Event, config.BufferSize),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if err = s.handshake(ctx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	go s.readLoop()
+	return s, nil
+}
+
+// Events returns the decoded notifications, the channel is closed once the connection is closed or lost
+func (s *Subscriber) Events() <-chan Event {
+	return s.events
+}
+
+// Close closes the connection
+func (s *Subscriber) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closing)
+		err = s.conn.Close()
+	})
+
+	<-s.done
+	return err
+}
+
+// Done returns a channel closed once the connection is closed or lost
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the connection was lost, or ErrClosed after Close
+func (s *Subscriber) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// handshake exchanges greetings and READY commands, then sends the subscriptions
+func (s *Subscriber) handshake(ctx context.Context) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.conn.SetDeadline(deadline)
+		defer func() {
+			_ = s.conn.SetDeadline(time.Time{})
+		}()
+	}
+
+	if _, err := s.conn.Write(greeting()); err != nil {
+		return fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+	}
+
+	peerGreeting := make([]byte, greetingSize)
+	if _, err := io.ReadFull(s.conn, peerGreeting); err != nil {
+		return fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+	}
+	if err := checkGreeting(peerGreeting); err != nil {
+		return err
+	}
+
+	if err := writeFrame(s.conn, frame{body: readyCommand(), command: true}); err != nil {
+		return fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+	}
+
+	for {
+		f, err := readFrame(s.conn)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+		}
+		if !f.command {
+			return fmt.Errorf("%w: message before READY", ErrHandshakeFailed)
+		}
+
+		name := commandName(f.body)
+		if name == "READY" {
+			break
+		}
+		if name == "ERROR" {
+			return fmt.Errorf("%w: publisher sent ERROR", ErrHandshakeFailed)
+		}
+	}
+
+	// ZMTP 3.0 subscriptions are messages made of 0x01 followed by the topic prefix
+	for _, topic := range s.config.Topics {
+		if err := writeFrame(s.conn, frame{body: append([]byte{1}, topic...)}); err != nil {
+			return fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+		}
+	}
+
+	return nil
+}
+
+// readLoop decodes notifications into events until the connection is closed or lost
+func (s *Subscriber) readLoop() {
+	sequences := make(map[Topic]uint32)
+
+	var err error
+	for {
+		var parts [][]byte
+		if parts, err = s.readMessage(); err != nil {
+			break
+		}
+
+		// bitcoind sends topic, body and a little endian sequence number
+		if len(parts) != 3 || len(parts[2]) != 4 {
+			err = fmt.Errorf("%w: message of %d parts", ErrInvalidMessage, len(parts))
+			break
+		}
+
+		topic := Topic(parts[0])
+		sequence := binary.LittleEndian.Uint32(parts[2])
+
+		var event Event
+		if event, err = decodeEvent(topic, parts[1], sequence); err != nil {
+			break
+		}
+		if event == nil {
+			continue
+		}
+
+		if next, ok := sequences[topic]; ok {
+			event.notification().Missed = sequence - next
+		}
+		sequences[topic] = sequence + 1
+
+		select {
+		case s.events <- event:
+		case <-s.closing:
+		}
+	}
+
+	s.mu.Lock()
+	select {
+	case <-s.closing:
+		s.err = ErrClosed
+	default:
+		if errors.Is(err, io.EOF) {
+			s.err = ErrClosed
+		} else {
+			s.err = fmt.Errorf("%w: %w", ErrClosed, err)
+		}
+	}
+	s.mu.Unlock()
+
+	_ = s.conn.Close()
+	close(s.events)
+	close(s.done)
+}
+
+// readMessage reads the parts of the next message, skipping commands
+func (s *Subscriber) readMessage() ([][]byte, error) {
+	var parts [][]byte
+	for {
+		f, err := readFrame(s.conn)
+		if err != nil {
+			return nil, err
+		}
+		if f.command {
+			continue
+		}
+
+		parts = append(parts, f.body)
+		if !f.more {
+			return parts, nil
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package zmq
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPublisher is a fake bitcoind ZMQ publisher speaking ZMTP 3.0 over TCP
+type testPublisher struct {
+	listener net.Listener
+
+	mu            sync.Mutex
+	conns         []net.Conn
+	subscriptions map[net.Conn][]string
+	sequences     map[string]uint32
+	subscribed    chan struct{}
+}
+
+// newTestPublisher starts a fake publisher
+func newTestPublisher(t *testing.T) *testPublisher {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	publisher := &testPublisher{
+		listener:      listener,
+		subscriptions: make(map[net.Conn][]string),
+		sequences:     make(map[string]uint32),
+		subscribed:    make(chan struct{}, 100),
+	}
+	t.Cleanup(publisher.close)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			publisher.mu.Lock()
+			publisher.conns = append(publisher.conns, conn)
+			publisher.mu.Unlock()
+
+			go publisher.serve(conn)
+		}
+	}()
+
+	return publisher
+}
+
+// serve runs the handshake of a connection and records its subscriptions
+func (p *testPublisher) serve(conn net.Conn) {
+	peerGreeting := make([]byte, greetingSize)
+	if _, err := io.ReadFull(conn, peerGreeting); err != nil {
+		return
+	}
+	if peerGreeting[0] != 0xff || peerGreeting[10] != 3 || !bytes.HasPrefix(peerGreeting[12:], []byte("NULL\x00")) {
+		return
+	}
+
+	g := make([]byte, greetingSize)
+	g[0], g[9], g[10], g[11] = 0xff, 0x7f, 3, 1
+	copy(g[12:], "NULL")
+	if _, err := conn.Write(g); err != nil {
+		return
+	}
+
+	// READY with Socket-Type PUB
+	ready := append([]byte{5}, "READY"...)
+	ready = append(ready, 11)
+	ready = append(ready, "Socket-Type"...)
+	ready = binary.BigEndian.AppendUint32(ready, 3)
+	ready = append(ready, "PUB"...)
+	if err := writeFrame(conn, frame{body: ready, command: true}); err != nil {
+		return
+	}
+
+	for {
+		f, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+
+		if !f.command && len(f.body) > 0 && f.body[0] == 1 {
+			p.mu.Lock()
+			p.subscriptions[conn] = append(p.subscriptions[conn], string(f.body[1:]))
+			p.mu.Unlock()
+			p.subscribed <- struct{}{}
+		}
+	}
+}
+
+// waitSubscribed waits until count subscriptions were received
+func (p *testPublisher) waitSubscribed(t *testing.T, count int) {
+	for range count {
+		select {
+		case <-p.subscribed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("subscriber did not subscribe")
+		}
+	}
+}
+
+// publish sends a notification to the connections subscribed to its topic, numbering it as bitcoind does
+func (p *testPublisher) publish(topic Topic, body []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sequence := binary.LittleEndian.AppendUint32(nil, p.sequences[string(topic)])
+	p.sequences[string(topic)]++
+
+	for conn, subscriptions := range p.subscriptions {
+		for _, subscription := range subscriptions {
+			if bytes.HasPrefix([]byte(topic), []byte(subscription)) {
+				_ = writeFrame(conn, frame{body: []byte(topic), more: true})
+				_ = writeFrame(conn, frame{body: body, more: true})
+				_ = writeFrame(conn, frame{body: sequence})
+				break
+			}
+		}
+	}
+}
+
+// skip drops the next sequence number of a topic, as a publisher dropping a notification
+func (p *testPublisher) skip(topic Topic) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sequences[string(topic)]++
+}
+
+// dropConnections closes the connections of the subscribers
+func (p *testPublisher) dropConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, conn := range p.conns {
+		_ = conn.Close()
+	}
+	p.conns = nil
+	p.subscriptions = make(map[net.Conn][]string)
+}
+
+// close stops the fake publisher
+func (p *testPublisher) close() {
+	_ = p.listener.Close()
+	p.dropConnections()
+}
+
+// newTestSubscriber dials a fake publisher and waits for its subscriptions
+func newTestSubscriber(t *testing.T, publisher *testPublisher, topics ...Topic) *Subscriber {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	subscriber, err := Dial(ctx, Config{Address: "tcp://" + publisher.listener.Addr().String(), Topics: topics})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = subscriber.Close()
+	})
+
+	if len(topics) == 0 {
+		topics = Topics
+	}
+	publisher.waitSubscribed(t, len(topics))
+
+	return subscriber
+}
+
+// nextEvent returns the next event of a subscriber
+func nextEvent(t *testing.T, subscriber *Subscriber) Event {
+	select {
+	case event, ok := <-subscriber.Events():
+		require.True(t, ok, "events closed")
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
+
+// TestDial will test the method Dial()
+func TestDial(t *testing.T) {
+	t.Parallel()
+
+	_, err := Dial(context.Background(), Config{})
+	assert.ErrorIs(t, err, ErrMissingAddress)
+
+	// a server not speaking ZMTP
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			_, _ = conn.Write(make([]byte, greetingSize))
+			_ = conn.Close()
+		}
+	}()
+
+	_, err = Dial(context.Background(), Config{Address: listener.Addr().String()})
+	assert.ErrorIs(t, err, ErrHandshakeFailed)
+}
+
+// TestSubscriberEvents will test the method Events()
+func TestSubscriberEvents(t *testing.T) {
+	t.Parallel()
+
+	publisher := newTestPublisher(t)
+	subscriber := newTestSubscriber(t, publisher)
+
+	block := chaincfg.MainNetParams.GenesisBlock
+	var rawBlock bytes.Buffer
+	require.NoError(t, block.Serialize(&rawBlock))
+	var rawTx bytes.Buffer
+	require.NoError(t, block.Transactions[0].Serialize(&rawTx))
+
+	// hashes are published in display order
+	blockHash := block.BlockHash()
+	displayHash := make([]byte, len(blockHash))
+	for i := range blockHash {
+		displayHash[i] = blockHash[len(blockHash)-1-i]
+	}
+
+	publisher.publish(TopicRawBlock, rawBlock.Bytes())
+	publisher.publish(TopicRawTx, rawTx.Bytes())
+	publisher.publish(TopicHashBlock, displayHash)
+	publisher.publish(Topic("unknown"), []byte{0x01})
+	publisher.publish(TopicSequence, append(displayHash, 'C'))
+
+	rawBlockEvent, ok := nextEvent(t, subscriber).(*RawBlockEvent)
+	require.True(t, ok)
+	assert.Equal(t, blockHash, rawBlockEvent.Block.BlockHash())
+	assert.Equal(t, TopicRawBlock, rawBlockEvent.Topic)
+
+	rawTxEvent, ok := nextEvent(t, subscriber).(*RawTxEvent)
+	require.True(t, ok)
+	assert.Equal(t, block.Transactions[0].TxHash(), rawTxEvent.Tx.TxHash())
+
+	hashBlockEvent, ok := nextEvent(t, subscriber).(*HashBlockEvent)
+	require.True(t, ok)
+	assert.Equal(t, blockHash.String(), hashBlockEvent.BlockHash)
+
+	sequenceEvent, ok := nextEvent(t, subscriber).(*SequenceEvent)
+	require.True(t, ok)
+	assert.Equal(t, blockHash.String(), sequenceEvent.Hash)
+	assert.Equal(t, SequenceBlockConnected, sequenceEvent.Label)
+
+	// dropped notifications show as missed
+	publisher.skip(TopicRawTx)
+	publisher.skip(TopicRawTx)
+	publisher.publish(TopicRawTx, rawTx.Bytes())
+
+	rawTxEvent, ok = nextEvent(t, subscriber).(*RawTxEvent)
+	require.True(t, ok)
+	assert.Equal(t, uint32(3), rawTxEvent.Sequence)
+	assert.Equal(t, uint32(2), rawTxEvent.Missed)
+}
+
+// TestSubscriberTopics will test subscribing to some topics only
+func TestSubscriberTopics(t *testing.T) {
+	t.Parallel()
+
+	publisher := newTestPublisher(t)
+	subscriber := newTestSubscriber(t, publisher, TopicHashTx)
+
+	txID := chaincfg.MainNetParams.GenesisBlock.Transactions[0].TxHash()
+	publisher.publish(TopicHashBlock, txID[:])
+	publisher.publish(TopicHashTx, txID[:])
+
+	event, ok := nextEvent(t, subscriber).(*HashTxEvent)
+	require.True(t, ok)
+	assert.Equal(t, TopicHashTx, event.Topic)
+}
+
+// TestSubscriberClose will test the methods Close(), Done() and Err()
+func TestSubscriberClose(t *testing.T) {
+	t.Parallel()
+
+	publisher := newTestPublisher(t)
+	subscriber := newTestSubscriber(t, publisher)
+
+	// a lost connection closes the events
+	publisher.dropConnections()
+	select {
+	case _, ok := <-subscriber.Events():
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("events still open")
+	}
+	<-subscriber.Done()
+	assert.ErrorIs(t, subscriber.Err(), ErrClosed)
+
+	// an invalid notification ends the connection
+	subscriber = newTestSubscriber(t, publisher)
+	publisher.publish(TopicHashTx, []byte{0x01})
+	<-subscriber.Done()
+	assert.ErrorIs(t, subscriber.Err(), ErrInvalidMessage)
+
+	// closing does not wait for unread events
+	subscriber = newTestSubscriber(t, publisher, TopicHashTx)
+	for range 200 {
+		publisher.publish(TopicHashTx, make([]byte, 32))
+	}
+	require.NoError(t, subscriber.Close())
+	assert.ErrorIs(t, subscriber.Err(), ErrClosed)
+}
@@ -0,0 +1,135 @@
+package zmq
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+)
+
+// Payment is an output paying to a watched address
+type Payment struct {
+	TxID      string
+	Vout      uint32
+	Address   string
+	Value     btcutil.Amount
+	Script    string // output script (hex string)
+	BlockHash string // empty for a transaction seen on the rawtx topic
+}
+
+// AddressWatcher filters the transactions of rawtx and rawblock events for outputs paying to a set of
+// addresses, safe for concurrent use. bitcoind publishes the transactions of connected blocks on rawtx too,
+// so a payment is usually seen once from the mempool and again from the block, possibly more after a reorg
+type AddressWatcher struct {
+	network bitcoin.NetworkType
+
+	mu      sync.RWMutex
+	scripts map[string]string // output script (hex string) to address
+}
+
+// NewAddressWatcher creates a watcher for addresses of network
+func NewAddressWatcher(network bitcoin.NetworkType) (*AddressWatcher, error) {
+
+	// Missing network
+	if network == nil {
+		return nil, ErrMissingNetwork
+	}
+
+	return &AddressWatcher{network: network, scripts: make(map[string]string)}, nil
+}
+
+// AddAddresses starts watching addresses, which are validated against the watcher's network
+func (w *AddressWatcher) AddAddresses(addresses ...string) error {
+	scripts := make(map[string]string, len(addresses))
+	for _, address := range addresses {
+		script, err := w.script(address)
+		if err != nil {
+			return err
+		}
+		scripts[script] = address
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for script, address := range scripts {
+		w.scripts[script] = address
+	}
+
+	return nil
+}
+
+// RemoveAddresses stops watching addresses
+func (w *AddressWatcher) RemoveAddresses(addresses ...string) error {
+	for _, address := range addresses {
+		script, err := w.script(address)
+		if err != nil {
+			return err
+		}
+
+		w.mu.Lock()
+		delete(w.scripts, script)
+		w.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Match returns the outputs of tx paying to watched addresses
+func (w *AddressWatcher) Match(tx *wire.MsgTx) []Payment {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var payments []Payment
+	for vout, out := range tx.TxOut {
+		script := hex.EncodeToString(out.PkScript)
+		address, ok := w.scripts[script]
+		if !ok {
+			continue
+		}
+
+		payments = append(payments, Payment{
+			TxID:    tx.TxHash().String(),
+			Vout:    uint32(vout),
+			Address: address,
+			Value:   btcutil.Amount(out.Value),
+			Script:  script,
+		})
+	}
+
+	return payments
+}
+
+// Payments returns the outputs paying to watched addresses in the transactions of a rawtx or
+// rawblock event, other events carry no payments
+func (w *AddressWatcher) Payments(event Event) []Payment {
+	switch event := event.(type) {
+	case *RawTxEvent:
+		return w.Match(event.Tx)
+
+	case *RawBlockEvent:
+		blockHash := event.Block.BlockHash().String()
+
+		var payments []Payment
+		for _, tx := range event.Block.Transactions {
+			for _, payment := range w.Match(tx) {
+				payment.BlockHash = blockHash
+				payments = append(payments, payment)
+			}
+		}
+		return payments
+	}
+
+	return nil
+}
+
+// script returns the output script of an address of the watcher's network
+func (w *AddressWatcher) script(address string) (string, error) {
+	if _, err := bitcoin.DecodeAddressForNetwork(address, w.network); err != nil {
+		return "", err
+	}
+
+	return bitcoin.GetScriptFromAddress(address, w.network)
+}
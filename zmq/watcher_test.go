@@ -0,0 +1,87 @@
+package zmq
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/funmi4194/go-bitcoin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testnetAddress = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+	mainnetAddress = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+)
+
+// newPaymentTx returns a transaction paying value to each address
+func newPaymentTx(t *testing.T, value int64, addresses ...string) *wire.MsgTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0), nil, nil))
+
+	for _, address := range addresses {
+		script, err := bitcoin.GetScriptFromAddress(address, bitcoin.Testnet)
+		require.NoError(t, err)
+		pkScript, err := hex.DecodeString(script)
+		require.NoError(t, err)
+		tx.AddTxOut(wire.NewTxOut(value, pkScript))
+	}
+
+	return tx
+}
+
+// TestNewAddressWatcher will test the method NewAddressWatcher()
+func TestNewAddressWatcher(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewAddressWatcher(nil)
+	assert.ErrorIs(t, err, ErrMissingNetwork)
+	assert.ErrorIs(t, err, bitcoin.ErrMissingNetwork)
+
+	watcher, err := NewAddressWatcher(bitcoin.Testnet)
+	require.NoError(t, err)
+
+	require.NoError(t, watcher.AddAddresses(testnetAddress))
+	assert.ErrorIs(t, watcher.AddAddresses(mainnetAddress), ErrWrongNetwork)
+	assert.Error(t, watcher.AddAddresses("invalid"))
+	assert.ErrorIs(t, watcher.RemoveAddresses(mainnetAddress), ErrWrongNetwork)
+}
+
+// TestAddressWatcherPayments will test the methods Match() and Payments()
+func TestAddressWatcherPayments(t *testing.T) {
+	t.Parallel()
+
+	watcher, err := NewAddressWatcher(bitcoin.Testnet)
+	require.NoError(t, err)
+	require.NoError(t, watcher.AddAddresses(testnetAddress))
+
+	other := "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn"
+	tx := newPaymentTx(t, 5000, other, testnetAddress)
+
+	payments := watcher.Payments(&RawTxEvent{Tx: tx})
+	require.Len(t, payments, 1)
+	assert.Equal(t, Payment{
+		TxID:    tx.TxHash().String(),
+		Vout:    1,
+		Address: testnetAddress,
+		Value:   btcutil.Amount(5000),
+		Script:  "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+	}, payments[0])
+
+	// payments in blocks carry the block hash
+	block := wire.NewMsgBlock(&wire.BlockHeader{})
+	require.NoError(t, block.AddTransaction(newPaymentTx(t, 1000, other)))
+	require.NoError(t, block.AddTransaction(tx))
+
+	payments = watcher.Payments(&RawBlockEvent{Block: block})
+	require.Len(t, payments, 1)
+	assert.Equal(t, block.BlockHash().String(), payments[0].BlockHash)
+
+	assert.Empty(t, watcher.Payments(&HashTxEvent{TxID: tx.TxHash().String()}))
+
+	require.NoError(t, watcher.RemoveAddresses(testnetAddress))
+	assert.Empty(t, watcher.Match(tx))
+}
@@ -0,0 +1,137 @@
+package zmq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ZMTP 3.0 framing, as spoken by the libzmq PUB sockets of bitcoind (https://rfc.zeromq.org/spec/23/)
+const (
+	greetingSize = 64
+
+	flagMore    = 0x01
+	flagLong    = 0x02
+	flagCommand = 0x04
+
+	// maxFrameSize bounds the frames accepted from the publisher, rawblock frames being the largest
+	maxFrameSize = wire.MaxMessagePayload
+)
+
+// frame is a ZMTP frame, either a message part or a command
+type frame struct {
+	body    []byte
+	more    bool
+	command bool
+}
+
+// greeting returns the greeting of a ZMTP 3.0 client using the NULL security mechanism. Minor version 0
+// makes libzmq expect subscriptions as messages rather than SUBSCRIBE commands
+func greeting() []byte {
+	g := make([]byte, greetingSize)
+	g[0] = 0xff
+	g[9] = 0x7f
+	g[10] = 3
+	g[11] = 0
+	copy(g[12:32], "NULL")
+	return g
+}
+
+// checkGreeting checks the greeting of the publisher
+func checkGreeting(g []byte) error {
+	if g[0] != 0xff || g[9] != 0x7f {
+		return fmt.Errorf("%w: invalid greeting signature", ErrHandshakeFailed)
+	}
+
+	if g[10] < 3 {
+		return fmt.Errorf("%w: unsupported ZMTP version %d", ErrHandshakeFailed, g[10])
+	}
+
+	if mechanism := string(bytes.TrimRight(g[12:32], "\x00")); mechanism != "NULL" {
+		return fmt.Errorf("%w: unsupported security mechanism %s", ErrHandshakeFailed, mechanism)
+	}
+
+	return nil
+}
+
+// readyCommand returns the READY command of a SUB socket
+func readyCommand() []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(5)
+	buf.WriteString("READY")
+	buf.WriteByte(byte(len("Socket-Type")))
+	buf.WriteString("Socket-Type")
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len("SUB")))
+	buf.WriteString("SUB")
+	return buf.Bytes()
+}
+
+// commandName returns the name of a command frame
+func commandName(body []byte) string {
+	if len(body) == 0 || int(body[0]) > len(body)-1 {
+		return ""
+	}
+
+	return string(body[1 : 1+body[0]])
+}
+
+// writeFrame writes a single frame
+func writeFrame(w io.Writer, f frame) error {
+	var flags byte
+	if f.more {
+		flags |= flagMore
+	}
+	if f.command {
+		flags |= flagCommand
+	}
+
+	var header []byte
+	if len(f.body) > 255 {
+		header = binary.BigEndian.AppendUint64([]byte{flags | flagLong}, uint64(len(f.body)))
+	} else {
+		header = []byte{flags, byte(len(f.body))}
+	}
+
+	if _, err := w.Write(append(header, f.body...)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// readFrame reads a single frame
+func readFrame(r io.Reader) (frame, error) {
+	var flags [1]byte
+	if _, err := io.ReadFull(r, flags[:]); err != nil {
+		return frame{}, err
+	}
+
+	var size uint64
+	if flags[0]&flagLong != 0 {
+		var long [8]byte
+		if _, err := io.ReadFull(r, long[:]); err != nil {
+			return frame{}, err
+		}
+		size = binary.BigEndian.Uint64(long[:])
+	} else {
+		var short [1]byte
+		if _, err := io.ReadFull(r, short[:]); err != nil {
+			return frame{}, err
+		}
+		size = uint64(short[0])
+	}
+
+	if size > maxFrameSize {
+		return frame{}, fmt.Errorf("%w: frame of %d bytes", ErrInvalidMessage, size)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return frame{}, err
+	}
+
+	return frame{body: body, more: flags[0]&flagMore != 0, command: flags[0]&flagCommand != 0}, nil
+}